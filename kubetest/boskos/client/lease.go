@@ -0,0 +1,291 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/sirupsen/logrus"
+
+	"k8s.io/test-infra/kubetest/boskos/common"
+)
+
+const (
+	// DefaultHeartbeatInterval is the interval at which leases are heartbeated
+	// unless configured otherwise.
+	DefaultHeartbeatInterval = 5 * time.Minute
+)
+
+// LeaseManagerOptions configures a LeaseManager.
+type LeaseManagerOptions struct {
+	// HeartbeatInterval is the interval at which every held resource is
+	// updated on the server. Defaults to DefaultHeartbeatInterval.
+	HeartbeatInterval time.Duration
+	// ReleaseState is the state resources are released to when their lease
+	// ends. Defaults to common.Dirty.
+	ReleaseState string
+}
+
+// LeaseMetadata describes a lease held by a LeaseManager.
+type LeaseMetadata struct {
+	// Resource is the leased resource as last known by the client.
+	Resource common.Resource
+	// AcquiredAt is the time the resource was acquired.
+	AcquiredAt time.Time
+	// LastHeartbeat is the time of the last successful heartbeat.
+	LastHeartbeat time.Time
+	// Heartbeats is the number of successful heartbeats.
+	Heartbeats int
+	// FailedHeartbeats is the number of heartbeats that failed in a row.
+	FailedHeartbeats int
+	// LastError is the error of the last failed heartbeat, if any.
+	LastError error
+}
+
+// LeaseManager acquires resources through a Client and keeps their leases
+// alive by heartbeating them until they are released, the context they were
+// acquired with is cancelled, or the process receives a signal registered
+// with ReleaseOnSignal.
+type LeaseManager struct {
+	client  *Client
+	options LeaseManagerOptions
+
+	lock   sync.Mutex
+	leases map[string]*lease
+	wg     sync.WaitGroup
+}
+
+type lease struct {
+	metadata LeaseMetadata
+	cancel   context.CancelFunc
+	done     chan struct{}
+}
+
+// NewLeaseManager creates a LeaseManager using the given client.
+func NewLeaseManager(client *Client, options LeaseManagerOptions) *LeaseManager {
+	if options.HeartbeatInterval == 0 {
+		options.HeartbeatInterval = DefaultHeartbeatInterval
+	}
+	if options.ReleaseState == "" {
+		options.ReleaseState = common.Dirty
+	}
+	return &LeaseManager{
+		client:  client,
+		options: options,
+		leases:  map[string]*lease{},
+	}
+}
+
+// Acquire blocks until a resource of type rtype in state is acquired and set
+// to dest, or ctx is cancelled. The lease is heartbeated until it is released
+// explicitly or ctx is cancelled, in which case the resource is released to
+// the configured release state.
+func (m *LeaseManager) Acquire(ctx context.Context, rtype, state, dest string) (LeaseMetadata, error) {
+	res, err := m.client.AcquireWait(ctx, rtype, state, dest)
+	if err != nil {
+		return LeaseMetadata{}, err
+	}
+	return m.track(ctx, *res), nil
+}
+
+// AcquireByState blocks until the named resources in state are acquired and
+// set to dest, or ctx is cancelled. The leases behave as with Acquire.
+func (m *LeaseManager) AcquireByState(ctx context.Context, state, dest string, names []string) ([]LeaseMetadata, error) {
+	resources, err := m.client.AcquireByStateWait(ctx, state, dest, names)
+	if err != nil {
+		return nil, err
+	}
+	var leases []LeaseMetadata
+	for _, res := range resources {
+		leases = append(leases, m.track(ctx, res))
+	}
+	return leases, nil
+}
+
+func (m *LeaseManager) track(ctx context.Context, res common.Resource) LeaseMetadata {
+	leaseCtx, cancel := context.WithCancel(ctx)
+	l := &lease{
+		metadata: LeaseMetadata{Resource: res, AcquiredAt: time.Now()},
+		cancel:   cancel,
+		done:     make(chan struct{}),
+	}
+	m.lock.Lock()
+	m.leases[res.Name] = l
+	m.lock.Unlock()
+
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		defer close(l.done)
+		m.heartbeat(leaseCtx, res.Name)
+		if ctx.Err() != nil {
+			// The caller's context was cancelled rather than the lease
+			// being released explicitly, so we release it on their behalf.
+			if err := m.release(res.Name); err != nil {
+				logrus.WithError(err).WithField("resource", res.Name).Warn("Failed to release resource after its context was cancelled.")
+			}
+		}
+	}()
+	return l.metadata
+}
+
+func (m *LeaseManager) heartbeat(ctx context.Context, name string) {
+	ticker := time.NewTicker(m.options.HeartbeatInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		m.lock.Lock()
+		l, ok := m.leases[name]
+		if !ok {
+			m.lock.Unlock()
+			return
+		}
+		state := l.metadata.Resource.State
+		m.lock.Unlock()
+
+		err := m.client.UpdateOne(name, state, nil)
+
+		m.lock.Lock()
+		if err != nil {
+			l.metadata.FailedHeartbeats++
+			l.metadata.LastError = err
+			logrus.WithError(err).WithField("resource", name).Warn("Failed to heartbeat resource.")
+		} else {
+			l.metadata.Heartbeats++
+			l.metadata.FailedHeartbeats = 0
+			l.metadata.LastError = nil
+			l.metadata.LastHeartbeat = time.Now()
+		}
+		m.lock.Unlock()
+	}
+}
+
+// Update sets the state and merges the user data of a held resource. The new
+// state is used for subsequent heartbeats.
+func (m *LeaseManager) Update(name, state string, userData *common.UserData) error {
+	m.lock.Lock()
+	_, ok := m.leases[name]
+	m.lock.Unlock()
+	if !ok {
+		return fmt.Errorf("no lease for resource %s", name)
+	}
+	// The client retries, so the lock is not held while it talks to the
+	// server to not block heartbeats and other leases.
+	if err := m.client.UpdateOne(name, state, userData); err != nil {
+		return err
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	l, ok := m.leases[name]
+	if !ok {
+		// Released concurrently.
+		return nil
+	}
+	l.metadata.Resource.State = state
+	if l.metadata.Resource.UserData == nil {
+		l.metadata.Resource.UserData = &common.UserData{}
+	}
+	l.metadata.Resource.UserData = common.UserDataFromMap(l.metadata.Resource.UserData.ToMap()).Update(userData)
+	return nil
+}
+
+// Leases returns the metadata of all held leases, sorted by resource name.
+func (m *LeaseManager) Leases() []LeaseMetadata {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	var leases []LeaseMetadata
+	for _, l := range m.leases {
+		leases = append(leases, l.metadata)
+	}
+	sort.Slice(leases, func(i, j int) bool { return leases[i].Resource.Name < leases[j].Resource.Name })
+	return leases
+}
+
+// Release stops heartbeating a held resource and releases it to the
+// configured release state.
+func (m *LeaseManager) Release(name string) error {
+	m.lock.Lock()
+	l, ok := m.leases[name]
+	m.lock.Unlock()
+	if !ok {
+		return fmt.Errorf("no lease for resource %s", name)
+	}
+	l.cancel()
+	<-l.done
+	return m.release(name)
+}
+
+func (m *LeaseManager) release(name string) error {
+	m.lock.Lock()
+	_, ok := m.leases[name]
+	delete(m.leases, name)
+	m.lock.Unlock()
+	if !ok {
+		// Already released concurrently.
+		return nil
+	}
+	return m.client.ReleaseOne(name, m.options.ReleaseState)
+}
+
+// ReleaseAll releases every held resource.
+func (m *LeaseManager) ReleaseAll() error {
+	var allErrors error
+	for _, l := range m.Leases() {
+		if err := m.Release(l.Resource.Name); err != nil {
+			allErrors = multierror.Append(allErrors, err)
+		}
+	}
+	return allErrors
+}
+
+// ReleaseOnSignal releases every held resource when the process receives one
+// of the given signals, then calls onRelease with the result. It stops
+// listening once ctx is cancelled.
+func (m *LeaseManager) ReleaseOnSignal(ctx context.Context, onRelease func(error), signals ...os.Signal) {
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, signals...)
+	go func() {
+		defer signal.Stop(c)
+		select {
+		case <-ctx.Done():
+			return
+		case sig := <-c:
+			logrus.WithField("signal", sig).Info("Releasing all leases after receiving signal.")
+			err := m.ReleaseAll()
+			if onRelease != nil {
+				onRelease(err)
+			}
+		}
+	}()
+}
+
+// Wait blocks until all heartbeat routines have finished.
+func (m *LeaseManager) Wait() {
+	m.wg.Wait()
+}
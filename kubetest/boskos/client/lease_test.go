@@ -0,0 +1,102 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	"k8s.io/test-infra/kubetest/boskos/common"
+	"k8s.io/test-infra/kubetest/boskos/fakeboskos"
+)
+
+func newLeaseManager(t *testing.T, resources ...common.Resource) (*LeaseManager, *fakeboskos.Server) {
+	server := fakeboskos.NewServer(resources...)
+	s := httptest.NewServer(server)
+	t.Cleanup(s.Close)
+	c, err := NewClient("owner", s.URL, "", "")
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	return NewLeaseManager(c, LeaseManagerOptions{HeartbeatInterval: 10 * time.Millisecond}), server
+}
+
+func TestLeaseManagerHeartbeatsAndReleases(t *testing.T) {
+	m, server := newLeaseManager(t, common.NewResource("project", "gce-project", common.Free, "", time.Time{}))
+
+	lease, err := m.Acquire(context.Background(), "gce-project", common.Free, common.Busy)
+	if err != nil {
+		t.Fatalf("failed to acquire: %v", err)
+	}
+	if lease.Resource.Name != "project" || lease.AcquiredAt.IsZero() {
+		t.Errorf("unexpected lease metadata %+v", lease)
+	}
+	acquired, _ := server.Resource("project")
+
+	if err := wait.PollImmediate(10*time.Millisecond, 5*time.Second, func() (bool, error) {
+		leases := m.Leases()
+		return len(leases) == 1 && leases[0].Heartbeats > 0, nil
+	}); err != nil {
+		t.Fatalf("lease was never heartbeated: %+v", m.Leases())
+	}
+	if heartbeated, _ := server.Resource("project"); !heartbeated.LastUpdate.After(acquired.LastUpdate) {
+		t.Errorf("expected the heartbeat to update the resource on the server")
+	}
+
+	if err := m.Update("project", common.Busy, common.UserDataFromMap(common.UserDataMap{"key": "value"})); err != nil {
+		t.Fatalf("failed to update: %v", err)
+	}
+	if leases := m.Leases(); leases[0].Resource.UserData.ToMap()["key"] != "value" {
+		t.Errorf("expected user data in lease metadata, got %v", leases[0].Resource.UserData.ToMap())
+	}
+
+	if err := m.Release("project"); err != nil {
+		t.Fatalf("failed to release: %v", err)
+	}
+	if res, _ := server.Resource("project"); res.Owner != "" || res.State != common.Dirty {
+		t.Errorf("expected the resource to be released to dirty, got %+v", res)
+	}
+	if leases := m.Leases(); len(leases) != 0 {
+		t.Errorf("expected no leases after release, got %+v", leases)
+	}
+}
+
+func TestLeaseManagerReleasesOnContextCancellation(t *testing.T) {
+	m, server := newLeaseManager(t,
+		common.NewResource("project-a", "gce-project", common.Free, "", time.Time{}),
+		common.NewResource("project-b", "gce-project", common.Free, "", time.Time{}),
+	)
+	ctx, cancel := context.WithCancel(context.Background())
+	if _, err := m.AcquireByState(ctx, common.Free, common.Busy, []string{"project-a", "project-b"}); err != nil {
+		t.Fatalf("failed to acquire: %v", err)
+	}
+	cancel()
+	m.Wait()
+
+	for _, res := range server.Resources() {
+		if res.Owner != "" || res.State != common.Dirty {
+			t.Errorf("expected %s to be released to dirty, got %+v", res.Name, res)
+		}
+	}
+	if leases := m.Leases(); len(leases) != 0 {
+		t.Errorf("expected no leases after cancellation, got %+v", leases)
+	}
+}
@@ -0,0 +1,309 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package fakeboskos implements an in-memory boskos server for local
+// development and tests. It serves the subset of the boskos HTTP API used by
+// the client package: acquire, acquirebystate, release, update, reset and
+// metric.
+package fakeboskos
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/test-infra/kubetest/boskos/common"
+)
+
+// Server is an in-memory boskos server. It implements http.Handler.
+type Server struct {
+	lock      sync.Mutex
+	resources map[string]common.Resource
+	mux       *http.ServeMux
+	// now is replaced in tests.
+	now func() time.Time
+}
+
+// NewServer returns a server holding the given resources.
+func NewServer(resources ...common.Resource) *Server {
+	s := &Server{
+		resources: map[string]common.Resource{},
+		mux:       http.NewServeMux(),
+		now:       time.Now,
+	}
+	for _, r := range resources {
+		s.resources[r.Name] = r
+	}
+	s.mux.HandleFunc("/acquire", s.post(s.handleAcquire))
+	s.mux.HandleFunc("/acquirebystate", s.post(s.handleAcquireByState))
+	s.mux.HandleFunc("/release", s.post(s.handleRelease))
+	s.mux.HandleFunc("/update", s.post(s.handleUpdate))
+	s.mux.HandleFunc("/reset", s.post(s.handleReset))
+	s.mux.HandleFunc("/metric", s.handleMetric)
+	return s
+}
+
+// NewServerFromConfig returns a server holding the static resources defined
+// in a boskos config. Dynamic resources are not supported.
+func NewServerFromConfig(config *common.BoskosConfig) (*Server, error) {
+	var resources []common.Resource
+	for _, entry := range config.Resources {
+		if entry.IsDRLC() {
+			return nil, fmt.Errorf("dynamic resources of type %q are not supported", entry.Type)
+		}
+		resources = append(resources, common.NewResourcesFromConfig(entry)...)
+	}
+	return NewServer(resources...), nil
+}
+
+// Resources returns a snapshot of all resources, sorted by name.
+func (s *Server) Resources() []common.Resource {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	var resources []common.Resource
+	for _, r := range s.resources {
+		resources = append(resources, r)
+	}
+	sort.Sort(common.ResourceByName(resources))
+	return resources
+}
+
+// Resource returns a single resource.
+func (s *Server) Resource(name string) (common.Resource, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	r, ok := s.resources[name]
+	return r, ok
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *Server) post(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, fmt.Sprintf("only POST is allowed, got %s", r.Method), http.StatusMethodNotAllowed)
+			return
+		}
+		handler(w, r)
+	}
+}
+
+func (s *Server) hasType(rtype string) bool {
+	for _, r := range s.resources {
+		if r.Type == rtype {
+			return true
+		}
+	}
+	return false
+}
+
+// sortedNames returns the names of the resources in a stable order so that
+// acquisitions are deterministic.
+func (s *Server) sortedNames() []string {
+	var names []string
+	for name := range s.resources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (s *Server) handleAcquire(w http.ResponseWriter, r *http.Request) {
+	rtype, state, dest, owner := r.URL.Query().Get("type"), r.URL.Query().Get("state"), r.URL.Query().Get("dest"), r.URL.Query().Get("owner")
+	if rtype == "" || state == "" || dest == "" || owner == "" {
+		http.Error(w, "type, state, dest and owner are required", http.StatusBadRequest)
+		return
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if !s.hasType(rtype) {
+		http.Error(w, common.ResourceTypeNotFoundMessage(rtype), http.StatusNotFound)
+		return
+	}
+	for _, name := range s.sortedNames() {
+		res := s.resources[name]
+		if res.Type != rtype || res.State != state || res.Owner != "" {
+			continue
+		}
+		res.State, res.Owner, res.LastUpdate = dest, owner, s.now()
+		s.resources[name] = res
+		writeJSON(w, res)
+		return
+	}
+	http.Error(w, fmt.Sprintf("no available resource %s in state %s", rtype, state), http.StatusNotFound)
+}
+
+func (s *Server) handleAcquireByState(w http.ResponseWriter, r *http.Request) {
+	state, dest, owner := r.URL.Query().Get("state"), r.URL.Query().Get("dest"), r.URL.Query().Get("owner")
+	names := strings.Split(r.URL.Query().Get("names"), ",")
+	if state == "" || dest == "" || owner == "" || r.URL.Query().Get("names") == "" {
+		http.Error(w, "state, dest, owner and names are required", http.StatusBadRequest)
+		return
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	var acquired []common.Resource
+	for _, name := range names {
+		res, ok := s.resources[name]
+		if !ok || res.State != state || res.Owner != "" {
+			continue
+		}
+		res.State, res.Owner, res.LastUpdate = dest, owner, s.now()
+		s.resources[name] = res
+		acquired = append(acquired, res)
+	}
+	if len(acquired) == 0 {
+		http.Error(w, fmt.Sprintf("no resource in state %s among %v", state, names), http.StatusNotFound)
+		return
+	}
+	writeJSON(w, acquired)
+}
+
+func (s *Server) handleRelease(w http.ResponseWriter, r *http.Request) {
+	name, dest, owner := r.URL.Query().Get("name"), r.URL.Query().Get("dest"), r.URL.Query().Get("owner")
+	if name == "" || dest == "" || owner == "" {
+		http.Error(w, "name, dest and owner are required", http.StatusBadRequest)
+		return
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	res, ok := s.resources[name]
+	if !ok {
+		http.Error(w, fmt.Sprintf("resource %s does not exist", name), http.StatusNotFound)
+		return
+	}
+	if res.Owner != owner {
+		http.Error(w, fmt.Sprintf("resource %s is owned by %q, not %q", name, res.Owner, owner), http.StatusUnauthorized)
+		return
+	}
+	res.State, res.Owner, res.LastUpdate = dest, "", s.now()
+	s.resources[name] = res
+}
+
+func (s *Server) handleUpdate(w http.ResponseWriter, r *http.Request) {
+	name, state, owner := r.URL.Query().Get("name"), r.URL.Query().Get("state"), r.URL.Query().Get("owner")
+	if name == "" || state == "" || owner == "" {
+		http.Error(w, "name, state and owner are required", http.StatusBadRequest)
+		return
+	}
+	var userData *common.UserData
+	if r.ContentLength != 0 {
+		userData = &common.UserData{}
+		if err := json.NewDecoder(r.Body).Decode(userData); err != nil {
+			http.Error(w, fmt.Sprintf("invalid user data: %v", err), http.StatusBadRequest)
+			return
+		}
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	res, ok := s.resources[name]
+	if !ok {
+		http.Error(w, fmt.Sprintf("resource %s does not exist", name), http.StatusNotFound)
+		return
+	}
+	if res.Owner != owner {
+		http.Error(w, fmt.Sprintf("resource %s is owned by %q, not %q", name, res.Owner, owner), http.StatusUnauthorized)
+		return
+	}
+	if res.State != state {
+		http.Error(w, fmt.Sprintf("resource %s is in state %s, not %s", name, res.State, state), http.StatusConflict)
+		return
+	}
+	if userData != nil {
+		if res.UserData == nil {
+			res.UserData = &common.UserData{}
+		}
+		res.UserData = copyUserData(res.UserData).Update(userData)
+	}
+	res.LastUpdate = s.now()
+	s.resources[name] = res
+}
+
+func (s *Server) handleReset(w http.ResponseWriter, r *http.Request) {
+	rtype, state, dest := r.URL.Query().Get("type"), r.URL.Query().Get("state"), r.URL.Query().Get("dest")
+	expire, err := time.ParseDuration(r.URL.Query().Get("expire"))
+	if err != nil || rtype == "" || state == "" || dest == "" {
+		http.Error(w, "type, state, expire and dest are required", http.StatusBadRequest)
+		return
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	reset := map[string]string{}
+	deadline := s.now().Add(-expire)
+	for _, name := range s.sortedNames() {
+		res := s.resources[name]
+		if res.Type != rtype || res.State != state || res.Owner == "" || !res.LastUpdate.Before(deadline) {
+			continue
+		}
+		reset[name] = res.Owner
+		res.State, res.Owner, res.LastUpdate = dest, "", s.now()
+		s.resources[name] = res
+	}
+	writeJSON(w, reset)
+}
+
+func (s *Server) handleMetric(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, fmt.Sprintf("only GET is allowed, got %s", r.Method), http.StatusMethodNotAllowed)
+		return
+	}
+	rtype := r.URL.Query().Get("type")
+	if rtype == "" {
+		http.Error(w, "type is required", http.StatusBadRequest)
+		return
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if !s.hasType(rtype) {
+		http.Error(w, common.ResourceTypeNotFoundMessage(rtype), http.StatusNotFound)
+		return
+	}
+	metric := common.NewMetric(rtype)
+	for _, res := range s.resources {
+		if res.Type != rtype {
+			continue
+		}
+		metric.Current[res.State]++
+		metric.Owners[res.Owner]++
+	}
+	writeJSON(w, metric)
+}
+
+// copyUserData returns a copy of the user data so that snapshots returned by
+// Resources are not modified by later updates.
+func copyUserData(in *common.UserData) *common.UserData {
+	return common.UserDataFromMap(in.ToMap())
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,119 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fakeboskos_test
+
+import (
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"k8s.io/test-infra/kubetest/boskos/client"
+	"k8s.io/test-infra/kubetest/boskos/common"
+	"k8s.io/test-infra/kubetest/boskos/fakeboskos"
+)
+
+func newClient(t *testing.T, owner, url string) *client.Client {
+	c, err := client.NewClient(owner, url, "", "")
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	c.DistinguishNotFoundVsTypeNotFound = true
+	return c
+}
+
+func TestServer(t *testing.T) {
+	client.SleepFunc = func(time.Duration) {}
+	defer func() { client.SleepFunc = time.Sleep }()
+
+	server, err := fakeboskos.NewServerFromConfig(&common.BoskosConfig{Resources: []common.ResourceEntry{
+		{Type: "gce-project", State: common.Free, Names: []string{"project-a", "project-b"}},
+		{Type: "aws-account", State: common.Dirty, Names: []string{"account-a"}},
+	}})
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	s := httptest.NewServer(server)
+	defer s.Close()
+	alice, bob := newClient(t, "alice", s.URL), newClient(t, "bob", s.URL)
+
+	if _, err := alice.Acquire("unknown", common.Free, common.Busy); !errors.Is(err, client.ErrTypeNotFound) {
+		t.Errorf("expected ErrTypeNotFound for an unknown type, got %v", err)
+	}
+	first, err := alice.Acquire("gce-project", common.Free, common.Busy)
+	if err != nil {
+		t.Fatalf("failed to acquire: %v", err)
+	}
+	if first.Name != "project-a" || first.Owner != "alice" || first.State != common.Busy {
+		t.Errorf("unexpected acquired resource %+v", first)
+	}
+	if _, err := bob.Acquire("gce-project", common.Free, common.Busy); err != nil {
+		t.Fatalf("failed to acquire: %v", err)
+	}
+	if _, err := bob.Acquire("gce-project", common.Free, common.Busy); !errors.Is(err, client.ErrNotFound) {
+		t.Errorf("expected ErrNotFound once all resources are busy, got %v", err)
+	}
+
+	if err := alice.UpdateOne(first.Name, common.Busy, common.UserDataFromMap(common.UserDataMap{"zone": "us-east1"})); err != nil {
+		t.Fatalf("failed to update: %v", err)
+	}
+	if res, _ := server.Resource(first.Name); res.UserData.ToMap()["zone"] != "us-east1" {
+		t.Errorf("expected user data to be stored, got %v", res.UserData.ToMap())
+	}
+	if err := bob.Update(first.Name, common.Busy, nil); err == nil {
+		t.Error("expected an update from another owner to fail")
+	}
+
+	metric, err := alice.Metric("gce-project")
+	if err != nil {
+		t.Fatalf("failed to get metric: %v", err)
+	}
+	if metric.Current[common.Busy] != 2 || metric.Owners["alice"] != 1 || metric.Owners["bob"] != 1 {
+		t.Errorf("unexpected metric %+v", metric)
+	}
+
+	if err := alice.ReleaseOne(first.Name, common.Dirty); err != nil {
+		t.Fatalf("failed to release: %v", err)
+	}
+	if res, _ := server.Resource(first.Name); res.Owner != "" || res.State != common.Dirty {
+		t.Errorf("expected released resource to be unowned and dirty, got %+v", res)
+	}
+
+	acquired, err := alice.AcquireByState(common.Dirty, common.Cleaning, []string{"project-a", "account-a"})
+	if err != nil {
+		t.Fatalf("failed to acquire by state: %v", err)
+	}
+	if len(acquired) != 2 {
+		t.Errorf("expected two resources acquired by state, got %+v", acquired)
+	}
+
+	reset, err := alice.Reset("gce-project", common.Busy, -time.Minute, common.Dirty)
+	if err != nil {
+		t.Fatalf("failed to reset: %v", err)
+	}
+	if len(reset) != 1 || reset["project-b"] != "bob" {
+		t.Errorf("expected project-b owned by bob to be reset, got %v", reset)
+	}
+}
+
+func TestNewServerFromConfigRejectsDynamicResources(t *testing.T) {
+	if _, err := fakeboskos.NewServerFromConfig(&common.BoskosConfig{Resources: []common.ResourceEntry{
+		{Type: "gke-cluster", State: common.Free, MaxCount: 3},
+	}}); err == nil {
+		t.Error("expected an error for dynamic resources")
+	}
+}
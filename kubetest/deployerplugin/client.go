@@ -0,0 +1,144 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deployerplugin
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/rpc"
+	"net/rpc/jsonrpc"
+	"os"
+	"os/exec"
+	"time"
+)
+
+// Lookup returns the path of the plugin binary for the named deployer, or an
+// error if there is no such binary on $PATH.
+func Lookup(name string) (string, error) {
+	return exec.LookPath(BinaryPrefix + name)
+}
+
+// Client is a Deployer backed by a plugin.
+type Client struct {
+	cmd    *exec.Cmd
+	client *rpc.Client
+}
+
+var _ Deployer = &Client{}
+
+// Start launches the plugin binary at path and returns a client talking to it.
+// The plugin's stderr is forwarded to the stderr of the current process.
+func Start(path string) (*Client, error) {
+	cmd := exec.Command(path)
+	cmd.Stderr = os.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start deployer plugin %s: %w", path, err)
+	}
+	c := NewClient(&readWriteCloser{Reader: stdout, Writer: stdin})
+	c.cmd = cmd
+	return c, nil
+}
+
+// NewClient returns a client talking to a plugin over conn.
+func NewClient(conn io.ReadWriteCloser) *Client {
+	return &Client{client: jsonrpc.NewClient(conn)}
+}
+
+// Close closes the connection to the plugin and, if it was started by Start,
+// waits for it to exit.
+func (c *Client) Close() error {
+	err := c.client.Close()
+	if c.cmd != nil {
+		if werr := c.cmd.Wait(); err == nil {
+			err = werr
+		}
+	}
+	return err
+}
+
+func (c *Client) call(method string, args, reply interface{}) error {
+	err := c.client.Call(ServiceName+"."+method, args, reply)
+	var serverErr rpc.ServerError
+	if errors.As(err, &serverErr) {
+		// Errors returned by the deployer are not wrapped so they read the
+		// same as if the deployer was built into kubetest.
+		return errors.New(string(serverErr))
+	}
+	if err != nil {
+		return fmt.Errorf("deployer plugin call %s failed: %w", method, err)
+	}
+	return nil
+}
+
+// Up calls Up on the plugin.
+func (c *Client) Up() error {
+	return c.call("Up", &Empty{}, &Empty{})
+}
+
+// IsUp calls IsUp on the plugin.
+func (c *Client) IsUp() error {
+	return c.call("IsUp", &Empty{}, &Empty{})
+}
+
+// DumpClusterLogs calls DumpClusterLogs on the plugin.
+func (c *Client) DumpClusterLogs(localPath, gcsPath string) error {
+	return c.call("DumpClusterLogs", &DumpClusterLogsArgs{LocalPath: localPath, GCSPath: gcsPath}, &Empty{})
+}
+
+// TestSetup calls TestSetup on the plugin.
+func (c *Client) TestSetup() error {
+	return c.call("TestSetup", &Empty{}, &Empty{})
+}
+
+// Down calls Down on the plugin.
+func (c *Client) Down() error {
+	return c.call("Down", &Empty{}, &Empty{})
+}
+
+// GetClusterCreated calls GetClusterCreated on the plugin.
+func (c *Client) GetClusterCreated(gcpProject string) (time.Time, error) {
+	var reply GetClusterCreatedReply
+	if err := c.call("GetClusterCreated", &GetClusterCreatedArgs{GCPProject: gcpProject}, &reply); err != nil {
+		return time.Time{}, err
+	}
+	return reply.Created, nil
+}
+
+// KubectlCommand calls KubectlCommand on the plugin. It returns a nil command
+// if the plugin does not provide one.
+func (c *Client) KubectlCommand() (*exec.Cmd, error) {
+	var reply KubectlCommandReply
+	if err := c.call("KubectlCommand", &Empty{}, &reply); err != nil {
+		return nil, err
+	}
+	if reply.Path == "" {
+		return nil, nil
+	}
+	cmd := exec.Command(reply.Path, reply.Args...)
+	cmd.Env = reply.Env
+	cmd.Dir = reply.Dir
+	return cmd, nil
+}
@@ -0,0 +1,115 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deployerplugin_test
+
+import (
+	"errors"
+	"os/exec"
+	"testing"
+	"time"
+
+	"k8s.io/test-infra/kubetest/deployerplugin"
+	"k8s.io/test-infra/kubetest/deployerplugin/deployerplugintest"
+)
+
+type fakeDeployer struct {
+	up          bool
+	dumpedTo    string
+	created     time.Time
+	kubectlPath string
+}
+
+func (f *fakeDeployer) Up() error {
+	f.up = true
+	return nil
+}
+
+func (f *fakeDeployer) IsUp() error {
+	if !f.up {
+		return errors.New("cluster is down")
+	}
+	return nil
+}
+
+func (f *fakeDeployer) DumpClusterLogs(localPath, gcsPath string) error {
+	f.dumpedTo = localPath
+	return nil
+}
+
+func (f *fakeDeployer) TestSetup() error {
+	return nil
+}
+
+func (f *fakeDeployer) Down() error {
+	f.up = false
+	return nil
+}
+
+func (f *fakeDeployer) GetClusterCreated(gcpProject string) (time.Time, error) {
+	return f.created, nil
+}
+
+func (f *fakeDeployer) KubectlCommand() (*exec.Cmd, error) {
+	cmd := exec.Command(f.kubectlPath, "--context=fake")
+	cmd.Env = []string{"KUBECONFIG=/tmp/kubeconfig"}
+	cmd.Dir = "/tmp/cluster"
+	return cmd, nil
+}
+
+func TestClientRoundTrip(t *testing.T) {
+	d := &fakeDeployer{
+		created:     time.Date(2022, 5, 4, 3, 2, 1, 0, time.UTC),
+		kubectlPath: "/usr/local/bin/kubectl",
+	}
+	client := deployerplugintest.Connect(t, d)
+
+	if err := client.IsUp(); err == nil || err.Error() != "cluster is down" {
+		t.Errorf("expected the deployer's error, got %v", err)
+	}
+	if err := client.Up(); err != nil {
+		t.Fatalf("Up failed: %v", err)
+	}
+	if !d.up {
+		t.Error("expected Up to reach the deployer")
+	}
+	if err := client.DumpClusterLogs("/tmp/logs", ""); err != nil {
+		t.Fatalf("DumpClusterLogs failed: %v", err)
+	}
+	if d.dumpedTo != "/tmp/logs" {
+		t.Errorf("expected logs to be dumped to /tmp/logs, got %q", d.dumpedTo)
+	}
+	created, err := client.GetClusterCreated("project")
+	if err != nil {
+		t.Fatalf("GetClusterCreated failed: %v", err)
+	}
+	if !created.Equal(d.created) {
+		t.Errorf("expected creation time %v, got %v", d.created, created)
+	}
+	cmd, err := client.KubectlCommand()
+	if err != nil {
+		t.Fatalf("KubectlCommand failed: %v", err)
+	}
+	if cmd.Path != d.kubectlPath || len(cmd.Args) != 2 || cmd.Args[1] != "--context=fake" || len(cmd.Env) != 1 || cmd.Dir != "/tmp/cluster" {
+		t.Errorf("unexpected kubectl command %q (env %q, dir %q)", cmd.Args, cmd.Env, cmd.Dir)
+	}
+}
+
+func TestConformance(t *testing.T) {
+	deployerplugintest.Conformance(t, func() deployerplugin.Deployer {
+		return &fakeDeployer{kubectlPath: "/usr/local/bin/kubectl"}
+	})
+}
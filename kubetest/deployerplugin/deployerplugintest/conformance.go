@@ -0,0 +1,142 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package deployerplugintest provides a conformance harness for deployers
+// served through the deployer plugin protocol.
+package deployerplugintest
+
+import (
+	"io"
+	"os/exec"
+	"reflect"
+	"testing"
+
+	"k8s.io/test-infra/kubetest/deployerplugin"
+)
+
+// Connect serves d over an in-memory connection and returns a client for it.
+// The connection is closed when the test ends.
+func Connect(t *testing.T, d deployerplugin.Deployer) *deployerplugin.Client {
+	t.Helper()
+	serverReader, clientWriter := io.Pipe()
+	clientReader, serverWriter := io.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		done <- deployerplugin.Serve(d, serverReader, serverWriter)
+	}()
+	client := deployerplugin.NewClient(&pipeConn{PipeReader: clientReader, PipeWriter: clientWriter})
+	t.Cleanup(func() {
+		if err := client.Close(); err != nil {
+			t.Errorf("failed to close client: %v", err)
+		}
+		serverWriter.Close()
+		if err := <-done; err != nil {
+			t.Errorf("failed to serve deployer: %v", err)
+		}
+	})
+	return client
+}
+
+type pipeConn struct {
+	*io.PipeReader
+	*io.PipeWriter
+}
+
+func (c *pipeConn) Close() error {
+	c.PipeWriter.Close()
+	return c.PipeReader.Close()
+}
+
+// Conformance checks that every method of the deployers built by newDeployer
+// behaves the same when called directly and when called through the plugin
+// protocol. Each method is called on two freshly built deployers, one called
+// directly and one served, so stateful deployers see every call once and
+// results don't depend on the order of the calls. Methods listed in skip are
+// not called, which allows testing deployers with side effects that are
+// undesirable in unit tests.
+func Conformance(t *testing.T, newDeployer func() deployerplugin.Deployer, skip ...string) {
+	skipped := map[string]bool{}
+	for _, method := range skip {
+		skipped[method] = true
+	}
+
+	errorCases := []struct {
+		method string
+		call   func(deployerplugin.Deployer) error
+	}{
+		{method: "Up", call: deployerplugin.Deployer.Up},
+		{method: "IsUp", call: deployerplugin.Deployer.IsUp},
+		{method: "DumpClusterLogs", call: func(d deployerplugin.Deployer) error {
+			return d.DumpClusterLogs(t.TempDir(), "gs://bucket/logs")
+		}},
+		{method: "TestSetup", call: deployerplugin.Deployer.TestSetup},
+		{method: "Down", call: deployerplugin.Deployer.Down},
+	}
+	for _, tc := range errorCases {
+		if skipped[tc.method] {
+			continue
+		}
+		t.Run(tc.method, func(t *testing.T) {
+			expectErrors(t, tc.call(newDeployer()), tc.call(Connect(t, newDeployer())))
+		})
+	}
+
+	if !skipped["GetClusterCreated"] {
+		t.Run("GetClusterCreated", func(t *testing.T) {
+			expected, expectedErr := newDeployer().GetClusterCreated("project")
+			actual, actualErr := Connect(t, newDeployer()).GetClusterCreated("project")
+			expectErrors(t, expectedErr, actualErr)
+			if expectedErr == nil && !expected.Equal(actual) {
+				t.Errorf("expected creation time %v, got %v", expected, actual)
+			}
+		})
+	}
+
+	if !skipped["KubectlCommand"] {
+		t.Run("KubectlCommand", func(t *testing.T) {
+			expected, expectedErr := newDeployer().KubectlCommand()
+			actual, actualErr := Connect(t, newDeployer()).KubectlCommand()
+			expectErrors(t, expectedErr, actualErr)
+			if expectedErr != nil {
+				return
+			}
+			if (expected == nil) != (actual == nil) {
+				t.Fatalf("expected command %v, got %v", expected, actual)
+			}
+			if expected != nil && !sameCommand(expected, actual) {
+				t.Errorf("expected command %q (env %q), got %q (env %q)", expected.Args, expected.Env, actual.Args, actual.Env)
+			}
+		})
+	}
+}
+
+func expectErrors(t *testing.T, expected, actual error) {
+	t.Helper()
+	switch {
+	case expected == nil && actual != nil:
+		t.Errorf("expected no error, got %v", actual)
+	case expected != nil && actual == nil:
+		t.Errorf("expected error %v, got none", expected)
+	case expected != nil && expected.Error() != actual.Error():
+		t.Errorf("expected error %q, got %q", expected.Error(), actual.Error())
+	}
+}
+
+func sameCommand(expected, actual *exec.Cmd) bool {
+	return expected.Path == actual.Path &&
+		reflect.DeepEqual(expected.Args[1:], actual.Args[1:]) &&
+		reflect.DeepEqual(expected.Env, actual.Env)
+}
@@ -0,0 +1,80 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package deployerplugin implements the protocol kubetest uses to talk to
+// out-of-tree deployers.
+//
+// A deployer plugin is an executable named kubetest-deployer-<name> on $PATH.
+// kubetest starts it once per run and exchanges JSON-RPC 1.0 messages with it
+// over the plugin's stdin and stdout, calling the methods of the "Deployer"
+// service (Deployer.Up, Deployer.IsUp, ...). Plugins must therefore write
+// their logs to stderr only. Plugins written in Go can use Serve to implement
+// the protocol; plugins in other languages only have to speak JSON-RPC 1.0
+// with the argument and reply types defined in this file.
+package deployerplugin
+
+import (
+	"os/exec"
+	"time"
+)
+
+const (
+	// BinaryPrefix is the prefix of the name of every deployer plugin binary.
+	BinaryPrefix = "kubetest-deployer-"
+	// ServiceName is the name of the JSON-RPC service exposed by plugins.
+	ServiceName = "Deployer"
+)
+
+// Deployer is the set of operations a plugin implements. It matches the
+// deployer interface of kubetest.
+type Deployer interface {
+	Up() error
+	IsUp() error
+	DumpClusterLogs(localPath, gcsPath string) error
+	TestSetup() error
+	Down() error
+	GetClusterCreated(gcpProject string) (time.Time, error)
+	KubectlCommand() (*exec.Cmd, error)
+}
+
+// Empty is used as argument and reply of the methods that do not exchange data.
+type Empty struct{}
+
+// DumpClusterLogsArgs are the arguments of Deployer.DumpClusterLogs.
+type DumpClusterLogsArgs struct {
+	LocalPath string `json:"localPath"`
+	GCSPath   string `json:"gcsPath"`
+}
+
+// GetClusterCreatedArgs are the arguments of Deployer.GetClusterCreated.
+type GetClusterCreatedArgs struct {
+	GCPProject string `json:"gcpProject"`
+}
+
+// GetClusterCreatedReply is the reply of Deployer.GetClusterCreated.
+type GetClusterCreatedReply struct {
+	Created time.Time `json:"created"`
+}
+
+// KubectlCommandReply is the reply of Deployer.KubectlCommand. Path is empty
+// if the deployer does not provide a kubectl command, in which case kubetest
+// falls back to its default.
+type KubectlCommandReply struct {
+	Path string   `json:"path,omitempty"`
+	Args []string `json:"args,omitempty"`
+	Env  []string `json:"env,omitempty"`
+	Dir  string   `json:"dir,omitempty"`
+}
@@ -0,0 +1,103 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deployerplugin
+
+import (
+	"io"
+	"net/rpc"
+	"net/rpc/jsonrpc"
+)
+
+// service adapts a Deployer to the method signatures required by net/rpc.
+type service struct {
+	deployer Deployer
+}
+
+func (s *service) Up(_ *Empty, _ *Empty) error {
+	return s.deployer.Up()
+}
+
+func (s *service) IsUp(_ *Empty, _ *Empty) error {
+	return s.deployer.IsUp()
+}
+
+func (s *service) DumpClusterLogs(args *DumpClusterLogsArgs, _ *Empty) error {
+	return s.deployer.DumpClusterLogs(args.LocalPath, args.GCSPath)
+}
+
+func (s *service) TestSetup(_ *Empty, _ *Empty) error {
+	return s.deployer.TestSetup()
+}
+
+func (s *service) Down(_ *Empty, _ *Empty) error {
+	return s.deployer.Down()
+}
+
+func (s *service) GetClusterCreated(args *GetClusterCreatedArgs, reply *GetClusterCreatedReply) error {
+	created, err := s.deployer.GetClusterCreated(args.GCPProject)
+	if err != nil {
+		return err
+	}
+	reply.Created = created
+	return nil
+}
+
+func (s *service) KubectlCommand(_ *Empty, reply *KubectlCommandReply) error {
+	cmd, err := s.deployer.KubectlCommand()
+	if err != nil || cmd == nil {
+		return err
+	}
+	reply.Path = cmd.Path
+	if len(cmd.Args) > 1 {
+		reply.Args = cmd.Args[1:]
+	}
+	reply.Env = cmd.Env
+	reply.Dir = cmd.Dir
+	return nil
+}
+
+// Serve serves requests for the deployer read from r and writes the replies
+// to w until r is exhausted. Plugin binaries typically call it with os.Stdin
+// and os.Stdout.
+func Serve(d Deployer, r io.Reader, w io.Writer) error {
+	server := rpc.NewServer()
+	if err := server.RegisterName(ServiceName, &service{deployer: d}); err != nil {
+		return err
+	}
+	server.ServeCodec(jsonrpc.NewServerCodec(&readWriteCloser{Reader: r, Writer: w}))
+	return nil
+}
+
+// readWriteCloser joins a reader and a writer into an io.ReadWriteCloser.
+// Closing it closes whichever of the two implement io.Closer.
+type readWriteCloser struct {
+	io.Reader
+	io.Writer
+}
+
+func (rwc *readWriteCloser) Close() error {
+	var err error
+	if c, ok := rwc.Writer.(io.Closer); ok {
+		err = c.Close()
+	}
+	if c, ok := rwc.Reader.(io.Closer); ok {
+		if cerr := c.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
@@ -0,0 +1,38 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"testing"
+
+	"k8s.io/test-infra/kubetest/deployerplugin"
+	"k8s.io/test-infra/kubetest/deployerplugin/deployerplugintest"
+)
+
+func TestNoneDeployerPluginConformance(t *testing.T) {
+	deployerplugintest.Conformance(t, func() deployerplugin.Deployer { return noneDeploy{} })
+}
+
+func TestLocalDeployerPluginConformance(t *testing.T) {
+	t.Setenv("KUBECONFIG", "")
+	t.Setenv("KUBERNETES_CONFORMANCE_TEST", "")
+	t.Setenv("KUBERNETES_PROVIDER", "")
+	// DumpClusterLogs and Down shell out to manipulate the host.
+	deployerplugintest.Conformance(t, func() deployerplugin.Deployer {
+		return localCluster{tempDir: t.TempDir()}
+	}, "DumpClusterLogs", "Down")
+}
@@ -157,7 +157,7 @@ func run(deploy deployer, o options) error {
 	}
 
 	if o.upgradeArgs != "" {
-		if err := control.XMLWrap(&suite, "test setup", deploy.TestSetup); err != nil {
+		if err := control.XMLWrap(&suite, "test setup", testSetup(deploy)); err != nil {
 			errs = util.AppendError(errs, err)
 		} else {
 			errs = util.AppendError(errs, control.XMLWrap(&suite, "UpgradeTest", func() error {
@@ -179,7 +179,7 @@ func run(deploy deployer, o options) error {
 
 	testArgs := argFields(o.testArgs, dump, o.clusterIPRange)
 	if o.test {
-		if err := control.XMLWrap(&suite, "test setup", deploy.TestSetup); err != nil {
+		if err := control.XMLWrap(&suite, "test setup", testSetup(deploy)); err != nil {
 			errs = util.AppendError(errs, err)
 		} else {
 			if o.preTestCmd != "" {
@@ -235,7 +235,7 @@ func run(deploy deployer, o options) error {
 	}
 
 	if kubemarkUpErr == nil && o.testCmd != "" {
-		if err := control.XMLWrap(&suite, "test setup", deploy.TestSetup); err != nil {
+		if err := control.XMLWrap(&suite, "test setup", testSetup(deploy)); err != nil {
 			errs = util.AppendError(errs, err)
 		} else {
 			if o.preTestCmd != "" {
@@ -64,6 +64,13 @@ var (
 	kindClusterName = flag.String("kind-cluster-name", kindClusterNameDefault,
 		"(kind only) Name of the kind cluster.")
 	kindNodeImage = flag.String("kind-node-image", "", "(kind only) name:tag of the node image to start the cluster. If build is enabled, this is ignored and built image is used.")
+	kindClusters  = flag.String("kind-clusters", "",
+		"(kind only) Comma-separated list of clusters to create, each in the form name or name=config-path. "+
+			"Overrides --kind-cluster-name and --kind-config-path. The first cluster is the one the tests run against by default.")
+	kindKubeRoot = flag.String("kind-kube-root", "",
+		"(kind only) Path to a local kubernetes checkout to build from. Defaults to $GOPATH/src/k8s.io/kubernetes.")
+	kindNodeImageCache = flag.Bool("kind-node-image-cache", false,
+		"(kind only) Tag built node images with the kubernetes commit SHA and reuse an existing image for the same commit instead of rebuilding it.")
 )
 
 var (
@@ -88,6 +95,43 @@ type Deployer struct {
 	kindNodeImage      string
 	kindBaseImage      string
 	kindClusterName    string
+	kindNodeImageCache bool
+	// clusters holds every cluster managed by this deployer. The first
+	// entry mirrors kindClusterName, configPath and kindKubeconfigPath.
+	clusters []cluster
+}
+
+// cluster describes a single named kind cluster.
+type cluster struct {
+	name           string
+	configPath     string
+	kubeconfigPath string
+}
+
+// parseClusters parses the value of --kind-clusters into name and config path
+// pairs. Names must be unique.
+func parseClusters(value string) ([]cluster, error) {
+	var clusters []cluster
+	seen := map[string]bool{}
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, configPath := entry, ""
+		if i := strings.Index(entry, "="); i >= 0 {
+			name, configPath = entry[:i], entry[i+1:]
+		}
+		if name == "" {
+			return nil, fmt.Errorf("invalid cluster %q: empty name", entry)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("cluster %q specified more than once", name)
+		}
+		seen[name] = true
+		clusters = append(clusters, cluster{name: name, configPath: configPath})
+	}
+	return clusters, nil
 }
 
 // NewDeployer creates a new kind deployer.
@@ -119,30 +163,47 @@ func initializeDeployer(ctl *process.Control, buildType string) (*Deployer, erro
 		}
 	}
 
-	kubeconfigPath := *kindKubeconfigPath
-	if kubeconfigPath == "" {
-		// Create directory for the cluster kube config
-		kindClusterDir := filepath.Join(kindBinaryDir, *kindClusterName)
-		if err := os.MkdirAll(kindClusterDir, 0770); err != nil {
+	clusters := []cluster{{name: *kindClusterName, configPath: *kindConfigPath}}
+	if *kindClusters != "" {
+		if clusters, err = parseClusters(*kindClusters); err != nil {
 			return nil, err
 		}
-		kubeconfigPath = filepath.Join(kindClusterDir, "kubeconfig")
+		if len(clusters) == 0 {
+			return nil, fmt.Errorf("--kind-clusters did not specify any cluster")
+		}
+	}
+	if *kindKubeconfigPath != "" && len(clusters) > 1 {
+		return nil, fmt.Errorf("--kind-kubeconfig-path cannot be used with more than one cluster")
+	}
+	for i := range clusters {
+		clusters[i].kubeconfigPath = *kindKubeconfigPath
+		if clusters[i].kubeconfigPath == "" {
+			// Create directory for the cluster kube config
+			kindClusterDir := filepath.Join(kindBinaryDir, clusters[i].name)
+			if err := os.MkdirAll(kindClusterDir, 0770); err != nil {
+				return nil, err
+			}
+			clusters[i].kubeconfigPath = filepath.Join(kindClusterDir, "kubeconfig")
+		}
 	}
 
 	d := &Deployer{
 		control:            ctl,
 		buildType:          buildType,
-		configPath:         *kindConfigPath,
+		configPath:         clusters[0].configPath,
 		kindBinaryDir:      kindBinaryDir,
 		kindBinaryPath:     filepath.Join(kindBinaryDir, "kind"),
 		kindBinaryVersion:  *kindBinaryVersion,
-		kindKubeconfigPath: kubeconfigPath,
+		kindKubeconfigPath: clusters[0].kubeconfigPath,
 		kindNodeImage:      *kindNodeImage,
-		kindClusterName:    *kindClusterName,
+		kindClusterName:    clusters[0].name,
+		kindNodeImageCache: *kindNodeImageCache,
+		clusters:           clusters,
 	}
 	// Obtain the import paths for k8s and kind
-	d.importPathK8s, err = d.getImportPath("k8s.io/kubernetes")
-	if err != nil {
+	if *kindKubeRoot != "" {
+		d.importPathK8s = *kindKubeRoot
+	} else if d.importPathK8s, err = d.getImportPath("k8s.io/kubernetes"); err != nil {
 		return nil, err
 	}
 	d.importPathKind, err = d.getImportPath("sigs.k8s.io/kind")
@@ -171,10 +232,26 @@ func (d *Deployer) getImportPath(path string) (string, error) {
 	return filepath.Join(trimmed, "src", path), nil
 }
 
-// setKubeConfigEnv sets the KUBECONFIG environment variable.
+// setKubeConfigEnv sets the KUBECONFIG environment variable. When more than one
+// cluster is managed, the kubeconfigs of all clusters are listed with the
+// first cluster's one leading so that its context stays the current one.
 func (d *Deployer) setKubeConfigEnv() error {
 	log.Println("kind.go:setKubeConfigEnv()")
-	return os.Setenv("KUBECONFIG", d.kindKubeconfigPath)
+	paths := []string{d.kindKubeconfigPath}
+	for _, c := range d.clusters[1:] {
+		paths = append(paths, c.kubeconfigPath)
+	}
+	return os.Setenv("KUBECONFIG", strings.Join(paths, string(os.PathListSeparator)))
+}
+
+// Kubeconfigs returns the kubeconfig of every cluster in the form name=path,
+// in the order of --kind-clusters.
+func (d *Deployer) Kubeconfigs() []string {
+	kubeconfigs := make([]string, 0, len(d.clusters))
+	for _, c := range d.clusters {
+		kubeconfigs = append(kubeconfigs, c.name+"="+c.kubeconfigPath)
+	}
+	return kubeconfigs
 }
 
 // prepareKindBinary either builds kind from source or pulls a binary from GitHub.
@@ -235,20 +312,40 @@ func (d *Deployer) Build() error {
 		buildNodeImage = kindNodeImageLatest
 	}
 
-	args := []string{"build", "node-image", "--type=" + buildType, flagLogLevel, "--kube-root=" + d.importPathK8s}
-	if buildNodeImage != "" {
-		args = append(args, "--image="+buildNodeImage)
-		// override user-specified node image
-		d.kindNodeImage = buildNodeImage
-	}
-	if d.kindBaseImage != "" {
-		args = append(args, "--base-image="+d.kindBaseImage)
+	cached := false
+	if d.kindNodeImageCache {
+		image, err := d.cachedNodeImage()
+		if err != nil {
+			return err
+		}
+		if image != "" {
+			buildNodeImage = image
+			cached, err = d.imageExists(image)
+			if err != nil {
+				return err
+			}
+		}
 	}
 
-	// Build the node image (including kubernetes)
-	cmd := exec.Command("kind", args...)
-	if err := d.control.FinishRunning(cmd); err != nil {
-		return err
+	if cached {
+		log.Printf("Reusing cached kind node image %q.", buildNodeImage)
+		d.kindNodeImage = buildNodeImage
+	} else {
+		args := []string{"build", "node-image", "--type=" + buildType, flagLogLevel, "--kube-root=" + d.importPathK8s}
+		if buildNodeImage != "" {
+			args = append(args, "--image="+buildNodeImage)
+			// override user-specified node image
+			d.kindNodeImage = buildNodeImage
+		}
+		if d.kindBaseImage != "" {
+			args = append(args, "--base-image="+d.kindBaseImage)
+		}
+
+		// Build the node image (including kubernetes)
+		cmd := exec.Command("kind", args...)
+		if err := d.control.FinishRunning(cmd); err != nil {
+			return err
+		}
 	}
 
 	// Ginkgo v1 is used by Kubernetes 1.24 and earlier and exists in the vendor directory.
@@ -302,14 +399,68 @@ func (d *Deployer) Build() error {
 	return nil
 }
 
-// Up creates a kind cluster. Allows passing node image and config.
+// cachedNodeImage returns the node image name keyed by the commit SHA of the
+// kubernetes checkout, or an empty string if the checkout has local changes
+// and therefore cannot be cached.
+func (d *Deployer) cachedNodeImage() (string, error) {
+	cmd := exec.Command("git", "status", "--porcelain")
+	cmd.Dir = d.importPathK8s
+	o, err := d.control.Output(cmd)
+	if err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(string(o)) != "" {
+		log.Printf("Kubernetes checkout at %q has local changes; not using the node image cache.", d.importPathK8s)
+		return "", nil
+	}
+	cmd = exec.Command("git", "rev-parse", "HEAD")
+	cmd.Dir = d.importPathK8s
+	o, err = d.control.Output(cmd)
+	if err != nil {
+		return "", err
+	}
+	return nodeImageForCommit(strings.TrimSpace(string(o))), nil
+}
+
+// nodeImageForCommit returns the node image name used to cache a build of the given commit.
+func nodeImageForCommit(sha string) string {
+	return "kindest/node:kubetest-" + sha
+}
+
+// imageExists checks if a docker image is present locally.
+func (d *Deployer) imageExists(image string) (bool, error) {
+	cmd := exec.Command("docker", "image", "ls", "--quiet", image)
+	o, err := d.control.Output(cmd)
+	if err != nil {
+		return false, err
+	}
+	return strings.TrimSpace(string(o)) != "", nil
+}
+
+// Up creates the kind clusters. Allows passing node image and config.
 func (d *Deployer) Up() error {
 	log.Println("kind.go:Up()")
+	for _, c := range d.clusters {
+		if err := d.up(c); err != nil {
+			return fmt.Errorf("failed to create cluster %q: %w", c.name, err)
+		}
+	}
+	log.Println("*************************************************************************************************")
+	log.Println("Cluster is UP")
+	for _, c := range d.clusters {
+		log.Printf("Run: \"export KUBECONFIG=%s\" to access to %q\n", c.kubeconfigPath, c.name)
+	}
+	log.Println("*************************************************************************************************")
+	return nil
+}
+
+// up creates a single kind cluster.
+func (d *Deployer) up(c cluster) error {
 	args := []string{"create", "cluster", "--retain", "--wait=1m", flagLogLevel}
 
 	// Handle the config flag.
-	if d.configPath != "" {
-		args = append(args, "--config="+d.configPath)
+	if c.configPath != "" {
+		args = append(args, "--config="+c.configPath)
 	}
 
 	// Handle the node image flag if we built a new node image.
@@ -318,37 +469,40 @@ func (d *Deployer) Up() error {
 	}
 
 	// Use a specific cluster name.
-	if d.kindClusterName != "" {
-		args = append(args, "--name="+d.kindClusterName)
+	if c.name != "" {
+		args = append(args, "--name="+c.name)
 	}
 
 	// Use specific path for the kubeconfig
-	if d.kindKubeconfigPath != "" {
-		args = append(args, "--kubeconfig="+d.kindKubeconfigPath)
+	if c.kubeconfigPath != "" {
+		args = append(args, "--kubeconfig="+c.kubeconfigPath)
 	}
 
 	// Build the kind cluster.
 	cmd := exec.Command("kind", args...)
-	if err := d.control.FinishRunning(cmd); err != nil {
-		return err
-	}
-	log.Println("*************************************************************************************************")
-	log.Println("Cluster is UP")
-	log.Printf("Run: \"export KUBECONFIG=%s\" to access to it\n", d.kindKubeconfigPath)
-	log.Println("*************************************************************************************************")
-	return nil
+	return d.control.FinishRunning(cmd)
 }
 
-// IsUp verifies if the cluster created by Up() is functional.
+// IsUp verifies if the clusters created by Up() are functional.
 func (d *Deployer) IsUp() error {
 	log.Println("kind.go:IsUp()")
 
+	for _, c := range d.clusters {
+		if err := d.isUp(c); err != nil {
+			return fmt.Errorf("cluster %q: %w", c.name, err)
+		}
+	}
+	return nil
+}
+
+// isUp verifies if a single cluster is functional.
+func (d *Deployer) isUp(c cluster) error {
 	// Check if kubectl reports nodes.
 	cmd, err := d.KubectlCommand()
 	if err != nil {
 		return err
 	}
-	cmd.Args = append(cmd.Args, []string{"get", "nodes", "--no-headers"}...)
+	cmd.Args = append(cmd.Args, []string{"--kubeconfig=" + c.kubeconfigPath, "get", "nodes", "--no-headers"}...)
 	o, err := d.control.Output(cmd)
 	if err != nil {
 		return err
@@ -364,19 +518,27 @@ func (d *Deployer) IsUp() error {
 	return nil
 }
 
-// DumpClusterLogs dumps the logs for this cluster in localPath.
+// DumpClusterLogs dumps the logs for the clusters in localPath. When more
+// than one cluster is managed, the logs of each cluster are placed in a
+// subdirectory named after it.
 func (d *Deployer) DumpClusterLogs(localPath, gcsPath string) error {
 	log.Println("kind.go:DumpClusterLogs()")
-	args := []string{"export", "logs", localPath, flagLogLevel}
+	for _, c := range d.clusters {
+		path := localPath
+		if len(d.clusters) > 1 {
+			path = filepath.Join(localPath, c.name)
+		}
+		args := []string{"export", "logs", path, flagLogLevel}
 
-	// Use a specific cluster name.
-	if d.kindClusterName != "" {
-		args = append(args, "--name="+d.kindClusterName)
-	}
+		// Use a specific cluster name.
+		if c.name != "" {
+			args = append(args, "--name="+c.name)
+		}
 
-	cmd := exec.Command("kind", args...)
-	if err := d.control.FinishRunning(cmd); err != nil {
-		log.Printf("kind.go:DumpClusterLogs(): ignoring error: %v", err)
+		cmd := exec.Command("kind", args...)
+		if err := d.control.FinishRunning(cmd); err != nil {
+			log.Printf("kind.go:DumpClusterLogs(): ignoring error for cluster %q: %v", c.name, err)
+		}
 	}
 	return nil
 }
@@ -391,11 +553,11 @@ func (d *Deployer) TestSetup() error {
 	}
 
 	// Proceed only if a cluster exists.
-	exists, err := d.clusterExists()
+	existing, err := d.existingClusters()
 	if err != nil {
 		return err
 	}
-	if !exists {
+	if !existing[d.kindClusterName] {
 		log.Printf("kind.go:TestSetup(): no such cluster %q; skipping the setup of KUBECONFIG!", d.kindClusterName)
 		return nil
 	}
@@ -408,47 +570,55 @@ func (d *Deployer) TestSetup() error {
 	return nil
 }
 
-// clusterExists checks if a kind cluster with 'name' exists
-func (d *Deployer) clusterExists() (bool, error) {
-	log.Println("kind.go:clusterExists()")
+// existingClusters returns the set of kind clusters that currently exist.
+func (d *Deployer) existingClusters() (map[string]bool, error) {
+	log.Println("kind.go:existingClusters()")
 
 	cmd := exec.Command("kind")
 	cmd.Args = append(cmd.Args, []string{"get", "clusters"}...)
 	out, err := d.control.Output(cmd)
 	if err != nil {
-		return false, err
+		return nil, err
 	}
 
-	lines := strings.Split(string(out), "\n")
-	for _, line := range lines {
-		if line == d.kindClusterName {
-			log.Printf("kind.go:clusterExists(): found %q", d.kindClusterName)
-			return true, nil
+	existing := map[string]bool{}
+	for _, line := range strings.Split(string(out), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			existing[line] = true
 		}
 	}
-	return false, nil
+	return existing, nil
 }
 
-// Down tears down the cluster.
+// Down tears down the clusters.
 func (d *Deployer) Down() error {
 	log.Println("kind.go:Down()")
 
-	// Proceed only if a cluster exists.
-	exists, err := d.clusterExists()
+	existing, err := d.existingClusters()
 	if err != nil {
 		return err
 	}
-	if !exists {
-		log.Printf("kind.go:Down(): no such cluster %q; skipping 'delete'!", d.kindClusterName)
-		return nil
+	for _, c := range d.clusters {
+		// Proceed only if a cluster exists.
+		if !existing[c.name] {
+			log.Printf("kind.go:Down(): no such cluster %q; skipping 'delete'!", c.name)
+			continue
+		}
+		if err := d.down(c); err != nil {
+			return fmt.Errorf("failed to delete cluster %q: %w", c.name, err)
+		}
 	}
+	return nil
+}
 
-	log.Printf("kind.go:Down(): deleting cluster: %s", d.kindClusterName)
+// down deletes a single kind cluster.
+func (d *Deployer) down(c cluster) error {
+	log.Printf("kind.go:Down(): deleting cluster: %s", c.name)
 	args := []string{"delete", "cluster", flagLogLevel}
 
 	// Use a specific cluster name.
-	if d.kindClusterName != "" {
-		args = append(args, "--name="+d.kindClusterName)
+	if c.name != "" {
+		args = append(args, "--name="+c.name)
 	}
 
 	// Delete the cluster.
@@ -457,8 +627,8 @@ func (d *Deployer) Down() error {
 		return err
 	}
 
-	if d.kindClusterName != "" {
-		kindClusterDir := filepath.Join(d.kindBinaryDir, d.kindClusterName)
+	if c.name != "" {
+		kindClusterDir := filepath.Join(d.kindBinaryDir, c.name)
 		if _, err := os.Stat(kindClusterDir); !os.IsNotExist(err) {
 			if err := os.RemoveAll(kindClusterDir); err != nil {
 				return err
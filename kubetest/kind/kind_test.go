@@ -0,0 +1,89 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kind
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseClusters(t *testing.T) {
+	cases := []struct {
+		name      string
+		value     string
+		expected  []cluster
+		expectErr bool
+	}{
+		{
+			name:  "single cluster without config",
+			value: "host",
+			expected: []cluster{
+				{name: "host"},
+			},
+		},
+		{
+			name:  "multiple clusters with and without config",
+			value: "host=/tmp/host.yaml, member1,member2=/tmp/member.yaml",
+			expected: []cluster{
+				{name: "host", configPath: "/tmp/host.yaml"},
+				{name: "member1"},
+				{name: "member2", configPath: "/tmp/member.yaml"},
+			},
+		},
+		{
+			name:  "empty entries are ignored",
+			value: "host,,",
+			expected: []cluster{
+				{name: "host"},
+			},
+		},
+		{
+			name:      "empty name is rejected",
+			value:     "=/tmp/config.yaml",
+			expectErr: true,
+		},
+		{
+			name:      "duplicate names are rejected",
+			value:     "host,host=/tmp/config.yaml",
+			expectErr: true,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := parseClusters(tc.value)
+			if tc.expectErr {
+				if err == nil {
+					t.Fatalf("expected an error, got clusters %v", actual)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(actual, tc.expected) {
+				t.Errorf("expected clusters %v, got %v", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestNodeImageForCommit(t *testing.T) {
+	const sha = "0123456789abcdef0123456789abcdef01234567"
+	if actual, expected := nodeImageForCommit(sha), "kindest/node:kubetest-"+sha; actual != expected {
+		t.Errorf("expected %q, got %q", expected, actual)
+	}
+}
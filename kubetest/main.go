@@ -22,6 +22,7 @@ import (
 	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"log"
 	"math/rand"
 	"os"
@@ -37,6 +38,7 @@ import (
 	"k8s.io/test-infra/kubetest/boskos/client"
 
 	"k8s.io/test-infra/kubetest/conformance"
+	"k8s.io/test-infra/kubetest/deployerplugin"
 	"k8s.io/test-infra/kubetest/kind"
 	"k8s.io/test-infra/kubetest/process"
 	"k8s.io/test-infra/kubetest/util"
@@ -242,6 +244,34 @@ type publisher interface {
 	Publish() error
 }
 
+// kubeconfigLister is implemented by deployers that manage more than one
+// cluster and want to expose all of their kubeconfigs to the test step
+type kubeconfigLister interface {
+	// Kubeconfigs returns the kubeconfig of every cluster in the form
+	// name=path, the cluster the tests run against by default first
+	Kubeconfigs() []string
+}
+
+// testSetup runs the TestSetup of the deployer and, if the deployer manages
+// several clusters, exports their kubeconfigs as $KUBETEST_KUBECONFIGS in the
+// form name=path,name=path with the default cluster first
+func testSetup(deploy deployer) func() error {
+	return func() error {
+		if err := deploy.TestSetup(); err != nil {
+			return err
+		}
+		lister, ok := deploy.(kubeconfigLister)
+		if !ok {
+			return nil
+		}
+		kubeconfigs := lister.Kubeconfigs()
+		if len(kubeconfigs) < 2 {
+			return nil
+		}
+		return os.Setenv("KUBETEST_KUBECONFIGS", strings.Join(kubeconfigs, ","))
+	}
+}
+
 func getDeployer(o *options) (deployer, error) {
 	switch o.deployment {
 	case "bash":
@@ -265,7 +295,17 @@ func getDeployer(o *options) (deployer, error) {
 	case "aks":
 		return newAksDeployer()
 	default:
-		return nil, fmt.Errorf("unknown deployment strategy %q", o.deployment)
+		// Fall back to a kubetest-deployer-<name> plugin on $PATH.
+		path, err := deployerplugin.Lookup(o.deployment)
+		if err != nil {
+			return nil, fmt.Errorf("unknown deployment strategy %q", o.deployment)
+		}
+		log.Printf("Using deployer plugin %s", path)
+		plugin, err := deployerplugin.Start(path)
+		if err != nil {
+			return nil, err
+		}
+		return plugin, nil
 	}
 }
 
@@ -363,6 +403,14 @@ func complete(o *options) error {
 	if err != nil {
 		return fmt.Errorf("error creating deployer: %w", err)
 	}
+	// Deployer plugins run as a separate process that must be stopped.
+	if closer, ok := deploy.(io.Closer); ok {
+		defer func() {
+			if err := closer.Close(); err != nil {
+				log.Printf("Failed to stop deployer %v: %v", o.deployment, err)
+			}
+		}()
+	}
 
 	// Check soaking before run tests
 	if o.soak {
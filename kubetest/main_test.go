@@ -242,3 +242,45 @@ func TestPrepareParallelism(t *testing.T) {
 		}
 	}
 }
+
+type fakeMultiClusterDeploy struct {
+	noneDeploy
+	kubeconfigs []string
+}
+
+func (d fakeMultiClusterDeploy) Kubeconfigs() []string {
+	return d.kubeconfigs
+}
+
+func TestTestSetupExportsKubeconfigs(t *testing.T) {
+	cases := []struct {
+		name        string
+		kubeconfigs []string
+		expected    string
+	}{
+		{
+			name:     "no clusters",
+			expected: "",
+		},
+		{
+			name:        "single cluster is not exported",
+			kubeconfigs: []string{"kind=/tmp/kind/kubeconfig"},
+			expected:    "",
+		},
+		{
+			name:        "multiple clusters keep the default cluster first",
+			kubeconfigs: []string{"member=/tmp/member/kubeconfig", "host=/tmp/host/kubeconfig"},
+			expected:    "member=/tmp/member/kubeconfig,host=/tmp/host/kubeconfig",
+		},
+	}
+	for _, tc := range cases {
+		os.Unsetenv("KUBETEST_KUBECONFIGS")
+		if err := testSetup(fakeMultiClusterDeploy{kubeconfigs: tc.kubeconfigs})(); err != nil {
+			t.Errorf("%s: unexpected error: %v", tc.name, err)
+		}
+		if actual := os.Getenv("KUBETEST_KUBECONFIGS"); actual != tc.expected {
+			t.Errorf("%s: expected %q, got %q", tc.name, tc.expected, actual)
+		}
+	}
+	os.Unsetenv("KUBETEST_KUBECONFIGS")
+}
@@ -129,6 +129,10 @@ type options struct {
 	tokens          int
 	tokenBurst      int
 	github          flagutil.GitHubOptions
+	plan            string
+	planMarkdown    string
+	fromPlan        string
+	maxDeletes      int
 }
 
 func gatherOptions() (opts options, deprecatedOptions bool) {
@@ -151,6 +155,10 @@ func gatherOptions() (opts options, deprecatedOptions bool) {
 	fs.StringVar(&o.docsOutput, "docs-output", "", "Path to output file for docs")
 	fs.IntVar(&o.tokens, "tokens", defaultTokens, "Throttle hourly token consumption (0 to disable). DEPRECATED: use --github-hourly-tokens")
 	fs.IntVar(&o.tokenBurst, "token-burst", defaultBurst, "Allow consuming a subset of hourly tokens in a short burst. DEPRECATED: use --github-allowed-burst")
+	fs.StringVar(&o.plan, "plan", "", "Write the planned changes as JSON to this path instead of applying them")
+	fs.StringVar(&o.planMarkdown, "plan-markdown", "", "Path to write the markdown summary of --plan to (defaults to the --plan path with a .md extension)")
+	fs.StringVar(&o.fromPlan, "from-plan", "", "Apply the changes of a plan written by --plan, skipping the ones already applied. Progress is saved back to the plan file")
+	fs.IntVar(&o.maxDeletes, "max-deletes", 0, "Refuse to apply changes if more than this many labels would be deleted or migrated in a single repo (0 to disable)")
 	o.github.AddCustomizedFlags(fs, flagutil.ThrottlerDefaults(defaultTokens, defaultBurst))
 	fs.Parse(os.Args[1:])
 
@@ -635,46 +643,8 @@ func (ru RepoUpdates) DoUpdates(org string, gc client) error {
 		go func(updates <-chan repoUpdate) {
 			defer wg.Done()
 			for item := range updates {
-				repo := item.repo
-				update := item.update
-				logrus.WithField("org", org).WithField("repo", repo).WithField("why", update.Why).Debug("running update")
-				switch update.Why {
-				case "missing":
-					err := gc.AddRepoLabel(org, repo, update.Wanted.Name, update.Wanted.Description, update.Wanted.Color)
-					if err != nil {
-						errChan <- err
-					}
-				case "change", "rename":
-					err := gc.UpdateRepoLabel(org, repo, update.Current.Name, update.Wanted.Name, update.Wanted.Description, update.Wanted.Color)
-					if err != nil {
-						errChan <- err
-					}
-				case "dead":
-					err := gc.DeleteRepoLabel(org, repo, update.Current.Name)
-					if err != nil {
-						errChan <- err
-					}
-				case "migrate":
-					issues, err := gc.FindIssuesWithOrg(org, fmt.Sprintf("is:open repo:%s/%s label:\"%s\" -label:\"%s\"", org, repo, update.Current.Name, update.Wanted.Name), "", false)
-					if err != nil {
-						errChan <- err
-					}
-					if len(issues) == 0 {
-						if err = gc.DeleteRepoLabel(org, repo, update.Current.Name); err != nil {
-							errChan <- err
-						}
-					}
-					for _, i := range issues {
-						if err = gc.AddLabel(org, repo, i.Number, update.Wanted.Name); err != nil {
-							errChan <- err
-							continue
-						}
-						if err = gc.RemoveLabel(org, repo, i.Number, update.Current.Name); err != nil {
-							errChan <- err
-						}
-					}
-				default:
-					errChan <- errors.New("unknown label operation: " + update.Why)
+				for _, err := range applyUpdate(gc, org, item.repo, item.update) {
+					errChan <- err
 				}
 			}
 		}(updateChan)
@@ -695,6 +665,51 @@ func (ru RepoUpdates) DoUpdates(org string, gc client) error {
 	return overallErr
 }
 
+// applyUpdate performs a single update and returns the errors it encountered
+func applyUpdate(gc client, org, repo string, update Update) []error {
+	var errs []error
+	logrus.WithField("org", org).WithField("repo", repo).WithField("why", update.Why).Debug("running update")
+	switch update.Why {
+	case "missing":
+		err := gc.AddRepoLabel(org, repo, update.Wanted.Name, update.Wanted.Description, update.Wanted.Color)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	case "change", "rename":
+		err := gc.UpdateRepoLabel(org, repo, update.Current.Name, update.Wanted.Name, update.Wanted.Description, update.Wanted.Color)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	case "dead":
+		err := gc.DeleteRepoLabel(org, repo, update.Current.Name)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	case "migrate":
+		issues, err := gc.FindIssuesWithOrg(org, fmt.Sprintf("is:open repo:%s/%s label:\"%s\" -label:\"%s\"", org, repo, update.Current.Name, update.Wanted.Name), "", false)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		if len(issues) == 0 {
+			if err = gc.DeleteRepoLabel(org, repo, update.Current.Name); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		for _, i := range issues {
+			if err = gc.AddLabel(org, repo, i.Number, update.Wanted.Name); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			if err = gc.RemoveLabel(org, repo, i.Number, update.Current.Name); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	default:
+		errs = append(errs, errors.New("unknown label operation: "+update.Why))
+	}
+	return errs
+}
+
 type client interface {
 	AddRepoLabel(org, repo, name, description, color string) error
 	UpdateRepoLabel(org, repo, currentName, newName, description, color string) error
@@ -761,6 +776,14 @@ func main() {
 		logrus.Fatalf("--only and --orgs cannot both be set")
 	}
 
+	if o.plan != "" && o.fromPlan != "" {
+		logrus.Fatalf("--plan and --from-plan cannot both be set")
+	}
+
+	if o.plan != "" && o.planMarkdown == "" {
+		o.planMarkdown = strings.TrimSuffix(o.plan, filepath.Ext(o.plan)) + ".md"
+	}
+
 	switch {
 	case o.action == "docs":
 		if err := writeDocs(o.docsTemplate, o.docsOutput, *config); err != nil {
@@ -788,6 +811,23 @@ func main() {
 
 		githubClient.SetMax404Retries(0)
 
+		if o.fromPlan != "" {
+			if err := applyPlan(o.fromPlan, githubClient, o.confirm, o.maxDeletes); err != nil {
+				logrus.WithError(err).Fatalf("failed to apply plan %s", o.fromPlan)
+			}
+			return
+		}
+
+		var plan *Plan
+		if o.plan != "" {
+			plan = &Plan{Generated: time.Now()}
+			defer func() {
+				if err := writePlan(plan, o.plan, o.planMarkdown); err != nil {
+					logrus.WithError(err).Fatal("failed to write plan")
+				}
+			}()
+		}
+
 		// there are three ways to configure which repos to sync:
 		//  - a list of org/repo values
 		//  - a list of orgs for which we sync all repos
@@ -798,7 +838,7 @@ func main() {
 				logrus.WithError(err).Fatal("invalid value for --only")
 			}
 			for org := range reposToSync {
-				if err = syncOrg(org, githubClient, *config, reposToSync[org], o.confirm); err != nil {
+				if err = syncOrg(org, githubClient, *config, reposToSync[org], o.confirm, o.maxDeletes, plan); err != nil {
 					logrus.WithError(err).Fatalf("failed to update %s", org)
 				}
 			}
@@ -825,7 +865,7 @@ func main() {
 			if skipped, exist := skippedRepos[org]; exist {
 				repos = sets.NewString(repos...).Difference(sets.NewString(skipped...)).UnsortedList()
 			}
-			if err = syncOrg(org, githubClient, *config, repos, o.confirm); err != nil {
+			if err = syncOrg(org, githubClient, *config, repos, o.confirm, o.maxDeletes, plan); err != nil {
 				logrus.WithError(err).Fatalf("failed to update %s", org)
 			}
 		}
@@ -931,7 +971,9 @@ func linkify(text string) string {
 	return strings.ToLower(link)
 }
 
-func syncOrg(org string, githubClient client, config Configuration, repos []string, confirm bool) error {
+// syncOrg computes the label updates for the repos of an org. If plan is set,
+// the updates are added to it instead of being applied.
+func syncOrg(org string, githubClient client, config Configuration, repos []string, confirm bool, maxDeletes int, plan *Plan) error {
 	logger := logrus.WithField("org", org)
 	logger.Infof("Found %d repos", len(repos))
 	currLabels, err := loadLabels(githubClient, org, repos)
@@ -948,6 +990,21 @@ func syncOrg(org string, githubClient client, config Configuration, repos []stri
 	y, _ := yaml.Marshal(updates)
 	logger.Debug(string(y))
 
+	if plan != nil {
+		logger.Info("Adding changes to the plan, no mutations made")
+		return plan.addUpdates(org, updates, githubClient)
+	}
+
+	var changes []PlannedChange
+	for repo, repoUpdates := range updates {
+		for _, update := range repoUpdates {
+			changes = append(changes, PlannedChange{Org: org, Repo: repo, Update: update})
+		}
+	}
+	if err := checkMaxDeletes(changes, maxDeletes); err != nil {
+		return err
+	}
+
 	if !confirm {
 		logger.Infof("Running without --confirm, no mutations made")
 		return nil
@@ -959,6 +1016,37 @@ func syncOrg(org string, githubClient client, config Configuration, repos []stri
 	return nil
 }
 
+// writePlan writes the plan as JSON to path and as markdown to markdownPath.
+func writePlan(plan *Plan, path, markdownPath string) error {
+	if err := plan.Write(path); err != nil {
+		return err
+	}
+	if err := os.WriteFile(markdownPath, []byte(plan.Markdown()), 0644); err != nil {
+		return err
+	}
+	logrus.WithField("plan", path).WithField("summary", markdownPath).Infof("Wrote plan with %d changes", len(plan.Changes))
+	return nil
+}
+
+// applyPlan applies the changes of the plan at path that were not applied
+// yet, recording progress in the plan file.
+func applyPlan(path string, githubClient client, confirm bool, maxDeletes int) error {
+	plan, err := LoadPlan(path)
+	if err != nil {
+		return err
+	}
+	if err := checkMaxDeletes(plan.Changes, maxDeletes); err != nil {
+		return err
+	}
+	if !confirm {
+		logrus.Infof("Running without --confirm, no mutations made")
+		return nil
+	}
+	return plan.Apply(githubClient, func(p *Plan) error {
+		return p.Write(path)
+	})
+}
+
 type labelCSSData struct {
 	BackgroundColor, Color, Name string
 }
@@ -0,0 +1,248 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Plan is a machine-readable list of label changes across orgs and repos.
+// It can be reviewed before being applied, and records which changes were
+// applied so that an interrupted execution can be resumed.
+type Plan struct {
+	Generated time.Time       `json:"generated"`
+	Changes   []PlannedChange `json:"changes"`
+}
+
+// PlannedChange is a single label update in a plan.
+type PlannedChange struct {
+	Org  string `json:"org"`
+	Repo string `json:"repo"`
+	Update
+	// Issues is the number of issues and PRs carrying the current label,
+	// for changes that affect an existing label.
+	Issues int `json:"issues"`
+	// Applied is set once the change was successfully made.
+	Applied bool `json:"applied,omitempty"`
+}
+
+// affectsExistingLabel returns true if the change renames, migrates or
+// deletes a label that may be set on issues.
+func (c PlannedChange) affectsExistingLabel() bool {
+	switch c.Why {
+	case "dead", "migrate", "rename":
+		return true
+	}
+	return false
+}
+
+// addUpdates adds the updates computed for an org to the plan, counting the
+// issues carrying every affected label.
+func (p *Plan) addUpdates(org string, updates RepoUpdates, gc client) error {
+	var repos []string
+	for repo := range updates {
+		repos = append(repos, repo)
+	}
+	sort.Strings(repos)
+	for _, repo := range repos {
+		for _, update := range updates[repo] {
+			change := PlannedChange{Org: org, Repo: repo, Update: update}
+			if change.affectsExistingLabel() {
+				issues, err := gc.FindIssuesWithOrg(org, fmt.Sprintf("repo:%s/%s label:\"%s\"", org, repo, update.Current.Name), "", false)
+				if err != nil {
+					return fmt.Errorf("failed to count issues with label %q in %s/%s: %w", update.Current.Name, org, repo, err)
+				}
+				change.Issues = len(issues)
+			}
+			p.Changes = append(p.Changes, change)
+		}
+	}
+	return nil
+}
+
+// LoadPlan reads a plan written by Write.
+func LoadPlan(path string) (*Plan, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var p Plan
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("failed to parse plan %s: %w", path, err)
+	}
+	return &p, nil
+}
+
+// Write writes the plan as JSON to path. The plan is written to a temporary
+// file that replaces path, so an interrupted write never corrupts the plan.
+func (p *Plan) Write(path string) error {
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Chmod(0644); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// Markdown renders the plan as a human-readable summary.
+func (p *Plan) Markdown() string {
+	var b bytes.Buffer
+	counts := map[string]int{}
+	issues := 0
+	for _, c := range p.Changes {
+		counts[c.Why]++
+		issues += c.Issues
+	}
+	fmt.Fprintf(&b, "# Label sync plan\n\nGenerated %s.\n\n", p.Generated.Format(time.RFC3339))
+	if len(p.Changes) == 0 {
+		b.WriteString("No changes.\n")
+		return b.String()
+	}
+	fmt.Fprintf(&b, "%d changes: %d created, %d changed, %d renamed, %d migrated, %d deleted, affecting %d issues and PRs.\n",
+		len(p.Changes), counts["missing"], counts["change"], counts["rename"], counts["migrate"], counts["dead"], issues)
+
+	var current string
+	for _, c := range p.Changes {
+		if repo := c.Org + "/" + c.Repo; repo != current {
+			current = repo
+			fmt.Fprintf(&b, "\n## %s\n\n| Action | Label | New label | Issues | Applied |\n| --- | --- | --- | --- | --- |\n", repo)
+		}
+		var from, to string
+		if c.Current != nil {
+			from = "`" + c.Current.Name + "`"
+		}
+		if c.Wanted != nil && (c.Current == nil || c.Wanted.Name != c.Current.Name) {
+			to = "`" + c.Wanted.Name + "`"
+		}
+		if from == "" {
+			from, to = to, ""
+		}
+		applied := ""
+		if c.Applied {
+			applied = "yes"
+		}
+		fmt.Fprintf(&b, "| %s | %s | %s | %d | %s |\n", describeWhy(c.Why), from, to, c.Issues, applied)
+	}
+	return b.String()
+}
+
+func describeWhy(why string) string {
+	switch why {
+	case "missing":
+		return "create"
+	case "dead":
+		return "delete"
+	default:
+		return why
+	}
+}
+
+// checkMaxDeletes returns an error if any repo would have more than max
+// labels deleted. Migrations count as deletes since the migrated label is
+// deleted once no issue or PR uses it anymore. A non-positive max disables the
+// check.
+func checkMaxDeletes(changes []PlannedChange, max int) error {
+	if max <= 0 {
+		return nil
+	}
+	deletes := map[string]int{}
+	for _, c := range changes {
+		if (c.Why == "dead" || c.Why == "migrate") && !c.Applied {
+			deletes[c.Org+"/"+c.Repo]++
+		}
+	}
+	var violations []string
+	for repo, count := range deletes {
+		if count > max {
+			violations = append(violations, fmt.Sprintf("%s (%d)", repo, count))
+		}
+	}
+	if len(violations) == 0 {
+		return nil
+	}
+	sort.Strings(violations)
+	return fmt.Errorf("refusing to delete more than %d labels per repo, --max-deletes exceeded for: %s", max, strings.Join(violations, ", "))
+}
+
+// Apply makes every change of the plan that was not applied yet. After each
+// successful change, save is called with the updated plan so that progress
+// survives interruptions.
+func (p *Plan) Apply(gc client, save func(*Plan) error) error {
+	work := make(chan int, len(p.Changes))
+	for i, c := range p.Changes {
+		if !c.Applied {
+			work <- i
+		}
+	}
+	logrus.Infof("Applying %d of %d planned changes", len(work), len(p.Changes))
+	close(work)
+
+	var lock sync.Mutex
+	var errs []error
+	wg := sync.WaitGroup{}
+	wg.Add(maxConcurrentWorkers)
+	for i := 0; i < maxConcurrentWorkers; i++ {
+		go func() {
+			defer wg.Done()
+			for idx := range work {
+				c := p.Changes[idx]
+				updateErrs := applyUpdate(gc, c.Org, c.Repo, c.Update)
+				lock.Lock()
+				if len(updateErrs) > 0 {
+					errs = append(errs, updateErrs...)
+				} else {
+					p.Changes[idx].Applied = true
+					if err := save(p); err != nil {
+						errs = append(errs, fmt.Errorf("failed to save plan progress: %w", err))
+					}
+				}
+				lock.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to apply plan: %v", errs)
+	}
+	return nil
+}
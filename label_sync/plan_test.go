@@ -0,0 +1,189 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"k8s.io/test-infra/prow/github"
+)
+
+type fakeClient struct {
+	lock     sync.Mutex
+	issues   map[string][]github.Issue
+	deleted  []string
+	added    []string
+	failRepo string
+}
+
+func (c *fakeClient) AddRepoLabel(org, repo, name, description, color string) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if repo == c.failRepo {
+		return errors.New("injected failure")
+	}
+	c.added = append(c.added, org+"/"+repo+":"+name)
+	return nil
+}
+
+func (c *fakeClient) UpdateRepoLabel(org, repo, currentName, newName, description, color string) error {
+	return nil
+}
+
+func (c *fakeClient) DeleteRepoLabel(org, repo, label string) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.deleted = append(c.deleted, org+"/"+repo+":"+label)
+	return nil
+}
+
+func (c *fakeClient) AddLabel(org, repo string, number int, label string) error {
+	return nil
+}
+
+func (c *fakeClient) RemoveLabel(org, repo string, number int, label string) error {
+	return nil
+}
+
+func (c *fakeClient) FindIssuesWithOrg(org, query, sort string, asc bool) ([]github.Issue, error) {
+	return c.issues[query], nil
+}
+
+func (c *fakeClient) GetRepos(org string, isUser bool) ([]github.Repo, error) {
+	return nil, nil
+}
+
+func (c *fakeClient) GetRepoLabels(string, string) ([]github.Label, error) {
+	return nil, nil
+}
+
+func (c *fakeClient) SetMax404Retries(int) {}
+
+func TestPlanAddUpdates(t *testing.T) {
+	gc := &fakeClient{issues: map[string][]github.Issue{
+		`repo:org/repo1 label:"old"`:  {{Number: 1}, {Number: 2}},
+		`repo:org/repo2 label:"dead"`: {{Number: 3}},
+	}}
+	updates := RepoUpdates{
+		"repo2": {kill("repo2", Label{Name: "dead"}), create("repo2", Label{Name: "new"})},
+		"repo1": {rename("repo1", Label{Name: "old"}, Label{Name: "renamed"})},
+	}
+	plan := &Plan{Generated: time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC)}
+	if err := plan.addUpdates("org", updates, gc); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var got []string
+	for _, c := range plan.Changes {
+		got = append(got, c.Repo+":"+c.Why)
+	}
+	if expected := "repo1:rename,repo2:dead,repo2:missing"; strings.Join(got, ",") != expected {
+		t.Errorf("expected changes %s, got %s", expected, strings.Join(got, ","))
+	}
+	if plan.Changes[0].Issues != 2 || plan.Changes[1].Issues != 1 || plan.Changes[2].Issues != 0 {
+		t.Errorf("unexpected issue counts in %+v", plan.Changes)
+	}
+
+	markdown := plan.Markdown()
+	for _, expected := range []string{
+		"3 changes: 1 created, 0 changed, 1 renamed, 0 migrated, 1 deleted, affecting 3 issues and PRs.",
+		"## org/repo1",
+		"| rename | `old` | `renamed` | 2 |  |",
+		"| delete | `dead` |  | 1 |  |",
+		"| create | `new` |  | 0 |  |",
+	} {
+		if !strings.Contains(markdown, expected) {
+			t.Errorf("expected markdown to contain %q, got:\n%s", expected, markdown)
+		}
+	}
+}
+
+func TestCheckMaxDeletes(t *testing.T) {
+	changes := []PlannedChange{
+		{Org: "org", Repo: "a", Update: Update{Why: "dead"}},
+		{Org: "org", Repo: "a", Update: Update{Why: "dead"}},
+		{Org: "org", Repo: "a", Update: Update{Why: "dead"}, Applied: true},
+		{Org: "org", Repo: "b", Update: Update{Why: "dead"}},
+		{Org: "org", Repo: "b", Update: Update{Why: "missing"}},
+		{Org: "org", Repo: "c", Update: Update{Why: "dead"}},
+		{Org: "org", Repo: "c", Update: Update{Why: "migrate"}},
+	}
+	cases := []struct {
+		name      string
+		max       int
+		expectErr bool
+	}{
+		{name: "disabled", max: 0},
+		{name: "within limit", max: 2},
+		{name: "exceeded in one repo", max: 1, expectErr: true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := checkMaxDeletes(changes, tc.max)
+			if tc.expectErr != (err != nil) {
+				t.Errorf("expected error: %t, got %v", tc.expectErr, err)
+			}
+			if err != nil && !strings.Contains(err.Error(), "org/a (2), org/c (2)") {
+				t.Errorf("expected the error to name the offending repos, got %v", err)
+			}
+		})
+	}
+}
+
+func TestPlanApplyResumes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "plan.json")
+	plan := &Plan{Changes: []PlannedChange{
+		{Org: "org", Repo: "a", Update: Update{Why: "dead", Current: &Label{Name: "done"}}, Applied: true},
+		{Org: "org", Repo: "a", Update: Update{Why: "dead", Current: &Label{Name: "todo"}}},
+		{Org: "org", Repo: "broken", Update: Update{Why: "missing", Wanted: &Label{Name: "new"}}},
+	}}
+	if err := plan.Write(path); err != nil {
+		t.Fatalf("failed to write plan: %v", err)
+	}
+
+	gc := &fakeClient{failRepo: "broken"}
+	if err := applyPlan(path, gc, true, 0); err == nil {
+		t.Error("expected an error for the failing change")
+	}
+	if len(gc.deleted) != 1 || gc.deleted[0] != "org/a:todo" {
+		t.Errorf("expected only the pending deletion to be made, got %v", gc.deleted)
+	}
+
+	saved, err := LoadPlan(path)
+	if err != nil {
+		t.Fatalf("failed to load plan: %v", err)
+	}
+	if !saved.Changes[0].Applied || !saved.Changes[1].Applied || saved.Changes[2].Applied {
+		t.Errorf("unexpected progress saved in plan: %+v", saved.Changes)
+	}
+
+	gc.failRepo = ""
+	if err := applyPlan(path, gc, true, 0); err != nil {
+		t.Fatalf("unexpected error resuming the plan: %v", err)
+	}
+	if len(gc.deleted) != 1 || len(gc.added) != 1 {
+		t.Errorf("expected resuming to only apply the failed change, deleted %v, added %v", gc.deleted, gc.added)
+	}
+	if files, _ := filepath.Glob(filepath.Join(filepath.Dir(path), "*")); len(files) != 1 {
+		t.Errorf("expected only the plan to be left behind, got %v", files)
+	}
+}
@@ -88,6 +88,8 @@ const (
 	JenkinsAgent ProwJobAgent = "jenkins"
 	// TektonAgent means prow will schedule the job via a tekton PipelineRun CRD resource.
 	TektonAgent = "tekton-pipeline"
+	// CloudBuildAgent means prow will schedule the job as a build of Google Cloud Build.
+	CloudBuildAgent ProwJobAgent = "cloud-build"
 )
 
 const (
@@ -193,6 +195,10 @@ type ProwJobSpec struct {
 	// https://github.com/tektoncd/pipeline
 	PipelineRunSpec *pipelinev1alpha1.PipelineRunSpec `json:"pipeline_run_spec,omitempty"`
 
+	// BuildSpec provides the basis for running the test as
+	// a build of Google Cloud Build
+	BuildSpec *CloudBuildSpec `json:"build_spec,omitempty"`
+
 	// DecorationConfig holds configuration options for
 	// decorating PodSpecs that users provide
 	DecorationConfig *DecorationConfig `json:"decoration_config,omitempty"`
@@ -956,9 +962,72 @@ type ProwJobStatus struct {
 	// ProwJob.
 	JenkinsBuildID string `json:"jenkins_build_id,omitempty"`
 
+	// JenkinsStages applies only to ProwJobs fulfilled by
+	// the jenkins-operator whose Jenkins job is a pipeline.
+	// It lists the stages of the build as reported by the
+	// workflow API of Jenkins.
+	JenkinsStages []JenkinsStage `json:"jenkins_stages,omitempty"`
+
 	// PrevReportStates stores the previous reported prowjob state per reporter
 	// So crier won't make duplicated report attempt
 	PrevReportStates map[string]ProwJobState `json:"prev_report_states,omitempty"`
+
+	// PipelineRunStatus applies only to ProwJobs fulfilled
+	// by the pipeline controller. It summarizes the results
+	// and the TaskRuns of the PipelineRun, so that they can
+	// be shown without access to the build cluster.
+	PipelineRunStatus *PipelineRunStatus `json:"pipeline_run_status,omitempty"`
+}
+
+// JenkinsStage is a stage of a Jenkins pipeline build.
+type JenkinsStage struct {
+	Name string `json:"name"`
+	// Status is the status of the stage reported by Jenkins,
+	// e.g. SUCCESS, FAILED, IN_PROGRESS or NOT_EXECUTED.
+	Status string `json:"status,omitempty"`
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+	// Duration is how long the stage ran, or has been running.
+	// +optional
+	Duration *Duration `json:"duration,omitempty"`
+}
+
+// PipelineRunStatus summarizes the status of a tekton PipelineRun.
+type PipelineRunStatus struct {
+	// Results are the results of the pipeline.
+	// +optional
+	Results []PipelineResult `json:"results,omitempty"`
+	// TaskRuns are the statuses of the TaskRuns of the pipeline,
+	// sorted by name.
+	// +optional
+	TaskRuns []TaskRunStatus `json:"task_runs,omitempty"`
+}
+
+// PipelineResult is a result of a pipeline or one of its tasks.
+type PipelineResult struct {
+	Name string `json:"name"`
+	// Value is the value of a string result, or the JSON
+	// encoding of an array result.
+	Value string `json:"value"`
+}
+
+// TaskRunStatus summarizes the status of a TaskRun of a PipelineRun.
+type TaskRunStatus struct {
+	// Name is the name of the TaskRun.
+	Name string `json:"name"`
+	// PipelineTaskName is the name of the task in the pipeline.
+	PipelineTaskName string `json:"pipeline_task_name,omitempty"`
+	// State is the state of the TaskRun, mapped to the ProwJob
+	// states the same way as the state of the PipelineRun.
+	State       ProwJobState `json:"state,omitempty"`
+	Description string       `json:"description,omitempty"`
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+	// Results are the results of the task.
+	// +optional
+	Results []PipelineResult `json:"results,omitempty"`
 }
 
 // Complete returns true if the prow job has finished
@@ -1080,6 +1149,51 @@ type JenkinsSpec struct {
 	GitHubBranchSourceJob bool `json:"github_branch_source_job,omitempty"`
 }
 
+// CloudBuildSpec is the build that Google Cloud Build runs for a job. It's a
+// subset of https://cloud.google.com/build/docs/api/reference/rest/v1/projects.builds
+type CloudBuildSpec struct {
+	// Project is the GCP project that runs the builds.
+	// +kubebuilder:validation:Required
+	Project string `json:"project"`
+	// Steps are the steps of the build. Each step gets the environment
+	// variables of the job, like JOB_NAME, BUILD_ID and PULL_NUMBER.
+	// +kubebuilder:validation:MinItems=1
+	Steps []CloudBuildStep `json:"steps"`
+	// Timeout is the timeout of the whole build, defaults to the timeout
+	// of Cloud Build.
+	Timeout *Duration `json:"timeout,omitempty"`
+	// ServiceAccount is the service account that runs the build, as
+	// projects/{project}/serviceAccounts/{email}.
+	ServiceAccount string `json:"service_account,omitempty"`
+	// MachineType of the build, like E2_HIGHCPU_8.
+	MachineType string `json:"machine_type,omitempty"`
+	// Substitutions are the user-defined substitutions of the build.
+	Substitutions map[string]string `json:"substitutions,omitempty"`
+	// LogsBucket is where Cloud Build stores the logs of the build,
+	// e.g. gs://bucket/logs. Defaults to the bucket of Cloud Build.
+	LogsBucket string `json:"logs_bucket,omitempty"`
+}
+
+// CloudBuildStep is a step of a build of Google Cloud Build.
+type CloudBuildStep struct {
+	// Name is the image that runs the step.
+	// +kubebuilder:validation:Required
+	Name string `json:"name"`
+	// ID identifies the step for WaitFor.
+	ID string `json:"id,omitempty"`
+	// Entrypoint overrides the entrypoint of the image.
+	Entrypoint string `json:"entrypoint,omitempty"`
+	// Args are the arguments of the step.
+	Args []string `json:"args,omitempty"`
+	// Env are additional environment variables of the step, as KEY=VALUE.
+	Env []string `json:"env,omitempty"`
+	// Dir is the working directory of the step, relative to /workspace.
+	Dir string `json:"dir,omitempty"`
+	// WaitFor are the IDs of the steps to wait for, defaults to all the
+	// previous steps.
+	WaitFor []string `json:"wait_for,omitempty"`
+}
+
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 
 // ProwJobList is a list of ProwJob resources
@@ -65,6 +65,72 @@ func (in *CensoringOptions) DeepCopy() *CensoringOptions {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CloudBuildSpec) DeepCopyInto(out *CloudBuildSpec) {
+	*out = *in
+	if in.Steps != nil {
+		in, out := &in.Steps, &out.Steps
+		*out = make([]CloudBuildStep, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Timeout != nil {
+		in, out := &in.Timeout, &out.Timeout
+		*out = new(Duration)
+		**out = **in
+	}
+	if in.Substitutions != nil {
+		in, out := &in.Substitutions, &out.Substitutions
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CloudBuildSpec.
+func (in *CloudBuildSpec) DeepCopy() *CloudBuildSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(CloudBuildSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CloudBuildStep) DeepCopyInto(out *CloudBuildStep) {
+	*out = *in
+	if in.Args != nil {
+		in, out := &in.Args, &out.Args
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Env != nil {
+		in, out := &in.Env, &out.Env
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.WaitFor != nil {
+		in, out := &in.WaitFor, &out.WaitFor
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CloudBuildStep.
+func (in *CloudBuildStep) DeepCopy() *CloudBuildStep {
+	if in == nil {
+		return nil
+	}
+	out := new(CloudBuildStep)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *DecorationConfig) DeepCopyInto(out *DecorationConfig) {
 	*out = *in
@@ -268,6 +334,31 @@ func (in *JenkinsSpec) DeepCopy() *JenkinsSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *JenkinsStage) DeepCopyInto(out *JenkinsStage) {
+	*out = *in
+	if in.StartTime != nil {
+		in, out := &in.StartTime, &out.StartTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Duration != nil {
+		in, out := &in.Duration, &out.Duration
+		*out = new(Duration)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new JenkinsStage.
+func (in *JenkinsStage) DeepCopy() *JenkinsStage {
+	if in == nil {
+		return nil
+	}
+	out := new(JenkinsStage)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *OauthTokenSecret) DeepCopyInto(out *OauthTokenSecret) {
 	*out = *in
@@ -284,6 +375,50 @@ func (in *OauthTokenSecret) DeepCopy() *OauthTokenSecret {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PipelineResult) DeepCopyInto(out *PipelineResult) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PipelineResult.
+func (in *PipelineResult) DeepCopy() *PipelineResult {
+	if in == nil {
+		return nil
+	}
+	out := new(PipelineResult)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PipelineRunStatus) DeepCopyInto(out *PipelineRunStatus) {
+	*out = *in
+	if in.Results != nil {
+		in, out := &in.Results, &out.Results
+		*out = make([]PipelineResult, len(*in))
+		copy(*out, *in)
+	}
+	if in.TaskRuns != nil {
+		in, out := &in.TaskRuns, &out.TaskRuns
+		*out = make([]TaskRunStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PipelineRunStatus.
+func (in *PipelineRunStatus) DeepCopy() *PipelineRunStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(PipelineRunStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ProwJob) DeepCopyInto(out *ProwJob) {
 	*out = *in
@@ -391,6 +526,11 @@ func (in *ProwJobSpec) DeepCopyInto(out *ProwJobSpec) {
 		*out = new(v1alpha1.PipelineRunSpec)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.BuildSpec != nil {
+		in, out := &in.BuildSpec, &out.BuildSpec
+		*out = new(CloudBuildSpec)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.DecorationConfig != nil {
 		in, out := &in.DecorationConfig, &out.DecorationConfig
 		*out = new(DecorationConfig)
@@ -436,6 +576,13 @@ func (in *ProwJobStatus) DeepCopyInto(out *ProwJobStatus) {
 		in, out := &in.CompletionTime, &out.CompletionTime
 		*out = (*in).DeepCopy()
 	}
+	if in.JenkinsStages != nil {
+		in, out := &in.JenkinsStages, &out.JenkinsStages
+		*out = make([]JenkinsStage, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	if in.PrevReportStates != nil {
 		in, out := &in.PrevReportStates, &out.PrevReportStates
 		*out = make(map[string]ProwJobState, len(*in))
@@ -443,6 +590,11 @@ func (in *ProwJobStatus) DeepCopyInto(out *ProwJobStatus) {
 			(*out)[key] = val
 		}
 	}
+	if in.PipelineRunStatus != nil {
+		in, out := &in.PipelineRunStatus, &out.PipelineRunStatus
+		*out = new(PipelineRunStatus)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -633,6 +785,35 @@ func (in *SlackReporterConfig) DeepCopy() *SlackReporterConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TaskRunStatus) DeepCopyInto(out *TaskRunStatus) {
+	*out = *in
+	if in.StartTime != nil {
+		in, out := &in.StartTime, &out.StartTime
+		*out = (*in).DeepCopy()
+	}
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Results != nil {
+		in, out := &in.Results, &out.Results
+		*out = make([]PipelineResult, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TaskRunStatus.
+func (in *TaskRunStatus) DeepCopy() *TaskRunStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(TaskRunStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *UtilityImages) DeepCopyInto(out *UtilityImages) {
 	*out = *in
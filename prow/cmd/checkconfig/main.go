@@ -41,6 +41,7 @@ import (
 
 	v1 "k8s.io/test-infra/prow/apis/prowjobs/v1"
 	"k8s.io/test-infra/prow/config"
+	"k8s.io/test-infra/prow/config/policy"
 	needsrebase "k8s.io/test-infra/prow/external-plugins/needs-rebase/plugin"
 	"k8s.io/test-infra/prow/flagutil"
 	configflagutil "k8s.io/test-infra/prow/flagutil/config"
@@ -78,6 +79,7 @@ type options struct {
 	warnings               flagutil.Strings
 	excludeWarnings        flagutil.Strings
 	requiredJobAnnotations flagutil.Strings
+	policyFiles            flagutil.Strings
 	strict                 bool
 	expensive              bool
 	includeDefaultWarnings bool
@@ -215,6 +217,7 @@ func (o *options) gatherOptions(flag *flag.FlagSet, args []string) error {
 	flag.Var(&o.warnings, "warnings", "Warnings to validate. Use repeatedly to provide a list of warnings")
 	flag.Var(&o.excludeWarnings, "exclude-warning", "Warnings to exclude. Use repeatedly to provide a list of warnings to exclude")
 	flag.Var(&o.requiredJobAnnotations, "required-job-annotations", "Required annotation names that job has to include in a definition. Use repeatedly to provide a list of required annotations")
+	flag.Var(&o.policyFiles, "policy-file", "Path to a file with policy rules the Prow config and plugin config must satisfy. Use repeatedly to provide a list of policy files")
 	flag.BoolVar(&o.expensive, "expensive-checks", false, "If set, additional expensive warnings will be enabled")
 	flag.BoolVar(&o.strict, "strict", false, "If set, consider all warnings as errors.")
 	flag.BoolVar(&o.includeDefaultWarnings, "include-default-warnings", false, "If set force inclusion of default warning set. Normally this is inferred based on a lack of '--warnings' flags.")
@@ -448,6 +451,32 @@ func validate(o options) error {
 		}
 	}
 
+	// Policy rules decide themselves whether violating them is an error.
+	var policyErrs []error
+	for _, path := range o.policyFiles.Strings() {
+		p, err := policy.Load(path)
+		if err != nil {
+			return err
+		}
+		violations, err := p.Evaluate(cfg, pcfg)
+		if err != nil {
+			return fmt.Errorf("error evaluating policy %s: %w", path, err)
+		}
+		for _, violation := range violations {
+			if violation.Severity == policy.SeverityError {
+				policyErrs = append(policyErrs, violation)
+			} else {
+				errs = append(errs, violation)
+			}
+		}
+	}
+	if len(policyErrs) > 0 {
+		if len(errs) > 0 {
+			reportWarning(false, utilerrors.NewAggregate(errs))
+		}
+		return fmt.Errorf("config violates policies: %w", utilerrors.NewAggregate(policyErrs))
+	}
+
 	return utilerrors.NewAggregate(errs)
 }
 func policyIsStrict(p config.Policy) bool {
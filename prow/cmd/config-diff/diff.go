@@ -0,0 +1,307 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"k8s.io/test-infra/prow/config"
+)
+
+type status string
+
+const (
+	added    status = "Added"
+	removed  status = "Removed"
+	modified status = "Modified"
+)
+
+// entry is something whose effective configuration is compared, usually a job.
+type entry struct {
+	kind   string
+	name   string
+	repo   string
+	source string
+	// branches identifies jobs of the same name that run for different
+	// branches.
+	branches string
+	value    interface{}
+}
+
+func (e entry) String() string {
+	s := fmt.Sprintf("%s `%s`", e.kind, e.name)
+	if e.name == "" {
+		s = e.kind
+	}
+	if e.repo != "" {
+		s = fmt.Sprintf("%s of %s", s, e.repo)
+	}
+	if e.source != "" {
+		s = fmt.Sprintf("%s (`%s`)", s, e.source)
+	}
+	return s
+}
+
+// fieldChange is a changed value in an entry. A nil value means it is unset.
+type fieldChange struct {
+	path     string
+	old, new interface{}
+}
+
+type change struct {
+	entry
+	status status
+	fields []fieldChange
+}
+
+// entries returns the jobs and the rest of the Prow config, keyed by kind,
+// repo and name so that the same entry can be found in another config. Jobs of
+// the same name may exist for different branches, so a key can hold several
+// entries.
+func entries(cfg *config.Config) (map[string][]entry, error) {
+	result := map[string][]entry{}
+	add := func(e entry, branches []string, v interface{}) error {
+		generic, err := toGeneric(v)
+		if err != nil {
+			return fmt.Errorf("%s: %w", e, err)
+		}
+		e.value = generic
+		e.branches = strings.Join(branches, ",")
+		key := strings.Join([]string{e.kind, e.repo, e.name}, "|")
+		result[key] = append(result[key], e)
+		return nil
+	}
+	for repo, jobs := range cfg.PresubmitsStatic {
+		for _, job := range jobs {
+			if err := add(entry{kind: "presubmit", name: job.Name, repo: repo, source: job.SourcePath}, job.Branches, job); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for repo, jobs := range cfg.PostsubmitsStatic {
+		for _, job := range jobs {
+			if err := add(entry{kind: "postsubmit", name: job.Name, repo: repo, source: job.SourcePath}, job.Branches, job); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for _, job := range cfg.Periodics {
+		if err := add(entry{kind: "periodic", name: job.Name, source: job.SourcePath}, nil, job); err != nil {
+			return nil, err
+		}
+	}
+	if err := add(entry{kind: "prow config"}, nil, cfg.ProwConfig); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func toGeneric(v interface{}) (interface{}, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var generic interface{}
+	if err := json.Unmarshal(raw, &generic); err != nil {
+		return nil, err
+	}
+	return generic, nil
+}
+
+// diffConfigs returns the entries that differ between the configs, ordered
+// by status, kind, repo and name.
+func diffConfigs(base, head *config.Config) ([]change, error) {
+	baseEntries, err := entries(base)
+	if err != nil {
+		return nil, err
+	}
+	headEntries, err := entries(head)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []change
+	for key, h := range headEntries {
+		pairs, removedEntries, addedEntries := pairEntries(baseEntries[key], h)
+		for _, e := range addedEntries {
+			changes = append(changes, change{entry: e, status: added})
+		}
+		for _, e := range removedEntries {
+			changes = append(changes, change{entry: e, status: removed})
+		}
+		for _, p := range pairs {
+			var fields []fieldChange
+			diffValues("", p[0].value, p[1].value, &fields)
+			if len(fields) > 0 {
+				changes = append(changes, change{entry: p[1], status: modified, fields: fields})
+			}
+		}
+	}
+	for key, b := range baseEntries {
+		if _, ok := headEntries[key]; !ok {
+			for _, e := range b {
+				changes = append(changes, change{entry: e, status: removed})
+			}
+		}
+	}
+	order := map[status]int{added: 0, removed: 1, modified: 2}
+	sort.Slice(changes, func(i, j int) bool {
+		a, b := changes[i], changes[j]
+		if a.status != b.status {
+			return order[a.status] < order[b.status]
+		}
+		if a.kind != b.kind {
+			return a.kind < b.kind
+		}
+		if a.repo != b.repo {
+			return a.repo < b.repo
+		}
+		if a.name != b.name {
+			return a.name < b.name
+		}
+		return a.source < b.source
+	})
+	return changes, nil
+}
+
+// pairEntries matches the entries of the same key in the base and head
+// configs. Entries for the same branches are matched first, the remaining ones
+// in order, so that a change of the branches shows up as a modification.
+func pairEntries(base, head []entry) (pairs [][2]entry, removedEntries, addedEntries []entry) {
+	matched := make([]bool, len(base))
+	var unmatched []entry
+	for _, h := range head {
+		found := false
+		for i, b := range base {
+			if !matched[i] && b.branches == h.branches {
+				matched[i], found = true, true
+				pairs = append(pairs, [2]entry{b, h})
+				break
+			}
+		}
+		if !found {
+			unmatched = append(unmatched, h)
+		}
+	}
+	for _, h := range unmatched {
+		found := false
+		for i, b := range base {
+			if !matched[i] {
+				matched[i], found = true, true
+				pairs = append(pairs, [2]entry{b, h})
+				break
+			}
+		}
+		if !found {
+			addedEntries = append(addedEntries, h)
+		}
+	}
+	for i, b := range base {
+		if !matched[i] {
+			removedEntries = append(removedEntries, b)
+		}
+	}
+	return pairs, removedEntries, addedEntries
+}
+
+// diffValues records the differences between two values as produced by
+// toGeneric. Lists of different length are reported as a whole.
+func diffValues(path string, old, new interface{}, changes *[]fieldChange) {
+	oldMap, oldIsMap := old.(map[string]interface{})
+	newMap, newIsMap := new.(map[string]interface{})
+	if oldIsMap && newIsMap {
+		keys := map[string]bool{}
+		for key := range oldMap {
+			keys[key] = true
+		}
+		for key := range newMap {
+			keys[key] = true
+		}
+		var sorted []string
+		for key := range keys {
+			sorted = append(sorted, key)
+		}
+		sort.Strings(sorted)
+		for _, key := range sorted {
+			fieldPath := key
+			if path != "" {
+				fieldPath = path + "." + key
+			}
+			diffValues(fieldPath, oldMap[key], newMap[key], changes)
+		}
+		return
+	}
+	oldList, oldIsList := old.([]interface{})
+	newList, newIsList := new.([]interface{})
+	if oldIsList && newIsList && len(oldList) == len(newList) {
+		for i := range oldList {
+			diffValues(fmt.Sprintf("%s[%d]", path, i), oldList[i], newList[i], changes)
+		}
+		return
+	}
+	if !reflect.DeepEqual(old, new) {
+		*changes = append(*changes, fieldChange{path: path, old: old, new: new})
+	}
+}
+
+// render formats the changes as markdown, suitable for a PR comment.
+func render(changes []change) string {
+	if len(changes) == 0 {
+		return "No changes to the effective Prow configuration.\n"
+	}
+	counts := map[status]int{}
+	for _, c := range changes {
+		counts[c.status]++
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "Changes to the effective Prow configuration: %d added, %d removed, %d modified.\n", counts[added], counts[removed], counts[modified])
+
+	var current status
+	for _, c := range changes {
+		if c.status != current {
+			current = c.status
+			fmt.Fprintf(&b, "\n#### %s\n\n", current)
+		}
+		if c.status != modified {
+			fmt.Fprintf(&b, "- %s\n", c.entry)
+			continue
+		}
+		fmt.Fprintf(&b, "<details>\n<summary>%s: %d fields</summary>\n\n```diff\n", c.entry, len(c.fields))
+		for _, f := range c.fields {
+			if f.old != nil {
+				fmt.Fprintf(&b, "- %s: %s\n", f.path, format(f.old))
+			}
+			if f.new != nil {
+				fmt.Fprintf(&b, "+ %s: %s\n", f.path, format(f.new))
+			}
+		}
+		b.WriteString("```\n\n</details>\n")
+	}
+	return b.String()
+}
+
+func format(v interface{}) string {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(raw)
+}
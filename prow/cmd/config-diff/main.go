@@ -0,0 +1,241 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// config-diff loads the Prow config at two git revisions and prints how the
+// effective configuration of every job changed, after presets, defaults and
+// decoration configs were applied.
+package main
+
+import (
+	"archive/tar"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	"k8s.io/test-infra/prow/config"
+	"k8s.io/test-infra/prow/logrusutil"
+)
+
+type options struct {
+	repoDir       string
+	base          string
+	head          string
+	configPath    string
+	jobConfigPath string
+}
+
+func (o *options) Validate() error {
+	if o.base == "" {
+		return errors.New("--base is required")
+	}
+	if o.configPath == "" {
+		return errors.New("--config-path is required")
+	}
+	for _, path := range []string{o.configPath, o.jobConfigPath} {
+		if filepath.IsAbs(path) {
+			return fmt.Errorf("%s must be relative to --repo-dir", path)
+		}
+	}
+	return nil
+}
+
+func gatherOptions(fs *flag.FlagSet, args ...string) options {
+	var o options
+	fs.StringVar(&o.repoDir, "repo-dir", ".", "Path to the git repository holding the config.")
+	fs.StringVar(&o.base, "base", "HEAD^", "Git revision to compare against.")
+	fs.StringVar(&o.head, "head", "", "Git revision to compare. The working tree is used if unset.")
+	fs.StringVar(&o.configPath, "config-path", "config/prow/config.yaml", "Path to the Prow config, relative to --repo-dir.")
+	fs.StringVar(&o.jobConfigPath, "job-config-path", "config/jobs", "Path to the job config, relative to --repo-dir. Optional.")
+	fs.Parse(args)
+	return o
+}
+
+func main() {
+	logrusutil.ComponentInit()
+
+	o := gatherOptions(flag.NewFlagSet(os.Args[0], flag.ExitOnError), os.Args[1:]...)
+	if err := o.Validate(); err != nil {
+		logrus.WithError(err).Fatal("Invalid options")
+	}
+
+	base, err := loadAt(o, o.base)
+	if err != nil {
+		logrus.WithError(err).Fatalf("Failed to load config at %s.", o.base)
+	}
+	head, err := loadAt(o, o.head)
+	if err != nil {
+		logrus.WithError(err).Fatalf("Failed to load config at %s.", o.head)
+	}
+	changes, err := diffConfigs(base, head)
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to compare configs.")
+	}
+	fmt.Print(render(changes))
+}
+
+// loadAt loads the config at the given revision, or in the working tree
+// if the revision is empty.
+func loadAt(o options, rev string) (*config.Config, error) {
+	root := o.repoDir
+	if rev != "" {
+		dir, err := os.MkdirTemp("", "config-diff")
+		if err != nil {
+			return nil, err
+		}
+		defer os.RemoveAll(dir)
+		paths := []string{o.configPath}
+		if o.jobConfigPath != "" {
+			paths = append(paths, o.jobConfigPath)
+		}
+		if err := extract(o.repoDir, rev, paths, dir); err != nil {
+			return nil, err
+		}
+		root = dir
+	}
+	jobConfigPath := ""
+	if o.jobConfigPath != "" {
+		jobConfigPath = filepath.Join(root, o.jobConfigPath)
+		if _, err := os.Stat(jobConfigPath); os.IsNotExist(err) {
+			jobConfigPath = ""
+		}
+	}
+	cfg, err := config.Load(filepath.Join(root, o.configPath), jobConfigPath, nil, "")
+	if err != nil {
+		return nil, err
+	}
+	relativizeSourcePaths(cfg, root)
+	return cfg, nil
+}
+
+// relativizeSourcePaths makes the paths jobs are defined in relative to the
+// repository, so they don't point into temporary directories.
+func relativizeSourcePaths(cfg *config.Config, root string) {
+	rel := func(path string) string {
+		if r, err := filepath.Rel(root, path); err == nil {
+			return r
+		}
+		return path
+	}
+	for _, jobs := range cfg.PresubmitsStatic {
+		for i := range jobs {
+			jobs[i].SourcePath = rel(jobs[i].SourcePath)
+		}
+	}
+	for _, jobs := range cfg.PostsubmitsStatic {
+		for i := range jobs {
+			jobs[i].SourcePath = rel(jobs[i].SourcePath)
+		}
+	}
+	for i := range cfg.Periodics {
+		cfg.Periodics[i].SourcePath = rel(cfg.Periodics[i].SourcePath)
+	}
+}
+
+// extract writes the given paths as of the revision to dst. Paths that don't
+// exist at the revision are skipped.
+func extract(repoDir, rev string, paths []string, dst string) error {
+	paths, err := existingPaths(repoDir, rev, paths)
+	if err != nil {
+		return err
+	}
+	if len(paths) == 0 {
+		return nil
+	}
+	cmd := exec.Command("git", append([]string{"archive", "--format=tar", rev, "--"}, paths...)...)
+	cmd.Dir = repoDir
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to run git archive: %w", err)
+	}
+	if err := untar(stdout, dst); err != nil {
+		cmd.Wait()
+		return err
+	}
+	if err := cmd.Wait(); err != nil {
+		return fmt.Errorf("git archive %s failed: %w: %s", rev, err, stderr.String())
+	}
+	return nil
+}
+
+// existingPaths filters the paths down to the ones that exist at the revision,
+// as git archive fails on missing paths.
+func existingPaths(repoDir, rev string, paths []string) ([]string, error) {
+	cmd := exec.Command("git", append([]string{"ls-tree", "-z", "--name-only", rev, "--"}, paths...)...)
+	cmd.Dir = repoDir
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git ls-tree %s failed: %w: %s", rev, err, stderr.String())
+	}
+	var existing []string
+	for _, path := range strings.Split(string(out), "\x00") {
+		if path != "" {
+			existing = append(existing, path)
+		}
+	}
+	return existing, nil
+}
+
+func untar(r io.Reader, dst string) error {
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read archive: %w", err)
+		}
+		path := filepath.Join(dst, header.Name)
+		if !strings.HasPrefix(path, filepath.Clean(dst)+string(os.PathSeparator)) {
+			return fmt.Errorf("archive contains invalid path %q", header.Name)
+		}
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(path, 0755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				return err
+			}
+			f, err := os.Create(path)
+			if err != nil {
+				return err
+			}
+			if _, err := io.Copy(f, tr); err != nil {
+				f.Close()
+				return err
+			}
+			if err := f.Close(); err != nil {
+				return err
+			}
+		}
+	}
+}
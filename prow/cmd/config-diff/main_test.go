@@ -0,0 +1,204 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	v1 "k8s.io/api/core/v1"
+
+	prowapi "k8s.io/test-infra/prow/apis/prowjobs/v1"
+	"k8s.io/test-infra/prow/config"
+)
+
+func podSpec(args ...string) *v1.PodSpec {
+	return &v1.PodSpec{Containers: []v1.Container{{Image: "image", Args: args}}}
+}
+
+func TestDiffConfigs(t *testing.T) {
+	job := func(name string, timeout time.Duration, args ...string) config.JobBase {
+		return config.JobBase{
+			Name:       name,
+			SourcePath: "jobs/" + name + ".yaml",
+			UtilityConfig: config.UtilityConfig{
+				DecorationConfig: &prowapi.DecorationConfig{Timeout: &prowapi.Duration{Duration: timeout}},
+			},
+			Spec: podSpec(args...),
+		}
+	}
+	base := &config.Config{
+		JobConfig: config.JobConfig{
+			PresubmitsStatic: map[string][]config.Presubmit{
+				"org/repo": {
+					{JobBase: job("pull-unchanged", time.Hour)},
+					{JobBase: job("pull-changed", 2*time.Hour, "a")},
+					{JobBase: job("pull-removed", time.Hour)},
+					{JobBase: job("pull-branches", time.Hour), Brancher: config.Brancher{Branches: []string{"main"}}},
+				},
+			},
+			Periodics: []config.Periodic{{JobBase: job("ci-args", time.Hour, "a")}},
+		},
+	}
+	head := &config.Config{
+		JobConfig: config.JobConfig{
+			PresubmitsStatic: map[string][]config.Presubmit{
+				"org/repo": {
+					{JobBase: job("pull-unchanged", time.Hour)},
+					{JobBase: job("pull-changed", 3*time.Hour, "b")},
+					{JobBase: job("pull-branches", time.Hour), Brancher: config.Brancher{Branches: []string{"main", "release"}}},
+				},
+			},
+			PostsubmitsStatic: map[string][]config.Postsubmit{
+				"org/repo": {{JobBase: job("post-added", time.Hour)}},
+			},
+			Periodics: []config.Periodic{{JobBase: job("ci-args", time.Hour, "a", "b")}},
+		},
+	}
+
+	changes, err := diffConfigs(base, head)
+	if err != nil {
+		t.Fatalf("failed to diff: %v", err)
+	}
+	expected := "Changes to the effective Prow configuration: 1 added, 1 removed, 3 modified.\n" +
+		"\n#### Added\n\n" +
+		"- postsubmit `post-added` of org/repo (`jobs/post-added.yaml`)\n" +
+		"\n#### Removed\n\n" +
+		"- presubmit `pull-removed` of org/repo (`jobs/pull-removed.yaml`)\n" +
+		"\n#### Modified\n\n" +
+		"<details>\n<summary>periodic `ci-args` (`jobs/ci-args.yaml`): 1 fields</summary>\n\n```diff\n" +
+		"- spec.containers[0].args: [\"a\"]\n" +
+		"+ spec.containers[0].args: [\"a\",\"b\"]\n" +
+		"```\n\n</details>\n" +
+		"<details>\n<summary>presubmit `pull-branches` of org/repo (`jobs/pull-branches.yaml`): 1 fields</summary>\n\n```diff\n" +
+		"- branches: [\"main\"]\n" +
+		"+ branches: [\"main\",\"release\"]\n" +
+		"```\n\n</details>\n" +
+		"<details>\n<summary>presubmit `pull-changed` of org/repo (`jobs/pull-changed.yaml`): 2 fields</summary>\n\n```diff\n" +
+		"- decoration_config.timeout: \"2h0m0s\"\n" +
+		"+ decoration_config.timeout: \"3h0m0s\"\n" +
+		"- spec.containers[0].args[0]: \"a\"\n" +
+		"+ spec.containers[0].args[0]: \"b\"\n" +
+		"```\n\n</details>\n"
+	if diff := cmp.Diff(expected, render(changes)); diff != "" {
+		t.Errorf("unexpected output (-want +got):\n%s", diff)
+	}
+
+	changes, err = diffConfigs(base, base)
+	if err != nil {
+		t.Fatalf("failed to diff: %v", err)
+	}
+	if len(changes) != 0 {
+		t.Errorf("expected no changes, got %v", changes)
+	}
+}
+
+func TestDiffValues(t *testing.T) {
+	var changes []fieldChange
+	diffValues("",
+		map[string]interface{}{"same": "x", "removed": "y", "nested": map[string]interface{}{"a": 1.0}},
+		map[string]interface{}{"same": "x", "added": true, "nested": map[string]interface{}{"a": 2.0}},
+		&changes)
+	expected := []fieldChange{
+		{path: "added", new: true},
+		{path: "nested.a", old: 1.0, new: 2.0},
+		{path: "removed", old: "y"},
+	}
+	if diff := cmp.Diff(expected, changes, cmp.AllowUnexported(fieldChange{})); diff != "" {
+		t.Errorf("unexpected changes (-want +got):\n%s", diff)
+	}
+}
+
+func TestLoadAt(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir := t.TempDir()
+	git := func(args ...string) {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v: %s", args, err, out)
+		}
+	}
+	write := func(path, content string) {
+		path = filepath.Join(dir, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	job := func(timeout string) string {
+		return `periodics:
+- name: ci-job
+  interval: 1h
+  decorate: true
+  decoration_config:
+    timeout: ` + timeout + `
+  spec:
+    containers:
+    - image: alpine
+      command: [run.sh]
+`
+	}
+	git("init", "--quiet")
+	write("config.yaml", "plank:\n  default_decoration_configs:\n    '*':\n      gcs_configuration:\n        bucket: bucket\n        path_strategy: explicit\n      utility_images:\n        clonerefs: clonerefs\n        initupload: initupload\n        entrypoint: entrypoint\n        sidecar: sidecar\n")
+	write("jobs/org/job.yaml", job("1h"))
+	git("add", "-A")
+	git("commit", "--quiet", "-m", "base")
+	write("jobs/org/job.yaml", job("2h"))
+
+	o := options{repoDir: dir, base: "HEAD", configPath: "config.yaml", jobConfigPath: "jobs"}
+	base, err := loadAt(o, o.base)
+	if err != nil {
+		t.Fatalf("failed to load base: %v", err)
+	}
+	head, err := loadAt(o, o.head)
+	if err != nil {
+		t.Fatalf("failed to load head: %v", err)
+	}
+	if source := base.Periodics[0].SourcePath; source != "jobs/org/job.yaml" {
+		t.Errorf("expected source path relative to the repo, got %q", source)
+	}
+	changes, err := diffConfigs(base, head)
+	if err != nil {
+		t.Fatalf("failed to diff: %v", err)
+	}
+	expected := []fieldChange{{path: "decoration_config.timeout", old: "1h0m0s", new: "2h0m0s"}}
+	if len(changes) != 1 {
+		t.Fatalf("expected one change, got %v", changes)
+	}
+	if diff := cmp.Diff(expected, changes[0].fields, cmp.AllowUnexported(fieldChange{})); diff != "" {
+		t.Errorf("unexpected changes (-want +got):\n%s", diff)
+	}
+
+	// Paths that don't exist at the revision are skipped.
+	o.jobConfigPath = "other-jobs"
+	base, err = loadAt(o, o.base)
+	if err != nil {
+		t.Fatalf("failed to load base without job config: %v", err)
+	}
+	if len(base.Periodics) != 0 {
+		t.Errorf("expected no periodics, got %v", base.Periodics)
+	}
+}
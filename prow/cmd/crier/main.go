@@ -39,6 +39,7 @@ import (
 	prowflagutil "k8s.io/test-infra/prow/flagutil"
 	configflagutil "k8s.io/test-infra/prow/flagutil/config"
 	"k8s.io/test-infra/prow/interrupts"
+	"k8s.io/test-infra/prow/io"
 	"k8s.io/test-infra/prow/logrusutil"
 	"k8s.io/test-infra/prow/metrics"
 	slackclient "k8s.io/test-infra/prow/slack"
@@ -61,6 +62,7 @@ type options struct {
 
 	slackTokenFile            string
 	additionalSlackTokenFiles slackclient.HostsFlag
+	slackThreadStatePath      string
 
 	storage prowflagutil.StorageClientOptions
 
@@ -119,6 +121,7 @@ func (o *options) parseArgs(fs *flag.FlagSet, args []string) error {
 	fs.IntVar(&o.k8sBlobStorageWorkers, "kubernetes-blob-storage-workers", 0, "Number of Kubernetes-specific blob storage report workers (0 means disabled)")
 	fs.Float64Var(&o.k8sReportFraction, "kubernetes-report-fraction", 1.0, "Approximate portion of jobs to report pod information for, if kubernetes-blob-storage-workers are enabled (0 - > none, 1.0 -> all)")
 	fs.StringVar(&o.slackTokenFile, "slack-token-file", "", "Path to a Slack token file")
+	fs.StringVar(&o.slackThreadStatePath, "slack-thread-state-path", "", "Path to store the Slack threads of failing periodics at, e.g. gs://bucket/slack-threads.json. Required for thread_periodics in slack_reporter_configs.")
 	fs.StringVar(&o.reportAgent, "report-agent", "", "Only report specified agent - empty means report to all agents (effective for github and Slack only)")
 
 	// TODO(krzyzacy): implement dryrun for gerrit/pubsub
@@ -204,7 +207,14 @@ func main() {
 				logrus.WithError(err).Fatal("could not read slack token")
 			}
 		}
-		slackReporter := slackreporter.New(slackConfig, o.dryrun, tokensMap)
+		var opener io.Opener
+		if o.slackThreadStatePath != "" {
+			var err error
+			if opener, err = o.storage.StorageClient(context.Background()); err != nil {
+				logrus.WithError(err).Fatal("Error creating opener")
+			}
+		}
+		slackReporter := slackreporter.New(slackConfig, o.dryrun, tokensMap, opener, o.slackThreadStatePath)
 		if err := crier.New(mgr, slackReporter, o.slackWorkers, o.githubEnablement.EnablementChecker()); err != nil {
 			logrus.WithError(err).Fatal("failed to construct slack reporter controller")
 		}
@@ -244,8 +254,14 @@ func main() {
 			logrus.WithError(err).Fatal("Error getting GitHub client.")
 		}
 
+		// The opener is used to summarize junit results of jobs reported as check runs.
+		opener, err := o.storage.StorageClient(context.Background())
+		if err != nil {
+			logrus.WithError(err).Fatal("Error creating opener")
+		}
+
 		hasReporter = true
-		githubReporter := githubreporter.NewReporter(githubClient, cfg, prowapi.ProwJobAgent(o.reportAgent), mgr.GetCache())
+		githubReporter := githubreporter.NewReporter(githubClient, cfg, prowapi.ProwJobAgent(o.reportAgent), mgr.GetCache(), opener)
 		if err := crier.New(mgr, githubReporter, o.githubWorkers, o.githubEnablement.EnablementChecker()); err != nil {
 			logrus.WithError(err).Fatal("failed to construct github reporter controller")
 		}
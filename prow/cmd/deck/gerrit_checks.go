@@ -0,0 +1,94 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	prowapi "k8s.io/test-infra/prow/apis/prowjobs/v1"
+	"k8s.io/test-infra/prow/config"
+	"k8s.io/test-infra/prow/deck/jobs"
+	"k8s.io/test-infra/prow/gerrit/checks"
+)
+
+// handleGerritChecks serves the ProwJobs of a patchset as check runs, for the
+// Prow plugin of the Checks UI of Gerrit. The url must look like this:
+//
+// /gerrit-checks?instance=<gerrit instance>&change=<change number>&patchset=<patchset number>
+//
+// Only the configured Gerrit instances can request the runs from a browser.
+func handleGerritChecks(cfg config.Getter, ja *jobs.JobAgent, log *logrus.Entry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		setHeadersNoCaching(w)
+		instances := cfg().Gerrit.OrgReposConfig.AllRepos()
+		if origin := r.Header.Get("Origin"); origin != "" {
+			if _, ok := instances[strings.TrimSuffix(origin, "/")]; ok {
+				w.Header().Set("Access-Control-Allow-Origin", origin)
+				w.Header().Set("Vary", "Origin")
+			}
+		}
+
+		resp, code := gerritChecks(r, instances, ja.ProwJobs)
+		if code != http.StatusOK {
+			log.WithField("url", r.URL.String()).Debug(resp.ErrorMessage)
+		}
+		body, err := json.Marshal(resp)
+		if err != nil {
+			log.WithError(err).Error("Error marshaling check runs.")
+			http.Error(w, "Error marshaling check runs.", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(code)
+		w.Write(body)
+	}
+}
+
+func gerritChecks(r *http.Request, instances map[string]map[string]*config.GerritQueryFilter, prowJobs func() []prowapi.ProwJob) (checks.Response, int) {
+	vals := r.URL.Query()
+	failed := func(format string, args ...interface{}) (checks.Response, int) {
+		return checks.Response{ResponseCode: "ERROR", ErrorMessage: fmt.Sprintf(format, args...)}, http.StatusBadRequest
+	}
+	instance := strings.TrimSuffix(vals.Get("instance"), "/")
+	if _, ok := instances[instance]; !ok {
+		return failed("Gerrit instance %q is not configured in Prow", instance)
+	}
+	change, err := strconv.Atoi(vals.Get("change"))
+	if err != nil {
+		return failed("invalid change %q", vals.Get("change"))
+	}
+	patchset, err := strconv.Atoi(vals.Get("patchset"))
+	if err != nil {
+		return failed("invalid patchset %q", vals.Get("patchset"))
+	}
+
+	runs := checks.Runs(prowJobs(), instance, change, patchset)
+	resp := checks.Response{ResponseCode: checks.ResponseCodeOK, Runs: runs}
+	for _, run := range runs {
+		if run.Status == checks.RunStatusCompleted && run.Results[0].Category == checks.CategoryError {
+			resp.Actions = []checks.Action{checks.RetestAction}
+			break
+		}
+	}
+	return resp, http.StatusOK
+}
@@ -0,0 +1,127 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	prowapi "k8s.io/test-infra/prow/apis/prowjobs/v1"
+	"k8s.io/test-infra/prow/config"
+	"k8s.io/test-infra/prow/deck/jobs"
+	"k8s.io/test-infra/prow/gerrit/checks"
+	"k8s.io/test-infra/prow/kube"
+)
+
+func TestHandleGerritChecks(t *testing.T) {
+	pj := func(name string, state prowapi.ProwJobState) prowapi.ProwJob {
+		return prowapi.ProwJob{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				Labels:      map[string]string{kube.GerritPatchset: "3"},
+				Annotations: map[string]string{kube.GerritInstance: "https://gerrit-review.example.com"},
+			},
+			Spec: prowapi.ProwJobSpec{
+				Job:  name,
+				Refs: &prowapi.Refs{Pulls: []prowapi.Pull{{Number: 42}}},
+			},
+			Status: prowapi.ProwJobStatus{State: state},
+		}
+	}
+	kc := fkc{pj("unit", prowapi.FailureState), pj("lint", prowapi.PendingState)}
+	ca := fca{c: config.Config{ProwConfig: config.ProwConfig{Gerrit: config.Gerrit{
+		OrgReposConfig: &config.GerritOrgRepoConfigs{{Org: "https://gerrit-review.example.com", Repos: []string{"foo"}}},
+	}}}}
+	ja := jobs.NewJobAgent(context.Background(), kc, false, true, []string{}, map[string]jobs.PodLogClient{}, ca.Config)
+	ja.Start()
+	handler := handleGerritChecks(ca.Config, ja, logrus.WithField("handler", "/gerrit-checks"))
+
+	testcases := []struct {
+		name         string
+		query        string
+		origin       string
+		expectedCode int
+		expectedRuns []string
+		allowOrigin  string
+	}{
+		{
+			name:         "runs of the patchset",
+			query:        "instance=https://gerrit-review.example.com&change=42&patchset=3",
+			origin:       "https://gerrit-review.example.com",
+			expectedCode: http.StatusOK,
+			expectedRuns: []string{"unit", "lint"},
+			allowOrigin:  "https://gerrit-review.example.com",
+		},
+		{
+			name:         "no runs for another patchset, other origins are not allowed",
+			query:        "instance=https://gerrit-review.example.com&change=42&patchset=2",
+			origin:       "https://evil.example.com",
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "unknown instance",
+			query:        "instance=https://other.example.com&change=42&patchset=3",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "invalid change",
+			query:        "instance=https://gerrit-review.example.com&change=abc&patchset=3",
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/gerrit-checks?"+tc.query, nil)
+			if tc.origin != "" {
+				req.Header.Set("Origin", tc.origin)
+			}
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+			if rr.Code != tc.expectedCode {
+				t.Fatalf("expected code %d, got %d: %s", tc.expectedCode, rr.Code, rr.Body.String())
+			}
+			if allowed := rr.Header().Get("Access-Control-Allow-Origin"); allowed != tc.allowOrigin {
+				t.Errorf("expected allowed origin %q, got %q", tc.allowOrigin, allowed)
+			}
+			var resp checks.Response
+			if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("failed to unmarshal response: %v", err)
+			}
+			runs := map[string]bool{}
+			for _, run := range resp.Runs {
+				runs[run.CheckName] = true
+			}
+			for _, name := range tc.expectedRuns {
+				if !runs[name] {
+					t.Errorf("expected a run of %s, got %+v", name, resp.Runs)
+				}
+			}
+			if len(runs) != len(tc.expectedRuns) {
+				t.Errorf("expected %d runs, got %d", len(tc.expectedRuns), len(runs))
+			}
+			if len(tc.expectedRuns) > 0 && (len(resp.Actions) != 1 || resp.Actions[0].Comment != "/retest") {
+				t.Errorf("expected a retest action for the failed run, got %+v", resp.Actions)
+			}
+		})
+	}
+}
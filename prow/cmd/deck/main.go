@@ -86,6 +86,7 @@ import (
 	_ "k8s.io/test-infra/prow/spyglass/lenses/buildlog"
 	_ "k8s.io/test-infra/prow/spyglass/lenses/coverage"
 	_ "k8s.io/test-infra/prow/spyglass/lenses/html"
+	_ "k8s.io/test-infra/prow/spyglass/lenses/jenkinsstages"
 	_ "k8s.io/test-infra/prow/spyglass/lenses/junit"
 	_ "k8s.io/test-infra/prow/spyglass/lenses/links"
 	_ "k8s.io/test-infra/prow/spyglass/lenses/metadata"
@@ -122,6 +123,7 @@ type options struct {
 	oauthURL               string
 	githubOAuthConfigFile  string
 	cookieSecretFile       string
+	slackSigningSecretFile string
 	redirectHTTPTo         string
 	hiddenOnly             bool
 	pregeneratedData       string
@@ -177,6 +179,7 @@ func gatherOptions(fs *flag.FlagSet, args ...string) options {
 	fs.StringVar(&o.oauthURL, "oauth-url", "", "Path to deck user dashboard endpoint.")
 	fs.StringVar(&o.githubOAuthConfigFile, "github-oauth-config-file", "/etc/github/secret", "Path to the file containing the GitHub App Client secret.")
 	fs.StringVar(&o.cookieSecretFile, "cookie-secret", "", "Path to the file containing the cookie secret key.")
+	fs.StringVar(&o.slackSigningSecretFile, "slack-signing-secret-file", "", "Path to the file containing the signing secret of the Slack app. Interactive actions on messages of the Slack reporter are served on /slack/interactivity if set.")
 	// use when behind a load balancer
 	fs.StringVar(&o.redirectHTTPTo, "redirect-http-to", "", "Host to redirect http->https to based on x-forwarded-proto == http.")
 	// use when behind an oauth proxy
@@ -445,7 +448,9 @@ func main() {
 	mux.Handle("/data.js", gziphandler.GzipHandler(handleData(ja, logrus.WithField("handler", "/data.js"))))
 	mux.Handle("/prowjobs.js", gziphandler.GzipHandler(handleProwJobs(ja, logrus.WithField("handler", "/prowjobs.js"))))
 	mux.Handle("/badge.svg", gziphandler.GzipHandler(handleBadge(ja)))
+	mux.Handle("/gerrit-checks", gziphandler.GzipHandler(handleGerritChecks(cfg, ja, logrus.WithField("handler", "/gerrit-checks"))))
 	mux.Handle("/log", gziphandler.GzipHandler(handleLog(ja, logrus.WithField("handler", "/log"))))
+	mux.Handle("/presubmit-impact", gziphandler.GzipHandler(handlePresubmitImpact(o, cfg, githubClient, gitClient, logrus.WithField("handler", "/presubmit-impact"))))
 
 	if o.spyglass {
 		initSpyglass(cfg, o, mux, ja, githubClient, gitClient)
@@ -499,7 +504,7 @@ func main() {
 
 	if csrfToken != nil {
 		CSRF := csrf.Protect(csrfToken, csrf.Path("/"), csrf.Secure(!o.allowInsecure))
-		logrus.WithError(http.ListenAndServe(":8080", CSRF(traceHandler(mux)))).Fatal("ListenAndServe returned.")
+		logrus.WithError(http.ListenAndServe(":8080", skipCSRFForSlack(CSRF(traceHandler(mux))))).Fatal("ListenAndServe returned.")
 		return
 	}
 	// setup done, actually start the server
@@ -654,6 +659,18 @@ func prodOnlyMain(cfg config.Getter, pluginAgent *plugins.ConfigAgent, authCfgGe
 	mux.Handle("/rerun", gziphandler.GzipHandler(handleRerun(cfg, prowJobClient, o.rerunCreatesJob, authCfgGetter, goa, githuboauth.NewAuthenticatedUserIdentifier(&o.github), githubClient, pluginAgent, logrus.WithField("handler", "/rerun"))))
 	mux.Handle("/abort", gziphandler.GzipHandler(handleAbort(prowJobClient, authCfgGetter, goa, githuboauth.NewAuthenticatedUserIdentifier(&o.github), githubClient, pluginAgent, logrus.WithField("handler", "/abort"))))
 
+	if o.slackSigningSecretFile != "" {
+		signingSecret, err := loadToken(o.slackSigningSecretFile)
+		if err != nil {
+			logrus.WithError(err).Fatal("Could not read Slack signing secret file.")
+		}
+		var pluginsConfig pluginsCfg
+		if pluginAgent != nil {
+			pluginsConfig = pluginAgent.Config
+		}
+		mux.Handle(slackInteractivityPath, handleSlackInteraction(cfg, prowJobClient, o.rerunCreatesJob, authCfgGetter, githubClient, pluginsConfig, func() []byte { return signingSecret }, logrus.WithField("handler", slackInteractivityPath)))
+	}
+
 	// optionally inject http->https redirect handler when behind loadbalancer
 	if o.redirectHTTPTo != "" {
 		redirectMux := http.NewServeMux()
@@ -1562,6 +1579,7 @@ func isValidatedGitOAuthConfig(githubOAuthConfig *githuboauth.Config) bool {
 type deckGitHubClient interface {
 	prowgithub.RerunClient
 	GetPullRequest(org, repo string, number int) (*prowgithub.PullRequest, error)
+	GetPullRequestChanges(org, repo string, number int) ([]prowgithub.PullRequestChange, error)
 	GetRef(org, repo, ref string) (string, error)
 	BotUserChecker() (func(candidate string) bool, error)
 }
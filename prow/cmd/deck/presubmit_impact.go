@@ -0,0 +1,145 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	"k8s.io/test-infra/prow/config"
+	"k8s.io/test-infra/prow/git/v2"
+	"k8s.io/test-infra/prow/pjutil"
+)
+
+type presubmitImpactTemplate struct {
+	Org    string
+	Repo   string
+	Branch string
+	PR     string
+	// Files are the changed files, one per line.
+	Files   string
+	Error   string
+	Note    string
+	Impacts []pjutil.PresubmitImpact
+}
+
+// handlePresubmitImpact handles requests to simulate which presubmits run for
+// a change. The url must look like one of these:
+//
+// /presubmit-impact?org=<org>&repo=<repo>&branch=<branch>&files=<changed files>
+// /presubmit-impact?org=<org>&repo=<repo>&pr=<pr number>
+//
+// Without parameters, only the form is rendered.
+func handlePresubmitImpact(o options, cfg config.Getter, gitHubClient deckGitHubClient, gitClient git.ClientFactory, log *logrus.Entry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		setHeadersNoCaching(w)
+		tmpl, err := getPresubmitImpact(r.URL, cfg(), gitHubClient, gitClient)
+		if err != nil {
+			log.WithField("url", r.URL.String()).WithError(err).Debug("Failed to simulate presubmits.")
+			tmpl.Error = err.Error()
+		}
+		handleSimpleTemplate(o, cfg, "presubmit-impact.html", tmpl)(w, r)
+	}
+}
+
+func getPresubmitImpact(u *url.URL, c *config.Config, gitHubClient deckGitHubClient, gitClient git.ClientFactory) (presubmitImpactTemplate, error) {
+	vals := u.Query()
+	tmpl := presubmitImpactTemplate{
+		Org:    vals.Get("org"),
+		Repo:   vals.Get("repo"),
+		Branch: vals.Get("branch"),
+		PR:     vals.Get("pr"),
+		Files:  vals.Get("files"),
+	}
+	if len(vals) == 0 {
+		return tmpl, nil
+	}
+	if tmpl.Org == "" || tmpl.Repo == "" {
+		return tmpl, errors.New("org and repo are required")
+	}
+	fullRepo := tmpl.Org + "/" + tmpl.Repo
+	inRepo := c.InRepoConfigEnabled(fullRepo)
+	if inRepo && (gitHubClient == nil || gitClient == nil) {
+		inRepo = false
+		tmpl.Note = "Presubmits defined in the repository are not shown, as Deck has no access to it."
+	}
+
+	var changes []string
+	var baseSHAGetter config.RefGetter
+	var headSHAGetters []config.RefGetter
+	if tmpl.PR != "" {
+		number, err := strconv.Atoi(tmpl.PR)
+		if err != nil {
+			return tmpl, fmt.Errorf("invalid PR number %q: %w", tmpl.PR, err)
+		}
+		if gitHubClient == nil {
+			return tmpl, errors.New("Deck has no GitHub client to look up pull requests")
+		}
+		refGetter := config.NewRefGetterForGitHubPullRequest(gitHubClient, tmpl.Org, tmpl.Repo, number)
+		pr, err := refGetter.PullRequest()
+		if err != nil {
+			return tmpl, fmt.Errorf("failed to get pull request: %w", err)
+		}
+		prChanges, err := gitHubClient.GetPullRequestChanges(tmpl.Org, tmpl.Repo, number)
+		if err != nil {
+			return tmpl, fmt.Errorf("failed to get changes of the pull request: %w", err)
+		}
+		for _, change := range prChanges {
+			changes = append(changes, change.Filename)
+		}
+		tmpl.Branch = pr.Base.Ref
+		tmpl.Files = strings.Join(changes, "\n")
+		baseSHAGetter = refGetter.BaseSHA
+		headSHAGetters = append(headSHAGetters, refGetter.HeadSHA)
+	} else {
+		if tmpl.Branch == "" {
+			return tmpl, errors.New("branch is required without a PR")
+		}
+		// Paths may contain spaces, so only lines separate them.
+		for _, line := range strings.Split(tmpl.Files, "\n") {
+			if line = strings.TrimSuffix(line, "\r"); strings.TrimSpace(line) != "" {
+				changes = append(changes, line)
+			}
+		}
+		baseSHAGetter = func() (string, error) {
+			return gitHubClient.GetRef(tmpl.Org, tmpl.Repo, "heads/"+tmpl.Branch)
+		}
+	}
+
+	static := c.GetPresubmitsStatic(fullRepo)
+	var inRepoPresubmits []config.Presubmit
+	if inRepo {
+		presubmits, err := c.GetPresubmits(gitClient, fullRepo, baseSHAGetter, headSHAGetters...)
+		if err != nil {
+			return tmpl, fmt.Errorf("failed to get presubmits: %w", err)
+		}
+		// The in-repo presubmits follow the static ones.
+		inRepoPresubmits = presubmits[len(static):]
+	}
+	impacts, err := pjutil.SimulatePresubmits(tmpl.Branch, changes, static, inRepoPresubmits)
+	if err != nil {
+		return tmpl, err
+	}
+	tmpl.Impacts = impacts
+	return tmpl, nil
+}
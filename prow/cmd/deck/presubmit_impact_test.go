@@ -0,0 +1,121 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"k8s.io/test-infra/prow/config"
+	"k8s.io/test-infra/prow/github"
+	"k8s.io/test-infra/prow/github/fakegithub"
+)
+
+func TestGetPresubmitImpact(t *testing.T) {
+	presubmits := []config.Presubmit{
+		{JobBase: config.JobBase{Name: "always"}, AlwaysRun: true},
+		{JobBase: config.JobBase{Name: "foo"}, RegexpChangeMatcher: config.RegexpChangeMatcher{RunIfChanged: "^foo/"}},
+		{JobBase: config.JobBase{Name: "notes"}, RegexpChangeMatcher: config.RegexpChangeMatcher{RunIfChanged: `^docs/release notes\.md$`}},
+	}
+	if err := config.SetPresubmitRegexes(presubmits); err != nil {
+		t.Fatalf("failed to set regexes: %v", err)
+	}
+	c := &config.Config{JobConfig: config.JobConfig{PresubmitsStatic: map[string][]config.Presubmit{"org/repo": presubmits}}}
+
+	ghc := fakegithub.NewFakeClient()
+	ghc.PullRequests = map[int]*github.PullRequest{
+		5: {Number: 5, Base: github.PullRequestBranch{Ref: "main", SHA: "base"}, Head: github.PullRequestBranch{SHA: "head"}},
+	}
+	ghc.PullRequestChanges = map[int][]github.PullRequestChange{
+		5: {{Filename: "foo/a.go"}, {Filename: "bar/b.go"}},
+	}
+
+	testCases := []struct {
+		name          string
+		query         string
+		expectedRuns  map[string]bool
+		expectedFiles string
+		expectedErr   string
+	}{
+		{
+			name: "form only",
+		},
+		{
+			name:        "missing repo",
+			query:       "org=org&branch=main",
+			expectedErr: "org and repo are required",
+		},
+		{
+			name:        "missing branch",
+			query:       "org=org&repo=repo&files=foo/a.go",
+			expectedErr: "branch is required without a PR",
+		},
+		{
+			name:          "changed files",
+			query:         "org=org&repo=repo&branch=main&files=" + url.QueryEscape("bar/b.go\nREADME.md"),
+			expectedRuns:  map[string]bool{"always": true, "foo": false, "notes": false},
+			expectedFiles: "bar/b.go\nREADME.md",
+		},
+		{
+			name:          "changed files with spaces",
+			query:         "org=org&repo=repo&branch=main&files=" + url.QueryEscape("docs/release notes.md\r\n\r\nREADME.md"),
+			expectedRuns:  map[string]bool{"always": true, "foo": false, "notes": true},
+			expectedFiles: "docs/release notes.md\r\n\r\nREADME.md",
+		},
+		{
+			name:          "pull request",
+			query:         "org=org&repo=repo&pr=5",
+			expectedRuns:  map[string]bool{"always": true, "foo": true, "notes": false},
+			expectedFiles: "foo/a.go\nbar/b.go",
+		},
+		{
+			name:        "unknown pull request",
+			query:       "org=org&repo=repo&pr=6",
+			expectedErr: "failed to get pull request",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tmpl, err := getPresubmitImpact(&url.URL{RawQuery: tc.query}, c, ghc, nil)
+			if tc.expectedErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.expectedErr) {
+					t.Fatalf("expected error containing %q, got %v", tc.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var runs map[string]bool
+			for _, impact := range tmpl.Impacts {
+				if runs == nil {
+					runs = map[string]bool{}
+				}
+				runs[impact.Name] = impact.Runs
+			}
+			if diff := cmp.Diff(tc.expectedRuns, runs); diff != "" {
+				t.Errorf("unexpected jobs (-want +got):\n%s", diff)
+			}
+			if tmpl.Files != tc.expectedFiles {
+				t.Errorf("expected files %q, got %q", tc.expectedFiles, tmpl.Files)
+			}
+		})
+	}
+}
@@ -0,0 +1,251 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gorilla/csrf"
+	"github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ktypes "k8s.io/apimachinery/pkg/types"
+
+	prowapi "k8s.io/test-infra/prow/apis/prowjobs/v1"
+	prowv1 "k8s.io/test-infra/prow/client/clientset/versioned/typed/prowjobs/v1"
+	"k8s.io/test-infra/prow/config"
+	"k8s.io/test-infra/prow/pjutil"
+	"k8s.io/test-infra/prow/slack"
+)
+
+const slackInteractivityPath = "/slack/interactivity"
+
+// skipCSRFForSlack exempts Slack interactions from CSRF protection. They cannot
+// carry a CSRF token and are authenticated by their signature instead.
+func skipCSRFForSlack(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == slackInteractivityPath {
+			r = csrf.UnsafeSkipCheck(r)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// slackResponder sends a message to the response URL of a Slack interaction.
+type slackResponder func(responseURL string, response slack.InteractionResponse) error
+
+// slackInteractionHandler performs the interactive actions on messages of the
+// Slack reporter. Actions are authorized like reruns and aborts from deck, as the
+// GitHub user that the Slack user who took them maps to in deck.slack_users.
+type slackInteractionHandler struct {
+	cfg           config.Getter
+	prowJobClient prowv1.ProwJobInterface
+	createProwJob bool
+	acfg          authCfgGetter
+	cli           deckGitHubClient
+	pluginsCfg    pluginsCfg
+	signingSecret func() []byte
+	respond       slackResponder
+	now           func() time.Time
+	log           *logrus.Entry
+}
+
+func handleSlackInteraction(cfg config.Getter, prowJobClient prowv1.ProwJobInterface, createProwJob bool, acfg authCfgGetter, cli deckGitHubClient, pluginsCfg pluginsCfg, signingSecret func() []byte, log *logrus.Entry) http.Handler {
+	return &slackInteractionHandler{
+		cfg:           cfg,
+		prowJobClient: prowJobClient,
+		createProwJob: createProwJob,
+		acfg:          acfg,
+		cli:           cli,
+		pluginsCfg:    pluginsCfg,
+		signingSecret: signingSecret,
+		respond:       slack.Respond,
+		now:           time.Now,
+		log:           log,
+	}
+}
+
+func (h *slackInteractionHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, fmt.Sprintf("bad verb %v", r.Method), http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "Failed to read request body.", http.StatusInternalServerError)
+		return
+	}
+	if err := slack.VerifySignature(r.Header, body, h.signingSecret(), h.now()); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid signature: %v.", err), http.StatusUnauthorized)
+		h.log.WithError(err).Debug("Invalid Slack signature.")
+		return
+	}
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid form: %v.", err), http.StatusBadRequest)
+		return
+	}
+	var payload slack.InteractionPayload
+	if err := json.Unmarshal([]byte(form.Get("payload")), &payload); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid payload: %v.", err), http.StatusBadRequest)
+		return
+	}
+
+	if payload.Type == "block_actions" {
+		for _, action := range payload.Actions {
+			l := h.log.WithFields(logrus.Fields{"action": action.ActionID, "prowjob": action.Value, "slack-user": payload.User.ID})
+			response, ok := h.handleAction(payload.User, action, l)
+			if !ok {
+				continue
+			}
+			if err := h.respond(payload.ResponseURL, response); err != nil {
+				l.WithError(err).Warning("Failed to respond to Slack interaction.")
+			}
+		}
+	}
+	// Slack only expects an acknowledgement, responses go to the response URL.
+	w.WriteHeader(http.StatusOK)
+}
+
+// handleAction performs an action and returns the response to it, if any.
+func (h *slackInteractionHandler) handleAction(user slack.User, action slack.Action, l *logrus.Entry) (slack.InteractionResponse, bool) {
+	if config.SlackAction(action.ActionID) == config.SlackActionLogs {
+		// The button only links to the job.
+		return slack.InteractionResponse{}, false
+	}
+	pj, err := h.prowJobClient.Get(context.TODO(), action.Value, metav1.GetOptions{})
+	if err != nil {
+		l.WithError(err).Debug("ProwJob not found.")
+		return ephemeral("Could not find ProwJob %s: %v.", action.Value, err), true
+	}
+
+	switch config.SlackAction(action.ActionID) {
+	case config.SlackActionRerun:
+		return h.rerun(user, pj, l), true
+	case config.SlackActionAbort:
+		return h.abort(user, pj, l), true
+	case config.SlackActionAssign:
+		return h.assign(user, pj), true
+	default:
+		return ephemeral("Unknown action %q.", action.ActionID), true
+	}
+}
+
+func (h *slackInteractionHandler) rerun(user slack.User, pj *prowapi.ProwJob, l *logrus.Entry) slack.InteractionResponse {
+	if !h.createProwJob {
+		return ephemeral("Direct rerun feature is not enabled. Enable with the '--rerun-creates-job' flag.")
+	}
+	newPJ := pjutil.NewProwJob(pj.Spec, pj.ObjectMeta.Labels, pj.ObjectMeta.Annotations)
+	login, allowed, err := h.isAllowed(user, newPJ, l)
+	if err != nil {
+		l.WithError(err).Debug("Could not verify if allowed to rerun.")
+		return ephemeral("Could not verify if you are allowed to rerun %s: %v.", pj.Spec.Job, err)
+	}
+	l.WithField("allowed", allowed).Info("Attempted rerun from Slack")
+	if !allowed {
+		return ephemeral("You don't have permission to rerun %s.", pj.Spec.Job)
+	}
+	newPJ.Status.Description = describe(login, "reran", pj.Name)
+	if _, err := h.prowJobClient.Create(context.TODO(), &newPJ, metav1.CreateOptions{}); err != nil {
+		l.WithError(err).Error("Error creating job.")
+		return ephemeral("Error creating job: %v.", err)
+	}
+	return inChannel("<@%s> triggered a rerun of %s.", user.ID, pj.Spec.Job)
+}
+
+func (h *slackInteractionHandler) abort(user slack.User, pj *prowapi.ProwJob, l *logrus.Entry) slack.InteractionResponse {
+	if pj.Status.State != prowapi.TriggeredState && pj.Status.State != prowapi.PendingState {
+		return ephemeral("Cannot abort job with state: %q.", pj.Status.State)
+	}
+	login, allowed, err := h.isAllowed(user, *pj, l)
+	if err != nil {
+		l.WithError(err).Debug("Could not verify if allowed to abort.")
+		return ephemeral("Could not verify if you are allowed to abort %s: %v.", pj.Spec.Job, err)
+	}
+	l.WithField("allowed", allowed).Info("Attempted abort from Slack")
+	if !allowed {
+		return ephemeral("You don't have permission to abort %s.", pj.Spec.Job)
+	}
+	pj.Status.State = prowapi.AbortedState
+	pj.Status.Description = describe(login, "aborted", pj.Name)
+	patch, err := json.Marshal(pj)
+	if err != nil {
+		l.WithError(err).Error("Error marshal source job.")
+		return ephemeral("Error marshal source job: %v.", err)
+	}
+	if _, err := h.prowJobClient.Patch(context.TODO(), pj.Name, ktypes.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+		l.WithError(err).Error("Could not patch aborted job.")
+		return ephemeral("Could not patch aborted job: %v.", err)
+	}
+	return inChannel("<@%s> aborted %s.", user.ID, pj.Spec.Job)
+}
+
+func (h *slackInteractionHandler) assign(user slack.User, pj *prowapi.ProwJob) slack.InteractionResponse {
+	refs := pj.Spec.Refs
+	if refs == nil && len(pj.Spec.ExtraRefs) > 0 {
+		refs = &pj.Spec.ExtraRefs[0]
+	}
+	onCall := h.cfg().SlackReporterConfigs.GetSlackReporter(refs).OnCall
+	if onCall == "" {
+		return ephemeral("No on-call is configured for %s.", pj.Spec.Job)
+	}
+	return inChannel("%s please take a look at %s, assigned by <@%s>.", slackMention(onCall), pj.Spec.Job, user.ID)
+}
+
+// isAllowed determines whether a Slack user may rerun or abort a job and returns the
+// GitHub login they map to, if that was needed to tell.
+func (h *slackInteractionHandler) isAllowed(user slack.User, pj prowapi.ProwJob, l *logrus.Entry) (string, bool, error) {
+	authConfig := h.acfg(&pj.Spec)
+	if pj.Spec.RerunAuthConfig.IsAllowAnyone() || authConfig.IsAllowAnyone() {
+		return "", true, nil
+	}
+	login, ok := h.cfg().Deck.SlackUsers[user.ID]
+	if !ok {
+		return "", false, fmt.Errorf("no GitHub login is configured for Slack user %s in deck.slack_users", user.ID)
+	}
+	allowed, err := canTriggerJob(login, pj, authConfig, h.cli, h.pluginsCfg, l.WithField("user", login))
+	return login, allowed, err
+}
+
+func describe(login, verb, name string) string {
+	if login == "" {
+		return fmt.Sprintf("Successfully %s %s from Slack.", verb, name)
+	}
+	return fmt.Sprintf("%s successfully %s %s from Slack.", login, verb, name)
+}
+
+// slackMention mentions a Slack user or, for IDs starting with S, a user group.
+func slackMention(id string) string {
+	if strings.HasPrefix(id, "S") {
+		return fmt.Sprintf("<!subteam^%s>", id)
+	}
+	return fmt.Sprintf("<@%s>", id)
+}
+
+func ephemeral(format string, args ...interface{}) slack.InteractionResponse {
+	return slack.InteractionResponse{ResponseType: "ephemeral", Text: fmt.Sprintf(format, args...)}
+}
+
+func inChannel(format string, args ...interface{}) slack.InteractionResponse {
+	return slack.InteractionResponse{ResponseType: "in_channel", Text: fmt.Sprintf(format, args...)}
+}
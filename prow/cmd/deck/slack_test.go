@@ -0,0 +1,230 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	prowapi "k8s.io/test-infra/prow/apis/prowjobs/v1"
+	"k8s.io/test-infra/prow/client/clientset/versioned/fake"
+	"k8s.io/test-infra/prow/config"
+	"k8s.io/test-infra/prow/github/fakegithub"
+	"k8s.io/test-infra/prow/slack"
+)
+
+func TestSlackInteraction(t *testing.T) {
+	secret := []byte("signing-secret")
+	now := time.Now()
+
+	testCases := []struct {
+		name          string
+		action        config.SlackAction
+		user          string
+		state         prowapi.ProwJobState
+		createProwJob bool
+		badSignature  bool
+
+		expectedCode     int
+		expectedResponse *slack.InteractionResponse
+		expectedJobs     int
+		expectedState    prowapi.ProwJobState
+	}{
+		{
+			name:         "invalid signature is rejected",
+			action:       config.SlackActionRerun,
+			user:         "U-authorized",
+			state:        prowapi.FailureState,
+			badSignature: true,
+			expectedCode: http.StatusUnauthorized,
+			expectedJobs: 1,
+		},
+		{
+			name:             "authorized user reruns job",
+			action:           config.SlackActionRerun,
+			user:             "U-authorized",
+			state:            prowapi.FailureState,
+			createProwJob:    true,
+			expectedCode:     http.StatusOK,
+			expectedResponse: &slack.InteractionResponse{ResponseType: "in_channel", Text: "<@U-authorized> triggered a rerun of whoa."},
+			expectedJobs:     2,
+		},
+		{
+			name:             "rerun requires direct reruns",
+			action:           config.SlackActionRerun,
+			user:             "U-authorized",
+			state:            prowapi.FailureState,
+			expectedCode:     http.StatusOK,
+			expectedResponse: &slack.InteractionResponse{ResponseType: "ephemeral", Text: "Direct rerun feature is not enabled. Enable with the '--rerun-creates-job' flag."},
+			expectedJobs:     1,
+		},
+		{
+			name:             "unauthorized user cannot rerun job",
+			action:           config.SlackActionRerun,
+			user:             "U-unauthorized",
+			state:            prowapi.FailureState,
+			createProwJob:    true,
+			expectedCode:     http.StatusOK,
+			expectedResponse: &slack.InteractionResponse{ResponseType: "ephemeral", Text: "You don't have permission to rerun whoa."},
+			expectedJobs:     1,
+		},
+		{
+			name:             "unmapped user cannot rerun job",
+			action:           config.SlackActionRerun,
+			user:             "U-unknown",
+			state:            prowapi.FailureState,
+			createProwJob:    true,
+			expectedCode:     http.StatusOK,
+			expectedResponse: &slack.InteractionResponse{ResponseType: "ephemeral", Text: "Could not verify if you are allowed to rerun whoa: no GitHub login is configured for Slack user U-unknown in deck.slack_users."},
+			expectedJobs:     1,
+		},
+		{
+			name:             "authorized user aborts job",
+			action:           config.SlackActionAbort,
+			user:             "U-authorized",
+			state:            prowapi.PendingState,
+			expectedCode:     http.StatusOK,
+			expectedResponse: &slack.InteractionResponse{ResponseType: "in_channel", Text: "<@U-authorized> aborted whoa."},
+			expectedJobs:     1,
+			expectedState:    prowapi.AbortedState,
+		},
+		{
+			name:             "finished job cannot be aborted",
+			action:           config.SlackActionAbort,
+			user:             "U-authorized",
+			state:            prowapi.SuccessState,
+			expectedCode:     http.StatusOK,
+			expectedResponse: &slack.InteractionResponse{ResponseType: "ephemeral", Text: `Cannot abort job with state: "success".`},
+			expectedJobs:     1,
+			expectedState:    prowapi.SuccessState,
+		},
+		{
+			name:             "anyone assigns job to on-call",
+			action:           config.SlackActionAssign,
+			user:             "U-unknown",
+			state:            prowapi.FailureState,
+			expectedCode:     http.StatusOK,
+			expectedResponse: &slack.InteractionResponse{ResponseType: "in_channel", Text: "<!subteam^S-oncall> please take a look at whoa, assigned by <@U-unknown>."},
+			expectedJobs:     1,
+		},
+		{
+			name:         "view logs needs no response",
+			action:       config.SlackActionLogs,
+			user:         "U-unknown",
+			state:        prowapi.FailureState,
+			expectedCode: http.StatusOK,
+			expectedJobs: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fakeProwJobClient := fake.NewSimpleClientset(&prowapi.ProwJob{
+				ObjectMeta: metav1.ObjectMeta{Name: "wowsuch", Namespace: "prowjobs"},
+				Spec: prowapi.ProwJobSpec{
+					Job:  "whoa",
+					Type: prowapi.PeriodicJob,
+				},
+				Status: prowapi.ProwJobStatus{State: tc.state},
+			})
+			cfg := &config.Config{ProwConfig: config.ProwConfig{
+				Deck: config.Deck{
+					SlackUsers: map[string]string{"U-authorized": "authorized", "U-unauthorized": "random-dude"},
+				},
+				SlackReporterConfigs: config.SlackReporterConfigs{"*": {OnCall: "S-oncall"}},
+			}}
+			authCfgGetter := func(*prowapi.ProwJobSpec) *prowapi.RerunAuthConfig {
+				return &prowapi.RerunAuthConfig{GitHubUsers: []string{"authorized"}}
+			}
+			handler := handleSlackInteraction(func() *config.Config { return cfg }, fakeProwJobClient.ProwV1().ProwJobs("prowjobs"), tc.createProwJob, authCfgGetter, fakegithub.NewFakeClient(), nil, func() []byte { return secret }, logrus.WithField("handler", slackInteractivityPath)).(*slackInteractionHandler)
+			handler.now = func() time.Time { return now }
+			var responses []slack.InteractionResponse
+			handler.respond = func(responseURL string, response slack.InteractionResponse) error {
+				if responseURL != "https://hooks.slack.com/actions/1" {
+					t.Errorf("unexpected response URL %q", responseURL)
+				}
+				responses = append(responses, response)
+				return nil
+			}
+
+			payload, err := json.Marshal(slack.InteractionPayload{
+				Type:        "block_actions",
+				User:        slack.User{ID: tc.user},
+				Actions:     []slack.Action{{ActionID: string(tc.action), Value: "wowsuch"}},
+				ResponseURL: "https://hooks.slack.com/actions/1",
+			})
+			if err != nil {
+				t.Fatalf("failed to marshal payload: %v", err)
+			}
+			body := url.Values{"payload": []string{string(payload)}}.Encode()
+			timestamp := strconv.FormatInt(now.Unix(), 10)
+			mac := hmac.New(sha256.New, secret)
+			fmt.Fprintf(mac, "v0:%s:%s", timestamp, body)
+			signature := "v0=" + hex.EncodeToString(mac.Sum(nil))
+			if tc.badSignature {
+				signature = "v0=abcd"
+			}
+			req := httptest.NewRequest(http.MethodPost, slackInteractivityPath, strings.NewReader(body))
+			req.Header.Set("X-Slack-Request-Timestamp", timestamp)
+			req.Header.Set("X-Slack-Signature", signature)
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != tc.expectedCode {
+				t.Fatalf("expected code %d, got %d: %s", tc.expectedCode, rr.Code, rr.Body.String())
+			}
+			var expectedResponses []slack.InteractionResponse
+			if tc.expectedResponse != nil {
+				expectedResponses = []slack.InteractionResponse{*tc.expectedResponse}
+			}
+			if diff := cmp.Diff(expectedResponses, responses); diff != "" {
+				t.Errorf("unexpected responses (-want +got):\n%s", diff)
+			}
+			jobs, err := fakeProwJobClient.ProwV1().ProwJobs("prowjobs").List(context.TODO(), metav1.ListOptions{})
+			if err != nil {
+				t.Fatalf("failed to list jobs: %v", err)
+			}
+			if len(jobs.Items) != tc.expectedJobs {
+				t.Errorf("expected %d jobs, got %d", tc.expectedJobs, len(jobs.Items))
+			}
+			if tc.expectedState != "" {
+				pj, err := fakeProwJobClient.ProwV1().ProwJobs("prowjobs").Get(context.TODO(), "wowsuch", metav1.GetOptions{})
+				if err != nil {
+					t.Fatalf("job not found: %v", err)
+				}
+				if pj.Status.State != tc.expectedState {
+					t.Errorf("expected state %q, got %q", tc.expectedState, pj.Status.State)
+				}
+			}
+		})
+	}
+}
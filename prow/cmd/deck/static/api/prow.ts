@@ -121,7 +121,39 @@ export interface ProwJobStatus {
   pod_name?: string;
   build_id?: string;
   jenkins_build_id?: string;
+  jenkins_stages?: JenkinsStage[];
   prev_report_states?: { [key: string]: ProwJobState };
+  pipeline_run_status?: PipelineRunStatus;
+}
+
+// JenkinsStage mirrors the JenkinsStage struct defined in prow/apis/prowjobs/v1/types.go.
+export interface JenkinsStage {
+  name: string;
+  status?: string;
+  startTime?: string;
+  duration?: string;
+}
+
+// PipelineRunStatus mirrors the PipelineRunStatus struct defined in prow/apis/prowjobs/v1/types.go.
+export interface PipelineRunStatus {
+  results?: PipelineResult[];
+  task_runs?: TaskRunStatus[];
+}
+
+export interface PipelineResult {
+  name: string;
+  value: string;
+}
+
+// TaskRunStatus mirrors the TaskRunStatus struct defined in prow/apis/prowjobs/v1/types.go.
+export interface TaskRunStatus {
+  name: string;
+  pipeline_task_name?: string;
+  state?: ProwJobState;
+  description?: string;
+  startTime?: string;
+  completionTime?: string;
+  results?: PipelineResult[];
 }
 
 // PodSpec is a description of a pod.
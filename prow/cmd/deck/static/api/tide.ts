@@ -17,7 +17,7 @@ export interface PullRequest extends BasePullRequest {
   Title: string;
 }
 
-export type Action = "WAIT" | "TRIGGER" | "TRIGGER_BATCH" | "MERGE" | "MERGE_BATCH" | "BLOCKED";
+export type Action = "WAIT" | "TRIGGER" | "TRIGGER_BATCH" | "MERGE" | "MERGE_BATCH" | "BLOCKED" | "FROZEN";
 
 export interface Blocker {
   Number: number;
@@ -25,6 +25,11 @@ export interface Blocker {
   URL: string;
 }
 
+export interface MergeFreeze {
+  Name: string;
+  Until: string;
+}
+
 export interface TidePool {
   Org: string;
   Repo: string;
@@ -39,6 +44,7 @@ export interface TidePool {
   Action: Action;
   Target: PullRequest[];
   Blockers: Blocker[];
+  MergeFreezes?: MergeFreeze[];
 }
 
 export interface TideData {
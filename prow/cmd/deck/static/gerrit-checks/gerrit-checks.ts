@@ -0,0 +1,105 @@
+// Gerrit plugin that shows the ProwJobs of a patchset in the Checks UI. Gerrit
+// loads it from Deck, e.g. https://prow.example.com/static/gerrit_checks_bundle.min.js,
+// and the plugin fetches the runs from the /gerrit-checks endpoint of the same
+// Deck. See https://gerrit-review.googlesource.com/Documentation/pg-plugin-checks-api.html
+
+// The parts of the Gerrit plugin API that are used here.
+interface ChangeData {
+  changeNumber: number;
+  patchsetNumber: number;
+  repo: string;
+}
+
+interface ActionResult {
+  message?: string;
+  shouldReload?: boolean;
+}
+
+type ActionCallback = (change: number, patchset: number) => Promise<ActionResult>;
+
+interface GerritAction {
+  name: string;
+  tooltip?: string;
+  primary?: boolean;
+  callback: ActionCallback;
+}
+
+interface ChecksPlugin {
+  register(provider: {fetch: (data: ChangeData) => Promise<FetchResponse>}): void;
+}
+
+interface Plugin {
+  checks(): ChecksPlugin;
+  restApi(): {post: (url: string, body: object) => Promise<unknown>};
+}
+
+declare const Gerrit: {install: (callback: (plugin: Plugin) => void) => void};
+
+// The response of /gerrit-checks, see prow/gerrit/checks.
+interface ProwAction {
+  name: string;
+  tooltip?: string;
+  primary?: boolean;
+  comment: string;
+}
+
+interface ProwRun {
+  actions?: ProwAction[];
+  [key: string]: unknown;
+}
+
+interface ProwResponse {
+  responseCode: string;
+  errorMessage?: string;
+  actions?: ProwAction[];
+  runs: ProwRun[];
+}
+
+interface FetchResponse {
+  responseCode: string;
+  errorMessage?: string;
+  actions?: GerritAction[];
+  runs?: object[];
+}
+
+// The script is served by Deck, the endpoint is relative to it.
+const deck = new URL("..", (document.currentScript as HTMLScriptElement).src).toString();
+
+function toGerritAction(plugin: Plugin, action: ProwAction): GerritAction {
+  return {
+    name: action.name,
+    tooltip: action.tooltip,
+    primary: action.primary,
+    // Prow triggers jobs from comments, the actions post the comment that a
+    // user would write.
+    callback: async (change: number, patchset: number): Promise<ActionResult> => {
+      await plugin.restApi().post(`/changes/${change}/revisions/${patchset}/review`, {message: action.comment});
+      return {message: `Commented ${action.comment}`, shouldReload: true};
+    },
+  };
+}
+
+async function fetchRuns(plugin: Plugin, data: ChangeData): Promise<FetchResponse> {
+  const params = new URLSearchParams({
+    instance: window.location.origin,
+    change: String(data.changeNumber),
+    patchset: String(data.patchsetNumber),
+  });
+  let resp: ProwResponse;
+  try {
+    const r = await fetch(`${deck}gerrit-checks?${params.toString()}`);
+    resp = await r.json() as ProwResponse;
+  } catch (err) {
+    return {responseCode: "ERROR", errorMessage: `Failed to fetch the Prow jobs: ${String(err)}`};
+  }
+  return {
+    responseCode: resp.responseCode,
+    errorMessage: resp.errorMessage,
+    actions: (resp.actions || []).map((a) => toGerritAction(plugin, a)),
+    runs: resp.runs.map((run) => ({...run, actions: (run.actions || []).map((a) => toGerritAction(plugin, a))})),
+  };
+}
+
+Gerrit.install((plugin: Plugin) => {
+  plugin.checks().register({fetch: (data: ChangeData) => fetchRuns(plugin, data)});
+});
@@ -1,5 +1,5 @@
 import moment from "moment";
-import {ProwJob, ProwJobList, ProwJobState, ProwJobType, Pull} from "../api/prow";
+import {PipelineResult, PipelineRunStatus, ProwJob, ProwJobList, ProwJobState, ProwJobType, Pull} from "../api/prow";
 import {createAbortProwJobIcon} from "../common/abort";
 import {cell, formatDuration, icon} from "../common/common";
 import {createRerunProwJobIcon} from "../common/rerun";
@@ -607,7 +607,11 @@ function redraw(fz: FuzzySearch, pushState = true): void {
     displayedJob++;
     const r = document.createElement("tr");
     // State column
-    r.appendChild(cell.state(state));
+    const stateCell = cell.state(state);
+    if (build.status.pipeline_run_status) {
+      stateCell.title += `\n${pipelineRunSummary(build.status.pipeline_run_status)}`;
+    }
+    r.appendChild(stateCell);
     // Log column
     r.appendChild(createLogCell(build, buildUrl));
     // Rerun column
@@ -725,6 +729,28 @@ function redraw(fz: FuzzySearch, pushState = true): void {
   componentHandler.upgradeDom();
 }
 
+function formatResults(results: PipelineResult[] = []): string {
+  return results.map((result) => `${result.name}=${result.value}`).join(", ");
+}
+
+// pipelineRunSummary lists the TaskRuns and results of a PipelineRun, one per line.
+function pipelineRunSummary(status: PipelineRunStatus): string {
+  const lines = (status.task_runs || []).map((taskRun) => {
+    let line = `${taskRun.pipeline_task_name || taskRun.name}: ${taskRun.state || "pending"}`;
+    if (taskRun.description) {
+      line += ` (${taskRun.description})`;
+    }
+    if (taskRun.results && taskRun.results.length) {
+      line += ` [${formatResults(taskRun.results)}]`;
+    }
+    return line;
+  });
+  if (status.results && status.results.length) {
+    lines.push(`Results: ${formatResults(status.results)}`);
+  }
+  return lines.join("\n");
+}
+
 function createAbortCell(modal: HTMLElement, modalContent: Element, job: string, state: ProwJobState, prowjob: string): HTMLTableCellElement {
   const c = document.createElement("td");
   c.appendChild(createAbortProwJobIcon(modal, modalContent, job, state, prowjob, csrfToken));
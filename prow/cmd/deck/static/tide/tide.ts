@@ -261,6 +261,10 @@ function createActionCell(pool: TidePool): HTMLTableDataCellElement {
   } else if (targeted) {
     addPRsToElem(c, pool, pool.Target);
   }
+  if (pool.MergeFreezes && pool.MergeFreezes.length) {
+    c.classList.add("blocked");
+    addMergeFreezesToElem(c, pool);
+  }
   return c;
 }
 
@@ -342,6 +346,18 @@ function addBlockersToElem(elem: HTMLElement, pool: TidePool): void {
   }
 }
 
+// addMergeFreezesToElem lists the active merge freezes of the pool. Only PRs
+// that are exempt from all of them are merged.
+function addMergeFreezesToElem(elem: HTMLElement, pool: TidePool): void {
+  if (!pool.MergeFreezes) {
+    return;
+  }
+  const freezes = pool.MergeFreezes.map((f) => `${f.Name} until ${new Date(f.Until).toLocaleString()}`);
+  const span = document.createElement("span");
+  span.appendChild(document.createTextNode(` (frozen by ${freezes.join(", ")})`));
+  elem.appendChild(span);
+}
+
 let idCounter = 0;
 function nextID(): string {
   idCounter++;
@@ -51,8 +51,21 @@ type options struct {
 	storage                prowflagutil.StorageClientOptions
 	instrumentationOptions prowflagutil.InstrumentationOptions
 	changeWorkerPoolSize   int
+	// eventSource is where changes are learned about: polling by default,
+	// or events streamed over ssh or read from the events-log plugin.
+	eventSource       string
+	sshUser           string
+	sshIdentityFile   string
+	sshPort           int
+	eventsLogInterval time.Duration
 }
 
+const (
+	eventSourcePoll      = "poll"
+	eventSourceSSH       = "ssh"
+	eventSourceEventsLog = "events-log"
+)
+
 func (o *options) validate() error {
 	if o.cookiefilePath != "" && o.tokenPathOverride != "" {
 		return fmt.Errorf("only one of --cookiefile=%q --token-path=%q allowed, not both", o.cookiefilePath, o.tokenPathOverride)
@@ -78,6 +91,14 @@ func (o *options) validate() error {
 	if o.changeWorkerPoolSize < 1 {
 		return errors.New("change-worker-pool-size must be at least 1")
 	}
+	switch o.eventSource {
+	case eventSourcePoll, eventSourceSSH, eventSourceEventsLog:
+	default:
+		return fmt.Errorf("--event-source must be one of %s, %s or %s, not %q", eventSourcePoll, eventSourceSSH, eventSourceEventsLog, o.eventSource)
+	}
+	if o.eventsLogInterval <= 0 {
+		return errors.New("--events-log-interval must be positive")
+	}
 	return nil
 }
 
@@ -88,6 +109,11 @@ func gatherOptions(fs *flag.FlagSet, args ...string) options {
 	fs.BoolVar(&o.dryRun, "dry-run", false, "Run in dry-run mode, performing no modifying actions.")
 	fs.StringVar(&o.tokenPathOverride, "token-path", "", "Force the use of the token in this path, use with gcloud auth print-access-token")
 	fs.IntVar(&o.changeWorkerPoolSize, "change-worker-pool-size", 1, "Number of workers processing changes for each instance.")
+	fs.StringVar(&o.eventSource, "event-source", eventSourcePoll, "How to find updated changes: poll queries the instances every tick, ssh streams events with 'gerrit stream-events', events-log reads the events-log plugin. With events, instances are only queried after (re)connecting.")
+	fs.StringVar(&o.sshUser, "ssh-user", "", "User to stream events over ssh as, defaults to the local user.")
+	fs.StringVar(&o.sshIdentityFile, "ssh-identity-file", "", "Private key to stream events over ssh with.")
+	fs.IntVar(&o.sshPort, "ssh-port", 29418, "SSH port of the Gerrit instances.")
+	fs.DurationVar(&o.eventsLogInterval, "events-log-interval", 5*time.Second, "How often to read the events-log plugin.")
 	for _, group := range []flagutil.OptionGroup{&o.kubernetes, &o.storage, &o.instrumentationOptions, &o.config} {
 		group.AddFlags(fs)
 	}
@@ -137,8 +163,20 @@ func main() {
 
 	logrus.Infof("Starting gerrit fetcher")
 
+	var source adapter.EventSource
+	switch o.eventSource {
+	case eventSourceSSH:
+		source = &adapter.SSHEventSource{User: o.sshUser, IdentityFile: o.sshIdentityFile, Port: o.sshPort}
+	case eventSourceEventsLog:
+		source = c.NewEventsLogSource(o.eventsLogInterval)
+	}
+
 	defer interrupts.WaitForGracefulShutdown()
 	interrupts.Tick(func() {
+		if source != nil {
+			c.SyncEvents(interrupts.Context(), source)
+			return
+		}
 		c.Sync()
 	}, func() time.Duration {
 		return cfg().Gerrit.TickInterval.Duration
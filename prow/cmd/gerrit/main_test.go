@@ -20,6 +20,7 @@ import (
 	"flag"
 	"reflect"
 	"testing"
+	"time"
 
 	"k8s.io/apimachinery/pkg/util/sets"
 
@@ -79,6 +80,26 @@ func TestFlags(t *testing.T) {
 				o.storage.S3CredentialsFile = "/creds"
 			},
 		},
+		{
+			name: "events streamed over ssh",
+			args: map[string]string{
+				"--event-source":      "ssh",
+				"--ssh-user":          "prow",
+				"--ssh-identity-file": "/etc/ssh/key",
+			},
+			expected: func(o *options) {
+				o.eventSource = eventSourceSSH
+				o.sshUser = "prow"
+				o.sshIdentityFile = "/etc/ssh/key"
+			},
+		},
+		{
+			name: "unknown event source",
+			args: map[string]string{
+				"--event-source": "webhook",
+			},
+			err: true,
+		},
 	}
 
 	for _, tc := range cases {
@@ -96,6 +117,9 @@ func TestFlags(t *testing.T) {
 				dryRun:                 false,
 				instrumentationOptions: flagutil.DefaultInstrumentationOptions(),
 				changeWorkerPoolSize:   1,
+				eventSource:            eventSourcePoll,
+				sshPort:                29418,
+				eventsLogInterval:      5 * time.Second,
 			}
 			if tc.expected != nil {
 				tc.expected(expected)
@@ -0,0 +1,137 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"k8s.io/test-infra/prow/hook/delivery"
+)
+
+// deadLettersOptions configure `hook dead-letters`, which lists the events
+// hook could not deliver to external plugins and redelivers them.
+type deadLettersOptions struct {
+	adminURL  string
+	plugin    string
+	redeliver bool
+
+	// ids are the dead letters to redeliver, all of the plugin if empty.
+	ids []string
+}
+
+func (o *deadLettersOptions) Validate() error {
+	if o.adminURL == "" {
+		return errors.New("--admin-url is required")
+	}
+	if o.redeliver && o.plugin == "" {
+		return errors.New("--plugin is required to redeliver dead letters")
+	}
+	if !o.redeliver && len(o.ids) > 0 {
+		return errors.New("dead letter ids can only be passed together with --redeliver")
+	}
+	return nil
+}
+
+func gatherDeadLettersOptions(fs *flag.FlagSet, args ...string) deadLettersOptions {
+	var o deadLettersOptions
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: hook dead-letters [flags] [id]...\n")
+		fs.PrintDefaults()
+	}
+	fs.StringVar(&o.adminURL, "admin-url", "http://localhost:8889", "URL of the admin endpoint of hook, see --admin-port.")
+	fs.StringVar(&o.plugin, "plugin", "", "Only consider the dead letters of this external plugin.")
+	fs.BoolVar(&o.redeliver, "redeliver", false, "Redeliver the dead letters with the passed ids, or all dead letters of the plugin if none are passed, instead of listing them.")
+	fs.Parse(args)
+	o.ids = fs.Args()
+	return o
+}
+
+func deadLetters(args []string, out io.Writer) error {
+	o := gatherDeadLettersOptions(flag.NewFlagSet("dead-letters", flag.ExitOnError), args...)
+	if err := o.Validate(); err != nil {
+		return fmt.Errorf("invalid options: %w", err)
+	}
+	client := &http.Client{Timeout: time.Minute}
+	adminURL := strings.TrimSuffix(o.adminURL, "/")
+
+	if o.redeliver {
+		query := url.Values{"plugin": []string{o.plugin}, "id": o.ids}
+		var resp delivery.RedeliverResponse
+		if err := doJSON(client, http.MethodPost, adminURL+delivery.RedeliverPath+"?"+query.Encode(), &resp); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Redelivering %d dead letters of %s.\n", resp.Redelivered, o.plugin)
+		return nil
+	}
+
+	query := url.Values{}
+	if o.plugin != "" {
+		query.Set("plugin", o.plugin)
+	}
+	var resp map[string][]delivery.DeadLetter
+	if err := doJSON(client, http.MethodGet, adminURL+delivery.DeadLettersPath+"?"+query.Encode(), &resp); err != nil {
+		return err
+	}
+	printDeadLetters(out, resp)
+	return nil
+}
+
+func doJSON(client *http.Client, method, url string, v interface{}) error {
+	req, err := http.NewRequest(method, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("response has status %q and body %q", resp.Status, string(body))
+	}
+	return json.Unmarshal(body, v)
+}
+
+func printDeadLetters(out io.Writer, deadLetters map[string][]delivery.DeadLetter) {
+	var plugins []string
+	for plugin := range deadLetters {
+		plugins = append(plugins, plugin)
+	}
+	sort.Strings(plugins)
+	if len(plugins) == 0 {
+		fmt.Fprintln(out, "No dead letters.")
+	}
+	for _, plugin := range plugins {
+		fmt.Fprintf(out, "%s:\n", plugin)
+		for _, d := range deadLetters[plugin] {
+			fmt.Fprintf(out, "\t%s %s %s (enqueued %s, %d attempts): %s\n", d.ID, d.EventType, d.GUID, d.EnqueuedAt.Format(time.RFC3339), d.Attempts, d.LastError)
+		}
+	}
+}
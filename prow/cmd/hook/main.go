@@ -17,7 +17,10 @@ limitations under the License.
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
+	"net"
 	"net/http"
 	"os"
 	"strconv"
@@ -35,6 +38,7 @@ import (
 	pluginsflagutil "k8s.io/test-infra/prow/flagutil/plugins"
 	"k8s.io/test-infra/prow/githubeventserver"
 	"k8s.io/test-infra/prow/hook"
+	"k8s.io/test-infra/prow/hook/delivery"
 	"k8s.io/test-infra/prow/interrupts"
 	jiraclient "k8s.io/test-infra/prow/jira"
 	"k8s.io/test-infra/prow/logrusutil"
@@ -45,6 +49,7 @@ import (
 	bzplugin "k8s.io/test-infra/prow/plugins/bugzilla"
 	"k8s.io/test-infra/prow/plugins/jira"
 	"k8s.io/test-infra/prow/plugins/ownersconfig"
+	"k8s.io/test-infra/prow/plugins/wasm"
 	"k8s.io/test-infra/prow/repoowners"
 	"k8s.io/test-infra/prow/slack"
 
@@ -70,18 +75,33 @@ type options struct {
 	bugzilla               prowflagutil.BugzillaOptions
 	instrumentationOptions prowflagutil.InstrumentationOptions
 	jira                   prowflagutil.JiraOptions
-
-	webhookSecretFile string
-	slackTokenFile    string
+	storage                prowflagutil.StorageClientOptions
+
+	webhookSecretFile     string
+	jiraWebhookSecretFile string
+	slackTokenFile        string
+	wasmPluginPath        string
+	recordPath            string
+
+	externalPluginQueuePath string
+	externalPluginQueue     delivery.Options
+	adminAddress            string
+	adminPort               int
 }
 
 func (o *options) Validate() error {
-	for _, group := range []flagutil.OptionGroup{&o.kubernetes, &o.github, &o.bugzilla, &o.jira, &o.githubEnablement, &o.config, &o.pluginsConfig} {
+	for _, group := range []flagutil.OptionGroup{&o.kubernetes, &o.github, &o.bugzilla, &o.jira, &o.githubEnablement, &o.storage, &o.config, &o.pluginsConfig} {
 		if err := group.Validate(o.dryRun); err != nil {
 			return err
 		}
 	}
 
+	if o.externalPluginQueuePath != "" {
+		if o.externalPluginQueue.MaxQueueSize < 1 || o.externalPluginQueue.MaxDeadLetters < 1 || o.externalPluginQueue.MaxAttempts < 1 {
+			return errors.New("--external-plugin-queue-size, --external-plugin-max-dead-letters and --external-plugin-max-attempts must be positive")
+		}
+	}
+
 	return nil
 }
 
@@ -93,12 +113,21 @@ func gatherOptions(fs *flag.FlagSet, args ...string) options {
 	fs.BoolVar(&o.dryRun, "dry-run", true, "Dry run for testing. Uses API tokens but does not mutate.")
 	fs.DurationVar(&o.gracePeriod, "grace-period", 180*time.Second, "On shutdown, try to handle remaining events for the specified duration. ")
 	o.pluginsConfig.PluginConfigPathDefault = "/etc/plugins/plugins.yaml"
-	for _, group := range []flagutil.OptionGroup{&o.kubernetes, &o.github, &o.bugzilla, &o.instrumentationOptions, &o.jira, &o.githubEnablement, &o.config, &o.pluginsConfig} {
+	for _, group := range []flagutil.OptionGroup{&o.kubernetes, &o.github, &o.bugzilla, &o.instrumentationOptions, &o.jira, &o.githubEnablement, &o.storage, &o.config, &o.pluginsConfig} {
 		group.AddFlags(fs)
 	}
 
 	fs.StringVar(&o.webhookSecretFile, "hmac-secret-file", "/etc/webhook/hmac", "Path to the file containing the GitHub HMAC secret.")
+	fs.StringVar(&o.jiraWebhookSecretFile, "jira-webhook-secret-file", "", "Path to the file containing the secret Jira webhooks are signed with. Jira webhooks are served on /jira if set and the jira plugin is enabled.")
 	fs.StringVar(&o.slackTokenFile, "slack-token-file", "", "Path to the file containing the Slack token to use.")
+	fs.StringVar(&o.wasmPluginPath, "wasm-plugin-path", "", "Directory or bucket (gs://, s3://) to load WebAssembly plugins from. WebAssembly plugins are disabled if unset.")
+	fs.StringVar(&o.recordPath, "record-path", "", "Directory or bucket (gs://, s3://) to record the received webhooks to for 'hook replay'. Payloads are censored of all loaded secrets. Recording is disabled if unset.")
+	fs.StringVar(&o.externalPluginQueuePath, "external-plugin-queue-path", "", "Directory or bucket (gs://, s3://) to persist the retry queues and dead letters of external plugins to. Failed deliveries to external plugins are dropped if unset.")
+	fs.IntVar(&o.externalPluginQueue.MaxQueueSize, "external-plugin-queue-size", 1000, "Maximum number of events queued for redelivery per external plugin.")
+	fs.IntVar(&o.externalPluginQueue.MaxDeadLetters, "external-plugin-max-dead-letters", 1000, "Maximum number of dead letters kept per external plugin.")
+	fs.IntVar(&o.externalPluginQueue.MaxAttempts, "external-plugin-max-attempts", 12, "Number of attempts to deliver an event to an external plugin before it is dead-lettered.")
+	fs.StringVar(&o.adminAddress, "admin-address", "127.0.0.1", "Address to bind --admin-port to. The admin endpoints are unauthenticated, so they are only served on localhost by default and can be reached with 'kubectl exec' or 'kubectl port-forward'.")
+	fs.IntVar(&o.adminPort, "admin-port", 8889, "Port to serve the dead letters of external plugins on when --external-plugin-queue-path is set. Must not be exposed publicly.")
 	fs.Parse(args)
 	return o
 }
@@ -106,6 +135,21 @@ func gatherOptions(fs *flag.FlagSet, args ...string) options {
 func main() {
 	logrusutil.ComponentInit()
 
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "replay":
+			if err := replay(os.Args[2:], os.Stdout); err != nil {
+				logrus.WithError(err).Fatal("Error replaying events.")
+			}
+			return
+		case "dead-letters":
+			if err := deadLetters(os.Args[2:], os.Stdout); err != nil {
+				logrus.WithError(err).Fatal("Error handling dead letters.")
+			}
+			return
+		}
+	}
+
 	o := gatherOptions(flag.NewFlagSet(os.Args[0], flag.ExitOnError), os.Args[1:]...)
 	if err := o.Validate(); err != nil {
 		logrus.WithError(err).Fatal("Invalid options")
@@ -136,6 +180,10 @@ func main() {
 		tokens = append(tokens, o.bugzilla.ApiKeyPath)
 	}
 
+	if o.jiraWebhookSecretFile != "" {
+		tokens = append(tokens, o.jiraWebhookSecretFile)
+	}
+
 	if err := secret.Add(tokens...); err != nil {
 		logrus.WithError(err).Fatal("Error starting secrets agent.")
 	}
@@ -249,8 +297,40 @@ func main() {
 		RepoEnabled:    o.githubEnablement.EnablementChecker(),
 		TokenGenerator: secret.GetTokenGenerator(o.webhookSecretFile),
 	}
+	if o.wasmPluginPath != "" || o.recordPath != "" || o.externalPluginQueuePath != "" {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		opener, err := o.storage.StorageClient(ctx)
+		if err != nil {
+			logrus.WithError(err).Fatal("Error creating opener.")
+		}
+		if o.wasmPluginPath != "" {
+			server.WasmPlugins = wasm.NewRuntime(opener, o.wasmPluginPath)
+		}
+		if o.recordPath != "" {
+			server.Recorder = hook.NewRecorder(opener, o.recordPath, secret.Censor)
+		}
+		if o.externalPluginQueuePath != "" {
+			queues := delivery.NewQueues(opener, o.externalPluginQueuePath, o.externalPluginQueue, secret.Censor, server.TokenGenerator)
+			var externalPlugins []string
+			for _, ps := range pluginAgent.Config().ExternalPlugins {
+				for _, p := range ps {
+					externalPlugins = append(externalPlugins, p.Name)
+				}
+			}
+			if err := queues.Load(ctx, externalPlugins); err != nil {
+				logrus.WithError(err).Fatal("Error loading external plugin queues.")
+			}
+			server.ExternalQueues = queues
+			interrupts.Run(queues.Run)
+			interrupts.ListenAndServe(&http.Server{Addr: net.JoinHostPort(o.adminAddress, strconv.Itoa(o.adminPort)), Handler: queues.Handler()}, 5*time.Second)
+		}
+	}
 	interrupts.OnInterrupt(func() {
 		server.GracefulShutdown()
+		if server.WasmPlugins != nil {
+			server.WasmPlugins.Close()
+		}
 		if err := gitClient.Clean(); err != nil {
 			logrus.WithError(err).Error("Could not clean up git client cache.")
 		}
@@ -267,6 +347,14 @@ func main() {
 	hookMux.Handle(o.webhookPath, server)
 	// Serve plugin help information from /plugin-help.
 	hookMux.Handle("/plugin-help", pluginhelp.NewHelpAgent(pluginAgent, githubClient))
+	// Sync the statuses of Jira issues back to pull requests from /jira.
+	if jiraClient != nil && o.jiraWebhookSecretFile != "" {
+		if jiraPluginEnabled(pluginAgent.Config()) {
+			hookMux.Handle("/jira", jira.NewWebhookHandler(pluginAgent.Config, jiraClient, githubClient, o.github.Host, secret.GetTokenGenerator(o.jiraWebhookSecretFile)))
+		} else {
+			logrus.Warn("The jira plugin is not enabled for any org or repo, not serving Jira webhooks.")
+		}
+	}
 
 	httpServer := &http.Server{Addr: ":" + strconv.Itoa(o.port), Handler: hookMux}
 
@@ -274,3 +362,9 @@ func main() {
 
 	interrupts.ListenAndServe(httpServer, o.gracePeriod)
 }
+
+// jiraPluginEnabled tells whether any org or repo enabled the jira plugin.
+func jiraPluginEnabled(cfg *plugins.Configuration) bool {
+	orgs, repos, _ := cfg.EnabledReposForPlugin(jira.PluginName)
+	return len(orgs) > 0 || len(repos) > 0
+}
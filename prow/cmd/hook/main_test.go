@@ -28,6 +28,7 @@ import (
 	"k8s.io/test-infra/prow/flagutil"
 	configflagutil "k8s.io/test-infra/prow/flagutil/config"
 	pluginsflagutil "k8s.io/test-infra/prow/flagutil/plugins"
+	"k8s.io/test-infra/prow/hook/delivery"
 	"k8s.io/test-infra/prow/plugins"
 )
 
@@ -109,6 +110,13 @@ func Test_gatherOptions(t *testing.T) {
 				gracePeriod:            180 * time.Second,
 				webhookSecretFile:      "/etc/webhook/hmac",
 				instrumentationOptions: flagutil.DefaultInstrumentationOptions(),
+				externalPluginQueue: delivery.Options{
+					MaxQueueSize:   1000,
+					MaxDeadLetters: 1000,
+					MaxAttempts:    12,
+				},
+				adminAddress: "127.0.0.1",
+				adminPort:    8889,
 			}
 			expectedfs := flag.NewFlagSet("fake-flags", flag.PanicOnError)
 			expected.github.AddFlags(expectedfs)
@@ -145,3 +153,21 @@ func Test_gatherOptions(t *testing.T) {
 		})
 	}
 }
+
+func TestJiraPluginEnabled(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		plugins  plugins.Plugins
+		expected bool
+	}{
+		{name: "not enabled", plugins: plugins.Plugins{"org": {Plugins: []string{"trigger"}}}},
+		{name: "enabled for an org", plugins: plugins.Plugins{"org": {Plugins: []string{"jira"}}}, expected: true},
+		{name: "enabled for a repo", plugins: plugins.Plugins{"org/repo": {Plugins: []string{"jira"}}}, expected: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := jiraPluginEnabled(&plugins.Configuration{Plugins: tc.plugins}); actual != tc.expected {
+				t.Errorf("expected %t, got %t", tc.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,158 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	"k8s.io/test-infra/pkg/flagutil"
+	prowflagutil "k8s.io/test-infra/prow/flagutil"
+	configflagutil "k8s.io/test-infra/prow/flagutil/config"
+	pluginsflagutil "k8s.io/test-infra/prow/flagutil/plugins"
+	"k8s.io/test-infra/prow/hook"
+	hookreplay "k8s.io/test-infra/prow/hook/replay"
+	pio "k8s.io/test-infra/prow/io"
+)
+
+// replayOptions configure `hook replay`, which runs events recorded with
+// --record-path through the plugins without changing anything on GitHub.
+type replayOptions struct {
+	config        configflagutil.ConfigOptions
+	pluginsConfig pluginsflagutil.PluginOptions
+	storage       prowflagutil.StorageClientOptions
+
+	// events are the paths of the recorded events, in the order to replay them.
+	events []string
+}
+
+func (o *replayOptions) Validate() error {
+	for _, group := range []flagutil.OptionGroup{&o.storage, &o.config, &o.pluginsConfig} {
+		if err := group.Validate(true); err != nil {
+			return err
+		}
+	}
+	if len(o.events) == 0 {
+		return errors.New("at least one recorded event must be passed")
+	}
+	return nil
+}
+
+func gatherReplayOptions(fs *flag.FlagSet, args ...string) replayOptions {
+	var o replayOptions
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: hook replay [flags] <recorded event>...\n")
+		fs.PrintDefaults()
+	}
+	o.pluginsConfig.PluginConfigPathDefault = "/etc/plugins/plugins.yaml"
+	for _, group := range []flagutil.OptionGroup{&o.storage, &o.config, &o.pluginsConfig} {
+		group.AddFlags(fs)
+	}
+	fs.Parse(args)
+	o.events = fs.Args()
+	return o
+}
+
+// replay prints the GitHub mutations every plugin would have made for the
+// recorded events.
+func replay(args []string, out io.Writer) error {
+	o := gatherReplayOptions(flag.NewFlagSet("replay", flag.ExitOnError), args...)
+	if err := o.Validate(); err != nil {
+		return fmt.Errorf("invalid options: %w", err)
+	}
+
+	configAgent, err := o.config.ConfigAgent()
+	if err != nil {
+		return fmt.Errorf("error starting config agent: %w", err)
+	}
+	pluginAgent, err := o.pluginsConfig.PluginAgent()
+	if err != nil {
+		return fmt.Errorf("error starting plugins: %w", err)
+	}
+	ctx := context.Background()
+	opener, err := o.storage.StorageClient(ctx)
+	if err != nil {
+		return fmt.Errorf("error creating opener: %w", err)
+	}
+
+	events, err := readRecordedEvents(ctx, opener, o.events)
+	if err != nil {
+		return err
+	}
+	printReplayResults(out, hookreplay.Run(configAgent, pluginAgent, events))
+	return nil
+}
+
+func readRecordedEvents(ctx context.Context, opener pio.Opener, paths []string) ([]hook.RecordedEvent, error) {
+	var events []hook.RecordedEvent
+	for _, path := range paths {
+		if !strings.Contains(path, "://") {
+			// The opener only treats absolute paths as local files.
+			abs, err := filepath.Abs(path)
+			if err != nil {
+				return nil, fmt.Errorf("failed to resolve %s: %w", path, err)
+			}
+			path = abs
+		}
+		content, err := pio.ReadContent(ctx, logrus.NewEntry(logrus.StandardLogger()), opener, path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		var event hook.RecordedEvent
+		if err := json.Unmarshal(content, &event); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+func printReplayResults(out io.Writer, results []hookreplay.Result) {
+	for _, result := range results {
+		fmt.Fprintf(out, "%s %s:\n", result.Event.Type, result.Event.GUID)
+		if result.Err != nil {
+			fmt.Fprintf(out, "\terror: %v\n", result.Err)
+			continue
+		}
+		if len(result.Mutations) == 0 && len(result.ProwJobs) == 0 {
+			fmt.Fprintln(out, "\tno changes")
+			continue
+		}
+		var names []string
+		for name := range result.Mutations {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			for _, mutation := range result.Mutations[name] {
+				fmt.Fprintf(out, "\t%s: %s\n", name, mutation)
+			}
+		}
+		for _, prowJob := range result.ProwJobs {
+			fmt.Fprintf(out, "\tprowjobs: %s\n", prowJob)
+		}
+	}
+}
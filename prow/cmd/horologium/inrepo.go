@@ -0,0 +1,141 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"k8s.io/test-infra/prow/config"
+	"k8s.io/test-infra/prow/git/v2"
+	"k8s.io/test-infra/prow/github"
+)
+
+type githubClient interface {
+	GetRepos(org string, isUser bool) ([]github.Repo, error)
+	GetRef(org, repo, ref string) (string, error)
+}
+
+// inRepoPeriodics reads the periodics of all repos that have in-repo periodics
+// enabled. The repos of the orgs that may enable them are listed and the heads
+// of their default branches resolved at most once per resync period, and the
+// in-repo config of a repo is only read again when its head or the config
+// changed.
+type inRepoPeriodics struct {
+	gc           git.ClientFactory
+	ghc          githubClient
+	resyncPeriod time.Duration
+	now          func() time.Time
+
+	// defaultBranches maps the repos of every listed org to their default branch.
+	defaultBranches map[string]map[string]string
+	listed          time.Time
+	// cache maps org/repo to the periodics last read from it.
+	cache map[string]cachedPeriodics
+}
+
+type cachedPeriodics struct {
+	baseSHA   string
+	config    *config.Config
+	resolved  time.Time
+	periodics []config.Periodic
+}
+
+func newInRepoPeriodics(gc git.ClientFactory, ghc githubClient, resyncPeriod time.Duration) *inRepoPeriodics {
+	return &inRepoPeriodics{
+		gc:              gc,
+		ghc:             ghc,
+		resyncPeriod:    resyncPeriod,
+		now:             time.Now,
+		defaultBranches: map[string]map[string]string{},
+		cache:           map[string]cachedPeriodics{},
+	}
+}
+
+// listRepos refreshes the repos of the orgs that may have in-repo periodics
+// enabled. Orgs that can't be listed keep the repos listed previously.
+func (irp *inRepoPeriodics) listRepos(cfg *config.Config) {
+	now := irp.now()
+	if !irp.listed.IsZero() && now.Sub(irp.listed) < irp.resyncPeriod {
+		return
+	}
+	irp.listed = now
+	listed := map[string]map[string]string{}
+	for _, org := range cfg.InRepoPeriodicsOrgs() {
+		repos, err := irp.ghc.GetRepos(org, false)
+		if err != nil {
+			logrus.WithError(err).WithField("org", org).Error("Failed to list repos for in-repo periodics.")
+			listed[org] = irp.defaultBranches[org]
+			continue
+		}
+		listed[org] = map[string]string{}
+		for _, repo := range repos {
+			if !repo.Archived {
+				listed[org][repo.Name] = repo.DefaultBranch
+			}
+		}
+	}
+	irp.defaultBranches = listed
+}
+
+// periodics returns the in-repo periodics of all repos that have them enabled.
+// Repos whose in-repo config can't be read keep the periodics read before, if
+// any, so that they don't flap.
+func (irp *inRepoPeriodics) periodics(cfg *config.Config) map[string][]config.Periodic {
+	irp.listRepos(cfg)
+	periodics := map[string][]config.Periodic{}
+	for org, repos := range irp.defaultBranches {
+		for repo, branch := range repos {
+			identifier := org + "/" + repo
+			if !cfg.InRepoPeriodicsEnabled(identifier) {
+				continue
+			}
+			cached, err := irp.get(cfg, org, repo, branch)
+			if err != nil {
+				logrus.WithError(err).WithField("repo", identifier).Error("Failed to get in-repo periodics.")
+			}
+			periodics[identifier] = cached
+		}
+	}
+	return periodics
+}
+
+func (irp *inRepoPeriodics) get(cfg *config.Config, org, repo, branch string) ([]config.Periodic, error) {
+	identifier := org + "/" + repo
+	cached, ok := irp.cache[identifier]
+	now := irp.now()
+	if ok && cached.config == cfg && now.Sub(cached.resolved) < irp.resyncPeriod {
+		return cached.periodics, nil
+	}
+	baseSHA, err := irp.ghc.GetRef(org, repo, "heads/"+branch)
+	if err != nil {
+		return cached.periodics, err
+	}
+	// The periodics are defaulted using the config, so they are read again
+	// when it changed.
+	if !ok || baseSHA != cached.baseSHA || cached.config != cfg {
+		periodics, err := cfg.GetInRepoPeriodicsAt(irp.gc, identifier, baseSHA)
+		if err != nil {
+			return cached.periodics, err
+		}
+		cached = cachedPeriodics{baseSHA: baseSHA, config: cfg, periodics: periodics}
+	}
+	cached.resolved = now
+	irp.cache[identifier] = cached
+	return cached.periodics, nil
+}
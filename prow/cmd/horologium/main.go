@@ -22,6 +22,8 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"os/exec"
+	"sort"
 	"time"
 
 	"github.com/sirupsen/logrus"
@@ -50,16 +52,22 @@ type options struct {
 	config configflagutil.ConfigOptions
 
 	kubernetes             flagutil.KubernetesOptions
+	github                 flagutil.GitHubOptions
 	instrumentationOptions prowflagutil.InstrumentationOptions
 	dryRun                 bool
+	cookiefilePath         string
+	inRepoResyncPeriod     time.Duration
 }
 
 func gatherOptions(fs *flag.FlagSet, args ...string) options {
 	var o options
 
 	fs.BoolVar(&o.dryRun, "dry-run", true, "Whether or not to make mutating API calls to Kubernetes.")
+	fs.StringVar(&o.cookiefilePath, "cookiefile", "", "Path to git http.cookiefile, leave empty for github or anonymous")
+	fs.DurationVar(&o.inRepoResyncPeriod, "in-repo-periodics-resync-period", 5*time.Minute, "How often repos are checked for changes of their in-repo periodics.")
 	o.config.AddFlags(fs)
 	o.kubernetes.AddFlags(fs)
+	o.github.AddFlags(fs)
 	o.instrumentationOptions.AddFlags(fs)
 
 	fs.Parse(args)
@@ -71,6 +79,10 @@ func (o *options) Validate() error {
 		return err
 	}
 
+	if err := o.github.Validate(o.dryRun); err != nil {
+		return err
+	}
+
 	if err := o.config.Validate(o.dryRun); err != nil {
 		return errors.New("--config-path is required")
 	}
@@ -121,6 +133,26 @@ func main() {
 		logrus.Fatal("Timed out waiting for cachesync")
 	}
 
+	// If we are provided credentials for Git hosts, use them. These credentials
+	// hold per-host information in them so it's safe to set them globally.
+	if o.cookiefilePath != "" {
+		cmd := exec.Command("git", "config", "--global", "http.cookiefile", o.cookiefilePath)
+		if err := cmd.Run(); err != nil {
+			logrus.WithError(err).Fatal("unable to set cookiefile")
+		}
+	}
+
+	// The git client is only used to read in-repo periodics.
+	gitClient, err := o.github.GitClientFactory(o.cookiefilePath, &o.config.InRepoConfigCacheDirBase, o.dryRun)
+	if err != nil {
+		logrus.WithError(err).Fatal("Error getting Git client.")
+	}
+	githubClient, err := o.github.GitHubClient(o.dryRun)
+	if err != nil {
+		logrus.WithError(err).Fatal("Error getting GitHub client.")
+	}
+	inRepo := newInRepoPeriodics(config.NewInRepoConfigGitCache(gitClient), githubClient, o.inRepoResyncPeriod)
+
 	// start a cron
 	cr := cron.New()
 	cr.Start()
@@ -133,7 +165,7 @@ func main() {
 	}
 	interrupts.TickLiteral(func() {
 		start := time.Now()
-		if err := sync(cluster.GetClient(), configAgent.Config(), cr, start); err != nil {
+		if err := sync(cluster.GetClient(), configAgent.Config(), inRepo, cr, start); err != nil {
 			logrus.WithError(err).Error("Error syncing periodic jobs.")
 		}
 		logrus.WithField("duration", time.Since(start)).Info("Synced periodic jobs")
@@ -141,18 +173,50 @@ func main() {
 }
 
 type cronClient interface {
-	SyncConfig(cfg *config.Config) error
+	SyncPeriodics(periodics []config.Periodic) error
 	QueuedJobs() []string
 }
 
-func sync(prowJobClient ctrlruntimeclient.Client, cfg *config.Config, cr cronClient, now time.Time) error {
+// allPeriodics returns the periodics of the central config together with the
+// in-repo periodics of all repos that have them enabled.
+func allPeriodics(cfg *config.Config, inRepo *inRepoPeriodics) []config.Periodic {
+	periodics := append([]config.Periodic{}, cfg.AllPeriodics()...)
+	if inRepo == nil || cfg.InRepoConfig.Periodics == nil {
+		return periodics
+	}
+	seen := sets.NewString()
+	for _, p := range periodics {
+		seen.Insert(p.Name)
+	}
+
+	inRepoPeriodics := inRepo.periodics(cfg)
+	repos := make([]string, 0, len(inRepoPeriodics))
+	for repo := range inRepoPeriodics {
+		repos = append(repos, repo)
+	}
+	sort.Strings(repos)
+	for _, repo := range repos {
+		for _, p := range inRepoPeriodics[repo] {
+			if seen.Has(p.Name) {
+				logrus.WithFields(logrus.Fields{"repo": repo, "job": p.Name}).Warn("Skipping in-repo periodic, a periodic with the same name already exists.")
+				continue
+			}
+			seen.Insert(p.Name)
+			periodics = append(periodics, p)
+		}
+	}
+	return periodics
+}
+
+func sync(prowJobClient ctrlruntimeclient.Client, cfg *config.Config, inRepo *inRepoPeriodics, cr cronClient, now time.Time) error {
 	jobs := &prowapi.ProwJobList{}
 	if err := prowJobClient.List(context.TODO(), jobs, ctrlruntimeclient.InNamespace(cfg.ProwJobNamespace)); err != nil {
 		return fmt.Errorf("error listing prow jobs: %w", err)
 	}
 	latestJobs := pjutil.GetLatestProwJobs(jobs.Items, prowapi.PeriodicJob)
 
-	if err := cr.SyncConfig(cfg); err != nil {
+	periodics := allPeriodics(cfg, inRepo)
+	if err := cr.SyncPeriodics(periodics); err != nil {
 		logrus.WithError(err).Error("Error syncing cron jobs.")
 	}
 
@@ -162,7 +226,7 @@ func sync(prowJobClient ctrlruntimeclient.Client, cfg *config.Config, cr cronCli
 	}
 
 	var errs []error
-	for _, p := range cfg.Periodics {
+	for _, p := range periodics {
 		j, previousFound := latestJobs[p.Name]
 		logger := logrus.WithFields(logrus.Fields{
 			"job":            p.Name,
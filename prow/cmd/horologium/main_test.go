@@ -23,6 +23,8 @@ import (
 	"testing"
 	"time"
 
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/sets"
@@ -33,14 +35,16 @@ import (
 	"k8s.io/test-infra/prow/config"
 	"k8s.io/test-infra/prow/flagutil"
 	configflagutil "k8s.io/test-infra/prow/flagutil/config"
+	"k8s.io/test-infra/prow/git/v2"
+	"k8s.io/test-infra/prow/github"
 )
 
 type fakeCron struct {
 	jobs []string
 }
 
-func (fc *fakeCron) SyncConfig(cfg *config.Config) error {
-	for _, p := range cfg.Periodics {
+func (fc *fakeCron) SyncPeriodics(periodics []config.Periodic) error {
+	for _, p := range periodics {
 		if p.Cron != "" {
 			fc.jobs = append(fc.jobs, p.Name)
 		}
@@ -141,7 +145,7 @@ func TestSync(t *testing.T) {
 		}
 		fakeProwJobClient := &createTrackingClient{Client: fakectrlruntimeclient.NewFakeClient(jobs...)}
 		fc := &fakeCron{}
-		if err := sync(fakeProwJobClient, &cfg, fc, now); err != nil {
+		if err := sync(fakeProwJobClient, &cfg, nil, fc, now); err != nil {
 			t.Fatalf("For case %s, didn't expect error: %v", tc.testName, err)
 		}
 
@@ -253,7 +257,7 @@ func TestSyncMinimumInterval(t *testing.T) {
 		}
 		fakeProwJobClient := &createTrackingClient{Client: fakectrlruntimeclient.NewFakeClient(jobs...)}
 		fc := &fakeCron{}
-		if err := sync(fakeProwJobClient, &cfg, fc, now); err != nil {
+		if err := sync(fakeProwJobClient, &cfg, nil, fc, now); err != nil {
 			t.Fatalf("For case %s, didn't expect error: %v", tc.testName, err)
 		}
 
@@ -329,7 +333,7 @@ func TestSyncCron(t *testing.T) {
 		}
 		fakeProwJobClient := &createTrackingClient{Client: fakectrlruntimeclient.NewFakeClient(jobs...)}
 		fc := &fakeCron{}
-		if err := sync(fakeProwJobClient, &cfg, fc, now); err != nil {
+		if err := sync(fakeProwJobClient, &cfg, nil, fc, now); err != nil {
 			t.Fatalf("For case %s, didn't expect error: %v", tc.testName, err)
 		}
 
@@ -388,6 +392,9 @@ func TestFlags(t *testing.T) {
 
 	for _, tc := range cases {
 		t.Run(tc.name, func(t *testing.T) {
+			ghoptions := flagutil.GitHubOptions{}
+			ghoptions.AddFlags(&flag.FlagSet{})
+			ghoptions.Validate(false)
 			expected := &options{
 				config: configflagutil.ConfigOptions{
 					ConfigPathFlagName:                    "config-path",
@@ -397,8 +404,10 @@ func TestFlags(t *testing.T) {
 					InRepoConfigCacheSize:                 100,
 					InRepoConfigCacheCopies:               1,
 				},
+				github:                 ghoptions,
 				dryRun:                 true,
 				instrumentationOptions: flagutil.DefaultInstrumentationOptions(),
+				inRepoResyncPeriod:     5 * time.Minute,
 			}
 			if tc.expected != nil {
 				tc.expected(expected)
@@ -443,3 +452,89 @@ func (ct *createTrackingClient) Create(ctx context.Context, obj ctrlruntimeclien
 	ct.sawCreate = true
 	return ct.Client.Create(ctx, obj, opts...)
 }
+
+type fakeGitHub struct {
+	repos map[string][]github.Repo
+	refs  map[string]string
+}
+
+func (f *fakeGitHub) GetRepos(org string, _ bool) ([]github.Repo, error) {
+	return f.repos[org], nil
+}
+
+func (f *fakeGitHub) GetRef(org, repo, ref string) (string, error) {
+	return f.refs[org+"/"+repo+":"+ref], nil
+}
+
+func TestSyncInRepoPeriodics(t *testing.T) {
+	enabled := true
+	reads := map[string]int{}
+	cfg := config.Config{
+		ProwConfig: config.ProwConfig{
+			ProwJobNamespace: "prowjobs",
+			InRepoConfig: config.InRepoConfig{
+				Enabled: map[string]*bool{"org": &enabled},
+				Periodics: &config.InRepoConfigPeriodics{
+					// Org-wide, so repos without central jobs must be found too.
+					Enabled: map[string]*bool{"org": &enabled},
+				},
+			},
+		},
+		JobConfig: config.JobConfig{
+			Periodics: []config.Periodic{{JobBase: config.JobBase{Name: "central"}, Cron: "@every 1h"}},
+			ProwYAMLGetterWithDefaults: func(_ *config.Config, _ git.ClientFactory, identifier, baseSHA string, _ ...string) (*config.ProwYAML, error) {
+				reads[identifier+"@"+baseSHA]++
+				if identifier != "org/repo" {
+					return &config.ProwYAML{}, nil
+				}
+				return &config.ProwYAML{Periodics: []config.Periodic{
+					{JobBase: config.JobBase{Name: "central"}, Cron: "@every 1h"},
+					{JobBase: config.JobBase{Name: "in-repo"}, Cron: "@every 2h"},
+				}}, nil
+			},
+		},
+	}
+	ghc := &fakeGitHub{
+		repos: map[string][]github.Repo{"org": {
+			{Name: "repo", DefaultBranch: "main"},
+			{Name: "archived", DefaultBranch: "main", Archived: true},
+		}},
+		refs: map[string]string{"org/repo:heads/main": "sha1"},
+	}
+	now := time.Now()
+	inRepo := newInRepoPeriodics(nil, ghc, 5*time.Minute)
+	inRepo.now = func() time.Time { return now }
+
+	fakeProwJobClient := &createTrackingClient{Client: fakectrlruntimeclient.NewFakeClient()}
+	fc := &fakeCron{}
+	if err := sync(fakeProwJobClient, &cfg, inRepo, fc, now); err != nil {
+		t.Fatalf("didn't expect error: %v", err)
+	}
+
+	jobs := &prowapi.ProwJobList{}
+	if err := fakeProwJobClient.List(context.Background(), jobs); err != nil {
+		t.Fatalf("failed to list prowjobs: %v", err)
+	}
+	var created []string
+	for _, job := range jobs.Items {
+		created = append(created, job.Spec.Job)
+	}
+	if diff := cmp.Diff([]string{"central", "in-repo"}, created, cmpopts.SortSlices(func(a, b string) bool { return a < b })); diff != "" {
+		t.Errorf("unexpected prowjobs created (-want +got):\n%s", diff)
+	}
+
+	// Within the resync period and with the head unchanged, the in-repo config
+	// is not read again.
+	now = now.Add(time.Minute)
+	allPeriodics(&cfg, inRepo)
+	now = now.Add(5 * time.Minute)
+	allPeriodics(&cfg, inRepo)
+	ghc.refs["org/repo:heads/main"] = "sha2"
+	now = now.Add(5 * time.Minute)
+	if periodics := allPeriodics(&cfg, inRepo); len(periodics) != 2 {
+		t.Errorf("expected the central and the in-repo periodic, got %v", periodics)
+	}
+	if diff := cmp.Diff(map[string]int{"org/repo@sha1": 1, "org/repo@sha2": 1}, reads); diff != "" {
+		t.Errorf("unexpected reads of in-repo config (-want +got):\n%s", diff)
+	}
+}
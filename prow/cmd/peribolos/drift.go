@@ -0,0 +1,344 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/push"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"k8s.io/test-infra/prow/config/org"
+	"k8s.io/test-infra/prow/github"
+)
+
+// DriftKind is the kind of object that drifted from the config.
+type DriftKind string
+
+const (
+	driftOrgMetadata DriftKind = "org_metadata"
+	driftOrgMember   DriftKind = "org_member"
+	driftTeam        DriftKind = "team"
+	driftTeamMember  DriftKind = "team_member"
+	driftTeamRepo    DriftKind = "team_repo"
+	driftRepo        DriftKind = "repo"
+)
+
+// driftKinds lists every kind so that gauges are reset to zero once the
+// drift of a kind is resolved.
+var driftKinds = []DriftKind{driftOrgMetadata, driftOrgMember, driftTeam, driftTeamMember, driftTeamRepo, driftRepo}
+
+// Drift is a single difference between the config and the live org. An empty
+// Want means the object exists on GitHub but not in the config, an empty Have
+// means the object is in the config but does not exist on GitHub.
+type Drift struct {
+	Kind DriftKind `json:"kind"`
+	// Target identifies the drifted object, e.g. a login, a team or a repo.
+	Target string `json:"target"`
+	// Field is the drifted setting of the target, if any.
+	Field string `json:"field,omitempty"`
+	Want  string `json:"want,omitempty"`
+	Have  string `json:"have,omitempty"`
+}
+
+func (d Drift) String() string {
+	target := d.Target
+	if d.Field != "" {
+		target += "." + d.Field
+	}
+	switch {
+	case d.Want == "":
+		return fmt.Sprintf("%s %s: unexpected %q", d.Kind, target, d.Have)
+	case d.Have == "":
+		return fmt.Sprintf("%s %s: missing, want %q", d.Kind, target, d.Want)
+	default:
+		return fmt.Sprintf("%s %s: want %q, have %q", d.Kind, target, d.Want, d.Have)
+	}
+}
+
+// DriftReport lists the drift of an org.
+type DriftReport struct {
+	Org    string  `json:"org"`
+	Drifts []Drift `json:"drifts"`
+}
+
+// Counts returns the number of drifts per kind, including kinds without drift.
+func (r DriftReport) Counts() map[DriftKind]int {
+	counts := map[DriftKind]int{}
+	for _, kind := range driftKinds {
+		counts[kind] = 0
+	}
+	for _, d := range r.Drifts {
+		counts[d.Kind]++
+	}
+	return counts
+}
+
+// Summary renders the report for humans.
+func (r DriftReport) Summary() string {
+	var b bytes.Buffer
+	if len(r.Drifts) == 0 {
+		fmt.Fprintf(&b, "%s: no drift\n", r.Org)
+		return b.String()
+	}
+	fmt.Fprintf(&b, "%s: %d drifted settings\n", r.Org, len(r.Drifts))
+	for _, d := range r.Drifts {
+		fmt.Fprintf(&b, "  %s\n", d)
+	}
+	return b.String()
+}
+
+// detectDrift compares the wanted config of an org with the live config as
+// returned by dumpOrgConfig. Pending invitees are not reported as missing
+// org members.
+func detectDrift(orgName string, want, have org.Config, invitees sets.String) DriftReport {
+	r := &DriftReport{Org: orgName}
+	r.metadata(want.Metadata, have.Metadata)
+	r.roles(driftOrgMember, "", map[string][]string{github.RoleAdmin: want.Admins, github.RoleMember: want.Members},
+		map[string][]string{github.RoleAdmin: have.Admins, github.RoleMember: have.Members}, invitees)
+	r.teams(want.Teams, have.Teams)
+	r.repos(want.Repos, have.Repos)
+	sort.SliceStable(r.Drifts, func(i, j int) bool {
+		if r.Drifts[i].Kind != r.Drifts[j].Kind {
+			return r.Drifts[i].Kind < r.Drifts[j].Kind
+		}
+		if r.Drifts[i].Target != r.Drifts[j].Target {
+			return r.Drifts[i].Target < r.Drifts[j].Target
+		}
+		return r.Drifts[i].Field < r.Drifts[j].Field
+	})
+	return *r
+}
+
+func (r *DriftReport) add(kind DriftKind, target, field string, want, have interface{}) {
+	r.Drifts = append(r.Drifts, Drift{Kind: kind, Target: target, Field: field, Want: render(want), Have: render(have)})
+}
+
+// render dereferences pointers so that values read naturally in the report.
+func render(v interface{}) string {
+	if isNilPointer(v) {
+		return ""
+	}
+	switch t := v.(type) {
+	case *string:
+		return *t
+	case *bool:
+		return fmt.Sprint(*t)
+	case *github.RepoPermissionLevel:
+		return string(*t)
+	case *org.Privacy:
+		return string(*t)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// field reports a drift if the config sets a value that differs from the live
+// one. Settings left unset in the config are not compared.
+func (r *DriftReport) field(kind DriftKind, target, name string, want, have interface{}) {
+	if isNilPointer(want) {
+		return
+	}
+	if wantValue, haveValue := render(want), render(have); wantValue != haveValue {
+		r.Drifts = append(r.Drifts, Drift{Kind: kind, Target: target, Field: name, Want: wantValue, Have: haveValue})
+	}
+}
+
+func isNilPointer(v interface{}) bool {
+	switch t := v.(type) {
+	case *string:
+		return t == nil
+	case *bool:
+		return t == nil
+	case *github.RepoPermissionLevel:
+		return t == nil
+	case *org.Privacy:
+		return t == nil
+	}
+	return v == nil
+}
+
+func (r *DriftReport) metadata(want, have org.Metadata) {
+	r.field(driftOrgMetadata, r.Org, "billing_email", want.BillingEmail, have.BillingEmail)
+	r.field(driftOrgMetadata, r.Org, "company", want.Company, have.Company)
+	r.field(driftOrgMetadata, r.Org, "email", want.Email, have.Email)
+	r.field(driftOrgMetadata, r.Org, "name", want.Name, have.Name)
+	r.field(driftOrgMetadata, r.Org, "description", want.Description, have.Description)
+	r.field(driftOrgMetadata, r.Org, "location", want.Location, have.Location)
+	r.field(driftOrgMetadata, r.Org, "has_organization_projects", want.HasOrganizationProjects, have.HasOrganizationProjects)
+	r.field(driftOrgMetadata, r.Org, "has_repository_projects", want.HasRepositoryProjects, have.HasRepositoryProjects)
+	r.field(driftOrgMetadata, r.Org, "default_repository_permission", want.DefaultRepositoryPermission, have.DefaultRepositoryPermission)
+	r.field(driftOrgMetadata, r.Org, "members_can_create_repositories", want.MembersCanCreateRepositories, have.MembersCanCreateRepositories)
+}
+
+// roles compares the role of every user. Targets are prefixed with prefix,
+// which is used to scope team members by team name.
+func (r *DriftReport) roles(kind DriftKind, prefix string, want, have map[string][]string, invitees sets.String) {
+	wantRoles, haveRoles := rolesByLogin(want), rolesByLogin(have)
+	for _, login := range sets.StringKeySet(wantRoles).Union(sets.StringKeySet(haveRoles)).List() {
+		wantRole, haveRole := wantRoles[login], haveRoles[login]
+		if wantRole == haveRole || (haveRole == "" && invitees.Has(login)) {
+			continue
+		}
+		r.add(kind, prefix+login, "role", wantRole, haveRole)
+	}
+}
+
+func rolesByLogin(roles map[string][]string) map[string]string {
+	byLogin := map[string]string{}
+	for role, logins := range roles {
+		for _, login := range logins {
+			byLogin[github.NormLogin(login)] = role
+		}
+	}
+	return byLogin
+}
+
+// flatTeam is a team with the name of its parent, as teams are compared
+// regardless of where they are nested.
+type flatTeam struct {
+	org.Team
+	parent string
+}
+
+func flattenTeams(teams map[string]org.Team, parent string, out map[string]flatTeam) map[string]flatTeam {
+	for name, team := range teams {
+		out[name] = flatTeam{Team: team, parent: parent}
+		flattenTeams(team.Children, name, out)
+	}
+	return out
+}
+
+func (r *DriftReport) teams(want, have map[string]org.Team) {
+	wantTeams := flattenTeams(want, "", map[string]flatTeam{})
+	haveTeams := flattenTeams(have, "", map[string]flatTeam{})
+	seen := sets.NewString()
+	for _, name := range sets.StringKeySet(wantTeams).List() {
+		wantTeam := wantTeams[name]
+		haveName := name
+		haveTeam, ok := haveTeams[name]
+		for _, previous := range wantTeam.Previously {
+			if ok {
+				break
+			}
+			haveName = previous
+			haveTeam, ok = haveTeams[previous]
+		}
+		if !ok {
+			r.add(driftTeam, name, "", "present", nil)
+			continue
+		}
+		seen.Insert(haveName)
+		if haveName != name {
+			r.add(driftTeam, name, "name", name, haveName)
+		}
+		if wantTeam.parent != haveTeam.parent {
+			r.add(driftTeam, name, "parent", wantTeam.parent, haveTeam.parent)
+		}
+		r.field(driftTeam, name, "description", wantTeam.Description, haveTeam.Description)
+		r.field(driftTeam, name, "privacy", wantTeam.Privacy, haveTeam.Privacy)
+		r.roles(driftTeamMember, name+"/", map[string][]string{github.RoleMaintainer: wantTeam.Maintainers, github.RoleMember: wantTeam.Members},
+			map[string][]string{github.RoleMaintainer: haveTeam.Maintainers, github.RoleMember: haveTeam.Members}, nil)
+		for _, repo := range sets.StringKeySet(wantTeam.Repos).Union(sets.StringKeySet(haveTeam.Repos)).List() {
+			wantLevel, haveLevel := wantTeam.Repos[repo], haveTeam.Repos[repo]
+			if wantLevel != haveLevel {
+				r.add(driftTeamRepo, name+"/"+repo, "permission", wantLevel, haveLevel)
+			}
+		}
+	}
+	for _, name := range sets.StringKeySet(haveTeams).Difference(seen).List() {
+		r.add(driftTeam, name, "", nil, "present")
+	}
+}
+
+// withRepoDefaults fills in the settings pruned by org.PruneRepoDefaults.
+func withRepoDefaults(repo org.Repo) org.Repo {
+	defaultString := func(p **string, def string) {
+		if *p == nil {
+			*p = &def
+		}
+	}
+	defaultBool := func(p **bool, def bool) {
+		if *p == nil {
+			*p = &def
+		}
+	}
+	defaultString(&repo.Description, "")
+	defaultString(&repo.HomePage, "")
+	defaultBool(&repo.Private, false)
+	defaultBool(&repo.HasIssues, true)
+	defaultBool(&repo.HasWiki, true)
+	defaultBool(&repo.AllowRebaseMerge, true)
+	defaultBool(&repo.AllowSquashMerge, true)
+	defaultBool(&repo.AllowMergeCommit, true)
+	defaultBool(&repo.Archived, false)
+	defaultString(&repo.DefaultBranch, "master")
+	return repo
+}
+
+// repos compares the settings of the repos in the config. Repos that are not
+// in the config are not reported, as peribolos does not manage them.
+func (r *DriftReport) repos(want, have map[string]org.Repo) {
+	for _, name := range sets.StringKeySet(want).List() {
+		wantRepo := want[name]
+		haveRepo, ok := have[name]
+		for _, previous := range wantRepo.Previously {
+			if ok {
+				break
+			}
+			haveRepo, ok = have[previous]
+			if ok {
+				r.add(driftRepo, name, "name", name, previous)
+			}
+		}
+		if !ok {
+			r.add(driftRepo, name, "", "present", nil)
+			continue
+		}
+		haveRepo = withRepoDefaults(haveRepo)
+		r.field(driftRepo, name, "description", wantRepo.Description, haveRepo.Description)
+		r.field(driftRepo, name, "homepage", wantRepo.HomePage, haveRepo.HomePage)
+		r.field(driftRepo, name, "private", wantRepo.Private, haveRepo.Private)
+		r.field(driftRepo, name, "has_issues", wantRepo.HasIssues, haveRepo.HasIssues)
+		r.field(driftRepo, name, "has_projects", wantRepo.HasProjects, haveRepo.HasProjects)
+		r.field(driftRepo, name, "has_wiki", wantRepo.HasWiki, haveRepo.HasWiki)
+		r.field(driftRepo, name, "allow_squash_merge", wantRepo.AllowSquashMerge, haveRepo.AllowSquashMerge)
+		r.field(driftRepo, name, "allow_merge_commit", wantRepo.AllowMergeCommit, haveRepo.AllowMergeCommit)
+		r.field(driftRepo, name, "allow_rebase_merge", wantRepo.AllowRebaseMerge, haveRepo.AllowRebaseMerge)
+		r.field(driftRepo, name, "default_branch", wantRepo.DefaultBranch, haveRepo.DefaultBranch)
+		r.field(driftRepo, name, "archived", wantRepo.Archived, haveRepo.Archived)
+	}
+}
+
+var driftGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "peribolos_drift",
+	Help: "Number of settings of a GitHub org that drifted from the peribolos config.",
+}, []string{"org", "kind"})
+
+// pushDriftMetrics pushes the drift counts of every report to a Prometheus
+// push gateway.
+func pushDriftMetrics(pushGateway string, reports []DriftReport) error {
+	for _, report := range reports {
+		for kind, count := range report.Counts() {
+			driftGauge.WithLabelValues(report.Org, string(kind)).Set(float64(count))
+		}
+	}
+	return push.New(pushGateway, "peribolos").Collector(driftGauge).Push()
+}
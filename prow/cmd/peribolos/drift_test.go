@@ -0,0 +1,167 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"k8s.io/test-infra/prow/config/org"
+	"k8s.io/test-infra/prow/github"
+)
+
+func TestDetectDrift(t *testing.T) {
+	str := func(s string) *string { return &s }
+	yes, no := true, false
+	closed, secret := org.Closed, org.Secret
+
+	cases := []struct {
+		name     string
+		want     org.Config
+		have     org.Config
+		invitees sets.String
+		expected []Drift
+	}{
+		{
+			name: "no drift",
+			want: org.Config{
+				Metadata: org.Metadata{Name: str("Org")},
+				Admins:   []string{"Alice"},
+				Members:  []string{"bob"},
+				Repos:    map[string]org.Repo{"repo": {HasWiki: &yes}},
+			},
+			have: org.Config{
+				Metadata: org.Metadata{Name: str("Org"), Company: str("ignored")},
+				Admins:   []string{"alice"},
+				Members:  []string{"bob"},
+				Repos:    map[string]org.Repo{"repo": {}, "unmanaged": {}},
+			},
+		},
+		{
+			name: "org metadata and members",
+			want: org.Config{
+				Metadata: org.Metadata{Name: str("Org"), HasRepositoryProjects: &yes},
+				Admins:   []string{"alice", "carol"},
+				Members:  []string{"dave", "erin"},
+			},
+			have: org.Config{
+				Metadata: org.Metadata{Name: str("Renamed"), HasRepositoryProjects: &no},
+				Admins:   []string{"alice", "mallory"},
+				Members:  []string{"carol"},
+			},
+			invitees: sets.NewString("erin"),
+			expected: []Drift{
+				{Kind: driftOrgMember, Target: "carol", Field: "role", Want: github.RoleAdmin, Have: github.RoleMember},
+				{Kind: driftOrgMember, Target: "dave", Field: "role", Want: github.RoleMember},
+				{Kind: driftOrgMember, Target: "mallory", Field: "role", Have: github.RoleAdmin},
+				{Kind: driftOrgMetadata, Target: "org", Field: "has_repository_projects", Want: "true", Have: "false"},
+				{Kind: driftOrgMetadata, Target: "org", Field: "name", Want: "Org", Have: "Renamed"},
+			},
+		},
+		{
+			name: "teams",
+			want: org.Config{Teams: map[string]org.Team{
+				"parent": {
+					TeamMetadata: org.TeamMetadata{Privacy: &closed},
+					Maintainers:  []string{"alice"},
+					Members:      []string{"bob"},
+					Repos:        map[string]github.RepoPermissionLevel{"repo": github.Write},
+					Children: map[string]org.Team{
+						"child": {Previously: []string{"old-child"}},
+					},
+				},
+				"missing": {},
+			}},
+			have: org.Config{Teams: map[string]org.Team{
+				"parent": {
+					TeamMetadata: org.TeamMetadata{Privacy: &secret},
+					Maintainers:  []string{"alice"},
+					Members:      []string{"mallory"},
+					Repos:        map[string]github.RepoPermissionLevel{"repo": github.Admin, "other": github.Read},
+				},
+				"old-child":  {},
+				"unexpected": {},
+			}},
+			expected: []Drift{
+				{Kind: driftTeam, Target: "child", Field: "name", Want: "child", Have: "old-child"},
+				{Kind: driftTeam, Target: "child", Field: "parent", Want: "parent"},
+				{Kind: driftTeam, Target: "missing", Want: "present"},
+				{Kind: driftTeam, Target: "parent", Field: "privacy", Want: "closed", Have: "secret"},
+				{Kind: driftTeam, Target: "unexpected", Have: "present"},
+				{Kind: driftTeamMember, Target: "parent/bob", Field: "role", Want: github.RoleMember},
+				{Kind: driftTeamMember, Target: "parent/mallory", Field: "role", Have: github.RoleMember},
+				{Kind: driftTeamRepo, Target: "parent/other", Field: "permission", Have: "read"},
+				{Kind: driftTeamRepo, Target: "parent/repo", Field: "permission", Want: "write", Have: "admin"},
+			},
+		},
+		{
+			name: "repos",
+			want: org.Config{Repos: map[string]org.Repo{
+				"repo":    {Description: str("wanted"), HasIssues: &yes, Archived: &no, DefaultBranch: str("main")},
+				"renamed": {Previously: []string{"old"}, Private: &yes},
+				"missing": {},
+			}},
+			have: org.Config{Repos: map[string]org.Repo{
+				// Settings are pruned to their defaults by dumpOrgConfig.
+				"repo": {Description: str("changed by hand"), HasIssues: nil, Archived: &yes},
+				"old":  {},
+			}},
+			expected: []Drift{
+				{Kind: driftRepo, Target: "missing", Want: "present"},
+				{Kind: driftRepo, Target: "renamed", Field: "name", Want: "renamed", Have: "old"},
+				{Kind: driftRepo, Target: "renamed", Field: "private", Want: "true", Have: "false"},
+				{Kind: driftRepo, Target: "repo", Field: "archived", Want: "false", Have: "true"},
+				{Kind: driftRepo, Target: "repo", Field: "default_branch", Want: "main", Have: "master"},
+				{Kind: driftRepo, Target: "repo", Field: "description", Want: "wanted", Have: "changed by hand"},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			report := detectDrift("org", tc.want, tc.have, tc.invitees)
+			if diff := cmp.Diff(tc.expected, report.Drifts); diff != "" {
+				t.Errorf("unexpected drift (-want +got):\n%s", diff)
+			}
+			counts := report.Counts()
+			if len(counts) != len(driftKinds) {
+				t.Errorf("expected counts for all %d kinds, got %v", len(driftKinds), counts)
+			}
+		})
+	}
+}
+
+func TestDriftSummary(t *testing.T) {
+	report := DriftReport{Org: "org", Drifts: []Drift{
+		{Kind: driftRepo, Target: "repo", Field: "archived", Want: "false", Have: "true"},
+		{Kind: driftTeam, Target: "unexpected", Have: "present"},
+		{Kind: driftTeam, Target: "missing", Want: "present"},
+	}}
+	expected := `org: 3 drifted settings
+  repo repo.archived: want "false", have "true"
+  team unexpected: unexpected "present"
+  team missing: missing, want "present"
+`
+	if diff := cmp.Diff(expected, report.Summary()); diff != "" {
+		t.Errorf("unexpected summary (-want +got):\n%s", diff)
+	}
+	if expected, actual := "org: no drift\n", (DriftReport{Org: "org"}).Summary(); expected != actual {
+		t.Errorf("expected %q, got %q", expected, actual)
+	}
+}
@@ -17,10 +17,12 @@ limitations under the License.
 package main
 
 import (
+	"encoding/json"
 	"errors"
 	"flag"
 	"fmt"
 	"os"
+	"sort"
 	"strings"
 
 	"github.com/sirupsen/logrus"
@@ -61,6 +63,9 @@ type options struct {
 	ignoreSecretTeams bool
 	allowRepoArchival bool
 	allowRepoPublish  bool
+	detectDrift       bool
+	driftOutput       string
+	driftPushGateway  string
 	github            flagutil.GitHubOptions
 
 	logLevel string
@@ -94,6 +99,9 @@ func (o *options) parseArgs(flags *flag.FlagSet, args []string) error {
 	flags.BoolVar(&o.fixRepos, "fix-repos", false, "Create/update repositories if set")
 	flags.BoolVar(&o.allowRepoArchival, "allow-repo-archival", false, "If set, archiving repos is allowed while updating repos")
 	flags.BoolVar(&o.allowRepoPublish, "allow-repo-publish", false, "If set, making private repos public is allowed while updating repos")
+	flags.BoolVar(&o.detectDrift, "detect-drift", false, "Report how the orgs drifted from --config-path instead of configuring them")
+	flags.StringVar(&o.driftOutput, "drift-output", "", "Write the --detect-drift report as JSON to this path if set")
+	flags.StringVar(&o.driftPushGateway, "drift-push-gateway", "", "Push the --detect-drift counts to this Prometheus push gateway if set")
 	flags.StringVar(&o.logLevel, "log-level", logrus.InfoLevel.String(), fmt.Sprintf("Logging level, one of %v", logrus.AllLevels))
 	o.github.AddCustomizedFlags(flags, flagutil.ThrottlerDefaults(defaultTokens, defaultBurst))
 	if err := flags.Parse(args); err != nil {
@@ -130,6 +138,14 @@ func (o *options) parseArgs(flags *flag.FlagSet, args []string) error {
 		return errors.New("--dump-full can't be used without --dump")
 	}
 
+	if o.detectDrift && o.config == "" {
+		return errors.New("--detect-drift requires --config-path")
+	}
+
+	if (o.driftOutput != "" || o.driftPushGateway != "") && !o.detectDrift {
+		return errors.New("--drift-output and --drift-push-gateway require --detect-drift")
+	}
+
 	if o.fixTeamMembers && !o.fixTeams {
 		return fmt.Errorf("--fix-team-members requires --fix-teams")
 	}
@@ -189,6 +205,13 @@ func main() {
 		logrus.WithError(err).Fatal("Failed to load configuration")
 	}
 
+	if o.detectDrift {
+		if err := reportDrift(o, githubClient, cfg); err != nil {
+			logrus.WithError(err).Fatal("Drift detection failed.")
+		}
+		return
+	}
+
 	for name, orgcfg := range cfg.Orgs {
 		if err := configureOrg(o, githubClient, name, orgcfg); err != nil {
 			logrus.Fatalf("Configuration failed: %v", err)
@@ -197,6 +220,55 @@ func main() {
 	logrus.Info("Finished syncing configuration.")
 }
 
+// reportDrift compares every org in the config with its live state and
+// outputs the differences without changing anything.
+func reportDrift(o options, client github.Client, cfg org.FullConfig) error {
+	var names []string
+	for name := range cfg.Orgs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var reports []DriftReport
+	for _, name := range names {
+		have, err := dumpOrgConfig(client, name, o.ignoreSecretTeams, o.github.AppID)
+		if err != nil {
+			return fmt.Errorf("failed to collect current data of %s: %w", name, err)
+		}
+		invitees := sets.String{}
+		if !o.ignoreInvitees {
+			is, err := client.ListOrgInvitations(name)
+			if err != nil {
+				return fmt.Errorf("failed to list invitations of %s: %w", name, err)
+			}
+			for _, i := range is {
+				if i.Login != "" {
+					invitees.Insert(github.NormLogin(i.Login))
+				}
+			}
+		}
+		report := detectDrift(name, cfg.Orgs[name], *have, invitees)
+		fmt.Print(report.Summary())
+		reports = append(reports, report)
+	}
+
+	if o.driftOutput != "" {
+		out, err := json.MarshalIndent(reports, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal drift report: %w", err)
+		}
+		if err := os.WriteFile(o.driftOutput, out, 0644); err != nil {
+			return fmt.Errorf("failed to write drift report: %w", err)
+		}
+	}
+	if o.driftPushGateway != "" {
+		if err := pushDriftMetrics(o.driftPushGateway, reports); err != nil {
+			return fmt.Errorf("failed to push drift metrics: %w", err)
+		}
+	}
+	return nil
+}
+
 type dumpClient interface {
 	GetOrg(name string) (*github.Organization, error)
 	ListOrgMembers(org, role string) ([]github.TeamMember, error)
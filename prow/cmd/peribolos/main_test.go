@@ -107,6 +107,24 @@ func TestOptions(t *testing.T) {
 			name: "reject --fix-team-members without --fix-teams",
 			args: []string{"--config-path=foo", "--fix-team-members"},
 		},
+		{
+			name: "reject --drift-output without --detect-drift",
+			args: []string{"--config-path=foo", "--drift-output=drift.json"},
+		},
+		{
+			name: "allow drift detection",
+			args: []string{"--config-path=foo", "--detect-drift", "--drift-output=drift.json", "--drift-push-gateway=pushgateway:9091"},
+			expected: &options{
+				config:           "foo",
+				minAdmins:        defaultMinAdmins,
+				requireSelf:      true,
+				maximumDelta:     defaultDelta,
+				detectDrift:      true,
+				driftOutput:      "drift.json",
+				driftPushGateway: "pushgateway:9091",
+				logLevel:         "info",
+			},
+		},
 		{
 			name: "allow dump without config",
 			args: []string{"--dump=frogger"},
@@ -18,8 +18,10 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -37,7 +39,9 @@ import (
 
 	"github.com/sirupsen/logrus"
 	pipelinev1alpha1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1alpha1"
+	pipelinev1beta1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
 	untypedcorev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/runtime"
@@ -444,13 +448,15 @@ func reconcile(c reconciler, key string) error {
 		return fmt.Errorf("no pipelinerun found or created for %q, wantPipelineRun was %t", key, wantPipelineRun)
 	}
 	wantState, wantMsg := prowJobStatus(p.Status)
+	newpj.Status.PipelineRunStatus = pipelineRunStatus(p.Status)
 	return updateProwJobState(c, key, newPipelineRun, pj, newpj, wantState, wantMsg)
 }
 
 func updateProwJobState(c reconciler, key string, newPipelineRun bool, pj *prowjobv1.ProwJob, newpj *prowjobv1.ProwJob, state prowjobv1.ProwJobState, msg string) error {
 	haveState := newpj.Status.State
 	haveMsg := newpj.Status.Description
-	if newPipelineRun || haveState != state || haveMsg != msg {
+	statusChanged := !equality.Semantic.DeepEqual(pj.Status.PipelineRunStatus, newpj.Status.PipelineRunStatus)
+	if newPipelineRun || haveState != state || haveMsg != msg || statusChanged {
 		if haveState != state && state == prowjobv1.PendingState {
 			now := c.now()
 			newpj.Status.PendingTime = &now
@@ -505,9 +511,12 @@ const (
 
 // prowJobStatus returns the desired state and description based on the pipeline status
 func prowJobStatus(ps pipelinev1alpha1.PipelineRunStatus) (prowjobv1.ProwJobState, string) {
-	started := ps.StartTime
-	finished := ps.CompletionTime
-	pcond := ps.GetCondition(apis.ConditionSucceeded)
+	return conditionState(ps.GetCondition(apis.ConditionSucceeded), ps.StartTime, ps.CompletionTime)
+}
+
+// conditionState returns the state and description for the succeeded
+// condition of a PipelineRun or TaskRun.
+func conditionState(pcond *apis.Condition, started, finished *metav1.Time) (prowjobv1.ProwJobState, string) {
 	if pcond == nil {
 		if !finished.IsZero() {
 			return prowjobv1.ErrorState, descMissingCondition
@@ -530,6 +539,48 @@ func prowJobStatus(ps pipelinev1alpha1.PipelineRunStatus) (prowjobv1.ProwJobStat
 	return prowjobv1.ErrorState, description(cond, descUnknown) // shouldn't happen
 }
 
+// pipelineRunStatus summarizes the results and the TaskRuns of a PipelineRun.
+// TaskRuns are only known when tekton embeds their full status in the
+// PipelineRun, which is the default.
+func pipelineRunStatus(ps pipelinev1alpha1.PipelineRunStatus) *prowjobv1.PipelineRunStatus {
+	if len(ps.PipelineResults) == 0 && len(ps.TaskRuns) == 0 {
+		return nil
+	}
+	status := &prowjobv1.PipelineRunStatus{}
+	for _, r := range ps.PipelineResults {
+		status.Results = append(status.Results, prowjobv1.PipelineResult{Name: r.Name, Value: r.Value})
+	}
+	for name, tr := range ps.TaskRuns {
+		trs := prowjobv1.TaskRunStatus{Name: name, PipelineTaskName: tr.PipelineTaskName}
+		if tr.Status == nil {
+			trs.State, trs.Description = prowjobv1.PendingState, descScheduling
+		} else {
+			trs.State, trs.Description = conditionState(tr.Status.GetCondition(apis.ConditionSucceeded), tr.Status.StartTime, tr.Status.CompletionTime)
+			trs.StartTime = tr.Status.StartTime
+			trs.CompletionTime = tr.Status.CompletionTime
+			for _, r := range tr.Status.TaskRunResults {
+				trs.Results = append(trs.Results, prowjobv1.PipelineResult{Name: r.Name, Value: resultValue(r.Value)})
+			}
+		}
+		status.TaskRuns = append(status.TaskRuns, trs)
+	}
+	sort.Slice(status.TaskRuns, func(i, j int) bool { return status.TaskRuns[i].Name < status.TaskRuns[j].Name })
+	return status
+}
+
+// resultValue returns the value of a string result or the JSON encoding of
+// an array result.
+func resultValue(v pipelinev1beta1.ArrayOrString) string {
+	if v.Type == pipelinev1beta1.ParamTypeString || v.Type == "" {
+		return v.StringVal
+	}
+	b, err := json.Marshal(v.ArrayVal)
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
+
 // pipelineMeta builds the pipeline metadata from prow job definition
 func pipelineMeta(name string, pj prowjobv1.ProwJob) metav1.ObjectMeta {
 	labels, annotations := decorate.LabelsAndAnnotationsForJob(pj)
@@ -590,6 +641,10 @@ func makePipelineGitResource(name string, refs prowjobv1.Refs, pj prowjobv1.Prow
 
 // makePipeline creates a PipelineRun and substitutes ProwJob managed pipeline resources with ResourceSpec instead of ResourceRef
 // so that we don't have to take care of potentially dangling created pipeline resources.
+//
+// TODO: create and watch Tekton v1 PipelineRuns from a v1 spec on ProwJobSpec.
+// That needs github.com/tektoncd/pipeline bumped from v0.36.0 to a release
+// with the v1 API and the prow/pipeline clientset regenerated.
 func makePipelineRun(pj prowjobv1.ProwJob) (*pipelinev1alpha1.PipelineRun, error) {
 	// First validate.
 	if pj.Spec.PipelineRunSpec == nil {
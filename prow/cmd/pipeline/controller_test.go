@@ -26,6 +26,7 @@ import (
 
 	"github.com/sirupsen/logrus"
 	pipelinev1alpha1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1alpha1"
+	pipelinev1beta1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
@@ -539,6 +540,53 @@ func TestReconcile(t *testing.T) {
 			},
 			expectedPipelineRun: noPipelineRunChange,
 		},
+		{
+			name: "prowjob records the task runs of the pipeline run",
+			observedJob: &prowjobv1.ProwJob{
+				Spec: prowjobv1.ProwJobSpec{
+					Agent:           prowjobv1.TektonAgent,
+					PipelineRunSpec: &pipelineSpec,
+				},
+				Status: prowjobv1.ProwJobStatus{
+					StartTime:   now,
+					State:       prowjobv1.PendingState,
+					Description: "hola",
+				},
+			},
+			observedPipelineRun: func() *pipelinev1alpha1.PipelineRun {
+				pj := prowjobv1.ProwJob{}
+				pj.Spec.Type = prowjobv1.PeriodicJob
+				pj.Spec.Agent = prowjobv1.TektonAgent
+				pj.Spec.PipelineRunSpec = &pipelineSpec
+				pj.Status.BuildID = pipelineID
+				p, err := makePipelineRun(pj)
+				if err != nil {
+					panic(err)
+				}
+				p.Status.StartTime = now.DeepCopy()
+				p.Status.SetCondition(&apis.Condition{
+					Type:    apis.ConditionSucceeded,
+					Status:  corev1.ConditionUnknown,
+					Message: "hola",
+				})
+				p.Status.TaskRuns = map[string]*pipelinev1beta1.PipelineRunTaskRunStatus{
+					"the-object-name-test": {PipelineTaskName: "test"},
+				}
+				return p
+			}(),
+			expectedJob: func(pj prowjobv1.ProwJob, _ pipelinev1alpha1.PipelineRun) prowjobv1.ProwJob {
+				pj.Status.PipelineRunStatus = &prowjobv1.PipelineRunStatus{
+					TaskRuns: []prowjobv1.TaskRunStatus{{
+						Name:             "the-object-name-test",
+						PipelineTaskName: "test",
+						State:            prowjobv1.PendingState,
+						Description:      descScheduling,
+					}},
+				}
+				return pj
+			},
+			expectedPipelineRun: noPipelineRunChange,
+		},
 		{
 			name: "prowjob fails when pipeline run fails",
 			observedJob: &prowjobv1.ProwJob{
@@ -1309,3 +1357,91 @@ func TestProwJobStatus(t *testing.T) {
 		})
 	}
 }
+
+func TestPipelineRunStatus(t *testing.T) {
+	now := metav1.Now()
+	later := metav1.NewTime(now.Time.Add(1 * time.Hour))
+	succeeded := duckv1beta1.Status{Conditions: []apis.Condition{{Type: apis.ConditionSucceeded, Status: corev1.ConditionTrue}}}
+	running := duckv1beta1.Status{Conditions: []apis.Condition{{Type: apis.ConditionSucceeded, Status: corev1.ConditionUnknown, Reason: "Running"}}}
+
+	cases := []struct {
+		name     string
+		input    pipelinev1alpha1.PipelineRunStatus
+		expected *prowjobv1.PipelineRunStatus
+	}{
+		{
+			name: "no results or task runs",
+		},
+		{
+			name: "results and task runs",
+			input: pipelinev1alpha1.PipelineRunStatus{
+				PipelineRunStatusFields: pipelinev1alpha1.PipelineRunStatusFields{
+					PipelineResults: []pipelinev1beta1.PipelineRunResult{{Name: "digest", Value: "sha256:abc"}},
+					TaskRuns: map[string]*pipelinev1beta1.PipelineRunTaskRunStatus{
+						"pj-test": {
+							PipelineTaskName: "test",
+							Status: &pipelinev1beta1.TaskRunStatus{
+								Status:              running,
+								TaskRunStatusFields: pipelinev1beta1.TaskRunStatusFields{StartTime: &later},
+							},
+						},
+						"pj-build": {
+							PipelineTaskName: "build",
+							Status: &pipelinev1beta1.TaskRunStatus{
+								Status: succeeded,
+								TaskRunStatusFields: pipelinev1beta1.TaskRunStatusFields{
+									StartTime:      &now,
+									CompletionTime: &later,
+									TaskRunResults: []pipelinev1beta1.TaskRunResult{
+										{Name: "digest", Value: *pipelinev1beta1.NewArrayOrString("sha256:abc")},
+										{Name: "tags", Type: pipelinev1beta1.ResultsTypeArray, Value: *pipelinev1beta1.NewArrayOrString("v1", "latest")},
+									},
+								},
+							},
+						},
+						"pj-deploy": {PipelineTaskName: "deploy"},
+					},
+				},
+			},
+			expected: &prowjobv1.PipelineRunStatus{
+				Results: []prowjobv1.PipelineResult{{Name: "digest", Value: "sha256:abc"}},
+				TaskRuns: []prowjobv1.TaskRunStatus{
+					{
+						Name:             "pj-build",
+						PipelineTaskName: "build",
+						State:            prowjobv1.SuccessState,
+						Description:      descSucceeded,
+						StartTime:        &now,
+						CompletionTime:   &later,
+						Results: []prowjobv1.PipelineResult{
+							{Name: "digest", Value: "sha256:abc"},
+							{Name: "tags", Value: `["v1","latest"]`},
+						},
+					},
+					{
+						Name:             "pj-deploy",
+						PipelineTaskName: "deploy",
+						State:            prowjobv1.PendingState,
+						Description:      descScheduling,
+					},
+					{
+						Name:             "pj-test",
+						PipelineTaskName: "test",
+						State:            prowjobv1.PendingState,
+						Description:      "Running",
+						StartTime:        &later,
+					},
+				},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := pipelineRunStatus(tc.input)
+			if !equality.Semantic.DeepEqual(tc.expected, actual) {
+				t.Errorf("pipeline run status differs from expected: %s", diff.ObjectReflectDiff(tc.expected, actual))
+			}
+		})
+	}
+}
@@ -0,0 +1,178 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// presubmit-impact tells which presubmits are triggered for a pull request
+// changing the given files, and why the others are skipped.
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/sirupsen/logrus"
+
+	"k8s.io/test-infra/prow/config"
+	prowflagutil "k8s.io/test-infra/prow/flagutil"
+	configflagutil "k8s.io/test-infra/prow/flagutil/config"
+	"k8s.io/test-infra/prow/logrusutil"
+	"k8s.io/test-infra/prow/pjutil"
+)
+
+type options struct {
+	config       configflagutil.ConfigOptions
+	repo         string
+	branch       string
+	changedFiles prowflagutil.Strings
+	repoDir      string
+	gitRange     string
+	output       string
+}
+
+func (o *options) Validate() error {
+	if err := o.config.Validate(false); err != nil {
+		return err
+	}
+	if _, _, err := config.SplitRepoName(o.repo); err != nil {
+		return fmt.Errorf("--repo: %w", err)
+	}
+	if o.branch == "" {
+		return errors.New("--branch is required")
+	}
+	if len(o.changedFiles.Strings()) > 0 == (o.gitRange != "") {
+		return errors.New("exactly one of --changed-files and --git-range is required")
+	}
+	if o.gitRange != "" && o.repoDir == "" {
+		return errors.New("--git-range requires --repo-dir")
+	}
+	if o.output != "text" && o.output != "json" {
+		return fmt.Errorf("--output must be text or json, not %q", o.output)
+	}
+	return nil
+}
+
+func gatherOptions(fs *flag.FlagSet, args ...string) options {
+	var o options
+	fs.StringVar(&o.repo, "repo", "", "Repository the pull request is for, as org/repo.")
+	fs.StringVar(&o.branch, "branch", "", "Branch the pull request targets.")
+	fs.Var(&o.changedFiles, "changed-files", "Files changed by the pull request. Can be passed multiple times or comma separated.")
+	fs.StringVar(&o.repoDir, "repo-dir", "", "Checkout of the repository at the head of the pull request. Used to read the in-repo config and to resolve --git-range.")
+	fs.StringVar(&o.gitRange, "git-range", "", "Git range whose changed files are used, eg origin/main...HEAD.")
+	fs.StringVar(&o.output, "output", "text", "Output format, text or json.")
+	o.config.AddFlags(fs)
+	fs.Parse(args)
+	return o
+}
+
+func main() {
+	logrusutil.ComponentInit()
+
+	o := gatherOptions(flag.NewFlagSet(os.Args[0], flag.ExitOnError), os.Args[1:]...)
+	if err := o.Validate(); err != nil {
+		logrus.WithError(err).Fatal("Invalid options")
+	}
+	ca, err := o.config.ConfigAgent()
+	if err != nil {
+		logrus.WithError(err).Fatal("Error loading config.")
+	}
+	cfg := ca.Config()
+
+	changes := o.changedFiles.Strings()
+	if o.gitRange != "" {
+		if changes, err = changedFiles(o.repoDir, o.gitRange); err != nil {
+			logrus.WithError(err).Fatal("Failed to get changed files.")
+		}
+	}
+
+	var inRepo []config.Presubmit
+	if cfg.InRepoConfigEnabled(o.repo) {
+		if o.repoDir == "" {
+			logrus.Warnf("In-repo config is enabled for %s, but presubmits defined in the repository are ignored without --repo-dir.", o.repo)
+		} else if inRepo, err = readInRepoPresubmits(cfg, o.repoDir, o.repo); err != nil {
+			logrus.WithError(err).Fatal("Failed to read in-repo config.")
+		}
+	}
+
+	impacts, err := pjutil.SimulatePresubmits(o.branch, changes, cfg.GetPresubmitsStatic(o.repo), inRepo)
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to simulate presubmits.")
+	}
+	if err := write(os.Stdout, o.output, impacts); err != nil {
+		logrus.WithError(err).Fatal("Failed to write output.")
+	}
+}
+
+func changedFiles(repoDir, gitRange string) ([]string, error) {
+	cmd := exec.Command("git", "diff", "--name-only", "-z", gitRange, "--")
+	cmd.Dir = repoDir
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git diff %s failed: %w: %s", gitRange, err, stderr.String())
+	}
+	// Paths are NUL terminated, so that paths containing whitespace survive.
+	var files []string
+	for _, file := range strings.Split(string(out), "\x00") {
+		if file != "" {
+			files = append(files, file)
+		}
+	}
+	return files, nil
+}
+
+func readInRepoPresubmits(cfg *config.Config, repoDir, repo string) ([]config.Presubmit, error) {
+	prowYAML, err := config.ReadProwYAML(logrus.WithField("repo", repo), repoDir, true)
+	if err != nil {
+		return nil, err
+	}
+	if err := config.DefaultAndValidateProwYAML(cfg, prowYAML, repo); err != nil {
+		return nil, err
+	}
+	return prowYAML.Presubmits, nil
+}
+
+func write(w io.Writer, output string, impacts []pjutil.PresubmitImpact) error {
+	if output == "json" {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(impacts)
+	}
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "JOB\tRUNS\tREASON")
+	for _, impact := range impacts {
+		runs := "no"
+		if impact.Runs {
+			runs = "yes"
+		}
+		name := impact.Name
+		if impact.InRepo {
+			name += " (in-repo)"
+		}
+		reason := impact.Reason
+		if len(impact.MatchingFiles) > 0 {
+			reason = fmt.Sprintf("%s: %s", reason, strings.Join(impact.MatchingFiles, ", "))
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", name, runs, reason)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,133 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"flag"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"k8s.io/test-infra/prow/pjutil"
+)
+
+func TestValidate(t *testing.T) {
+	testCases := []struct {
+		name        string
+		args        []string
+		expectedErr string
+	}{
+		{
+			name: "changed files",
+			args: []string{"--config-path=config.yaml", "--repo=org/repo", "--branch=main", "--changed-files=a,b"},
+		},
+		{
+			name: "git range",
+			args: []string{"--config-path=config.yaml", "--repo=org/repo", "--branch=main", "--git-range=main...HEAD", "--repo-dir=."},
+		},
+		{
+			name:        "invalid repo",
+			args:        []string{"--config-path=config.yaml", "--repo=org", "--branch=main", "--changed-files=a"},
+			expectedErr: "--repo",
+		},
+		{
+			name:        "files and range",
+			args:        []string{"--config-path=config.yaml", "--repo=org/repo", "--branch=main", "--changed-files=a", "--git-range=main...HEAD", "--repo-dir=."},
+			expectedErr: "exactly one of --changed-files and --git-range is required",
+		},
+		{
+			name:        "range without checkout",
+			args:        []string{"--config-path=config.yaml", "--repo=org/repo", "--branch=main", "--git-range=main...HEAD"},
+			expectedErr: "--git-range requires --repo-dir",
+		},
+		{
+			name:        "unknown output",
+			args:        []string{"--config-path=config.yaml", "--repo=org/repo", "--branch=main", "--changed-files=a", "--output=yaml"},
+			expectedErr: "--output must be text or json",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			o := gatherOptions(flag.NewFlagSet(tc.name, flag.ContinueOnError), tc.args...)
+			err := o.Validate()
+			if tc.expectedErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.expectedErr) {
+				t.Fatalf("expected error containing %q, got %v", tc.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestWrite(t *testing.T) {
+	impacts := []pjutil.PresubmitImpact{
+		{Name: "pull-foo", Runs: true, Reason: "run_if_changed \"^foo/\" matches 1 changed files", MatchingFiles: []string{"foo/a.go"}},
+		{Name: "pull-in-repo", InRepo: true, Reason: "only runs when triggered explicitly"},
+	}
+	var out strings.Builder
+	if err := write(&out, "text", impacts); err != nil {
+		t.Fatalf("failed to write: %v", err)
+	}
+	expected := `JOB                     RUNS  REASON
+pull-foo                yes   run_if_changed "^foo/" matches 1 changed files: foo/a.go
+pull-in-repo (in-repo)  no    only runs when triggered explicitly
+`
+	if out.String() != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, out.String())
+	}
+}
+
+func TestChangedFiles(t *testing.T) {
+	dir := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v: %s", args, err, out)
+		}
+	}
+	git("init", "-q")
+	git("commit", "-q", "--allow-empty", "-m", "base")
+	for _, file := range []string{"docs/release notes.md", "foo/a.go"} {
+		path := filepath.Join(dir, file)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte("content"), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", file, err)
+		}
+	}
+	git("add", "-A")
+	git("commit", "-q", "-m", "change")
+
+	files, err := changedFiles(dir, "HEAD~1...HEAD")
+	if err != nil {
+		t.Fatalf("failed to get changed files: %v", err)
+	}
+	if diff := cmp.Diff([]string{"docs/release notes.md", "foo/a.go"}, files); diff != "" {
+		t.Errorf("unexpected changed files (-want +got):\n%s", diff)
+	}
+}
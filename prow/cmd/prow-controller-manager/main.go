@@ -37,6 +37,8 @@ import (
 	"k8s.io/test-infra/pkg/flagutil"
 	prowflagutil "k8s.io/test-infra/prow/flagutil"
 	configflagutil "k8s.io/test-infra/prow/flagutil/config"
+	cloudbuild "k8s.io/test-infra/prow/googlecloudbuild/client"
+	cloudbuildcontroller "k8s.io/test-infra/prow/googlecloudbuild/controller"
 	"k8s.io/test-infra/prow/interrupts"
 	"k8s.io/test-infra/prow/io"
 	"k8s.io/test-infra/prow/logrusutil"
@@ -46,7 +48,11 @@ import (
 	_ "k8s.io/test-infra/prow/version"
 )
 
-var allControllers = sets.NewString(plank.ControllerName)
+var allControllers = sets.NewString(plank.ControllerName, cloudbuildcontroller.ControllerName)
+
+// defaultControllers don't include the cloud-build controller, it needs
+// credentials for Google Cloud Build.
+var defaultControllers = sets.NewString(plank.ControllerName)
 
 type options struct {
 	totURL string
@@ -60,15 +66,18 @@ type options struct {
 	github                 prowflagutil.GitHubOptions // TODO(fejta): remove
 	instrumentationOptions prowflagutil.InstrumentationOptions
 	storage                prowflagutil.StorageClientOptions
+
+	cloudBuildCredentialsFile string
 }
 
 func gatherOptions(fs *flag.FlagSet, args ...string) options {
 	var o options
-	o.enabledControllers = prowflagutil.NewStrings(allControllers.List()...)
+	o.enabledControllers = prowflagutil.NewStrings(defaultControllers.List()...)
 	fs.StringVar(&o.totURL, "tot-url", "", "Tot URL")
 
 	fs.StringVar(&o.selector, "label-selector", labels.Everything().String(), "Label selector to be applied in prowjobs. See https://kubernetes.io/docs/concepts/overview/working-with-objects/labels/#label-selectors for constructing a label selector.")
-	fs.Var(&o.enabledControllers, "enable-controller", fmt.Sprintf("Controllers to enable. Can be passed multiple times. Defaults to all controllers (%v)", allControllers.List()))
+	fs.Var(&o.enabledControllers, "enable-controller", fmt.Sprintf("Controllers to enable. Can be passed multiple times. One of %v, defaults to %v", allControllers.List(), defaultControllers.List()))
+	fs.StringVar(&o.cloudBuildCredentialsFile, "cloud-build-credentials-file", "", "File with the credentials of the cloud-build controller for Google Cloud Build. Uses the default credentials if unset.")
 
 	fs.BoolVar(&o.dryRun, "dry-run", true, "Whether or not to make mutating API calls to GitHub.")
 	for _, group := range []flagutil.OptionGroup{&o.kubernetes, &o.github, &o.instrumentationOptions, &o.config, &o.storage} {
@@ -197,6 +206,16 @@ func main() {
 		}
 	}
 
+	if enabledControllersSet.Has(cloudbuildcontroller.ControllerName) {
+		cb, err := cloudbuild.NewClient(interrupts.Context(), o.cloudBuildCredentialsFile)
+		if err != nil {
+			logrus.WithError(err).Fatal("Error creating Cloud Build client.")
+		}
+		if err := cloudbuildcontroller.Add(mgr, cfg, cb, opener, o.totURL, 10); err != nil {
+			logrus.WithError(err).Fatal("Failed to add cloud-build controller to manager")
+		}
+	}
+
 	// Expose prometheus metrics
 	metrics.ExposeMetrics("plank", cfg().PushGateway, o.instrumentationOptions.MetricsPort)
 	// Serve readiness endpoint
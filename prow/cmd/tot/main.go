@@ -19,13 +19,14 @@ limitations under the License.
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"flag"
 	"fmt"
 	"io"
+	"math/rand"
 	"net/http"
-	"os"
 	"strconv"
 	"strings"
 	"sync"
@@ -38,6 +39,7 @@ import (
 	prowflagutil "k8s.io/test-infra/prow/flagutil"
 	configflagutil "k8s.io/test-infra/prow/flagutil/config"
 	"k8s.io/test-infra/prow/interrupts"
+	"k8s.io/test-infra/prow/io/providers"
 	"k8s.io/test-infra/prow/logrusutil"
 	"k8s.io/test-infra/prow/pjutil"
 	"k8s.io/test-infra/prow/pod-utils/downwardapi"
@@ -47,6 +49,7 @@ import (
 type options struct {
 	port        int
 	storagePath string
+	storage     prowflagutil.StorageClientOptions
 
 	useFallback bool
 	fallbackURI string
@@ -59,7 +62,8 @@ type options struct {
 func gatherOptions() options {
 	o := options{}
 	flag.IntVar(&o.port, "port", 8888, "Port to listen on.")
-	flag.StringVar(&o.storagePath, "storage", "tot.json", "Where to store the results.")
+	flag.StringVar(&o.storagePath, "storage", "tot.json", "Where to store the results. Either a local file, which only one replica can use, or a GCS path like gs://bucket/tot, below which the counter of every job is stored in an object of its own that any number of replicas can share.")
+	o.storage.AddFlags(flag.CommandLine)
 
 	flag.BoolVar(&o.useFallback, "fallback", false, "Fallback to GCS bucket for missing builds.")
 	flag.StringVar(&o.fallbackURI, "fallback-url-template",
@@ -83,90 +87,136 @@ func (o *options) Validate() error {
 	if o.config.ConfigPath == "" && o.fallbackBucket != "" {
 		return errors.New("you need to provide the prow config when a fallback bucket is specified")
 	}
+	if strings.Contains(o.storagePath, "://") && !o.sharedStorage() && !strings.HasPrefix(o.storagePath, providers.File+"://") {
+		return fmt.Errorf("--storage=%s: only GCS supports the conditional writes that tot needs", o.storagePath)
+	}
 	return nil
 }
 
+func (o *options) sharedStorage() bool {
+	return strings.HasPrefix(o.storagePath, providers.GS+"://")
+}
+
 type store struct {
-	Number       map[string]int // job name -> last vended build number
-	mutex        sync.Mutex
-	storagePath  string
+	backend      backend
 	fallbackFunc func(string) int
+
+	lock sync.Mutex
+	// jobLocks serialize the updates of every job within this replica.
+	jobLocks map[string]*sync.Mutex
 }
 
 func newStore(storagePath string) (*store, error) {
-	s := &store{
-		Number:      make(map[string]int),
-		storagePath: storagePath,
-	}
-	buf, err := os.ReadFile(storagePath)
-	if err == nil {
-		err = json.Unmarshal(buf, s)
-		if err != nil {
-			return nil, err
-		}
-	} else if !os.IsNotExist(err) {
+	b := &fileBackend{path: storagePath}
+	// Fail early on a corrupt file.
+	if _, err := b.list(context.Background()); err != nil {
 		return nil, err
 	}
-	return s, nil
+	return &store{backend: b}, nil
 }
 
-func (s *store) save() error {
-	buf, err := json.Marshal(s)
-	if err != nil {
-		return err
+// lockJob locks the updates of the job and returns the function unlocking it.
+func (s *store) lockJob(job string) func() {
+	s.lock.Lock()
+	if s.jobLocks == nil {
+		s.jobLocks = map[string]*sync.Mutex{}
 	}
-	err = os.WriteFile(s.storagePath+".tmp", buf, 0644)
-	if err != nil {
-		return err
+	l, ok := s.jobLocks[job]
+	if !ok {
+		l = &sync.Mutex{}
+		s.jobLocks[job] = l
 	}
-	return os.Rename(s.storagePath+".tmp", s.storagePath)
+	s.lock.Unlock()
+	l.Lock()
+	return l.Unlock
 }
 
-func (s *store) vend(jobName string) int {
-	s.mutex.Lock()
-	defer s.mutex.Unlock()
-	n, ok := s.Number[jobName]
-	if !ok && s.fallbackFunc != nil {
-		n = s.fallbackFunc(jobName)
+// update applies f to the counter of the job and saves it. The backend is
+// shared with other replicas of tot when it supports compare-and-swap, so f is
+// applied again to the fresh counter whenever another replica saved it first.
+func (s *store) update(ctx context.Context, job string, f func(n int, ok bool) int) (int, error) {
+	defer s.lockJob(job)()
+	for attempt := 1; ; attempt++ {
+		n, ok, version, err := s.backend.load(ctx, job)
+		if err != nil {
+			return 0, fmt.Errorf("load counter: %w", err)
+		}
+		n = f(n, ok)
+		err = s.backend.save(ctx, job, n, version)
+		if err == nil {
+			return n, nil
+		}
+		if attempt == maxUpdateAttempts {
+			return 0, fmt.Errorf("save counter: %w", err)
+		}
+		switch {
+		case errors.Is(err, errConflict):
+			logrus.WithField("attempt", attempt).Debug("Counter changed concurrently, retrying.")
+			time.Sleep(time.Duration(rand.Intn(50*attempt)) * time.Millisecond)
+		case errors.Is(err, errRateLimited):
+			logrus.WithField("attempt", attempt).Info("Counter saved too often, backing off.")
+			time.Sleep(rateLimitBackoff(attempt))
+		default:
+			return 0, fmt.Errorf("save counter: %w", err)
+		}
 	}
-	n++
-
-	s.Number[jobName] = n
+}
 
-	err := s.save()
-	if err != nil {
-		logrus.Error(err)
+// rateLimitBackoff returns how long to wait before the attempt after a rate
+// limited one. GCS allows to replace an object about once per second.
+func rateLimitBackoff(attempt int) time.Duration {
+	backoff := rateLimitInitialBackoff << (attempt - 1)
+	if backoff > rateLimitMaxBackoff || backoff <= 0 {
+		backoff = rateLimitMaxBackoff
 	}
-
-	return n
+	return backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)))
 }
 
-func (s *store) peek(jobName string) int {
-	s.mutex.Lock()
-	defer s.mutex.Unlock()
-	return s.Number[jobName]
+func (s *store) vend(jobName string) (int, error) {
+	fallback := -1
+	return s.update(context.Background(), jobName, func(n int, ok bool) int {
+		if !ok && s.fallbackFunc != nil {
+			// The fallback is slow, don't ask it again on retries.
+			if fallback < 0 {
+				fallback = s.fallbackFunc(jobName)
+			}
+			n = fallback
+		}
+		return n + 1
+	})
 }
 
-func (s *store) set(jobName string, n int) {
-	s.mutex.Lock()
-	defer s.mutex.Unlock()
-	s.Number[jobName] = n
+func (s *store) peek(jobName string) (int, error) {
+	n, _, _, err := s.backend.load(context.Background(), jobName)
+	return n, err
+}
 
-	err := s.save()
-	if err != nil {
-		logrus.Error(err)
-	}
+func (s *store) set(jobName string, n int) error {
+	_, err := s.update(context.Background(), jobName, func(int, bool) int {
+		return n
+	})
+	return err
 }
 
 func (s *store) handle(w http.ResponseWriter, r *http.Request) {
 	jobName := r.URL.Path[len("/vend/"):]
 	switch r.Method {
 	case "GET":
-		n := s.vend(jobName)
+		n, err := s.vend(jobName)
+		if err != nil {
+			logrus.WithError(err).Errorf("Failed to vend %s number.", jobName)
+			http.Error(w, "Failed to vend a number.", http.StatusInternalServerError)
+			return
+		}
 		logrus.Infof("Vending %s number %d to %s.", jobName, n, r.RemoteAddr)
 		fmt.Fprintf(w, "%d", n)
 	case "HEAD":
-		n := s.peek(jobName)
+		n, err := s.peek(jobName)
+		if err != nil {
+			logrus.WithError(err).Errorf("Failed to peek %s number.", jobName)
+			http.Error(w, "Failed to peek the number.", http.StatusInternalServerError)
+			return
+		}
 		logrus.Infof("Peeking %s number %d to %s.", jobName, n, r.RemoteAddr)
 		fmt.Fprintf(w, "%d", n)
 	case "POST":
@@ -181,7 +231,67 @@ func (s *store) handle(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 		logrus.Infof("Setting %s to %d from %s.", jobName, n, r.RemoteAddr)
-		s.set(jobName, n)
+		if err := s.set(jobName, n); err != nil {
+			logrus.WithError(err).Errorf("Failed to set %s number.", jobName)
+			http.Error(w, "Failed to set the number.", http.StatusInternalServerError)
+		}
+	}
+}
+
+// handleAdmin lists and sets the counters of all jobs at once, which is how
+// counters are migrated from one storage to another:
+//
+//	curl http://old-tot/admin/counters | curl --data-binary @- http://new-tot/admin/counters?keep-higher=true
+//
+// GET returns the counters in the format of the local storage file. POST sets
+// the counters of the jobs in the body one by one and leaves the others alone.
+// With
+// keep-higher=true counters are never lowered, so that the numbers vended in
+// the meantime aren't vended again.
+func (s *store) handleAdmin(w http.ResponseWriter, r *http.Request) {
+	var c *counters
+	switch r.Method {
+	case http.MethodGet:
+		var err error
+		if c, err = s.backend.list(r.Context()); err != nil {
+			logrus.WithError(err).Error("Failed to load counters.")
+			http.Error(w, "Failed to load counters.", http.StatusInternalServerError)
+			return
+		}
+	case http.MethodPost:
+		var req counters
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, fmt.Sprintf("Invalid counters: %v", err), http.StatusBadRequest)
+			return
+		}
+		keepHigher := r.URL.Query().Get("keep-higher") == "true"
+		for job, n := range req.Number {
+			n := n
+			if _, err := s.update(r.Context(), job, func(current int, _ bool) int {
+				if keepHigher && current > n {
+					return current
+				}
+				return n
+			}); err != nil {
+				logrus.WithError(err).Errorf("Failed to set %s number.", job)
+				http.Error(w, "Failed to set counters.", http.StatusInternalServerError)
+				return
+			}
+		}
+		logrus.Infof("Set %d counters from %s.", len(req.Number), r.RemoteAddr)
+		var err error
+		if c, err = s.backend.list(r.Context()); err != nil {
+			logrus.WithError(err).Error("Failed to load counters.")
+			http.Error(w, "Failed to load counters.", http.StatusInternalServerError)
+			return
+		}
+	default:
+		http.Error(w, fmt.Sprintf("Method %s is not allowed.", r.Method), http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(c); err != nil {
+		logrus.WithError(err).Error("Failed to write counters.")
 	}
 }
 
@@ -284,9 +394,19 @@ func main() {
 	pprof.Instrument(o.instrumentationOptions)
 	health := pjutil.NewHealthOnPort(o.instrumentationOptions.HealthPort)
 
-	s, err := newStore(o.storagePath)
-	if err != nil {
-		logrus.WithError(err).Fatal("newStore failed")
+	var s *store
+	if o.sharedStorage() {
+		opener, err := o.storage.StorageClient(interrupts.Context())
+		if err != nil {
+			logrus.WithError(err).Fatal("Error creating opener.")
+		}
+		s = &store{backend: &openerBackend{opener: opener, path: o.storagePath}}
+	} else {
+		var err error
+		s, err = newStore(strings.TrimPrefix(o.storagePath, providers.File+"://"))
+		if err != nil {
+			logrus.WithError(err).Fatal("newStore failed")
+		}
 	}
 
 	if o.useFallback {
@@ -308,6 +428,7 @@ func main() {
 
 	mux := http.NewServeMux()
 	mux.HandleFunc("/vend/", s.handle)
+	mux.HandleFunc("/admin/counters", s.handleAdmin)
 	server := &http.Server{Addr: ":" + strconv.Itoa(o.port), Handler: mux}
 	health.ServeReady()
 	interrupts.ListenAndServe(server, 5*time.Second)
@@ -37,45 +37,57 @@ func expectEqual(t *testing.T, msg string, have interface{}, want interface{}) {
 }
 
 func makeStore(t *testing.T) *store {
-	tmp, err := os.CreateTemp("", "tot_test_")
+	store, err := newStore(path.Join(t.TempDir(), "tot.json"))
 	if err != nil {
 		t.Fatal(err)
 	}
-	os.Remove(tmp.Name()) // json decoding an empty file throws an error
 
-	store, err := newStore(tmp.Name())
+	return store
+}
+
+func vend(t *testing.T, s *store, jobName string) int {
+	n, err := s.vend(jobName)
 	if err != nil {
-		t.Fatal(err)
+		t.Fatalf("vend %s: %v", jobName, err)
 	}
+	return n
+}
 
-	return store
+func peek(t *testing.T, s *store, jobName string) int {
+	n, err := s.peek(jobName)
+	if err != nil {
+		t.Fatalf("peek %s: %v", jobName, err)
+	}
+	return n
 }
 
 func TestVend(t *testing.T) {
 	store := makeStore(t)
-	defer os.Remove(store.storagePath)
 
-	expectEqual(t, "empty vend", store.vend("a"), 1)
-	expectEqual(t, "second vend", store.vend("a"), 2)
-	expectEqual(t, "third vend", store.vend("a"), 3)
-	expectEqual(t, "second empty", store.vend("b"), 1)
+	expectEqual(t, "empty vend", vend(t, store, "a"), 1)
+	expectEqual(t, "second vend", vend(t, store, "a"), 2)
+	expectEqual(t, "third vend", vend(t, store, "a"), 3)
+	expectEqual(t, "second empty", vend(t, store, "b"), 1)
 
-	store2, err := newStore(store.storagePath)
+	store2, err := newStore(store.backend.(*fileBackend).path)
 	if err != nil {
 		t.Fatal(err)
 	}
-	expectEqual(t, "fourth vend, different instance", store2.vend("a"), 4)
+	expectEqual(t, "fourth vend, different instance", vend(t, store2, "a"), 4)
 }
 
 func TestSet(t *testing.T) {
 	store := makeStore(t)
-	defer os.Remove(store.storagePath)
 
-	store.set("foo", 300)
-	expectEqual(t, "peek", store.peek("foo"), 300)
-	store.set("foo2", 300)
-	expectEqual(t, "vend", store.vend("foo2"), 301)
-	expectEqual(t, "vend", store.vend("foo2"), 302)
+	if err := store.set("foo", 300); err != nil {
+		t.Fatal(err)
+	}
+	expectEqual(t, "peek", peek(t, store, "foo"), 300)
+	if err := store.set("foo2", 300); err != nil {
+		t.Fatal(err)
+	}
+	expectEqual(t, "vend", vend(t, store, "foo2"), 301)
+	expectEqual(t, "vend", vend(t, store, "foo2"), 302)
 }
 
 func expectResponse(t *testing.T, handler http.Handler, req *http.Request, msg, value string) {
@@ -90,7 +102,6 @@ func expectResponse(t *testing.T, handler http.Handler, req *http.Request, msg,
 
 func TestHandler(t *testing.T) {
 	store := makeStore(t)
-	defer os.Remove(store.storagePath)
 
 	handler := http.HandlerFunc(store.handle)
 
@@ -124,6 +135,31 @@ func TestHandler(t *testing.T) {
 	expectResponse(t, handler, req, "http vend", "40")
 }
 
+func TestAdminHandler(t *testing.T) {
+	store := makeStore(t)
+	handler := http.HandlerFunc(store.handleAdmin)
+	if err := store.set("foo", 10); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.set("bar", 50); err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/counters", nil)
+	expectResponse(t, handler, req, "list counters", `{"Number":{"bar":50,"foo":10}}`+"\n")
+
+	req = httptest.NewRequest(http.MethodPost, "/admin/counters?keep-higher=true", strings.NewReader(`{"Number":{"foo":20,"bar":40,"baz":1}}`))
+	expectResponse(t, handler, req, "migrate counters", `{"Number":{"bar":50,"baz":1,"foo":20}}`+"\n")
+
+	req = httptest.NewRequest(http.MethodPost, "/admin/counters", strings.NewReader(`{"Number":{"bar":40}}`))
+	expectResponse(t, handler, req, "set counters", `{"Number":{"bar":40,"baz":1,"foo":20}}`+"\n")
+	expectEqual(t, "vend after setting", vend(t, store, "bar"), 41)
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/admin/counters", strings.NewReader("40")))
+	expectEqual(t, "invalid counters", rr.Code, http.StatusBadRequest)
+}
+
 type mapHandler map[string]string
 
 func (h mapHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
@@ -139,15 +175,14 @@ func TestFallback(t *testing.T) {
 	defer serv.Close()
 
 	store := makeStore(t)
-	defer os.Remove(store.storagePath)
 	store.fallbackFunc = fallbackHandler{template: serv.URL + "/logs/%s/latest-build.txt"}.get
 
-	expectEqual(t, "vend foo 1", store.vend("foo"), 201)
-	expectEqual(t, "vend foo 2", store.vend("foo"), 202)
+	expectEqual(t, "vend foo 1", vend(t, store, "foo"), 201)
+	expectEqual(t, "vend foo 2", vend(t, store, "foo"), 202)
 
-	expectEqual(t, "vend bar", store.vend("bar"), 301)
-	expectEqual(t, "vend baz", store.vend("baz"), 1)
-	expectEqual(t, "vend quux", store.vend("quux"), 1)
+	expectEqual(t, "vend bar", vend(t, store, "bar"), 301)
+	expectEqual(t, "vend baz", vend(t, store, "baz"), 1)
+	expectEqual(t, "vend quux", vend(t, store, "quux"), 1)
 }
 
 func TestGetURL(t *testing.T) {
@@ -0,0 +1,241 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gocloud.dev/gcerrors"
+	"google.golang.org/api/googleapi"
+
+	pkgio "k8s.io/test-infra/prow/io"
+)
+
+// maxUpdateAttempts is how many times an update is tried when other replicas
+// keep saving the counter first or the storage keeps rate limiting writes.
+const maxUpdateAttempts = 10
+
+// rateLimitInitialBackoff and rateLimitMaxBackoff bound how long to wait after
+// rate limited writes, the backoff doubles with every attempt.
+var (
+	rateLimitInitialBackoff = 500 * time.Millisecond
+	rateLimitMaxBackoff     = 8 * time.Second
+)
+
+// errConflict means that the counter was saved by someone else since it was
+// loaded.
+var errConflict = errors.New("counter changed concurrently")
+
+// errRateLimited means that the counter was saved too often. GCS limits how
+// often a single object can be replaced.
+var errRateLimited = errors.New("counter saved too often")
+
+// counters is the content of the local storage file and how counters are
+// listed and set through /admin/counters.
+type counters struct {
+	Number map[string]int // job name -> last vended build number
+}
+
+// backend persists the counters.
+type backend interface {
+	// load returns the counter of the job, whether it exists and its version.
+	load(ctx context.Context, job string) (int, bool, int64, error)
+	// save replaces the counter of the job if it is still at version,
+	// otherwise it returns errConflict. It returns errRateLimited if the
+	// storage rejected the write because of rate limits.
+	save(ctx context.Context, job string, n int, version int64) error
+	// list returns the counters of all jobs.
+	list(ctx context.Context) (*counters, error)
+}
+
+func decodeCounters(buf []byte) (*counters, error) {
+	c := &counters{}
+	if err := json.Unmarshal(buf, c); err != nil {
+		return nil, err
+	}
+	if c.Number == nil {
+		c.Number = map[string]int{}
+	}
+	return c, nil
+}
+
+// fileBackend stores the counters in a local file. It has no versions, so
+// only one replica of tot can use it.
+type fileBackend struct {
+	path string
+	// lock serializes saves, which rewrite the counters of all jobs.
+	lock sync.Mutex
+}
+
+func (b *fileBackend) load(ctx context.Context, job string) (int, bool, int64, error) {
+	c, err := b.list(ctx)
+	if err != nil {
+		return 0, false, 0, err
+	}
+	n, ok := c.Number[job]
+	return n, ok, 0, nil
+}
+
+func (b *fileBackend) list(_ context.Context) (*counters, error) {
+	buf, err := os.ReadFile(b.path)
+	if os.IsNotExist(err) {
+		return &counters{Number: map[string]int{}}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return decodeCounters(buf)
+}
+
+func (b *fileBackend) save(ctx context.Context, job string, n int, _ int64) error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	c, err := b.list(ctx)
+	if err != nil {
+		return err
+	}
+	c.Number[job] = n
+	buf, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(b.path+".tmp", buf, 0644); err != nil {
+		return err
+	}
+	return os.Rename(b.path+".tmp", b.path)
+}
+
+// openerBackend stores the counter of every job in an object of its own below
+// a path, e.g. gs://bucket/tot/<job>.json, so that writes for different jobs
+// don't contend. The version is the generation of the object, and writes are
+// conditional on it, so that any number of replicas of tot can share them.
+type openerBackend struct {
+	opener pkgio.Opener
+	path   string
+}
+
+// jobPath returns the path of the object of the job. Job names are escaped,
+// so that every job has an object directly below the path, and escaped again
+// since storage paths are URLs.
+func (b *openerBackend) jobPath(job string) string {
+	return fmt.Sprintf("%s/%s.json", strings.TrimSuffix(b.path, "/"), url.PathEscape(url.PathEscape(job)))
+}
+
+func (b *openerBackend) load(ctx context.Context, job string) (int, bool, int64, error) {
+	path := b.jobPath(job)
+	// The generation is read before the content: if the object is replaced in
+	// between, the content is newer than the generation and saving fails.
+	attrs, err := b.opener.Attributes(ctx, path)
+	if pkgio.IsNotExist(err) {
+		return 0, false, 0, nil
+	}
+	if err != nil {
+		return 0, false, 0, fmt.Errorf("get attributes of %s: %w", path, err)
+	}
+	n, err := b.read(ctx, path)
+	return n, err == nil, attrs.Generation, err
+}
+
+func (b *openerBackend) read(ctx context.Context, path string) (int, error) {
+	buf, err := pkgio.ReadContent(ctx, logrus.NewEntry(logrus.StandardLogger()), b.opener, path)
+	if err != nil {
+		return 0, fmt.Errorf("read %s: %w", path, err)
+	}
+	var n int
+	if err := json.Unmarshal(buf, &n); err != nil {
+		return 0, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return n, nil
+}
+
+func (b *openerBackend) list(ctx context.Context) (*counters, error) {
+	prefix := strings.TrimSuffix(b.path, "/") + "/"
+	it, err := b.opener.Iterator(ctx, prefix, "/")
+	if err != nil {
+		return nil, fmt.Errorf("list %s: %w", prefix, err)
+	}
+	c := &counters{Number: map[string]int{}}
+	for {
+		attrs, err := it.Next(ctx)
+		if err == io.EOF {
+			return c, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("list %s: %w", prefix, err)
+		}
+		escaped := strings.TrimSuffix(attrs.ObjName, ".json")
+		if attrs.IsDir || escaped == attrs.ObjName {
+			continue
+		}
+		job, err := url.PathUnescape(escaped)
+		if err != nil {
+			continue
+		}
+		if c.Number[job], err = b.read(ctx, b.jobPath(job)); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (b *openerBackend) save(ctx context.Context, job string, n int, version int64) error {
+	buf, err := json.Marshal(n)
+	if err != nil {
+		return err
+	}
+	opts := pkgio.WriterOptions{PreconditionGenerationMatch: &version}
+	if version == 0 {
+		doesNotExist := true
+		opts = pkgio.WriterOptions{PreconditionDoesNotExist: &doesNotExist}
+	}
+	// Not pkgio.WriteContent, it swallows failed preconditions.
+	w, err := b.opener.Writer(ctx, b.jobPath(job), opts)
+	if err != nil {
+		return classifySaveError(err)
+	}
+	if _, err := w.Write(buf); err != nil {
+		w.Close()
+		return classifySaveError(err)
+	}
+	return classifySaveError(w.Close())
+}
+
+// classifySaveError wraps errors of conditional writes that are worth
+// retrying in errConflict or errRateLimited.
+func classifySaveError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if pkgio.IsPreconditionFailed(err) || gcerrors.Code(err) == gcerrors.FailedPrecondition {
+		return fmt.Errorf("%w: %v", errConflict, err)
+	}
+	var apiErr *googleapi.Error
+	if (errors.As(err, &apiErr) && apiErr.Code == http.StatusTooManyRequests) || gcerrors.Code(err) == gcerrors.ResourceExhausted {
+		return fmt.Errorf("%w: %v", errRateLimited, err)
+	}
+	return err
+}
@@ -0,0 +1,134 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"google.golang.org/api/googleapi"
+
+	"k8s.io/test-infra/prow/io/fakeopener"
+)
+
+// racingBackend lets another replica vend a number between each load and
+// save, the first times counters are saved.
+type racingBackend struct {
+	backend
+	other *store
+	races int
+	t     *testing.T
+}
+
+func (b *racingBackend) save(ctx context.Context, job string, n int, version int64) error {
+	if b.races > 0 {
+		b.races--
+		vend(b.t, b.other, job)
+	}
+	return b.backend.save(ctx, job, n, version)
+}
+
+func TestOpenerBackend(t *testing.T) {
+	opener := &fakeopener.FakeOpener{}
+	const path = "gs://bucket/tot"
+	replica1 := &store{backend: &openerBackend{opener: opener, path: path}}
+	replica2 := &store{backend: &openerBackend{opener: opener, path: path}}
+
+	expectEqual(t, "first vend", vend(t, replica1, "a"), 1)
+	expectEqual(t, "vend of the other replica", vend(t, replica2, "a"), 2)
+	expectEqual(t, "peek of the first replica", peek(t, replica1, "a"), 2)
+
+	replica1.backend = &racingBackend{backend: replica1.backend, other: replica2, races: 2, t: t}
+	// The other replica vends 3 and 4 while replica1 tries to save.
+	expectEqual(t, "vend after conflicts", vend(t, replica1, "a"), 5)
+	expectEqual(t, "vend after conflicts, other replica", vend(t, replica2, "a"), 6)
+
+	replica1.backend = &racingBackend{backend: replica1.backend, other: replica2, races: maxUpdateAttempts, t: t}
+	if n, err := replica1.vend("a"); err == nil {
+		t.Errorf("expected to give up after %d conflicts, vended %d", maxUpdateAttempts, n)
+	}
+
+	// Every job has an object of its own.
+	expectEqual(t, "vend of another job", vend(t, replica2, "b/c"), 1)
+	if _, ok := opener.Buffer["gs://bucket/tot/b%252Fc.json"]; !ok {
+		t.Errorf("expected the counter of b/c to be stored in its own object, got %v", opener.Buffer)
+	}
+	all, err := replica1.backend.list(context.Background())
+	if err != nil {
+		t.Fatalf("failed to list counters: %v", err)
+	}
+	expectEqual(t, "listed counters", all.Number, map[string]int{"a": 6 + maxUpdateAttempts, "b/c": 1})
+}
+
+// rateLimitedBackend rejects the first saves like GCS does when an object is
+// replaced too often.
+type rateLimitedBackend struct {
+	backend
+	rejections int
+}
+
+func (b *rateLimitedBackend) save(ctx context.Context, job string, n int, version int64) error {
+	if b.rejections > 0 {
+		b.rejections--
+		return classifySaveError(fmt.Errorf("close: %w", &googleapi.Error{Code: http.StatusTooManyRequests}))
+	}
+	return b.backend.save(ctx, job, n, version)
+}
+
+func TestRateLimitedSaves(t *testing.T) {
+	initial, max := rateLimitInitialBackoff, rateLimitMaxBackoff
+	rateLimitInitialBackoff, rateLimitMaxBackoff = 2*time.Millisecond, 4*time.Millisecond
+	defer func() { rateLimitInitialBackoff, rateLimitMaxBackoff = initial, max }()
+
+	opener := &fakeopener.FakeOpener{}
+	s := &store{backend: &rateLimitedBackend{backend: &openerBackend{opener: opener, path: "gs://bucket/tot"}, rejections: 3}}
+	expectEqual(t, "vend after rate limits", vend(t, s, "a"), 1)
+
+	s.backend = &rateLimitedBackend{backend: s.backend.(*rateLimitedBackend).backend, rejections: maxUpdateAttempts}
+	if _, err := s.vend("a"); !errors.Is(err, errRateLimited) {
+		t.Errorf("expected to give up after %d rate limited saves, got %v", maxUpdateAttempts, err)
+	}
+}
+
+func TestClassifySaveError(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		err      error
+		expected error
+	}{
+		{name: "generation mismatch", err: &googleapi.Error{Code: http.StatusPreconditionFailed}, expected: errConflict},
+		{name: "rate limited", err: &googleapi.Error{Code: http.StatusTooManyRequests}, expected: errRateLimited},
+		{name: "other errors are kept", err: &googleapi.Error{Code: http.StatusForbidden}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := classifySaveError(tc.err)
+			if tc.expected == nil {
+				if errors.Is(err, errConflict) || errors.Is(err, errRateLimited) {
+					t.Errorf("expected error not to be retried, got %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, err)
+			}
+		})
+	}
+}
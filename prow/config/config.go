@@ -211,6 +211,50 @@ type InRepoConfig struct {
 	// a given repo. All clusters that are allowed for the specific repo, its org or
 	// globally can be used.
 	AllowedClusters map[string][]string `json:"allowed_clusters,omitempty"`
+	// Periodics configures periodic jobs that are versioned inside the repo and
+	// read from the default branch by horologium.
+	Periodics *InRepoConfigPeriodics `json:"periodics,omitempty"`
+}
+
+const (
+	// defaultInRepoPeriodicsMinimumInterval is the shortest period at which an
+	// in-repo periodic may be triggered unless configured otherwise.
+	defaultInRepoPeriodicsMinimumInterval = time.Hour
+	// defaultInRepoPeriodicsMaxJobsPerRepo is the number of periodics a repo
+	// may define unless configured otherwise.
+	defaultInRepoPeriodicsMaxJobsPerRepo = 10
+)
+
+// InRepoConfigPeriodics holds the settings for periodics defined in-repo.
+type InRepoConfigPeriodics struct {
+	// Enabled describes whether periodics are read from the in-repo config of a
+	// given repository. This can be set globally, per org or per repo using '*',
+	// 'org' or 'org/repo' as key. The narrowest match always takes precedence.
+	// InRepoConfig must be enabled for the repository as well.
+	Enabled map[string]*bool `json:"enabled,omitempty"`
+	// MinimumInterval is the shortest period at which an in-repo periodic may
+	// be triggered. It applies to the interval, minimum_interval and cron of a
+	// job alike. Defaults to one hour.
+	MinimumInterval *metav1.Duration `json:"minimum_interval,omitempty"`
+	// MaxJobsPerRepo is the maximum number of periodics a single repo may
+	// define. Defaults to 10.
+	MaxJobsPerRepo int `json:"max_jobs_per_repo,omitempty"`
+}
+
+// GetMinimumInterval returns the configured minimum interval or its default.
+func (p *InRepoConfigPeriodics) GetMinimumInterval() time.Duration {
+	if p == nil || p.MinimumInterval == nil {
+		return defaultInRepoPeriodicsMinimumInterval
+	}
+	return p.MinimumInterval.Duration
+}
+
+// GetMaxJobsPerRepo returns the configured job cap or its default.
+func (p *InRepoConfigPeriodics) GetMaxJobsPerRepo() int {
+	if p == nil || p.MaxJobsPerRepo == 0 {
+		return defaultInRepoPeriodicsMaxJobsPerRepo
+	}
+	return p.MaxJobsPerRepo
 }
 
 func SplitRepoName(fullRepoName string) (string, string, error) {
@@ -237,6 +281,20 @@ func (c *Config) InRepoConfigEnabled(identifier string) bool {
 	return false
 }
 
+// InRepoPeriodicsEnabled returns whether periodics are read from the
+// InRepoConfig of a given repository.
+func (c *Config) InRepoPeriodicsEnabled(identifier string) bool {
+	if c.InRepoConfig.Periodics == nil || !c.InRepoConfigEnabled(identifier) {
+		return false
+	}
+	for _, key := range keysForIdentifier(identifier) {
+		if c.InRepoConfig.Periodics.Enabled[key] != nil {
+			return *c.InRepoConfig.Periodics.Enabled[key]
+		}
+	}
+	return false
+}
+
 // InRepoConfigAllowsCluster determines if a given cluster may be used for a given repository
 // Assumes that config will not include http:// or https://
 func (c *Config) InRepoConfigAllowsCluster(clusterName, identifier string) bool {
@@ -515,6 +573,81 @@ func (c *Config) GetPostsubmitsStatic(identifier string) []Postsubmit {
 	return res
 }
 
+// GetInRepoPeriodics returns the periodics that are versioned inside the
+// given repo on its default branch, if in-repo periodics are enabled for it.
+func (c *Config) GetInRepoPeriodics(gc git.ClientFactory, identifier string) ([]Periodic, error) {
+	if identifier == "" {
+		return nil, errors.New("no identifier for repo given")
+	}
+	if !c.InRepoPeriodicsEnabled(identifier) {
+		return nil, nil
+	}
+	if gc == nil {
+		return nil, errors.New("gitClient is nil")
+	}
+
+	orgRepo := *NewOrgRepo(identifier)
+	if orgRepo.Repo == "" {
+		return nil, fmt.Errorf("didn't get two results when splitting repo identifier %q", identifier)
+	}
+	repo, err := gc.ClientFor(orgRepo.Org, orgRepo.Repo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to clone repo for %q: %w", identifier, err)
+	}
+	// The getter below clones the repo again, so the client must be released
+	// before calling it.
+	baseSHA, err := repo.RevParse("refs/remotes/origin/HEAD")
+	if cleanErr := repo.Clean(); cleanErr != nil {
+		logrus.WithField("repo", identifier).WithError(cleanErr).Error("Failed to clean up repo.")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve default branch of %q: %w", identifier, err)
+	}
+	return c.GetInRepoPeriodicsAt(gc, identifier, strings.TrimSpace(baseSHA))
+}
+
+// GetInRepoPeriodicsAt returns the periodics that are versioned inside the
+// given repo at baseSHA, which should be the head of its default branch.
+func (c *Config) GetInRepoPeriodicsAt(gc git.ClientFactory, identifier, baseSHA string) ([]Periodic, error) {
+	if !c.InRepoPeriodicsEnabled(identifier) {
+		return nil, nil
+	}
+	prowYAML, err := c.ProwYAMLGetterWithDefaults(c, gc, identifier, baseSHA)
+	if err != nil {
+		return nil, err
+	}
+	return prowYAML.Periodics, nil
+}
+
+// InRepoPeriodicsOrgs returns the orgs that may have repos with in-repo
+// periodics enabled. If they are enabled globally, these are all orgs the
+// config knows of.
+func (c *Config) InRepoPeriodicsOrgs() []string {
+	if c.InRepoConfig.Periodics == nil {
+		return nil
+	}
+	orgs := sets.NewString()
+	var global bool
+	for key, enabled := range c.InRepoConfig.Periodics.Enabled {
+		if key == "*" {
+			global = enabled != nil && *enabled
+			continue
+		}
+		orgs.Insert(NewOrgRepo(key).Org)
+	}
+	if global {
+		for _, repo := range c.AllRepos.UnsortedList() {
+			orgs.Insert(NewOrgRepo(repo).Org)
+		}
+		for key := range c.InRepoConfig.Enabled {
+			if key != "*" {
+				orgs.Insert(NewOrgRepo(key).Org)
+			}
+		}
+	}
+	return orgs.List()
+}
+
 // OwnersDirDenylist is used to configure regular expressions matching directories
 // to ignore when searching for OWNERS{,_ALIAS} files in a repo.
 type OwnersDirDenylist struct {
@@ -929,6 +1062,12 @@ type GerritOrgRepoConfig struct {
 	// Filters are used for limiting the scope of querying the Gerrit server.
 	// Currently supports branches and excluded branches.
 	Filters *GerritQueryFilter `json:"filters,omitempty"`
+	// ReportWithChecks is the flag for showing the jobs of the repos as check
+	// runs in the Checks UI of Gerrit, through the Prow plugin served by Deck
+	// at /static/gerrit_checks_bundle.min.js. The messages of the Gerrit
+	// reporter are then tagged as autogenerated, which hides them from the
+	// change history by default. Votes are still cast.
+	ReportWithChecks bool `json:"report_with_checks,omitempty"`
 }
 
 type GerritQueryFilter struct {
@@ -969,6 +1108,25 @@ func (goc *GerritOrgRepoConfigs) OptOutHelpRepos() map[string]sets.String {
 	return res
 }
 
+// ReportsWithChecks tells whether the jobs of the repo are shown in the Checks
+// UI of Gerrit.
+func (goc *GerritOrgRepoConfigs) ReportsWithChecks(org, repo string) bool {
+	if goc == nil {
+		return false
+	}
+	for _, orgConfig := range *goc {
+		if orgConfig.Org != org || !orgConfig.ReportWithChecks {
+			continue
+		}
+		for _, r := range orgConfig.Repos {
+			if r == repo {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // Horologium is config for the Horologium.
 type Horologium struct {
 	// TickInterval is the interval in which we check if new jobs need to be
@@ -1006,6 +1164,22 @@ type GitHubReporter struct {
 	// comments is only sent when all jobs from current SHA are finished. Status
 	// contexts will still be written.
 	SummaryCommentRepos []string `json:"summary_comment_repos,omitempty"`
+	// CheckRunRepos is a list of orgs and org/repos for which jobs are reported
+	// as GitHub check runs instead of status contexts. Check runs link to the
+	// job's Spyglass page, summarize its junit results and can be re-run from
+	// the GitHub UI when the trigger plugin is enabled.
+	CheckRunRepos []string `json:"check_run_repos,omitempty"`
+}
+
+// CheckRunsEnabled returns whether jobs for the given repo are reported as check runs.
+func (gr *GitHubReporter) CheckRunsEnabled(org, repo string) bool {
+	fullRepo := org + "/" + repo
+	for _, ident := range gr.CheckRunRepos {
+		if ident == org || ident == fullRepo {
+			return true
+		}
+	}
+	return false
 }
 
 // Sinker is config for the sinker controller.
@@ -1191,6 +1365,10 @@ type Deck struct {
 	// (in addition to those listed in the GCSConfiguration).
 	// Setting this field requires "SkipStoragePathValidation" also be set to `false`.
 	AdditionalAllowedBuckets []string `json:"additional_allowed_buckets,omitempty"`
+	// SlackUsers maps Slack user IDs to GitHub logins. Interactive actions on
+	// messages of the Slack reporter are authorized like reruns and aborts in
+	// deck, as the GitHub user that the Slack user who takes them maps to.
+	SlackUsers map[string]string `json:"slack_users,omitempty"`
 	// AllKnownStorageBuckets contains all storage buckets configured in all of the
 	// job configs.
 	AllKnownStorageBuckets sets.String `json:"-"`
@@ -1458,6 +1636,147 @@ type PubSubTrigger struct {
 	AllowedClusters []string `json:"allowed_clusters"`
 	// MaxOutstandingMessages is the max number of messaged being processed, default is 10.
 	MaxOutstandingMessages int `json:"max_outstanding_messages"`
+	// CloudEvents maps CloudEvents received on the topics to the jobs they
+	// trigger. Events in structured and binary mode are supported, see
+	// https://github.com/google/knative-gcp/blob/main/docs/spec/pubsub-protocol-binding.md.
+	// Other messages without the prow.k8s.io/pubsub.EventType attribute are
+	// mapped as events of type `google.cloud.pubsub.topic.v1.messagePublished`
+	// from the source `//pubsub.googleapis.com/<subscription>`.
+	CloudEvents []CloudEventMapping `json:"cloud_events,omitempty"`
+}
+
+// CloudEventMapping triggers jobs for CloudEvents, for example for the image
+// pushes of Artifact Registry.
+type CloudEventMapping struct {
+	// Type is the type of the events to match, e.g. `google.cloud.pubsub.topic.v1.messagePublished`.
+	Type string `json:"type"`
+	// Source optionally matches the source of the events. A trailing `*`
+	// matches any suffix.
+	Source string `json:"source,omitempty"`
+	// JobType is the type of the jobs to trigger, one of periodic (the
+	// default), presubmit or postsubmit.
+	JobType prowapi.ProwJobType `json:"job_type,omitempty"`
+	// Jobs are the names of the jobs to trigger.
+	Jobs []string `json:"jobs"`
+	// Refs are the refs presubmits and postsubmits run against. They are
+	// required for these job types.
+	Refs *CloudEventRefs `json:"refs,omitempty"`
+	// Envs are added to the containers of the triggered jobs. The values are
+	// Go templates executed on the event, e.g. `{{.Subject}}` or `{{.Data.tag}}`
+	// for events with JSON data.
+	Envs map[string]string `json:"envs,omitempty"`
+	// AuthConfig restricts which events may trigger the jobs. No events are
+	// allowed unless it is set.
+	AuthConfig *CloudEventAuthConfig `json:"auth_config,omitempty"`
+}
+
+// CloudEventRefs are the refs of the jobs triggered by a CloudEvent. Like
+// envs, the values are Go templates executed on the event.
+type CloudEventRefs struct {
+	Org     string `json:"org"`
+	Repo    string `json:"repo"`
+	BaseRef string `json:"base_ref"`
+	BaseSHA string `json:"base_sha"`
+	// PullNumber and PullSHA are the pull request presubmits run against.
+	PullNumber string `json:"pull_number,omitempty"`
+	PullSHA    string `json:"pull_sha,omitempty"`
+}
+
+// Templates returns the templates of the refs by field name.
+func (r *CloudEventRefs) Templates() map[string]string {
+	return map[string]string{
+		"org":         r.Org,
+		"repo":        r.Repo,
+		"base_ref":    r.BaseRef,
+		"base_sha":    r.BaseSHA,
+		"pull_number": r.PullNumber,
+		"pull_sha":    r.PullSHA,
+	}
+}
+
+// CloudEventAuthConfig lists the events that may trigger jobs. Sources and
+// subjects support a trailing `*` to match any suffix.
+type CloudEventAuthConfig struct {
+	// If AllowAnyone is set to true, any event can trigger the jobs.
+	AllowAnyone bool `json:"allow_anyone,omitempty"`
+	// Sources contains the sources of events that can trigger the jobs.
+	Sources []string `json:"sources,omitempty"`
+	// Subjects contains the subjects of events that can trigger the jobs.
+	Subjects []string `json:"subjects,omitempty"`
+}
+
+// IsAuthorized returns true if AllowAnyone is set to true or if the source or
+// the subject of the event is permitted.
+func (c *CloudEventAuthConfig) IsAuthorized(source, subject string) bool {
+	if c == nil {
+		return false
+	}
+	if c.AllowAnyone {
+		return true
+	}
+	for _, s := range c.Sources {
+		if MatchCloudEventPattern(s, source) {
+			return true
+		}
+	}
+	if subject == "" {
+		return false
+	}
+	for _, s := range c.Subjects {
+		if MatchCloudEventPattern(s, subject) {
+			return true
+		}
+	}
+	return false
+}
+
+// Matches determines whether the mapping applies to an event.
+func (m *CloudEventMapping) Matches(eventType, source string) bool {
+	return m.Type == eventType && (m.Source == "" || MatchCloudEventPattern(m.Source, source))
+}
+
+// MatchCloudEventPattern matches a value against a pattern that is either
+// literal or ends in `*` to match any suffix.
+func MatchCloudEventPattern(pattern, value string) bool {
+	if strings.HasSuffix(pattern, "*") {
+		return strings.HasPrefix(value, strings.TrimSuffix(pattern, "*"))
+	}
+	return pattern == value
+}
+
+func (m *CloudEventMapping) validate() error {
+	if m.Type == "" {
+		return errors.New("type must be set")
+	}
+	if len(m.Jobs) == 0 {
+		return fmt.Errorf("jobs must be set for type %q", m.Type)
+	}
+	switch m.JobType {
+	case "", prowapi.PeriodicJob:
+		if m.Refs != nil {
+			return fmt.Errorf("refs can't be set for periodics of type %q", m.Type)
+		}
+	case prowapi.PresubmitJob, prowapi.PostsubmitJob:
+		if m.Refs == nil || m.Refs.Org == "" || m.Refs.Repo == "" || m.Refs.BaseRef == "" || m.Refs.BaseSHA == "" {
+			return fmt.Errorf("refs with org, repo, base_ref and base_sha must be set for %ss of type %q", m.JobType, m.Type)
+		}
+		if m.JobType == prowapi.PresubmitJob && (m.Refs.PullNumber == "" || m.Refs.PullSHA == "") {
+			return fmt.Errorf("refs.pull_number and refs.pull_sha must be set for presubmits of type %q", m.Type)
+		}
+		for name, value := range m.Refs.Templates() {
+			if _, err := template.New(name).Parse(value); err != nil {
+				return fmt.Errorf("invalid template for refs.%s: %w", name, err)
+			}
+		}
+	default:
+		return fmt.Errorf("unsupported job_type %q for type %q", m.JobType, m.Type)
+	}
+	for name, value := range m.Envs {
+		if _, err := template.New(name).Parse(value); err != nil {
+			return fmt.Errorf("invalid template for env %s: %w", name, err)
+		}
+	}
+	return nil
 }
 
 // GitHubOptions allows users to control how prow applications display GitHub website links.
@@ -1491,10 +1810,37 @@ type ManagedWebhooks struct {
 // SlackReporter represents the config for the Slack reporter. The channel can be overridden
 // on the job via the .reporter_config.slack.channel property.
 type SlackReporter struct {
-	JobTypesToReport            []prowapi.ProwJobType `json:"job_types_to_report,omitempty"`
+	JobTypesToReport []prowapi.ProwJobType `json:"job_types_to_report,omitempty"`
+	// InteractiveActions are the buttons added to reported messages. Clicking
+	// them requires the interactivity endpoint of the Slack app to be set to
+	// the `/slack/interactivity` endpoint of deck. Valid values are `rerun`,
+	// `abort`, `logs` and `assign`.
+	InteractiveActions []SlackAction `json:"interactive_actions,omitempty"`
+	// OnCall is the ID of the Slack user or user group that the `assign` action
+	// assigns jobs to, e.g. `U012AB3CD` or `S012AB3CD`.
+	OnCall string `json:"on_call,omitempty"`
+	// ThreadPeriodics makes failing periodics keep a single thread instead of
+	// posting a new message for every run. The first failure is posted to the
+	// channel, later runs reply in its thread and the message is edited once
+	// the job recovers. Requires crier to run with --slack-thread-state-path.
+	ThreadPeriodics             bool `json:"thread_periodics,omitempty"`
 	prowapi.SlackReporterConfig `json:",inline"`
 }
 
+// SlackAction is an interactive action on messages of the Slack reporter.
+type SlackAction string
+
+const (
+	// SlackActionRerun reruns the job, like the rerun button of deck.
+	SlackActionRerun SlackAction = "rerun"
+	// SlackActionAbort aborts the job, like the abort button of deck.
+	SlackActionAbort SlackAction = "abort"
+	// SlackActionLogs links to the job in deck.
+	SlackActionLogs SlackAction = "logs"
+	// SlackActionAssign notifies the on-call of the job.
+	SlackActionAssign SlackAction = "assign"
+)
+
 // SlackReporterConfigs represents the config for the Slack reporter(s).
 // Use `org/repo`, `org` or `*` as key and an `SlackReporter` struct as value.
 type SlackReporterConfigs map[string]SlackReporter
@@ -1547,6 +1893,18 @@ func (cfg *SlackReporter) DefaultAndValidate() error {
 		return errors.New("channel must be set")
 	}
 
+	for _, action := range cfg.InteractiveActions {
+		switch action {
+		case SlackActionRerun, SlackActionAbort, SlackActionLogs:
+		case SlackActionAssign:
+			if cfg.OnCall == "" {
+				return fmt.Errorf("on_call must be set for the %q action", action)
+			}
+		default:
+			return fmt.Errorf("invalid interactive action %q", action)
+		}
+	}
+
 	// Validate ReportTemplate.
 	tmpl, err := template.New("").Parse(cfg.ReportTemplate)
 	if err != nil {
@@ -2048,6 +2406,20 @@ func (c *Config) DefaultPeriodic(periodic *Periodic) error {
 	return resolvePresets(periodic.Name, periodic.Labels, periodic.Spec, c.Presets)
 }
 
+// defaultInRepoPeriodics defaults the periodics for one repo.
+func defaultInRepoPeriodics(periodics []Periodic, additionalPresets []Preset, c *Config) error {
+	var errs []error
+	for i := range periodics {
+		c.defaultPeriodicFields(&periodics[i])
+		setPeriodicDecorationDefaults(c, &periodics[i])
+		setPeriodicProwJobDefaults(c, &periodics[i])
+		if err := resolvePresets(periodics[i].Name, periodics[i].Labels, periodics[i].Spec, append(c.Presets, additionalPresets...)); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
 // defaultPeriodics defaults c.Periodics.
 func defaultPeriodics(c *Config) error {
 	var errs []error
@@ -2118,6 +2490,14 @@ func (c *Config) validateComponentConfig() error {
 		}
 	}
 
+	for _, trigger := range c.PubSubTriggers {
+		for _, mapping := range trigger.CloudEvents {
+			if err := mapping.validate(); err != nil {
+				return fmt.Errorf("invalid cloud_events mapping for project %s: %w", trigger.Project, err)
+			}
+		}
+	}
+
 	if err := c.Deck.FinalizeDefaultRerunAuthConfigs(); err != nil {
 		return err
 	}
@@ -2165,6 +2545,9 @@ func (c Config) validateJobBase(v JobBase, jobType prowapi.ProwJobType) error {
 	if err := ValidatePipelineRunSpec(jobType, v.ExtraRefs, v.PipelineRunSpec); err != nil {
 		return err
 	}
+	if err := validateBuildSpec(v.BuildSpec); err != nil {
+		return err
+	}
 	if err := validateLabels(v.Labels); err != nil {
 		return err
 	}
@@ -2643,6 +3026,12 @@ func parseProwConfig(c *Config) error {
 		}
 	}
 
+	for i := range c.Tide.MergeFreezes {
+		if err := c.Tide.MergeFreezes[i].Validate(); err != nil {
+			return fmt.Errorf("tide merge freeze (index %d) is invalid: %w", i, err)
+		}
+	}
+
 	if c.ProwJobNamespace == "" {
 		c.ProwJobNamespace = "default"
 	}
@@ -2726,7 +3115,8 @@ func validateAgent(v JobBase, podNamespace string) error {
 	k := string(prowapi.KubernetesAgent)
 	j := string(prowapi.JenkinsAgent)
 	p := string(prowapi.TektonAgent)
-	agents := sets.NewString(k, j, p)
+	b := string(prowapi.CloudBuildAgent)
+	agents := sets.NewString(k, j, p, b)
 	agent := v.Agent
 	switch {
 	case !agents.Has(agent):
@@ -2740,6 +3130,10 @@ func validateAgent(v JobBase, podNamespace string) error {
 		return fmt.Errorf("job pipeline_run_spec require agent: %s (found %q)", p, agent)
 	case agent == p && v.PipelineRunSpec == nil:
 		return fmt.Errorf("agent: %s jobs require a pipeline_run_spec", p)
+	case v.BuildSpec != nil && agent != b:
+		return fmt.Errorf("job build_spec require agent: %s (found %q)", b, agent)
+	case agent == b && v.BuildSpec == nil:
+		return fmt.Errorf("agent: %s jobs require a build_spec", b)
 	case v.DecorationConfig != nil && agent != k:
 		// TODO(fejta): only source decoration supported...
 		return fmt.Errorf("decoration requires agent: %s (found %q)", k, agent)
@@ -2782,6 +3176,24 @@ func resolvePresets(name string, labels map[string]string, spec *v1.PodSpec, pre
 	return nil
 }
 
+func validateBuildSpec(spec *prowapi.CloudBuildSpec) error {
+	if spec == nil {
+		return nil
+	}
+	if spec.Project == "" {
+		return errors.New("build_spec requires a project")
+	}
+	if len(spec.Steps) == 0 {
+		return errors.New("build_spec requires at least one step")
+	}
+	for i, step := range spec.Steps {
+		if step.Name == "" {
+			return fmt.Errorf("build_spec step %d requires a name", i)
+		}
+	}
+	return nil
+}
+
 var ReProwExtraRef = regexp.MustCompile(`PROW_EXTRA_GIT_REF_(\d+)`)
 
 func ValidatePipelineRunSpec(jobType prowapi.ProwJobType, extraRefs []prowapi.Refs, spec *pipelinev1alpha1.PipelineRunSpec) error {
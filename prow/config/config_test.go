@@ -1221,6 +1221,30 @@ func TestValidateAgent(t *testing.T) {
 			},
 			pass: true,
 		},
+		{
+			name: "accept cloud-build agent",
+			base: func(j *JobBase) {
+				j.Agent = string(prowapi.CloudBuildAgent)
+				j.Spec = nil
+				j.DecorationConfig = nil
+				j.BuildSpec = &prowapi.CloudBuildSpec{Project: "project"}
+			},
+			pass: true,
+		},
+		{
+			name: "cloud-build agent requires build_spec",
+			base: func(j *JobBase) {
+				j.Agent = string(prowapi.CloudBuildAgent)
+				j.Spec = nil
+				j.DecorationConfig = nil
+			},
+		},
+		{
+			name: "build_spec requires cloud-build agent",
+			base: func(j *JobBase) {
+				j.BuildSpec = &prowapi.CloudBuildSpec{Project: "project"}
+			},
+		},
 	}
 
 	for _, tc := range cases {
@@ -1239,6 +1263,47 @@ func TestValidateAgent(t *testing.T) {
 	}
 }
 
+func TestValidateBuildSpec(t *testing.T) {
+	cases := []struct {
+		name string
+		spec *prowapi.CloudBuildSpec
+		pass bool
+	}{
+		{
+			name: "allow nil spec",
+			pass: true,
+		},
+		{
+			name: "happy case",
+			spec: &prowapi.CloudBuildSpec{Project: "project", Steps: []prowapi.CloudBuildStep{{Name: "golang", Args: []string{"make"}}}},
+			pass: true,
+		},
+		{
+			name: "project is required",
+			spec: &prowapi.CloudBuildSpec{Steps: []prowapi.CloudBuildStep{{Name: "golang"}}},
+		},
+		{
+			name: "steps are required",
+			spec: &prowapi.CloudBuildSpec{Project: "project"},
+		},
+		{
+			name: "steps require a name",
+			spec: &prowapi.CloudBuildSpec{Project: "project", Steps: []prowapi.CloudBuildStep{{Args: []string{"make"}}}},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			switch err := validateBuildSpec(tc.spec); {
+			case err == nil && !tc.pass:
+				t.Error("validation failed to raise an error")
+			case err != nil && tc.pass:
+				t.Errorf("validation should have passed, got: %v", err)
+			}
+		})
+	}
+}
+
 func TestValidatePodSpec(t *testing.T) {
 	periodEnv := sets.NewString(downwardapi.EnvForType(prowapi.PeriodicJob)...)
 	postEnv := sets.NewString(downwardapi.EnvForType(prowapi.PostsubmitJob)...)
@@ -2319,6 +2384,32 @@ func TestGerritOptOutHelpRepos(t *testing.T) {
 	}
 }
 
+func TestGerritReportsWithChecks(t *testing.T) {
+	configs := &GerritOrgRepoConfigs{
+		{Org: "org-1", Repos: []string{"repo-1"}, ReportWithChecks: true},
+		{Org: "org-1", Repos: []string{"repo-2"}},
+	}
+	tests := []struct {
+		name      string
+		in        *GerritOrgRepoConfigs
+		org, repo string
+		want      bool
+	}{
+		{name: "enabled", in: configs, org: "org-1", repo: "repo-1", want: true},
+		{name: "not enabled", in: configs, org: "org-1", repo: "repo-2", want: false},
+		{name: "other org", in: configs, org: "org-2", repo: "repo-1", want: false},
+		{name: "nil", org: "org-1", repo: "repo-1", want: false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.in.ReportsWithChecks(tc.org, tc.repo); got != tc.want {
+				t.Errorf("expected %t, got %t", tc.want, got)
+			}
+		})
+	}
+}
+
 // integration test for fake config loading
 func TestValidConfigLoading(t *testing.T) {
 	ptrOrBool := func(p *bool) string {
@@ -4602,6 +4693,64 @@ func TestSlackReporterValidation(t *testing.T) {
 			},
 			successExpected: true,
 		},
+		{
+			name: "Valid interactive actions - no error",
+			config: func() Config {
+				slackCfg := map[string]SlackReporter{
+					"*": {
+						InteractiveActions: []SlackAction{SlackActionRerun, SlackActionAbort, SlackActionLogs, SlackActionAssign},
+						OnCall:             "S012AB3CD",
+						SlackReporterConfig: prowjobv1.SlackReporterConfig{
+							Channel: "my-channel",
+						},
+					},
+				}
+				return Config{
+					ProwConfig: ProwConfig{
+						SlackReporterConfigs: slackCfg,
+					},
+				}
+			},
+			successExpected: true,
+		},
+		{
+			name: "Assign action without on-call - error",
+			config: func() Config {
+				slackCfg := map[string]SlackReporter{
+					"*": {
+						InteractiveActions: []SlackAction{SlackActionAssign},
+						SlackReporterConfig: prowjobv1.SlackReporterConfig{
+							Channel: "my-channel",
+						},
+					},
+				}
+				return Config{
+					ProwConfig: ProwConfig{
+						SlackReporterConfigs: slackCfg,
+					},
+				}
+			},
+			successExpected: false,
+		},
+		{
+			name: "Unknown interactive action - error",
+			config: func() Config {
+				slackCfg := map[string]SlackReporter{
+					"*": {
+						InteractiveActions: []SlackAction{"merge"},
+						SlackReporterConfig: prowjobv1.SlackReporterConfig{
+							Channel: "my-channel",
+						},
+					},
+				}
+				return Config{
+					ProwConfig: ProwConfig{
+						SlackReporterConfigs: slackCfg,
+					},
+				}
+			},
+			successExpected: false,
+		},
 		{
 			name: "No channel w/ slack_reporter_configs - error",
 			config: func() Config {
@@ -4689,6 +4838,94 @@ func TestSlackReporterValidation(t *testing.T) {
 		})
 	}
 }
+
+func TestCloudEventMappings(t *testing.T) {
+	testCases := []struct {
+		name            string
+		mapping         CloudEventMapping
+		successExpected bool
+	}{
+		{
+			name:            "valid mapping",
+			mapping:         CloudEventMapping{Type: "type", Jobs: []string{"job"}, Envs: map[string]string{"IMAGE": "{{.Data.tag}}"}},
+			successExpected: true,
+		},
+		{
+			name:    "missing type",
+			mapping: CloudEventMapping{Jobs: []string{"job"}},
+		},
+		{
+			name:    "missing jobs",
+			mapping: CloudEventMapping{Type: "type"},
+		},
+		{
+			name:    "invalid env template",
+			mapping: CloudEventMapping{Type: "type", Jobs: []string{"job"}, Envs: map[string]string{"IMAGE": "{{.Data.tag"}},
+		},
+		{
+			name: "valid postsubmit mapping",
+			mapping: CloudEventMapping{Type: "type", JobType: prowapi.PostsubmitJob, Jobs: []string{"job"},
+				Refs: &CloudEventRefs{Org: "org", Repo: "repo", BaseRef: "main", BaseSHA: "{{.Data.sha}}"}},
+			successExpected: true,
+		},
+		{
+			name: "valid presubmit mapping",
+			mapping: CloudEventMapping{Type: "type", JobType: prowapi.PresubmitJob, Jobs: []string{"job"},
+				Refs: &CloudEventRefs{Org: "org", Repo: "repo", BaseRef: "main", BaseSHA: "abc", PullNumber: "{{.Data.number}}", PullSHA: "{{.Data.sha}}"}},
+			successExpected: true,
+		},
+		{
+			name:    "postsubmit without refs",
+			mapping: CloudEventMapping{Type: "type", JobType: prowapi.PostsubmitJob, Jobs: []string{"job"}},
+		},
+		{
+			name: "presubmit without pull",
+			mapping: CloudEventMapping{Type: "type", JobType: prowapi.PresubmitJob, Jobs: []string{"job"},
+				Refs: &CloudEventRefs{Org: "org", Repo: "repo", BaseRef: "main", BaseSHA: "abc"}},
+		},
+		{
+			name: "invalid refs template",
+			mapping: CloudEventMapping{Type: "type", JobType: prowapi.PostsubmitJob, Jobs: []string{"job"},
+				Refs: &CloudEventRefs{Org: "org", Repo: "repo", BaseRef: "main", BaseSHA: "{{.Data.sha"}},
+		},
+		{
+			name: "periodic with refs",
+			mapping: CloudEventMapping{Type: "type", Jobs: []string{"job"},
+				Refs: &CloudEventRefs{Org: "org", Repo: "repo", BaseRef: "main", BaseSHA: "abc"}},
+		},
+		{
+			name:    "unsupported job type",
+			mapping: CloudEventMapping{Type: "type", JobType: prowapi.BatchJob, Jobs: []string{"job"}},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := Config{ProwConfig: ProwConfig{PubSubTriggers: PubSubTriggers{{Project: "project", CloudEvents: []CloudEventMapping{tc.mapping}}}}}
+			if err := cfg.validateComponentConfig(); (err == nil) != tc.successExpected {
+				t.Errorf("expected success %t, got error %v", tc.successExpected, err)
+			}
+		})
+	}
+
+	auth := &CloudEventAuthConfig{Sources: []string{"//example.com/*"}, Subjects: []string{"images/allowed"}}
+	for _, tc := range []struct {
+		auth     *CloudEventAuthConfig
+		source   string
+		subject  string
+		expected bool
+	}{
+		{auth: nil, source: "//example.com/a"},
+		{auth: &CloudEventAuthConfig{AllowAnyone: true}, source: "//other.com", expected: true},
+		{auth: auth, source: "//example.com/a", expected: true},
+		{auth: auth, source: "//other.com", subject: "images/allowed", expected: true},
+		{auth: auth, source: "//other.com", subject: "images/allowed-not"},
+		{auth: auth, source: "//example.com"},
+	} {
+		if actual := tc.auth.IsAuthorized(tc.source, tc.subject); actual != tc.expected {
+			t.Errorf("%+v: expected %s (%s) to be authorized %t, got %t", tc.auth, tc.source, tc.subject, tc.expected, actual)
+		}
+	}
+}
 func TestManagedHmacEntityValidation(t *testing.T) {
 	testCases := []struct {
 		name       string
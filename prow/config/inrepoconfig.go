@@ -25,10 +25,13 @@ import (
 	"path/filepath"
 	"strings"
 	"sync"
+	"time"
 
 	gitignore "github.com/denormal/go-gitignore"
 	"github.com/sirupsen/logrus"
+	"gopkg.in/robfig/cron.v2"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
 	gerritsource "k8s.io/test-infra/prow/gerrit/source"
 
 	"k8s.io/test-infra/prow/git/types"
@@ -44,11 +47,14 @@ const (
 // +k8s:deepcopy-gen=true
 
 // ProwYAML represents the content of a .prow.yaml file
-// used to version Presubmits and Postsubmits inside the tested repo.
+// used to version Presubmits, Postsubmits and Periodics inside the tested repo.
 type ProwYAML struct {
 	Presets     []Preset     `json:"presets"`
 	Presubmits  []Presubmit  `json:"presubmits"`
 	Postsubmits []Postsubmit `json:"postsubmits"`
+	// Periodics are only read from the default branch of the repo and only
+	// if in-repo periodics are enabled for it.
+	Periodics []Periodic `json:"periodics,omitempty"`
 
 	// ProwIgnored is a well known, unparsed field where non-Prow fields can
 	// be defined without conflicting with unknown field validation.
@@ -156,6 +162,7 @@ func ReadProwYAML(log *logrus.Entry, dir string, strict bool) (*ProwYAML, error)
 			c.Presets = append(a.Presets, b.Presets...)
 			c.Presubmits = append(a.Presubmits, b.Presubmits...)
 			c.Postsubmits = append(a.Postsubmits, b.Postsubmits...)
+			c.Periodics = append(a.Periodics, b.Periodics...)
 
 			return c
 		}
@@ -248,6 +255,10 @@ func DefaultAndValidateProwYAML(c *Config, p *ProwYAML, identifier string) error
 		return err
 	}
 
+	if err := defaultAndValidateInRepoPeriodics(c, p, identifier); err != nil {
+		return err
+	}
+
 	var errs []error
 	for _, pre := range p.Presubmits {
 		if !c.InRepoConfigAllowsCluster(pre.Cluster, identifier) {
@@ -262,12 +273,96 @@ func DefaultAndValidateProwYAML(c *Config, p *ProwYAML, identifier string) error
 
 	if len(errs) == 0 {
 		log := logrus.WithField("repo", identifier)
-		log.Debugf("Successfully got %d presubmits and %d postsubmits.", len(p.Presubmits), len(p.Postsubmits))
+		log.Debugf("Successfully got %d presubmits, %d postsubmits and %d periodics.", len(p.Presubmits), len(p.Postsubmits), len(p.Periodics))
 	}
 
 	return utilerrors.NewAggregate(errs)
 }
 
+// defaultAndValidateInRepoPeriodics defaults the periodics of an in-repo config
+// and makes sure they stay within the limits the central config sets for them.
+// Periodics of repos that don't have them enabled are dropped.
+func defaultAndValidateInRepoPeriodics(c *Config, p *ProwYAML, identifier string) error {
+	if len(p.Periodics) == 0 {
+		return nil
+	}
+	if !c.InRepoPeriodicsEnabled(identifier) {
+		logrus.WithField("repo", identifier).Debugf("Ignoring %d periodics as in-repo periodics are not enabled.", len(p.Periodics))
+		p.Periodics = nil
+		return nil
+	}
+
+	limits := c.InRepoConfig.Periodics
+	if max := limits.GetMaxJobsPerRepo(); len(p.Periodics) > max {
+		return fmt.Errorf("repository %q defines %d periodics, at most %d are allowed", identifier, len(p.Periodics), max)
+	}
+	if err := defaultInRepoPeriodics(p.Periodics, p.Presets, c); err != nil {
+		return err
+	}
+	if err := c.validatePeriodics(p.Periodics); err != nil {
+		return err
+	}
+
+	orgRepo := *NewOrgRepo(identifier)
+	centralPeriodics := sets.NewString()
+	for _, periodic := range c.Periodics {
+		centralPeriodics.Insert(periodic.Name)
+	}
+	minimumInterval := limits.GetMinimumInterval()
+	var errs []error
+	for _, periodic := range p.Periodics {
+		if centralPeriodics.Has(periodic.Name) {
+			errs = append(errs, fmt.Errorf("duplicated periodic job: %s", periodic.Name))
+		}
+		if refs := periodic.ExtraRefs; len(refs) == 0 || refs[0].Org != orgRepo.Org || refs[0].Repo != orgRepo.Repo {
+			errs = append(errs, fmt.Errorf("periodic %s must have %q as its first extra_ref", periodic.Name, identifier))
+		}
+		if !c.InRepoConfigAllowsCluster(periodic.Cluster, identifier) {
+			errs = append(errs, fmt.Errorf("cluster %q is not allowed for repository %q", periodic.Cluster, identifier))
+		}
+		if interval := periodicInterval(periodic, minimumInterval); interval < minimumInterval {
+			errs = append(errs, fmt.Errorf("periodic %s runs every %s, the minimum interval for in-repo periodics is %s", periodic.Name, interval, minimumInterval))
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// periodicInterval returns the shortest period between two runs of an already
+// validated periodic. For cron schedules, this is the smallest gap between
+// activations in the next week, or the first gap found below floor.
+func periodicInterval(p Periodic, floor time.Duration) time.Duration {
+	switch {
+	case p.Interval != "":
+		return p.GetInterval()
+	case p.MinimumInterval != "":
+		return p.GetMinimumInterval()
+	}
+	schedule, err := cron.Parse(p.Cron)
+	if err != nil {
+		return 0
+	}
+	var shortest time.Duration
+	start := time.Now().UTC()
+	for prev := schedule.Next(start); !prev.IsZero() && prev.Before(start.Add(7*24*time.Hour)); {
+		next := schedule.Next(prev)
+		if next.IsZero() {
+			break
+		}
+		if gap := next.Sub(prev); shortest == 0 || gap < shortest {
+			shortest = gap
+		}
+		if shortest < floor {
+			break
+		}
+		prev = next
+	}
+	if shortest == 0 {
+		// Fewer than two runs a week.
+		shortest = 7 * 24 * time.Hour
+	}
+	return shortest
+}
+
 // InRepoConfigGitCache is a wrapper around a git.ClientFactory that allows for
 // threadsafe reuse of git.RepoClients when one already exists for the specified repo.
 type InRepoConfigGitCache struct {
@@ -22,8 +22,14 @@ import (
 	"os"
 	"path"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	prowapi "k8s.io/test-infra/prow/apis/prowjobs/v1"
 	"k8s.io/test-infra/prow/git/localgit"
 	"k8s.io/test-infra/prow/git/v2"
 	"k8s.io/test-infra/prow/kube"
@@ -819,3 +825,239 @@ func TestInRepoConfigClean(t *testing.T) {
 		t.Fatalf("%s should have been deleted", f)
 	}
 }
+
+func TestDefaultAndValidateInRepoPeriodics(t *testing.T) {
+	identifier := "org/repo"
+	enabled := true
+	periodic := func(name string, mutate ...func(*Periodic)) Periodic {
+		p := Periodic{
+			JobBase: JobBase{
+				Name: name,
+				Spec: &v1.PodSpec{Containers: []v1.Container{{}}},
+				UtilityConfig: UtilityConfig{
+					ExtraRefs: []prowapi.Refs{{Org: "org", Repo: "repo", BaseRef: "main"}},
+				},
+			},
+			Interval: "2h",
+		}
+		for _, m := range mutate {
+			m(&p)
+		}
+		return p
+	}
+	testCases := []struct {
+		name          string
+		periodics     []Periodic
+		presets       []Preset
+		disabled      bool
+		limits        InRepoConfigPeriodics
+		central       []Periodic
+		expectedErr   string
+		expectedCount int
+	}{
+		{
+			name:          "valid periodics are kept",
+			periodics:     []Periodic{periodic("a"), periodic("b", func(p *Periodic) { p.Interval, p.Cron = "", "0 */2 * * *" })},
+			expectedCount: 2,
+		},
+		{
+			name:      "periodics are dropped when not enabled for the repo",
+			periodics: []Periodic{periodic("a", func(p *Periodic) { p.Interval = "1m" })},
+			disabled:  true,
+		},
+		{
+			name:        "too many periodics",
+			periodics:   []Periodic{periodic("a"), periodic("b")},
+			limits:      InRepoConfigPeriodics{MaxJobsPerRepo: 1},
+			expectedErr: `repository "org/repo" defines 2 periodics, at most 1 are allowed`,
+		},
+		{
+			name:        "interval below the default minimum",
+			periodics:   []Periodic{periodic("a", func(p *Periodic) { p.Interval = "30m" })},
+			expectedErr: "periodic a runs every 30m0s, the minimum interval for in-repo periodics is 1h0m0s",
+		},
+		{
+			name:          "interval allowed by a configured minimum",
+			periodics:     []Periodic{periodic("a", func(p *Periodic) { p.Interval = "30m" })},
+			limits:        InRepoConfigPeriodics{MinimumInterval: &metav1.Duration{Duration: 15 * time.Minute}},
+			expectedCount: 1,
+		},
+		{
+			name:        "cron below the minimum",
+			periodics:   []Periodic{periodic("a", func(p *Periodic) { p.Interval, p.Cron = "", "0,30 9 * * *" })},
+			expectedErr: "periodic a runs every 30m0s, the minimum interval for in-repo periodics is 1h0m0s",
+		},
+		{
+			name:        "first extra ref must be the repo itself",
+			periodics:   []Periodic{periodic("a", func(p *Periodic) { p.ExtraRefs[0].Repo = "other" })},
+			expectedErr: `periodic a must have "org/repo" as its first extra_ref`,
+		},
+		{
+			name:        "cluster must be allowed",
+			periodics:   []Periodic{periodic("a", func(p *Periodic) { p.Cluster = "trusted" })},
+			expectedErr: `cluster "trusted" is not allowed for repository "org/repo"`,
+		},
+		{
+			name:        "name must not clash with central periodics",
+			periodics:   []Periodic{periodic("a")},
+			central:     []Periodic{periodic("a")},
+			expectedErr: "duplicated periodic job: a",
+		},
+		{
+			name: "in-repo presets are applied",
+			periodics: []Periodic{periodic("a", func(p *Periodic) {
+				p.Labels = map[string]string{"preset-foo": "true"}
+			})},
+			presets:       []Preset{{Labels: map[string]string{"preset-foo": "true"}, Env: []v1.EnvVar{{Name: "FOO", Value: "bar"}}}},
+			expectedCount: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := &Config{
+				JobConfig: JobConfig{Periodics: tc.central},
+				ProwConfig: ProwConfig{
+					PodNamespace: "my-ns",
+					InRepoConfig: InRepoConfig{
+						Enabled:         map[string]*bool{"*": &enabled},
+						AllowedClusters: map[string][]string{"*": {kube.DefaultClusterAlias}},
+						Periodics:       &tc.limits,
+					},
+				},
+			}
+			if !tc.disabled {
+				c.InRepoConfig.Periodics.Enabled = map[string]*bool{identifier: &enabled}
+			}
+			p := &ProwYAML{Presets: tc.presets, Periodics: tc.periodics}
+
+			err := defaultAndValidateInRepoPeriodics(c, p, identifier)
+			if tc.expectedErr != "" {
+				if err == nil || err.Error() != tc.expectedErr {
+					t.Fatalf("expected error %q, got %v", tc.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(p.Periodics) != tc.expectedCount {
+				t.Fatalf("expected %d periodics, got %d", tc.expectedCount, len(p.Periodics))
+			}
+			for _, periodic := range p.Periodics {
+				if periodic.Cluster != kube.DefaultClusterAlias {
+					t.Errorf("expected periodic %s to be defaulted to cluster %q, got %q", periodic.Name, kube.DefaultClusterAlias, periodic.Cluster)
+				}
+				if len(tc.presets) > 0 && len(periodic.Spec.Containers[0].Env) != 1 {
+					t.Errorf("expected presets to be applied to periodic %s, got env %v", periodic.Name, periodic.Spec.Containers[0].Env)
+				}
+			}
+		})
+	}
+}
+
+func TestGetInRepoPeriodics(t *testing.T) {
+	lg, gc, err := localgit.NewV2()
+	if err != nil {
+		t.Fatalf("Making local git repo: %v", err)
+	}
+	defer func() {
+		if err := lg.Clean(); err != nil {
+			t.Errorf("Error cleaning LocalGit: %v", err)
+		}
+		if err := gc.Clean(); err != nil {
+			t.Errorf("Error cleaning Client: %v", err)
+		}
+	}()
+	if err := lg.MakeFakeRepo("org", "repo"); err != nil {
+		t.Fatalf("Making fake repo: %v", err)
+	}
+	if err := lg.AddCommit("org", "repo", map[string][]byte{
+		".prow.yaml": []byte(`periodics: [{"name": "nightly", "interval": "24h", "extra_refs": [{"org": "org", "repo": "repo", "base_ref": "` + defaultBranch + `"}], "spec": {"containers": [{}]}}]`),
+	}); err != nil {
+		t.Fatalf("failed to commit: %v", err)
+	}
+	// Changes on other branches must not be picked up.
+	if err := lg.CheckoutNewBranch("org", "repo", "feature"); err != nil {
+		t.Fatalf("failed to create new branch: %v", err)
+	}
+	if err := lg.AddCommit("org", "repo", map[string][]byte{".prow.yaml": []byte(`periodics: []`)}); err != nil {
+		t.Fatalf("failed to commit: %v", err)
+	}
+	if err := lg.Checkout("org", "repo", defaultBranch); err != nil {
+		t.Fatalf("failed to checkout default branch: %v", err)
+	}
+
+	enabled := true
+	c := &Config{
+		JobConfig: JobConfig{ProwYAMLGetterWithDefaults: prowYAMLGetterWithDefaults},
+		ProwConfig: ProwConfig{
+			PodNamespace: "my-ns",
+			InRepoConfig: InRepoConfig{
+				Enabled:         map[string]*bool{"org/repo": &enabled},
+				AllowedClusters: map[string][]string{"*": {kube.DefaultClusterAlias}},
+				Periodics:       &InRepoConfigPeriodics{Enabled: map[string]*bool{"org": &enabled}},
+			},
+		},
+	}
+
+	for _, client := range []git.ClientFactory{gc, NewInRepoConfigGitCache(gc)} {
+		periodics, err := c.GetInRepoPeriodics(client, "org/repo")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(periodics) != 1 || periodics[0].Name != "nightly" {
+			t.Fatalf("expected the nightly periodic, got %v", periodics)
+		}
+		if periodics[0].GetInterval() != 24*time.Hour {
+			t.Errorf("expected the interval to be set, got %s", periodics[0].GetInterval())
+		}
+	}
+
+	periodics, err := c.GetInRepoPeriodics(gc, "org/other")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(periodics) != 0 {
+		t.Errorf("expected no periodics for a repo without in-repo config, got %v", periodics)
+	}
+}
+
+func TestInRepoPeriodicsOrgs(t *testing.T) {
+	enabled, disabled := true, false
+	testCases := []struct {
+		name     string
+		config   InRepoConfig
+		expected []string
+	}{
+		{
+			name: "periodics not configured",
+		},
+		{
+			name: "orgs of org and repo keys",
+			config: InRepoConfig{Periodics: &InRepoConfigPeriodics{Enabled: map[string]*bool{
+				"org":         &enabled,
+				"other/repo":  &enabled,
+				"org/ignored": &disabled,
+			}}},
+			expected: []string{"org", "other"},
+		},
+		{
+			name: "global enablement covers all known orgs",
+			config: InRepoConfig{
+				Enabled:   map[string]*bool{"*": &enabled, "inrepo": &enabled},
+				Periodics: &InRepoConfigPeriodics{Enabled: map[string]*bool{"*": &enabled}},
+			},
+			expected: []string{"central", "inrepo"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := &Config{ProwConfig: ProwConfig{InRepoConfig: tc.config}}
+			c.AllRepos = sets.NewString("central/repo")
+			if diff := cmp.Diff(tc.expected, c.InRepoPeriodicsOrgs()); diff != "" {
+				t.Errorf("unexpected orgs (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -114,6 +114,8 @@ type JobBase struct {
 	Spec *v1.PodSpec `json:"spec,omitempty"`
 	// PipelineRunSpec is the tekton pipeline spec used if Agent is tekton-pipeline.
 	PipelineRunSpec *pipelinev1alpha1.PipelineRunSpec `json:"pipeline_run_spec,omitempty"`
+	// BuildSpec is the Google Cloud Build build used if Agent is cloud-build.
+	BuildSpec *prowapi.CloudBuildSpec `json:"build_spec,omitempty"`
 	// Annotations are unused by prow itself, but provide a space to configure other automation.
 	Annotations map[string]string `json:"annotations,omitempty"`
 	// ReporterConfig provides the option to configure reporting on job level
@@ -239,6 +241,8 @@ type Postsubmit struct {
 	JenkinsSpec *JenkinsSpec `json:"jenkins_spec,omitempty"`
 }
 
+// +k8s:deepcopy-gen=true
+
 // Periodic runs on a timer.
 type Periodic struct {
 	JobBase
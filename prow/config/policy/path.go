@@ -0,0 +1,164 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// value is found at a concrete path, eg "spec.containers[0].image".
+type value struct {
+	path string
+	v    interface{}
+}
+
+// failure is a value that does not satisfy a condition.
+type failure struct {
+	path    string
+	message string
+}
+
+// lookup returns the values found at the path in an object as produced by
+// toGeneric.
+func lookup(obj interface{}, path string) []value {
+	current := []value{{v: obj}}
+	for _, segment := range strings.Split(path, ".") {
+		var next []value
+		for _, c := range current {
+			switch typed := c.v.(type) {
+			case map[string]interface{}:
+				if segment != "*" {
+					if v, ok := typed[segment]; ok && v != nil {
+						next = append(next, value{path: field(c.path, segment), v: v})
+					}
+					continue
+				}
+				var keys []string
+				for key := range typed {
+					keys = append(keys, key)
+				}
+				sort.Strings(keys)
+				for _, key := range keys {
+					if typed[key] != nil {
+						next = append(next, value{path: fmt.Sprintf("%s[%s]", c.path, key), v: typed[key]})
+					}
+				}
+			case []interface{}:
+				for i, v := range typed {
+					if v == nil || (segment != "*" && segment != strconv.Itoa(i)) {
+						continue
+					}
+					next = append(next, value{path: fmt.Sprintf("%s[%d]", c.path, i), v: v})
+				}
+			}
+		}
+		current = next
+	}
+	return current
+}
+
+func field(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// evaluate returns the values at the path of the condition that don't
+// satisfy it.
+func (c *Condition) evaluate(obj interface{}) []failure {
+	values := lookup(obj, c.Path)
+	if c.Exists != nil {
+		switch {
+		case *c.Exists && len(values) == 0:
+			return []failure{{path: c.Path, message: "must be set"}}
+		case !*c.Exists && len(values) > 0:
+			return []failure{{path: values[0].path, message: "must not be set"}}
+		}
+		return nil
+	}
+	var failures []failure
+	for _, v := range values {
+		if msg := c.check(v.v); msg != "" {
+			failures = append(failures, failure{path: v.path, message: fmt.Sprintf("is %s, %s", format(v.v), msg)})
+		}
+	}
+	return failures
+}
+
+// check returns why the value doesn't satisfy the condition, or nothing if
+// it does.
+func (c *Condition) check(v interface{}) string {
+	switch {
+	case c.Equals != nil:
+		if !reflect.DeepEqual(v, c.Equals) {
+			return fmt.Sprintf("must be %s", format(c.Equals))
+		}
+	case c.NotEquals != nil:
+		if reflect.DeepEqual(v, c.NotEquals) {
+			return fmt.Sprintf("must not be %s", format(c.NotEquals))
+		}
+	case c.In != nil:
+		for _, allowed := range c.In {
+			if reflect.DeepEqual(v, allowed) {
+				return ""
+			}
+		}
+		return fmt.Sprintf("must be one of %s", format(c.In))
+	case c.NotIn != nil:
+		for _, forbidden := range c.NotIn {
+			if reflect.DeepEqual(v, forbidden) {
+				return fmt.Sprintf("must not be one of %s", format(c.NotIn))
+			}
+		}
+	case c.matches != nil:
+		s, ok := v.(string)
+		if !ok || !c.matches.MatchString(s) {
+			return fmt.Sprintf("must match %q", c.Matches)
+		}
+	case c.MaxDuration != "" || c.MinDuration != "":
+		s, ok := v.(string)
+		if !ok {
+			return "must be a duration"
+		}
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return "must be a duration"
+		}
+		if c.MaxDuration != "" && d > c.maxDuration {
+			return fmt.Sprintf("must be at most %s", c.maxDuration)
+		}
+		if c.MinDuration != "" && d < c.minDuration {
+			return fmt.Sprintf("must be at least %s", c.minDuration)
+		}
+	}
+	return ""
+}
+
+func format(v interface{}) string {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(raw)
+}
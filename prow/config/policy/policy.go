@@ -0,0 +1,378 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package policy evaluates declarative rules against the Prow config and the
+// plugin config. A policy file looks like:
+//
+//	rules:
+//	- name: presubmit-timeout
+//	  description: Presubmits of kubernetes/kubernetes must finish within 2h.
+//	  severity: error
+//	  target: presubmits
+//	  repos:
+//	  - kubernetes/kubernetes
+//	  require:
+//	  - path: decoration_config.timeout
+//	    max_duration: 2h
+//	- name: privileged-jobs
+//	  severity: warn
+//	  target: jobs
+//	  when:
+//	  - path: cluster
+//	    not_in: [trusted]
+//	  require:
+//	  - path: spec.containers.*.securityContext.privileged
+//	    not_equals: true
+//
+// Paths refer to the fields as they are written in the config files, after
+// defaulting. A "*" selects all elements of a list or all values of a map.
+package policy
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/yaml"
+
+	"k8s.io/test-infra/prow/config"
+	"k8s.io/test-infra/prow/plugins"
+)
+
+// Severity tells how bad it is to violate a rule.
+type Severity string
+
+const (
+	// SeverityError fails the validation.
+	SeverityError Severity = "error"
+	// SeverityWarn only produces a warning.
+	SeverityWarn Severity = "warn"
+)
+
+// Target is the kind of object a rule applies to.
+type Target string
+
+const (
+	TargetPresubmits   Target = "presubmits"
+	TargetPostsubmits  Target = "postsubmits"
+	TargetPeriodics    Target = "periodics"
+	TargetJobs         Target = "jobs"
+	TargetProwConfig   Target = "prow-config"
+	TargetPluginConfig Target = "plugin-config"
+)
+
+// Policy is a set of rules.
+type Policy struct {
+	Rules []Rule `json:"rules"`
+}
+
+// Rule requires all objects of its target that match its When conditions
+// to satisfy its Require conditions.
+type Rule struct {
+	// Name identifies the rule in violations.
+	Name string `json:"name"`
+	// Description is added to violations to tell users what to do.
+	Description string `json:"description,omitempty"`
+	// Severity defaults to error.
+	Severity Severity `json:"severity,omitempty"`
+	// Target is one of presubmits, postsubmits, periodics, jobs (all of
+	// them), prow-config or plugin-config.
+	Target Target `json:"target"`
+	// Repos limits presubmits and postsubmits to those of the given orgs or
+	// org/repos.
+	Repos []string `json:"repos,omitempty"`
+	// When selects the objects the rule applies to, all if empty.
+	When []Condition `json:"when,omitempty"`
+	// Require are the conditions every selected object must satisfy.
+	Require []Condition `json:"require"`
+}
+
+// Condition checks the values found at a path. Exactly one check must be set.
+// Apart from Exists, checks hold if the path yields no values.
+type Condition struct {
+	Path string `json:"path"`
+
+	Exists      *bool         `json:"exists,omitempty"`
+	Equals      interface{}   `json:"equals,omitempty"`
+	NotEquals   interface{}   `json:"not_equals,omitempty"`
+	In          []interface{} `json:"in,omitempty"`
+	NotIn       []interface{} `json:"not_in,omitempty"`
+	Matches     string        `json:"matches,omitempty"`
+	MaxDuration string        `json:"max_duration,omitempty"`
+	MinDuration string        `json:"min_duration,omitempty"`
+
+	matches     *regexp.Regexp
+	maxDuration time.Duration
+	minDuration time.Duration
+}
+
+// Violation is an object that does not satisfy a rule.
+type Violation struct {
+	Rule     string
+	Severity Severity
+	// Location names the object, eg the job and the file it is defined in.
+	Location string
+	// Path is where in the object the offending value was found.
+	Path    string
+	Message string
+	// Description of the rule.
+	Description string
+}
+
+func (v Violation) Error() string {
+	msg := fmt.Sprintf("%s: %s", v.Location, v.Message)
+	if v.Path != "" {
+		msg = fmt.Sprintf("%s: %s %s", v.Location, v.Path, v.Message)
+	}
+	if v.Description != "" {
+		msg = fmt.Sprintf("%s: %s", msg, v.Description)
+	}
+	return fmt.Sprintf("%s (policy %q, severity %s)", msg, v.Rule, v.Severity)
+}
+
+// Load reads and validates a policy file.
+func Load(path string) (*Policy, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	var p Policy
+	if err := yaml.UnmarshalStrict(raw, &p); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if err := p.DefaultAndValidate(); err != nil {
+		return nil, fmt.Errorf("invalid policy %s: %w", path, err)
+	}
+	return &p, nil
+}
+
+// DefaultAndValidate defaults the rules and compiles their conditions.
+func (p *Policy) DefaultAndValidate() error {
+	var errs []error
+	names := sets.NewString()
+	for i := range p.Rules {
+		r := &p.Rules[i]
+		if r.Name == "" {
+			errs = append(errs, fmt.Errorf("rule %d has no name", i))
+		} else if names.Has(r.Name) {
+			errs = append(errs, fmt.Errorf("rule %q is defined more than once", r.Name))
+		}
+		names.Insert(r.Name)
+		if r.Severity == "" {
+			r.Severity = SeverityError
+		}
+		if r.Severity != SeverityError && r.Severity != SeverityWarn {
+			errs = append(errs, fmt.Errorf("rule %q: severity must be %q or %q, not %q", r.Name, SeverityError, SeverityWarn, r.Severity))
+		}
+		switch r.Target {
+		case TargetPresubmits, TargetPostsubmits:
+		case TargetPeriodics, TargetJobs, TargetProwConfig, TargetPluginConfig:
+			if len(r.Repos) > 0 {
+				errs = append(errs, fmt.Errorf("rule %q: repos can only be used with presubmits and postsubmits", r.Name))
+			}
+		default:
+			errs = append(errs, fmt.Errorf("rule %q: unknown target %q", r.Name, r.Target))
+		}
+		if len(r.Require) == 0 {
+			errs = append(errs, fmt.Errorf("rule %q: require must not be empty", r.Name))
+		}
+		for j := range r.When {
+			if err := r.When[j].compile(); err != nil {
+				errs = append(errs, fmt.Errorf("rule %q: when[%d]: %w", r.Name, j, err))
+			}
+		}
+		for j := range r.Require {
+			if err := r.Require[j].compile(); err != nil {
+				errs = append(errs, fmt.Errorf("rule %q: require[%d]: %w", r.Name, j, err))
+			}
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+func (c *Condition) compile() error {
+	if c.Path == "" {
+		return errors.New("path must be set")
+	}
+	var checks int
+	for _, set := range []bool{c.Exists != nil, c.Equals != nil, c.NotEquals != nil, c.In != nil, c.NotIn != nil, c.Matches != "", c.MaxDuration != "", c.MinDuration != ""} {
+		if set {
+			checks++
+		}
+	}
+	if checks != 1 {
+		return fmt.Errorf("exactly one check must be set for %s, got %d", c.Path, checks)
+	}
+	var err error
+	if c.Matches != "" {
+		if c.matches, err = regexp.Compile(c.Matches); err != nil {
+			return fmt.Errorf("invalid regular expression for %s: %w", c.Path, err)
+		}
+	}
+	if c.MaxDuration != "" {
+		if c.maxDuration, err = time.ParseDuration(c.MaxDuration); err != nil {
+			return fmt.Errorf("invalid max_duration for %s: %w", c.Path, err)
+		}
+	}
+	if c.MinDuration != "" {
+		if c.minDuration, err = time.ParseDuration(c.MinDuration); err != nil {
+			return fmt.Errorf("invalid min_duration for %s: %w", c.Path, err)
+		}
+	}
+	return nil
+}
+
+// object is something a rule can be evaluated against.
+type object struct {
+	location string
+	// repo is the org/repo of presubmits and postsubmits.
+	repo  string
+	value interface{}
+}
+
+// Evaluate returns the violations of the policy. The plugin config may be
+// nil, in which case rules targeting it are skipped.
+func (p *Policy) Evaluate(cfg *config.Config, pcfg *plugins.Configuration) ([]Violation, error) {
+	objects, err := targets(cfg, pcfg)
+	if err != nil {
+		return nil, err
+	}
+	var violations []Violation
+	for _, r := range p.Rules {
+		var candidates []object
+		switch r.Target {
+		case TargetJobs:
+			candidates = append(append(append(candidates, objects[TargetPresubmits]...), objects[TargetPostsubmits]...), objects[TargetPeriodics]...)
+		default:
+			candidates = objects[r.Target]
+		}
+		for _, o := range candidates {
+			if !r.appliesTo(o) {
+				continue
+			}
+			for _, c := range r.Require {
+				for _, f := range c.evaluate(o.value) {
+					violations = append(violations, Violation{
+						Rule:        r.Name,
+						Severity:    r.Severity,
+						Location:    o.location,
+						Path:        f.path,
+						Message:     f.message,
+						Description: r.Description,
+					})
+				}
+			}
+		}
+	}
+	return violations, nil
+}
+
+func (r *Rule) appliesTo(o object) bool {
+	if len(r.Repos) > 0 {
+		org := strings.Split(o.repo, "/")[0]
+		if !sets.NewString(r.Repos...).HasAny(o.repo, org) {
+			return false
+		}
+	}
+	for _, c := range r.When {
+		if len(c.evaluate(o.value)) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// targets converts everything rules may apply to into the form they are
+// written in the config files.
+func targets(cfg *config.Config, pcfg *plugins.Configuration) (map[Target][]object, error) {
+	objects := map[Target][]object{}
+	add := func(target Target, location, repo string, v interface{}) error {
+		generic, err := toGeneric(v)
+		if err != nil {
+			return fmt.Errorf("%s: %w", location, err)
+		}
+		objects[target] = append(objects[target], object{location: location, repo: repo, value: generic})
+		return nil
+	}
+	sourced := func(kind, name, repo, source string) string {
+		location := fmt.Sprintf("%s %q", kind, name)
+		if repo != "" {
+			location = fmt.Sprintf("%s of %s", location, repo)
+		}
+		if source != "" {
+			location = fmt.Sprintf("%s (%s)", location, source)
+		}
+		return location
+	}
+
+	var repos []string
+	for repo := range cfg.PresubmitsStatic {
+		repos = append(repos, repo)
+	}
+	sort.Strings(repos)
+	for _, repo := range repos {
+		for _, job := range cfg.PresubmitsStatic[repo] {
+			if err := add(TargetPresubmits, sourced("presubmit", job.Name, repo, job.SourcePath), repo, job); err != nil {
+				return nil, err
+			}
+		}
+	}
+	repos = nil
+	for repo := range cfg.PostsubmitsStatic {
+		repos = append(repos, repo)
+	}
+	sort.Strings(repos)
+	for _, repo := range repos {
+		for _, job := range cfg.PostsubmitsStatic[repo] {
+			if err := add(TargetPostsubmits, sourced("postsubmit", job.Name, repo, job.SourcePath), repo, job); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for _, job := range cfg.Periodics {
+		if err := add(TargetPeriodics, sourced("periodic", job.Name, "", job.SourcePath), "", job); err != nil {
+			return nil, err
+		}
+	}
+	if err := add(TargetProwConfig, "prow config", "", cfg.ProwConfig); err != nil {
+		return nil, err
+	}
+	if pcfg != nil {
+		if err := add(TargetPluginConfig, "plugin config", "", pcfg); err != nil {
+			return nil, err
+		}
+	}
+	return objects, nil
+}
+
+func toGeneric(v interface{}) (interface{}, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var generic interface{}
+	if err := json.Unmarshal(raw, &generic); err != nil {
+		return nil, err
+	}
+	return generic, nil
+}
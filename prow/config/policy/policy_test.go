@@ -0,0 +1,276 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	prowapi "k8s.io/test-infra/prow/apis/prowjobs/v1"
+	"k8s.io/test-infra/prow/config"
+	"k8s.io/test-infra/prow/plugins"
+)
+
+func boolPtr(b bool) *bool {
+	return &b
+}
+
+func TestLoad(t *testing.T) {
+	testCases := []struct {
+		name        string
+		policy      string
+		expectedErr string
+	}{
+		{
+			name: "valid policy",
+			policy: `rules:
+- name: timeout
+  target: presubmits
+  repos: [org/repo]
+  require:
+  - path: decoration_config.timeout
+    max_duration: 2h
+`,
+		},
+		{
+			name: "unknown field",
+			policy: `rules:
+- name: timeout
+  target: presubmits
+  requires: []
+`,
+			expectedErr: `unknown field "requires"`,
+		},
+		{
+			name: "invalid rules",
+			policy: `rules:
+- target: presubmits
+  severity: fatal
+  require:
+  - path: cluster
+    equals: trusted
+    not_equals: untrusted
+- name: dup
+  target: periodics
+  repos: [org]
+  require:
+  - path: name
+    matches: "("
+- name: dup
+  target: everything
+  when:
+  - path: cluster
+  require:
+  - path: decoration_config.timeout
+    min_duration: soon
+`,
+			expectedErr: `invalid policy`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "policy.yaml")
+			if err := os.WriteFile(path, []byte(tc.policy), 0644); err != nil {
+				t.Fatalf("failed to write policy: %v", err)
+			}
+			p, err := Load(path)
+			if tc.expectedErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if p.Rules[0].Severity != SeverityError {
+					t.Errorf("expected severity to default to error, got %q", p.Rules[0].Severity)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.expectedErr) {
+				t.Fatalf("expected error containing %q, got %v", tc.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestDefaultAndValidate(t *testing.T) {
+	p := Policy{Rules: []Rule{
+		{Target: TargetPresubmits, Severity: "fatal", Require: []Condition{{Path: "cluster", Equals: "trusted", NotEquals: "untrusted"}}},
+		{Name: "dup", Target: TargetPeriodics, Repos: []string{"org"}, Require: []Condition{{Path: "name", Matches: "("}}},
+		{Name: "dup", Target: "everything", When: []Condition{{Path: "cluster"}}, Require: []Condition{{Path: "timeout", MinDuration: "soon"}}},
+		{Name: "empty", Target: TargetJobs},
+	}}
+	err := p.DefaultAndValidate()
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	for _, expected := range []string{
+		`rule 0 has no name`,
+		`severity must be "error" or "warn", not "fatal"`,
+		`exactly one check must be set for cluster, got 2`,
+		`rule "dup": repos can only be used with presubmits and postsubmits`,
+		`invalid regular expression for name`,
+		`rule "dup" is defined more than once`,
+		`unknown target "everything"`,
+		`rule "dup": when[0]: exactly one check must be set for cluster, got 0`,
+		`invalid min_duration for timeout`,
+		`rule "empty": require must not be empty`,
+	} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("expected error to contain %q, got %v", expected, err)
+		}
+	}
+}
+
+func TestLookup(t *testing.T) {
+	obj := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"containers": []interface{}{
+				map[string]interface{}{"image": "a"},
+				map[string]interface{}{"image": "b", "securityContext": map[string]interface{}{"privileged": true}},
+			},
+		},
+		"labels": map[string]interface{}{"z": "1", "a.b": "2", "empty": nil},
+	}
+	testCases := []struct {
+		path     string
+		expected []value
+	}{
+		{
+			path:     "spec.containers.*.image",
+			expected: []value{{path: "spec.containers[0].image", v: "a"}, {path: "spec.containers[1].image", v: "b"}},
+		},
+		{
+			path:     "spec.containers.1.securityContext.privileged",
+			expected: []value{{path: "spec.containers[1].securityContext.privileged", v: true}},
+		},
+		{
+			path:     "labels.*",
+			expected: []value{{path: "labels[a.b]", v: "2"}, {path: "labels[z]", v: "1"}},
+		},
+		{
+			path: "spec.missing.*",
+		},
+		{
+			path: "labels.empty",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.path, func(t *testing.T) {
+			if diff := cmp.Diff(tc.expected, lookup(obj, tc.path), cmp.AllowUnexported(value{})); diff != "" {
+				t.Errorf("unexpected values (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	job := func(name, cluster string, timeout time.Duration, privileged bool) config.JobBase {
+		return config.JobBase{
+			Name:       name,
+			Cluster:    cluster,
+			SourcePath: "jobs/" + name + ".yaml",
+			UtilityConfig: config.UtilityConfig{
+				DecorationConfig: &prowapi.DecorationConfig{Timeout: &prowapi.Duration{Duration: timeout}},
+			},
+			Spec: &v1.PodSpec{Containers: []v1.Container{{
+				Image:           "image",
+				SecurityContext: &v1.SecurityContext{Privileged: &privileged},
+			}}},
+		}
+	}
+	cfg := &config.Config{
+		JobConfig: config.JobConfig{
+			PresubmitsStatic: map[string][]config.Presubmit{
+				"org/repo":  {{JobBase: job("pull-slow", "default", 3*time.Hour, false)}, {JobBase: job("pull-fast", "default", time.Hour, false)}},
+				"other/foo": {{JobBase: job("pull-other-slow", "default", 3*time.Hour, false)}},
+			},
+			PostsubmitsStatic: map[string][]config.Postsubmit{
+				"org/repo": {{JobBase: job("post-privileged", "default", time.Hour, true)}},
+			},
+			Periodics: []config.Periodic{
+				{JobBase: job("ci-privileged", "trusted", time.Hour, true)},
+			},
+		},
+		ProwConfig: config.ProwConfig{
+			Tide: config.Tide{SyncPeriod: &metav1.Duration{Duration: time.Minute}},
+		},
+	}
+	pcfg := &plugins.Configuration{
+		Lgtm: []plugins.Lgtm{{Repos: []string{"org"}, StickyLgtmTeam: "team"}},
+	}
+	p := Policy{Rules: []Rule{
+		{
+			Name:        "timeout",
+			Description: "Split up the job.",
+			Target:      TargetPresubmits,
+			Repos:       []string{"org"},
+			Require:     []Condition{{Path: "decoration_config.timeout", MaxDuration: "2h"}},
+		},
+		{
+			Name:     "privileged",
+			Severity: SeverityWarn,
+			Target:   TargetJobs,
+			When:     []Condition{{Path: "cluster", NotIn: []interface{}{"trusted"}}},
+			Require:  []Condition{{Path: "spec.containers.*.securityContext.privileged", NotEquals: true}},
+		},
+		{
+			Name:    "sticky-lgtm",
+			Target:  TargetPluginConfig,
+			Require: []Condition{{Path: "lgtm.*.trusted_team_for_sticky_lgtm", Exists: boolPtr(false)}},
+		},
+		{
+			Name:    "tide-sync",
+			Target:  TargetProwConfig,
+			Require: []Condition{{Path: "tide.sync_period", MinDuration: "2m"}},
+		},
+	}}
+	if err := p.DefaultAndValidate(); err != nil {
+		t.Fatalf("invalid policy: %v", err)
+	}
+
+	violations, err := p.Evaluate(cfg, pcfg)
+	if err != nil {
+		t.Fatalf("failed to evaluate: %v", err)
+	}
+	var got []string
+	for _, v := range violations {
+		got = append(got, v.Error())
+	}
+	expected := []string{
+		`presubmit "pull-slow" of org/repo (jobs/pull-slow.yaml): decoration_config.timeout is "3h0m0s", must be at most 2h0m0s: Split up the job. (policy "timeout", severity error)`,
+		`postsubmit "post-privileged" of org/repo (jobs/post-privileged.yaml): spec.containers[0].securityContext.privileged is true, must not be true (policy "privileged", severity warn)`,
+		`plugin config: lgtm[0].trusted_team_for_sticky_lgtm must not be set (policy "sticky-lgtm", severity error)`,
+		`prow config: tide.sync_period is "1m0s", must be at least 2m0s (policy "tide-sync", severity error)`,
+	}
+	if diff := cmp.Diff(expected, got); diff != "" {
+		t.Errorf("unexpected violations (-want +got):\n%s", diff)
+	}
+
+	// Rules for the plugin config are skipped without one.
+	violations, err = p.Evaluate(cfg, nil)
+	if err != nil {
+		t.Fatalf("failed to evaluate: %v", err)
+	}
+	if len(violations) != 3 {
+		t.Errorf("expected 3 violations without plugin config, got %v", violations)
+	}
+}
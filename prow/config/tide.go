@@ -19,11 +19,14 @@ package config
 import (
 	"errors"
 	"fmt"
+	"regexp"
 	"strings"
 	"sync"
 	"text/template"
+	"time"
 
 	"github.com/sirupsen/logrus"
+	"gopkg.in/robfig/cron.v2"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
@@ -100,10 +103,130 @@ type Tide struct {
 	// starting a new one requires to start new instances of all tests.
 	// Use '*' as key to set this globally. Defaults to true.
 	PrioritizeExistingBatchesMap map[string]bool `json:"prioritize_existing_batches,omitempty"`
+	// MergeFreezes are periods during which Tide does not merge into the
+	// matching branches, eg weekends, holidays or release code freezes.
+	MergeFreezes []TideMergeFreeze `json:"merge_freezes,omitempty"`
 
 	TideGitHubConfig `json:",inline"`
 }
 
+// TideMergeFreeze stops Tide from merging into the matching branches while it
+// is active, except for PRs that have one of the exempt labels. A freeze is
+// either recurring, with Cron and Duration, or one-off, with Start and End.
+type TideMergeFreeze struct {
+	// Name identifies the freeze in the Tide status context and on the Tide
+	// dashboard, eg "v1.25 code freeze".
+	Name string `json:"name"`
+	// Repos are the orgs or org/repos the freeze applies to. Defaults to all.
+	Repos []string `json:"repos,omitempty"`
+	// Branches are regular expressions of the branches the freeze applies to.
+	// Defaults to all.
+	Branches []string `json:"branches,omitempty"`
+	// Cron starts a recurring freeze, eg "0 18 * * 5" with a duration of 62h
+	// freezes every weekend.
+	Cron string `json:"cron,omitempty"`
+	// Duration is how long each recurring freeze lasts.
+	Duration *metav1.Duration `json:"duration,omitempty"`
+	// Timezone the cron schedule is evaluated in. Defaults to UTC.
+	Timezone string `json:"timezone,omitempty"`
+	// Start of a one-off freeze.
+	Start *metav1.Time `json:"start,omitempty"`
+	// End of a one-off freeze.
+	End *metav1.Time `json:"end,omitempty"`
+	// ExemptLabels allow PRs to merge during the freeze, eg for critical
+	// fixes. On Gerrit, hashtags are used as labels.
+	ExemptLabels []string `json:"exempt_labels,omitempty"`
+
+	branches *regexp.Regexp
+	schedule cron.Schedule
+}
+
+// Validate checks the freeze and compiles its schedule.
+func (f *TideMergeFreeze) Validate() error {
+	if f.Name == "" {
+		return errors.New("name must be set")
+	}
+	if len(f.Branches) > 0 {
+		re, err := regexp.Compile(fmt.Sprintf(`^(%s)$`, strings.Join(f.Branches, "|")))
+		if err != nil {
+			return fmt.Errorf("invalid branches: %w", err)
+		}
+		f.branches = re
+	}
+	switch {
+	case f.Cron != "" && (f.Start != nil || f.End != nil):
+		return errors.New("cron and start/end are mutually exclusive")
+	case f.Cron != "":
+		if f.Duration == nil || f.Duration.Duration <= 0 {
+			return errors.New("a positive duration is required with cron")
+		}
+		timezone := f.Timezone
+		if timezone == "" {
+			timezone = "UTC"
+		}
+		if _, err := time.LoadLocation(timezone); err != nil {
+			return fmt.Errorf("invalid timezone: %w", err)
+		}
+		schedule, err := cron.Parse(fmt.Sprintf("TZ=%s %s", timezone, f.Cron))
+		if err != nil {
+			return fmt.Errorf("invalid cron %q: %w", f.Cron, err)
+		}
+		f.schedule = schedule
+	case f.Start != nil && f.End != nil:
+		if f.Duration != nil || f.Timezone != "" {
+			return errors.New("duration and timezone can only be used with cron")
+		}
+		if !f.End.After(f.Start.Time) {
+			return errors.New("end must be after start")
+		}
+	default:
+		return errors.New("either cron or start and end must be set")
+	}
+	return nil
+}
+
+// AppliesTo determines if the freeze applies to the branch of the repo.
+func (f *TideMergeFreeze) AppliesTo(org, repo, branch string) bool {
+	if len(f.Repos) > 0 && !sets.NewString(f.Repos...).HasAny(org, org+"/"+repo) {
+		return false
+	}
+	return f.branches == nil || f.branches.MatchString(branch)
+}
+
+// ActiveUntil returns when the freeze that is active at the given time ends,
+// or the zero time if it is not active.
+func (f *TideMergeFreeze) ActiveUntil(now time.Time) time.Time {
+	if f.schedule != nil {
+		// The last start of the freeze is the first one after a freeze that
+		// started then would have ended.
+		if start := f.schedule.Next(now.Add(-f.Duration.Duration)); !start.IsZero() && !start.After(now) {
+			return start.Add(f.Duration.Duration)
+		}
+		return time.Time{}
+	}
+	if f.Start != nil && f.End != nil && !now.Before(f.Start.Time) && now.Before(f.End.Time) {
+		return f.End.Time
+	}
+	return time.Time{}
+}
+
+// Exempts determines if a PR with the labels may merge during the freeze.
+func (f *TideMergeFreeze) Exempts(labels []string) bool {
+	return sets.NewString(f.ExemptLabels...).HasAny(labels...)
+}
+
+// ActiveMergeFreezes returns the freezes of the branch that are active at the
+// given time.
+func (t *Tide) ActiveMergeFreezes(org, repo, branch string, now time.Time) []TideMergeFreeze {
+	var active []TideMergeFreeze
+	for _, f := range t.MergeFreezes {
+		if f.AppliesTo(org, repo, branch) && !f.ActiveUntil(now).IsZero() {
+			active = append(active, f)
+		}
+	}
+	return active
+}
+
 // TideGitHubConfig is the tide config for GitHub.
 type TideGitHubConfig struct {
 	// StatusUpdatePeriod specifies how often Tide will update GitHub status contexts.
@@ -21,7 +21,9 @@ import (
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/diff"
 	"k8s.io/apimachinery/pkg/util/sets"
 	utilpointer "k8s.io/utils/pointer"
@@ -1099,3 +1101,167 @@ func fakeProwYAMLGetterFactory(presubmits []Presubmit, postsubmits []Postsubmit)
 		}, nil
 	}
 }
+
+func TestTideMergeFreeze_Validate(t *testing.T) {
+	start := metav1.NewTime(time.Date(2022, 12, 23, 0, 0, 0, 0, time.UTC))
+	end := metav1.NewTime(time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC))
+	testCases := []struct {
+		name        string
+		freeze      TideMergeFreeze
+		expectedErr string
+	}{
+		{
+			name:   "recurring",
+			freeze: TideMergeFreeze{Name: "weekend", Cron: "0 18 * * 5", Duration: &metav1.Duration{Duration: 62 * time.Hour}, Timezone: "Europe/Berlin"},
+		},
+		{
+			name:   "one-off",
+			freeze: TideMergeFreeze{Name: "holidays", Start: &start, End: &end, Branches: []string{"release-.*"}},
+		},
+		{
+			name:        "no name",
+			freeze:      TideMergeFreeze{Start: &start, End: &end},
+			expectedErr: "name must be set",
+		},
+		{
+			name:        "invalid branches",
+			freeze:      TideMergeFreeze{Name: "f", Start: &start, End: &end, Branches: []string{"("}},
+			expectedErr: "invalid branches",
+		},
+		{
+			name:        "cron and start",
+			freeze:      TideMergeFreeze{Name: "f", Cron: "@daily", Duration: &metav1.Duration{Duration: time.Hour}, Start: &start},
+			expectedErr: "mutually exclusive",
+		},
+		{
+			name:        "cron without duration",
+			freeze:      TideMergeFreeze{Name: "f", Cron: "@daily"},
+			expectedErr: "a positive duration is required with cron",
+		},
+		{
+			name:        "invalid cron",
+			freeze:      TideMergeFreeze{Name: "f", Cron: "0 18 *", Duration: &metav1.Duration{Duration: time.Hour}},
+			expectedErr: "invalid cron",
+		},
+		{
+			name:        "invalid timezone",
+			freeze:      TideMergeFreeze{Name: "f", Cron: "@daily", Duration: &metav1.Duration{Duration: time.Hour}, Timezone: "Mars/Olympus"},
+			expectedErr: "invalid timezone",
+		},
+		{
+			name:        "end before start",
+			freeze:      TideMergeFreeze{Name: "f", Start: &end, End: &start},
+			expectedErr: "end must be after start",
+		},
+		{
+			name:        "duration without cron",
+			freeze:      TideMergeFreeze{Name: "f", Start: &start, End: &end, Duration: &metav1.Duration{Duration: time.Hour}},
+			expectedErr: "duration and timezone can only be used with cron",
+		},
+		{
+			name:        "no schedule",
+			freeze:      TideMergeFreeze{Name: "f", Start: &start},
+			expectedErr: "either cron or start and end must be set",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.freeze.Validate()
+			if tc.expectedErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.expectedErr) {
+				t.Fatalf("expected error containing %q, got %v", tc.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestActiveMergeFreezes(t *testing.T) {
+	start := metav1.NewTime(time.Date(2022, 12, 23, 0, 0, 0, 0, time.UTC))
+	end := metav1.NewTime(time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC))
+	tide := Tide{MergeFreezes: []TideMergeFreeze{
+		// Fridays 18:00 to Mondays 8:00 in Berlin.
+		{Name: "weekend", Cron: "0 18 * * 5", Duration: &metav1.Duration{Duration: 62 * time.Hour}, Timezone: "Europe/Berlin"},
+		{Name: "holidays", Repos: []string{"org"}, Start: &start, End: &end},
+		{Name: "code freeze", Repos: []string{"org/repo"}, Branches: []string{"release-.*"}, Start: &start, End: &end, ExemptLabels: []string{"critical"}},
+	}}
+	for i := range tide.MergeFreezes {
+		if err := tide.MergeFreezes[i].Validate(); err != nil {
+			t.Fatalf("invalid freeze: %v", err)
+		}
+	}
+	testCases := []struct {
+		name          string
+		repo          string
+		branch        string
+		now           time.Time
+		expected      []string
+		expectedUntil time.Time
+	}{
+		{
+			name: "weekday",
+			repo: "org/repo",
+			now:  time.Date(2022, 11, 16, 12, 0, 0, 0, time.UTC),
+		},
+		{
+			name:          "weekend",
+			repo:          "other/repo",
+			now:           time.Date(2022, 11, 19, 12, 0, 0, 0, time.UTC),
+			expected:      []string{"weekend"},
+			expectedUntil: time.Date(2022, 11, 21, 7, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "weekend over",
+			repo: "other/repo",
+			now:  time.Date(2022, 11, 21, 7, 0, 0, 0, time.UTC),
+		},
+		{
+			name:          "holidays",
+			repo:          "org/repo",
+			branch:        "main",
+			now:           time.Date(2022, 12, 28, 12, 0, 0, 0, time.UTC),
+			expected:      []string{"holidays"},
+			expectedUntil: end.Time,
+		},
+		{
+			name:          "code freeze",
+			repo:          "org/repo",
+			branch:        "release-1.0",
+			now:           time.Date(2022, 12, 28, 12, 0, 0, 0, time.UTC),
+			expected:      []string{"holidays", "code freeze"},
+			expectedUntil: end.Time,
+		},
+		{
+			name:   "other repo during holidays",
+			repo:   "other/repo",
+			branch: "release-1.0",
+			now:    time.Date(2022, 12, 28, 12, 0, 0, 0, time.UTC),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			org, repo, _ := SplitRepoName(tc.repo)
+			var names []string
+			for _, f := range tide.ActiveMergeFreezes(org, repo, tc.branch, tc.now) {
+				names = append(names, f.Name)
+				if until := f.ActiveUntil(tc.now); !until.Equal(tc.expectedUntil) {
+					t.Errorf("expected %s to end at %s, got %s", f.Name, tc.expectedUntil, until)
+				}
+			}
+			if !reflect.DeepEqual(names, tc.expected) {
+				t.Errorf("expected freezes %v, got %v", tc.expected, names)
+			}
+		})
+	}
+
+	if !tide.MergeFreezes[2].Exempts([]string{"lgtm", "critical"}) {
+		t.Error("expected PR with exempt label to be exempt")
+	}
+	if tide.MergeFreezes[1].Exempts([]string{"critical"}) {
+		t.Error("expected freeze without exempt labels to exempt nothing")
+	}
+}
@@ -98,6 +98,11 @@ func (in *JobBase) DeepCopyInto(out *JobBase) {
 		*out = new(v1alpha1.PipelineRunSpec)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.BuildSpec != nil {
+		in, out := &in.BuildSpec, &out.BuildSpec
+		*out = new(prowjobsv1.CloudBuildSpec)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.Annotations != nil {
 		in, out := &in.Annotations, &out.Annotations
 		*out = make(map[string]string, len(*in))
@@ -134,6 +139,28 @@ func (in *JobBase) DeepCopy() *JobBase {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Periodic) DeepCopyInto(out *Periodic) {
+	*out = *in
+	in.JobBase.DeepCopyInto(&out.JobBase)
+	if in.Tags != nil {
+		in, out := &in.Tags, &out.Tags
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Periodic.
+func (in *Periodic) DeepCopy() *Periodic {
+	if in == nil {
+		return nil
+	}
+	out := new(Periodic)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Postsubmit) DeepCopyInto(out *Postsubmit) {
 	*out = *in
@@ -261,6 +288,13 @@ func (in *ProwYAML) DeepCopyInto(out *ProwYAML) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Periodics != nil {
+		in, out := &in.Periodics, &out.Periodics
+		*out = make([]Periodic, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	if in.ProwIgnored != nil {
 		in, out := &in.ProwIgnored, &out.ProwIgnored
 		*out = new(json.RawMessage)
@@ -102,7 +102,7 @@ var (
 )
 
 type gerritClient interface {
-	SetReview(instance, id, revision, message string, labels map[string]string) error
+	SetReviewWithTag(instance, id, revision, message, tag string, labels map[string]string) error
 	GetChange(instance, id string, additionalFields ...string) (*gerrit.ChangeInfo, error)
 	ChangeExist(instance, id string) (bool, error)
 }
@@ -112,6 +112,9 @@ type Client struct {
 	gc          gerritClient
 	pjclientset ctrlruntimeclient.Client
 	prLocks     *criercommonlib.ShardedLock
+	// orgRepoConfigGetter tells which repos show their results in the
+	// Checks UI of Gerrit, it's nil in tests.
+	orgRepoConfigGetter func() *config.GerritOrgRepoConfigs
 }
 
 // Job is the view of a prowjob scoped for a report
@@ -152,9 +155,10 @@ func NewReporter(orgRepoConfigGetter func() *config.GerritOrgRepoConfigs, cookie
 	gc.Authenticate(cookiefilePath, "")
 
 	c := &Client{
-		gc:          gc,
-		pjclientset: pjclientset,
-		prLocks:     criercommonlib.NewShardedLock(),
+		gc:                  gc,
+		pjclientset:         pjclientset,
+		prLocks:             criercommonlib.NewShardedLock(),
+		orgRepoConfigGetter: orgRepoConfigGetter,
 	}
 
 	c.prLocks.RunCleanup()
@@ -411,8 +415,16 @@ func (c *Client) Report(ctx context.Context, logger *logrus.Entry, pj *v1.ProwJo
 		reviewLabels = map[string]string{reportLabel: vote}
 	}
 
+	// Repos that show their results in the Checks UI still get the votes, but
+	// the messages are tagged as autogenerated so that Gerrit hides them by
+	// default.
+	var tag string
+	if c.orgRepoConfigGetter != nil && pj.Spec.Refs != nil && c.orgRepoConfigGetter().ReportsWithChecks(pj.Spec.Refs.Org, pj.Spec.Refs.Repo) {
+		tag = client.AutogeneratedTag
+	}
+
 	logger.Infof("Reporting to instance %s on id %s with message %s", gerritInstance, gerritID, message)
-	if err := c.gc.SetReview(gerritInstance, gerritID, gerritRevision, message, reviewLabels); err != nil {
+	if err := c.gc.SetReviewWithTag(gerritInstance, gerritID, gerritRevision, message, tag, reviewLabels); err != nil {
 		logger.WithError(err).WithField("gerrit_id", gerritID).WithField("label", reportLabel).Info("Failed to set review.")
 
 		// It could be that the commit is deleted by the time we want to report.
@@ -453,7 +465,7 @@ func (c *Client) Report(ctx context.Context, logger *logrus.Entry, pj *v1.ProwJo
 			}
 			// Retry without voting on a label
 			message := fmt.Sprintf("[NOTICE]: Prow Bot cannot access %s label!\n%s", reportLabel, message)
-			if err := c.gc.SetReview(gerritInstance, gerritID, gerritRevision, message, nil); err != nil {
+			if err := c.gc.SetReviewWithTag(gerritInstance, gerritID, gerritRevision, message, tag, nil); err != nil {
 				return nil, nil, err
 			}
 		}
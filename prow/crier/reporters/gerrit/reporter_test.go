@@ -37,7 +37,9 @@ import (
 	fakectrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
 
 	v1 "k8s.io/test-infra/prow/apis/prowjobs/v1"
+	"k8s.io/test-infra/prow/config"
 	"k8s.io/test-infra/prow/crier/reporters/criercommonlib"
+	"k8s.io/test-infra/prow/gerrit/client"
 	"k8s.io/test-infra/prow/kube"
 )
 
@@ -51,12 +53,13 @@ const (
 type fgc struct {
 	reportMessage string
 	reportLabel   map[string]string
+	reportTag     string
 	instance      string
 	changes       map[string][]*gerrit.ChangeInfo
 	count         int
 }
 
-func (f *fgc) SetReview(instance, id, revision, message string, labels map[string]string) error {
+func (f *fgc) SetReviewWithTag(instance, id, revision, message, tag string, labels map[string]string) error {
 	if instance != f.instance {
 		return fmt.Errorf("wrong instance: %s", instance)
 	}
@@ -82,6 +85,7 @@ func (f *fgc) SetReview(instance, id, revision, message string, labels map[strin
 		}
 	}
 	f.reportMessage = message
+	f.reportTag = tag
 	if len(labels) > 0 {
 		f.reportLabel = labels
 	}
@@ -2064,6 +2068,79 @@ func TestReport(t *testing.T) {
 	}
 }
 
+func TestReportWithChecks(t *testing.T) {
+	changes := map[string][]*gerrit.ChangeInfo{
+		"gerrit": {
+			{ID: "123-abc", Status: "NEW", Revisions: map[string]gerrit.RevisionInfo{"abc": {}}},
+		},
+	}
+	pj := &v1.ProwJob{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels: map[string]string{
+				kube.GerritRevision:    "abc",
+				kube.ProwJobTypeLabel:  presubmit,
+				kube.GerritReportLabel: "Code-Review",
+			},
+			Annotations: map[string]string{
+				kube.GerritID:       "123-abc",
+				kube.GerritInstance: "gerrit",
+			},
+			Name:      "ci-foo",
+			Namespace: "test-pods",
+		},
+		Status: v1.ProwJobStatus{
+			State: v1.SuccessState,
+			URL:   "guber/foo",
+		},
+		Spec: v1.ProwJobSpec{
+			Refs: &v1.Refs{
+				Org:   "gerrit",
+				Repo:  "foo",
+				Pulls: []v1.Pull{{Number: 0}},
+			},
+			Job:    "ci-foo",
+			Report: true,
+		},
+	}
+
+	for _, tc := range []struct {
+		name        string
+		repos       []string
+		expectedTag string
+	}{
+		{
+			name:  "repo without checks gets plain messages",
+			repos: []string{"bar"},
+		},
+		{
+			name:        "repo with checks gets autogenerated messages",
+			repos:       []string{"foo"},
+			expectedTag: client.AutogeneratedTag,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fgc := &fgc{instance: "gerrit", changes: changes}
+			reporter := &Client{
+				gc:          fgc,
+				pjclientset: fakectrlruntimeclient.NewFakeClient(pj.DeepCopy()),
+				prLocks:     criercommonlib.NewShardedLock(),
+				orgRepoConfigGetter: func() *config.GerritOrgRepoConfigs {
+					return &config.GerritOrgRepoConfigs{{Org: "gerrit", Repos: tc.repos, ReportWithChecks: true}}
+				},
+			}
+			if _, _, err := reporter.Report(context.Background(), logrus.NewEntry(logrus.StandardLogger()), pj.DeepCopy()); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if fgc.reportTag != tc.expectedTag {
+				t.Errorf("tag: got %q, want %q", fgc.reportTag, tc.expectedTag)
+			}
+			if expected := map[string]string{codeReview: lgtm}; !reflect.DeepEqual(expected, fgc.reportLabel) {
+				t.Errorf("labels: got %v, want %v", fgc.reportLabel, expected)
+			}
+		})
+	}
+}
+
 func TestMultipleWorks(t *testing.T) {
 	samplePJ := v1.ProwJob{
 		ObjectMeta: metav1.ObjectMeta{
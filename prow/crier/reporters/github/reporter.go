@@ -22,9 +22,13 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
+	"path"
+	"regexp"
 	"strings"
 	"time"
 
+	"github.com/GoogleCloudPlatform/testgrid/metadata/junit"
 	"github.com/sirupsen/logrus"
 	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
@@ -32,7 +36,10 @@ import (
 	v1 "k8s.io/test-infra/prow/apis/prowjobs/v1"
 	"k8s.io/test-infra/prow/config"
 	"k8s.io/test-infra/prow/crier/reporters/criercommonlib"
+	"k8s.io/test-infra/prow/crier/reporters/gcs/util"
 	"k8s.io/test-infra/prow/github/report"
+	pkgio "k8s.io/test-infra/prow/io"
+	"k8s.io/test-infra/prow/io/providers"
 	"k8s.io/test-infra/prow/kube"
 )
 
@@ -41,23 +48,36 @@ const (
 	GitHubReporterName = "github-reporter"
 )
 
+// junitArtifact matches the junit files summarized in check runs. It is the
+// same pattern the junit Spyglass lens is usually configured with.
+var junitArtifact = regexp.MustCompile(`^junit.*\.xml$`)
+
+// GitHubClient is the GitHub client used by the reporter.
+type GitHubClient interface {
+	report.GitHubClient
+	report.CheckRunClient
+}
+
 // Client is a github reporter client
 type Client struct {
-	gc          report.GitHubClient
+	gc          GitHubClient
 	config      config.Getter
 	reportAgent v1.ProwJobAgent
 	prLocks     *criercommonlib.ShardedLock
 	lister      ctrlruntimeclient.Reader
+	opener      pkgio.Opener
 }
 
-// NewReporter returns a reporter client
-func NewReporter(gc report.GitHubClient, cfg config.Getter, reportAgent v1.ProwJobAgent, lister ctrlruntimeclient.Reader) *Client {
+// NewReporter returns a reporter client. The opener is used to read junit
+// results for jobs reported as check runs and may be nil.
+func NewReporter(gc GitHubClient, cfg config.Getter, reportAgent v1.ProwJobAgent, lister ctrlruntimeclient.Reader, opener pkgio.Opener) *Client {
 	c := &Client{
 		gc:          gc,
 		config:      cfg,
 		reportAgent: reportAgent,
 		prLocks:     criercommonlib.NewShardedLock(),
 		lister:      lister,
+		opener:      opener,
 	}
 	c.prLocks.RunCleanup()
 	return c
@@ -77,8 +97,8 @@ func (c *Client) ShouldReport(_ context.Context, _ *logrus.Entry, pj *v1.ProwJob
 	switch {
 	case pj.Labels[kube.GerritReportLabel] != "":
 		return false // TODO(fejta): opt-in to github reporting
-	case pj.Spec.Type != v1.PresubmitJob && pj.Spec.Type != v1.PostsubmitJob:
-		return false // Report presubmit and postsubmit github jobs for github reporter
+	case pj.Spec.Type != v1.PresubmitJob && pj.Spec.Type != v1.PostsubmitJob && !report.IsMergeGroupJob(*pj):
+		return false // Report presubmit, postsubmit and merge group github jobs for github reporter
 	case c.reportAgent != "" && pj.Spec.Agent != c.reportAgent:
 		return false // Only report for specified agent
 	}
@@ -92,7 +112,12 @@ func (c *Client) Report(ctx context.Context, log *logrus.Entry, pj *v1.ProwJob)
 	defer cancel()
 
 	// TODO(krzyzacy): ditch ReportTemplate, and we can drop reference to config.Getter
-	err := report.ReportStatusContext(ctx, c.gc, *pj, c.config().GitHubReporter)
+	var err error
+	if refs := pj.Spec.Refs; refs != nil && c.config().GitHubReporter.CheckRunsEnabled(refs.Org, refs.Repo) {
+		err = report.ReportCheckRun(c.gc, *pj, c.config().GitHubReporter, c.junitSuites(ctx, log, pj))
+	} else {
+		err = report.ReportStatusContext(ctx, c.gc, *pj, c.config().GitHubReporter)
+	}
 	if err != nil {
 		if strings.Contains(err.Error(), "This SHA and context has reached the maximum number of statuses") {
 			// This is completely unrecoverable, so just swallow the error to make sure we wont retry, even when crier gets restarted.
@@ -152,6 +177,63 @@ func (c *Client) Report(ctx context.Context, log *logrus.Entry, pj *v1.ProwJob)
 	return []*v1.ProwJob{pj}, nil, err
 }
 
+// junitSuites reads the junit results a completed job uploaded. Failing to
+// read them is not fatal: the check run is reported without a test summary.
+func (c *Client) junitSuites(ctx context.Context, log *logrus.Entry, pj *v1.ProwJob) []*junit.Suites {
+	if c.opener == nil || !pj.Complete() {
+		return nil
+	}
+	bucket, dir, err := util.GetJobDestination(c.config, pj)
+	if err != nil {
+		log.WithError(err).Debug("Could not determine job destination, not reading junit results")
+		return nil
+	}
+	prefix, err := providers.StoragePath(bucket, path.Join(dir, "artifacts")+"/")
+	if err != nil {
+		log.WithError(err).Warn("Could not determine artifacts path")
+		return nil
+	}
+	it, err := c.opener.Iterator(ctx, prefix, "")
+	if err != nil {
+		log.WithError(err).Warn("Could not list artifacts")
+		return nil
+	}
+	var suites []*junit.Suites
+	for {
+		attr, err := it.Next(ctx)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			log.WithError(err).Warn("Could not list artifacts")
+			break
+		}
+		if attr.IsDir || !junitArtifact.MatchString(attr.ObjName) {
+			continue
+		}
+		s, err := c.readJUnit(ctx, bucket, attr.Name)
+		if err != nil {
+			log.WithError(err).WithField("artifact", attr.Name).Info("Error reading junit file")
+			continue
+		}
+		suites = append(suites, s)
+	}
+	return suites
+}
+
+func (c *Client) readJUnit(ctx context.Context, bucket, name string) (*junit.Suites, error) {
+	p, err := providers.StoragePath(bucket, name)
+	if err != nil {
+		return nil, err
+	}
+	r, err := c.opener.Reader(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return junit.ParseStream(r)
+}
+
 func pjsToReport(ctx context.Context, log *logrus.Entry, lister ctrlruntimeclient.Reader, pj *v1.ProwJob) ([]v1.ProwJob, error) {
 	if len(pj.Spec.Refs.Pulls) != 1 {
 		return nil, nil
@@ -17,8 +17,11 @@ limitations under the License.
 package github
 
 import (
+	"bytes"
 	"context"
 	"errors"
+	"fmt"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -31,7 +34,9 @@ import (
 
 	v1 "k8s.io/test-infra/prow/apis/prowjobs/v1"
 	"k8s.io/test-infra/prow/config"
+	"k8s.io/test-infra/prow/crier/reporters/gcs/util"
 	"k8s.io/test-infra/prow/github/fakegithub"
+	"k8s.io/test-infra/prow/io/fakeopener"
 	"k8s.io/test-infra/prow/kube"
 
 	fakectrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
@@ -74,6 +79,19 @@ func TestShouldReport(t *testing.T) {
 			},
 			report: false,
 		},
+		{
+			name: "should report merge group batch job",
+			pj: v1.ProwJob{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{kube.MergeGroupLabel: "true"},
+				},
+				Spec: v1.ProwJobSpec{
+					Type:   v1.BatchJob,
+					Report: true,
+				},
+			},
+			report: true,
+		},
 		{
 			name: "should report presubmit job",
 			pj: v1.ProwJob{
@@ -102,7 +120,7 @@ func TestShouldReport(t *testing.T) {
 
 	for _, tc := range testcases {
 		t.Run(tc.name, func(t *testing.T) {
-			c := NewReporter(nil, nil, tc.reportAgent, nil)
+			c := NewReporter(nil, nil, tc.reportAgent, nil, nil)
 			if r := c.ShouldReport(context.Background(), logrus.NewEntry(logrus.StandardLogger()), &tc.pj); r == tc.report {
 				return
 			}
@@ -133,6 +151,7 @@ func TestPresumitReportingLocks(t *testing.T) {
 		},
 		v1.ProwJobAgent(""),
 		nil,
+		nil,
 	)
 
 	pj := &v1.ProwJob{
@@ -1017,3 +1036,72 @@ func TestPjsToReport(t *testing.T) {
 		})
 	}
 }
+
+func TestReportCheckRun(t *testing.T) {
+	cfg := func() *config.Config {
+		return &config.Config{
+			ProwConfig: config.ProwConfig{
+				GitHubReporter: config.GitHubReporter{
+					JobTypesToReport: []v1.ProwJobType{v1.PresubmitJob},
+					NoCommentRepos:   []string{"org"},
+					CheckRunRepos:    []string{"org/repo"},
+				},
+			},
+		}
+	}
+	pj := &v1.ProwJob{
+		ObjectMeta: metav1.ObjectMeta{Name: "some-job"},
+		Spec: v1.ProwJobSpec{
+			Type:    v1.PresubmitJob,
+			Job:     "pull-unit",
+			Context: "pull-unit",
+			Report:  true,
+			Refs: &v1.Refs{
+				Org:   "org",
+				Repo:  "repo",
+				Pulls: []v1.Pull{{Number: 1, SHA: "head"}},
+			},
+			DecorationConfig: &v1.DecorationConfig{
+				GCSConfiguration: &v1.GCSConfiguration{
+					Bucket:       "bucket",
+					PathStrategy: v1.PathStrategyExplicit,
+				},
+			},
+		},
+		Status: v1.ProwJobStatus{
+			State:          v1.FailureState,
+			BuildID:        "123",
+			URL:            "https://prow.example.com/view/gs/bucket/some-job",
+			CompletionTime: &metav1.Time{},
+		},
+	}
+	bucket, dir, err := util.GetJobDestination(cfg, pj)
+	if err != nil {
+		t.Fatalf("failed to get job destination: %v", err)
+	}
+	opener := &fakeopener.FakeOpener{Buffer: map[string]*bytes.Buffer{
+		fmt.Sprintf("gs://%s/%s/artifacts/junit_01.xml", bucket, dir): bytes.NewBufferString(
+			`<testsuites><testsuite name="unit"><testcase name="TestPass"/><testcase name="TestFail" classname="pkg/a"><failure message="boom"/></testcase></testsuite></testsuites>`),
+		fmt.Sprintf("gs://%s/%s/artifacts/build-log.txt", bucket, dir): bytes.NewBufferString("not junit"),
+	}}
+	fghc := fakegithub.NewFakeClient()
+	c := NewReporter(fghc, cfg, v1.ProwJobAgent(""), nil, opener)
+
+	if _, _, err := c.Report(context.Background(), logrus.NewEntry(logrus.StandardLogger()), pj); err != nil {
+		t.Fatalf("failed to report: %v", err)
+	}
+	if len(fghc.CreatedStatuses["head"]) != 0 {
+		t.Errorf("expected no status contexts for a check run repo, got %v", fghc.CreatedStatuses["head"])
+	}
+	checkRuns := fghc.CheckRuns["head"]
+	if len(checkRuns) != 1 {
+		t.Fatalf("expected one check run, got %d", len(checkRuns))
+	}
+	output := checkRuns[0].Output
+	if !strings.HasPrefix(output.Summary, "2 tests: 1 passed, 1 failed, 0 skipped.") {
+		t.Errorf("expected junit summary, got %q", output.Summary)
+	}
+	if len(output.Annotations) != 1 || output.Annotations[0].Title != "TestFail" {
+		t.Errorf("expected an annotation for the failed test, got %+v", output.Annotations)
+	}
+}
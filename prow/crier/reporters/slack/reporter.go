@@ -29,6 +29,7 @@ import (
 	prowapi "k8s.io/test-infra/prow/apis/prowjobs/v1"
 	v1 "k8s.io/test-infra/prow/apis/prowjobs/v1"
 	"k8s.io/test-infra/prow/config"
+	"k8s.io/test-infra/prow/io"
 	slackclient "k8s.io/test-infra/prow/slack"
 )
 
@@ -39,12 +40,17 @@ const (
 
 type slackClient interface {
 	WriteMessage(text, channel string) error
+	WriteMessageWithBlocks(text string, blocks []slackclient.Block, channel string) error
+	PostMessage(text string, blocks []slackclient.Block, channel, threadTS string) (*slackclient.MessageRef, error)
+	UpdateMessage(ref slackclient.MessageRef, text string, blocks []slackclient.Block) error
 }
 
 type slackReporter struct {
 	clients map[string]slackClient
 	config  func(*prowapi.Refs) config.SlackReporter
 	dryRun  bool
+	// threads is nil unless crier stores the threads of failing periodics.
+	threads *threadStore
 }
 
 func hostAndChannel(cfg *v1.SlackReporterConfig) (string, string) {
@@ -68,11 +74,16 @@ func (sr *slackReporter) getConfig(pj *v1.ProwJob) (*config.SlackReporter, *v1.S
 	return &globalConfig, jobSlackConfig
 }
 
-func (sr *slackReporter) Report(_ context.Context, log *logrus.Entry, pj *v1.ProwJob) ([]*v1.ProwJob, *reconcile.Result, error) {
-	return []*v1.ProwJob{pj}, nil, sr.report(log, pj)
+// threaded determines whether the job reports to a thread while it is failing.
+func (sr *slackReporter) threaded(cfg *config.SlackReporter, pj *v1.ProwJob) bool {
+	return sr.threads != nil && cfg.ThreadPeriodics && pj.Spec.Type == v1.PeriodicJob
 }
 
-func (sr *slackReporter) report(log *logrus.Entry, pj *v1.ProwJob) error {
+func (sr *slackReporter) Report(ctx context.Context, log *logrus.Entry, pj *v1.ProwJob) ([]*v1.ProwJob, *reconcile.Result, error) {
+	return []*v1.ProwJob{pj}, nil, sr.report(ctx, log, pj)
+}
+
+func (sr *slackReporter) report(ctx context.Context, log *logrus.Entry, pj *v1.ProwJob) error {
 	globalSlackConfig, jobSlackConfig := sr.getConfig(pj)
 	if globalSlackConfig != nil {
 		jobSlackConfig = jobSlackConfig.ApplyDefault(&globalSlackConfig.SlackReporterConfig)
@@ -100,18 +111,131 @@ func (sr *slackReporter) report(log *logrus.Entry, pj *v1.ProwJob) error {
 		log.WithField("messagetext", b.String()).Debug("Skipping reporting because dry-run is enabled")
 		return nil
 	}
-	if err := client.WriteMessage(b.String(), channel); err != nil {
+	var blocks []slackclient.Block
+	if actions := actionButtons(pj, globalSlackConfig.InteractiveActions); len(actions) > 0 {
+		blocks = []slackclient.Block{slackclient.NewSectionBlock(b.String()), slackclient.NewActionsBlock(actions...)}
+	}
+	switch {
+	case sr.threaded(globalSlackConfig, pj):
+		err = sr.reportThreaded(ctx, client, host, channel, pj, b.String(), blocks)
+	case len(blocks) > 0:
+		err = client.WriteMessageWithBlocks(b.String(), blocks, channel)
+	default:
+		err = client.WriteMessage(b.String(), channel)
+	}
+	if err != nil {
 		log.WithError(err).Error("failed to write Slack message")
 		return fmt.Errorf("failed to write Slack message: %w", err)
 	}
 	return nil
 }
 
+// reportThreaded reports a periodic to the thread it keeps while it is failing.
+// The first failure starts the thread, later runs reply to it and a success
+// closes it, marking the parent message as recovered.
+func (sr *slackReporter) reportThreaded(ctx context.Context, client slackClient, host, channel string, pj *v1.ProwJob, text string, blocks []slackclient.Block) error {
+	key := threadKey(host, channel, pj.Spec.Job)
+	// Reports of the same job may be made concurrently by several workers.
+	unlock := sr.threads.lockKey(key)
+	defer unlock()
+	if sr.threads.wasReported(key, pj.Name) {
+		// The report was posted, but saving the threads or marking the
+		// parent message as recovered failed.
+		if err := sr.threads.save(ctx); err != nil {
+			return err
+		}
+		return sr.markRecovered(client, key)
+	}
+	t, err := sr.threads.get(ctx, key)
+	if err != nil {
+		return err
+	}
+	failed := pj.Status.State == v1.FailureState || pj.Status.State == v1.ErrorState
+
+	if t == nil {
+		ref, err := client.PostMessage(text, blocks, channel, "")
+		if err != nil || !failed {
+			return err
+		}
+		sr.threads.markReported(key, pj.Name)
+		return sr.threads.set(ctx, key, &thread{MessageRef: *ref, Text: text, Failures: 1})
+	}
+
+	if _, err := client.PostMessage(text, blocks, t.Channel, t.Timestamp); err != nil {
+		return err
+	}
+	sr.threads.markReported(key, pj.Name)
+	switch {
+	case failed:
+		t.Failures++
+		return sr.threads.set(ctx, key, t)
+	case pj.Status.State == v1.SuccessState:
+		// The edit is recorded before closing the thread, so that a retry
+		// makes it even though the thread is gone.
+		sr.threads.setRecovery(key, &recovery{MessageRef: t.MessageRef, Text: recoveredText(pj, t)})
+		if err := sr.threads.set(ctx, key, nil); err != nil {
+			return err
+		}
+		return sr.markRecovered(client, key)
+	}
+	return nil
+}
+
+// markRecovered makes the pending edit of the parent message for key, if any.
+func (sr *slackReporter) markRecovered(client slackClient, key string) error {
+	r := sr.threads.pendingRecovery(key)
+	if r == nil {
+		return nil
+	}
+	if err := client.UpdateMessage(r.MessageRef, r.Text, nil); err != nil {
+		return err
+	}
+	sr.threads.setRecovery(key, nil)
+	return nil
+}
+
+func recoveredText(pj *v1.ProwJob, t *thread) string {
+	runs := "runs"
+	if t.Failures == 1 {
+		runs = "run"
+	}
+	return fmt.Sprintf("%s\n:white_check_mark: %s recovered after %d failed %s.", t.Text, pj.Spec.Job, t.Failures, runs)
+}
+
+// actionButtons returns the buttons for the interactive actions that apply to
+// the job in its current state. Their value is the name of the job.
+func actionButtons(pj *v1.ProwJob, actions []config.SlackAction) []slackclient.Element {
+	// Deck can only abort jobs in these states.
+	running := pj.Status.State == v1.TriggeredState || pj.Status.State == v1.PendingState
+	var buttons []slackclient.Element
+	for _, action := range actions {
+		switch action {
+		case config.SlackActionRerun:
+			if !running {
+				buttons = append(buttons, slackclient.NewButton("Rerun", string(action), pj.Name, ""))
+			}
+		case config.SlackActionAbort:
+			if running {
+				button := slackclient.NewButton("Abort", string(action), pj.Name, "")
+				button.Style = "danger"
+				buttons = append(buttons, button)
+			}
+		case config.SlackActionLogs:
+			if pj.Status.URL != "" {
+				buttons = append(buttons, slackclient.NewButton("View logs", string(action), pj.Name, pj.Status.URL))
+			}
+		case config.SlackActionAssign:
+			buttons = append(buttons, slackclient.NewButton("Assign to on-call", string(action), pj.Name, ""))
+		}
+	}
+	return buttons
+}
+
 func (sr *slackReporter) GetName() string {
 	return reporterName
 }
 
-func (sr *slackReporter) ShouldReport(_ context.Context, logger *logrus.Entry, pj *v1.ProwJob) bool {
+func (sr *slackReporter) ShouldReport(ctx context.Context, logger *logrus.Entry, pj *v1.ProwJob) bool {
 	globalSlackConfig, jobSlackConfig := sr.getConfig(pj)
 
 	var typeShouldReport bool
@@ -136,7 +260,8 @@ func (sr *slackReporter) ShouldReport(_ context.Context, logger *logrus.Entry, p
 	// Note the JobStatesToReport configured in the Prow job can overwrite the
 	// Prow config.
 	var stateShouldReport bool
-	if merged := jobSlackConfig.ApplyDefault(&globalSlackConfig.SlackReporterConfig); merged != nil && merged.JobStatesToReport != nil {
+	merged := jobSlackConfig.ApplyDefault(&globalSlackConfig.SlackReporterConfig)
+	if merged != nil && merged.JobStatesToReport != nil {
 		if merged.Report != nil && !*merged.Report {
 			logger.WithField("job_states_to_report", merged.JobStatesToReport).Debug("Skip slack reporting as 'report: false', could result from 'job_states_to_report: []'.")
 			return false
@@ -150,18 +275,35 @@ func (sr *slackReporter) ShouldReport(_ context.Context, logger *logrus.Entry, p
 	}
 
 	shouldReport := stateShouldReport && (typeShouldReport || jobShouldReport)
+
+	// A success closes the open thread of a periodic, even if successes are
+	// not reported otherwise.
+	if !shouldReport && (typeShouldReport || jobShouldReport) && merged != nil && pj.Status.State == v1.SuccessState && sr.threaded(globalSlackConfig, pj) {
+		host, channel := hostAndChannel(merged)
+		t, err := sr.threads.get(ctx, threadKey(host, channel, pj.Spec.Job))
+		if err != nil {
+			logger.WithError(err).Warn("Failed to look up the Slack thread of the job.")
+		}
+		shouldReport = t != nil
+	}
 	logger.WithField("reporting", shouldReport).Debug("Determined should report")
 	return shouldReport
 }
 
-func New(cfg func(refs *prowapi.Refs) config.SlackReporter, dryRun bool, tokensMap map[string]func() []byte) *slackReporter {
+// New returns a Slack reporter. If threadStatePath is set, the threads of failing
+// periodics are stored there using opener.
+func New(cfg func(refs *prowapi.Refs) config.SlackReporter, dryRun bool, tokensMap map[string]func() []byte, opener io.Opener, threadStatePath string) *slackReporter {
 	clients := map[string]slackClient{}
 	for key, val := range tokensMap {
 		clients[key] = slackclient.NewClient(val)
 	}
-	return &slackReporter{
+	sr := &slackReporter{
 		clients: clients,
 		config:  cfg,
 		dryRun:  dryRun,
 	}
+	if threadStatePath != "" {
+		sr.threads = &threadStore{opener: opener, path: threadStatePath}
+	}
+	return sr
 }
@@ -18,12 +18,19 @@ package slack
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"strings"
 	"testing"
 
+	"github.com/google/go-cmp/cmp"
 	"github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	v1 "k8s.io/test-infra/prow/apis/prowjobs/v1"
 	"k8s.io/test-infra/prow/config"
+	"k8s.io/test-infra/prow/io/fakeopener"
+	slackclient "k8s.io/test-infra/prow/slack"
 )
 
 func TestShouldReport(t *testing.T) {
@@ -622,6 +629,17 @@ func TestShouldReportDefaultsToExtraRefs(t *testing.T) {
 
 type fakeSlackClient struct {
 	messages map[string]string
+	blocks   map[string][]slackclient.Block
+	posts    []fakePost
+	updates  []fakePost
+	// updateError is returned by the next UpdateMessage, which then succeeds.
+	updateError error
+}
+
+type fakePost struct {
+	text     string
+	channel  string
+	threadTS string
 }
 
 func (fsc *fakeSlackClient) WriteMessage(text, channel string) error {
@@ -632,6 +650,28 @@ func (fsc *fakeSlackClient) WriteMessage(text, channel string) error {
 	return nil
 }
 
+func (fsc *fakeSlackClient) WriteMessageWithBlocks(text string, blocks []slackclient.Block, channel string) error {
+	if fsc.blocks == nil {
+		fsc.blocks = map[string][]slackclient.Block{}
+	}
+	fsc.blocks[channel] = blocks
+	return fsc.WriteMessage(text, channel)
+}
+
+func (fsc *fakeSlackClient) PostMessage(text string, blocks []slackclient.Block, channel, threadTS string) (*slackclient.MessageRef, error) {
+	fsc.posts = append(fsc.posts, fakePost{text: text, channel: channel, threadTS: threadTS})
+	return &slackclient.MessageRef{Channel: "C" + channel, Timestamp: fmt.Sprintf("%d", len(fsc.posts))}, nil
+}
+
+func (fsc *fakeSlackClient) UpdateMessage(ref slackclient.MessageRef, text string, blocks []slackclient.Block) error {
+	if err := fsc.updateError; err != nil {
+		fsc.updateError = nil
+		return err
+	}
+	fsc.updates = append(fsc.updates, fakePost{text: text, channel: ref.Channel, threadTS: ref.Timestamp})
+	return nil
+}
+
 var _ slackClient = &fakeSlackClient{}
 
 func TestReportDefaultsToExtraRefs(t *testing.T) {
@@ -669,3 +709,242 @@ func TestReportDefaultsToExtraRefs(t *testing.T) {
 		t.Errorf("expected the channel 'emergency' to contain message 'there you go' but wasn't the case, all messages: %v", fsc.messages)
 	}
 }
+
+func TestReportWithInteractiveActions(t *testing.T) {
+	actions := []config.SlackAction{config.SlackActionRerun, config.SlackActionAbort, config.SlackActionLogs, config.SlackActionAssign}
+	testCases := []struct {
+		name            string
+		state           v1.ProwJobState
+		url             string
+		actions         []config.SlackAction
+		expectedButtons []string
+	}{
+		{
+			name:            "finished job can be rerun",
+			state:           v1.FailureState,
+			url:             "https://prow.k8s.io/view/1",
+			actions:         actions,
+			expectedButtons: []string{"rerun", "logs", "assign"},
+		},
+		{
+			name:            "running job can be aborted",
+			state:           v1.PendingState,
+			actions:         actions,
+			expectedButtons: []string{"abort", "assign"},
+		},
+		{
+			name:  "no actions configured",
+			state: v1.FailureState,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			job := &v1.ProwJob{
+				ObjectMeta: metav1.ObjectMeta{Name: "my-job"},
+				Spec:       v1.ProwJobSpec{Type: v1.PeriodicJob},
+				Status:     v1.ProwJobStatus{State: tc.state, URL: tc.url},
+			}
+			fsc := &fakeSlackClient{}
+			sr := slackReporter{
+				config: func(*v1.Refs) config.SlackReporter {
+					return config.SlackReporter{
+						InteractiveActions: tc.actions,
+						SlackReporterConfig: v1.SlackReporterConfig{
+							Channel:        "channel",
+							ReportTemplate: "report",
+						},
+					}
+				},
+				clients: map[string]slackClient{DefaultHostName: fsc},
+			}
+			if _, _, err := sr.Report(context.Background(), logrus.NewEntry(logrus.StandardLogger()), job); err != nil {
+				t.Fatalf("reporting failed: %v", err)
+			}
+			if fsc.messages["channel"] != "report" {
+				t.Errorf("expected message 'report', got %q", fsc.messages["channel"])
+			}
+
+			blocks := fsc.blocks["channel"]
+			if tc.expectedButtons == nil {
+				if blocks != nil {
+					t.Errorf("expected a plain message, got blocks %+v", blocks)
+				}
+				return
+			}
+			if len(blocks) != 2 {
+				t.Fatalf("expected a section and an actions block, got %+v", blocks)
+			}
+			var buttons []string
+			for _, element := range blocks[1].Elements {
+				if element.Value != "my-job" {
+					t.Errorf("expected button %s to carry the job name, got %q", element.ActionID, element.Value)
+				}
+				buttons = append(buttons, element.ActionID)
+			}
+			if diff := cmp.Diff(tc.expectedButtons, buttons); diff != "" {
+				t.Errorf("unexpected buttons (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestReportThreadedPeriodics(t *testing.T) {
+	cfg := func(*v1.Refs) config.SlackReporter {
+		return config.SlackReporter{
+			JobTypesToReport: []v1.ProwJobType{v1.PeriodicJob},
+			ThreadPeriodics:  true,
+			SlackReporterConfig: v1.SlackReporterConfig{
+				Channel:           "channel",
+				JobStatesToReport: []v1.ProwJobState{v1.FailureState, v1.ErrorState},
+				ReportTemplate:    "{{.Spec.Job}} {{.Status.State}}",
+			},
+		}
+	}
+	opener := &fakeopener.FakeOpener{}
+	fsc := &fakeSlackClient{}
+	newReporter := func() *slackReporter {
+		sr := New(cfg, false, nil, opener, "gs://bucket/threads.json")
+		sr.clients = map[string]slackClient{DefaultHostName: fsc}
+		return sr
+	}
+	log := logrus.WithField("test", t.Name())
+
+	runs := []struct {
+		state          v1.ProwJobState
+		expectedReport bool
+	}{
+		{state: v1.SuccessState},
+		{state: v1.FailureState, expectedReport: true},
+		{state: v1.ErrorState, expectedReport: true},
+		{state: v1.SuccessState, expectedReport: true},
+		{state: v1.SuccessState},
+	}
+	for i, run := range runs {
+		// A new reporter for every run shows the threads survive restarts.
+		sr := newReporter()
+		pj := &v1.ProwJob{
+			Spec:   v1.ProwJobSpec{Type: v1.PeriodicJob, Job: "periodic"},
+			Status: v1.ProwJobStatus{State: run.state},
+		}
+		if shouldReport := sr.ShouldReport(context.Background(), log, pj); shouldReport != run.expectedReport {
+			t.Fatalf("run %d: expected ShouldReport %t, got %t", i, run.expectedReport, shouldReport)
+		}
+		if !run.expectedReport {
+			continue
+		}
+		if _, _, err := sr.Report(context.Background(), log, pj); err != nil {
+			t.Fatalf("run %d: reporting failed: %v", i, err)
+		}
+	}
+
+	expectedPosts := []fakePost{
+		{text: "periodic failure", channel: "channel"},
+		{text: "periodic error", channel: "Cchannel", threadTS: "1"},
+		{text: "periodic success", channel: "Cchannel", threadTS: "1"},
+	}
+	if diff := cmp.Diff(expectedPosts, fsc.posts, cmp.AllowUnexported(fakePost{})); diff != "" {
+		t.Errorf("unexpected posts (-want +got):\n%s", diff)
+	}
+	expectedUpdates := []fakePost{
+		{text: "periodic failure\n:white_check_mark: periodic recovered after 2 failed runs.", channel: "Cchannel", threadTS: "1"},
+	}
+	if diff := cmp.Diff(expectedUpdates, fsc.updates, cmp.AllowUnexported(fakePost{})); diff != "" {
+		t.Errorf("unexpected updates (-want +got):\n%s", diff)
+	}
+	if state := opener.Buffer["gs://bucket/threads.json"].String(); state != "{}" {
+		t.Errorf("expected no open threads, got %s", state)
+	}
+}
+
+func TestReportThreadedRetriesSavingThreads(t *testing.T) {
+	cfg := func(*v1.Refs) config.SlackReporter {
+		return config.SlackReporter{
+			JobTypesToReport: []v1.ProwJobType{v1.PeriodicJob},
+			ThreadPeriodics:  true,
+			SlackReporterConfig: v1.SlackReporterConfig{
+				Channel:           "channel",
+				JobStatesToReport: []v1.ProwJobState{v1.FailureState},
+				ReportTemplate:    "{{.Spec.Job}} {{.Status.State}}",
+			},
+		}
+	}
+	opener := &fakeopener.FakeOpener{WriteError: errors.New("injected")}
+	fsc := &fakeSlackClient{}
+	sr := New(cfg, false, nil, opener, "gs://bucket/threads.json")
+	sr.clients = map[string]slackClient{DefaultHostName: fsc}
+	log := logrus.WithField("test", t.Name())
+	pj := &v1.ProwJob{
+		ObjectMeta: metav1.ObjectMeta{Name: "run-1"},
+		Spec:       v1.ProwJobSpec{Type: v1.PeriodicJob, Job: "periodic"},
+		Status:     v1.ProwJobStatus{State: v1.FailureState},
+	}
+
+	if _, _, err := sr.Report(context.Background(), log, pj); err == nil {
+		t.Fatal("expected reporting to fail when the threads can't be saved")
+	}
+	opener.WriteError = nil
+	if _, _, err := sr.Report(context.Background(), log, pj); err != nil {
+		t.Fatalf("retrying the report failed: %v", err)
+	}
+
+	if diff := cmp.Diff([]fakePost{{text: "periodic failure", channel: "channel"}}, fsc.posts, cmp.AllowUnexported(fakePost{})); diff != "" {
+		t.Errorf("unexpected posts (-want +got):\n%s", diff)
+	}
+	if state := opener.Buffer["gs://bucket/threads.json"].String(); !strings.Contains(state, `"failures":1`) {
+		t.Errorf("expected the thread to be saved, got %s", state)
+	}
+}
+
+func TestReportThreadedRetriesMarkingRecovery(t *testing.T) {
+	cfg := func(*v1.Refs) config.SlackReporter {
+		return config.SlackReporter{
+			JobTypesToReport: []v1.ProwJobType{v1.PeriodicJob},
+			ThreadPeriodics:  true,
+			SlackReporterConfig: v1.SlackReporterConfig{
+				Channel:           "channel",
+				JobStatesToReport: []v1.ProwJobState{v1.FailureState, v1.SuccessState},
+				ReportTemplate:    "{{.Spec.Job}} {{.Status.State}}",
+			},
+		}
+	}
+	opener := &fakeopener.FakeOpener{}
+	fsc := &fakeSlackClient{}
+	sr := New(cfg, false, nil, opener, "gs://bucket/threads.json")
+	sr.clients = map[string]slackClient{DefaultHostName: fsc}
+	log := logrus.WithField("test", t.Name())
+	failure := &v1.ProwJob{
+		ObjectMeta: metav1.ObjectMeta{Name: "run-1"},
+		Spec:       v1.ProwJobSpec{Type: v1.PeriodicJob, Job: "periodic"},
+		Status:     v1.ProwJobStatus{State: v1.FailureState},
+	}
+	success := &v1.ProwJob{
+		ObjectMeta: metav1.ObjectMeta{Name: "run-2"},
+		Spec:       v1.ProwJobSpec{Type: v1.PeriodicJob, Job: "periodic"},
+		Status:     v1.ProwJobStatus{State: v1.SuccessState},
+	}
+
+	if _, _, err := sr.Report(context.Background(), log, failure); err != nil {
+		t.Fatalf("reporting the failure failed: %v", err)
+	}
+	fsc.updateError = errors.New("injected")
+	if _, _, err := sr.Report(context.Background(), log, success); err == nil {
+		t.Fatal("expected reporting to fail when the parent message can't be edited")
+	}
+	if _, _, err := sr.Report(context.Background(), log, success); err != nil {
+		t.Fatalf("retrying the report failed: %v", err)
+	}
+
+	expectedPosts := []fakePost{
+		{text: "periodic failure", channel: "channel"},
+		{text: "periodic success", channel: "Cchannel", threadTS: "1"},
+	}
+	if diff := cmp.Diff(expectedPosts, fsc.posts, cmp.AllowUnexported(fakePost{})); diff != "" {
+		t.Errorf("unexpected posts (-want +got):\n%s", diff)
+	}
+	expectedUpdates := []fakePost{
+		{text: "periodic failure\n:white_check_mark: periodic recovered after 1 failed run.", channel: "Cchannel", threadTS: "1"},
+	}
+	if diff := cmp.Diff(expectedUpdates, fsc.updates, cmp.AllowUnexported(fakePost{})); diff != "" {
+		t.Errorf("unexpected updates (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,200 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+
+	"k8s.io/test-infra/prow/io"
+	slackclient "k8s.io/test-infra/prow/slack"
+)
+
+// thread is the thread a failing periodic reports to until it recovers.
+type thread struct {
+	slackclient.MessageRef
+	// Text is the text of the parent message, kept to edit it on recovery.
+	Text string `json:"text"`
+	// Failures counts the failed runs reported to the thread.
+	Failures int `json:"failures"`
+}
+
+// threadStore keeps the open threads in a JSON file so they survive restarts
+// of crier. The file is read on first use and rewritten after every change.
+type threadStore struct {
+	opener io.Opener
+	path   string
+
+	lock sync.Mutex
+	// threads maps host/channel/job to the open thread, nil until loaded.
+	threads map[string]thread
+	// reported maps host/channel/job to the ProwJob that was last posted, so
+	// that retrying a report whose threads failed to be saved doesn't post it
+	// again.
+	reported map[string]string
+	// recoveries maps host/channel/job to the edit marking the parent message
+	// of a closed thread as recovered, until it is made.
+	recoveries map[string]recovery
+	// keyLocks serialize the reports for each host/channel/job.
+	keyLocks map[string]*sync.Mutex
+}
+
+// recovery is a pending edit of the parent message of a recovered thread.
+type recovery struct {
+	slackclient.MessageRef
+	Text string
+}
+
+func threadKey(host, channel, job string) string {
+	return fmt.Sprintf("%s/%s/%s", host, channel, job)
+}
+
+// lockKey locks the key until the returned function is called, so that the
+// reports of a job to a channel are made one at a time.
+func (s *threadStore) lockKey(key string) func() {
+	s.lock.Lock()
+	if s.keyLocks == nil {
+		s.keyLocks = map[string]*sync.Mutex{}
+	}
+	l, ok := s.keyLocks[key]
+	if !ok {
+		l = &sync.Mutex{}
+		s.keyLocks[key] = l
+	}
+	s.lock.Unlock()
+	l.Lock()
+	return l.Unlock
+}
+
+func (s *threadStore) load(ctx context.Context) error {
+	if s.threads != nil {
+		return nil
+	}
+	content, err := io.ReadContent(ctx, logrus.WithField("path", s.path), s.opener, s.path)
+	if err != nil {
+		if io.IsNotExist(err) {
+			s.threads = map[string]thread{}
+			return nil
+		}
+		return fmt.Errorf("failed to read Slack threads from %s: %w", s.path, err)
+	}
+	threads := map[string]thread{}
+	if err := json.Unmarshal(content, &threads); err != nil {
+		return fmt.Errorf("failed to unmarshal Slack threads from %s: %w", s.path, err)
+	}
+	s.threads = threads
+	return nil
+}
+
+// get returns the open thread for key, if any.
+func (s *threadStore) get(ctx context.Context, key string) (*thread, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if err := s.load(ctx); err != nil {
+		return nil, err
+	}
+	t, ok := s.threads[key]
+	if !ok {
+		return nil, nil
+	}
+	return &t, nil
+}
+
+// wasReported tells whether the ProwJob was the last one posted for key.
+func (s *threadStore) wasReported(key, prowJob string) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	reported, ok := s.reported[key]
+	return ok && reported == prowJob
+}
+
+// markReported records that the ProwJob was posted for key.
+func (s *threadStore) markReported(key, prowJob string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.reported == nil {
+		s.reported = map[string]string{}
+	}
+	s.reported[key] = prowJob
+}
+
+// pendingRecovery returns the edit still to be made for key, if any.
+func (s *threadStore) pendingRecovery(key string) *recovery {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	r, ok := s.recoveries[key]
+	if !ok {
+		return nil
+	}
+	return &r
+}
+
+// setRecovery records the edit to make for key, or forgets it if r is nil.
+func (s *threadStore) setRecovery(key string, r *recovery) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if r == nil {
+		delete(s.recoveries, key)
+		return
+	}
+	if s.recoveries == nil {
+		s.recoveries = map[string]recovery{}
+	}
+	s.recoveries[key] = *r
+}
+
+// set stores the open thread for key, or closes it if t is nil. The change is
+// kept even if the threads can't be written, so that a retry only needs to
+// save them.
+func (s *threadStore) set(ctx context.Context, key string, t *thread) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if err := s.load(ctx); err != nil {
+		return err
+	}
+	if t == nil {
+		delete(s.threads, key)
+	} else {
+		s.threads[key] = *t
+	}
+	return s.write(ctx)
+}
+
+// save writes the threads, e.g. after set failed to.
+func (s *threadStore) save(ctx context.Context) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if err := s.load(ctx); err != nil {
+		return err
+	}
+	return s.write(ctx)
+}
+
+func (s *threadStore) write(ctx context.Context) error {
+	content, err := json.Marshal(s.threads)
+	if err == nil {
+		err = io.WriteContent(ctx, logrus.WithField("path", s.path), s.opener, s.path, content)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write Slack threads to %s: %w", s.path, err)
+	}
+	return nil
+}
@@ -93,6 +93,8 @@ type gerritClient interface {
 	Authenticate(cookiefilePath, tokenPath string)
 	QueryChanges(lastState client.LastSyncState, rateLimit int) map[string][]client.ChangeInfo
 	QueryChangesForInstance(instance string, lastState client.LastSyncState, rateLimit int) []client.ChangeInfo
+	GetChangeIfNew(instance, id string, lastUpdate time.Time) (*client.ChangeInfo, error)
+	EventsSince(instance string, since time.Time) ([]client.Event, error)
 	GetBranchRevision(instance, project, branch string) (string, error)
 	SetReview(instance, id, revision, message string, labels map[string]string) error
 	Account(instance string) (*gerrit.AccountInfo, error)
@@ -112,6 +114,7 @@ type Controller struct {
 	inRepoConfigCacheHandler *config.InRepoConfigCacheHandler
 	inRepoConfigFailures     map[string]bool
 	instancesWithWorker      map[string]bool
+	instancesWithEvents      map[string]bool
 	latestMux                sync.Mutex
 	workerPoolSize           int
 }
@@ -150,6 +153,7 @@ func NewController(ctx context.Context, prowJobClient prowv1.ProwJobInterface, o
 		inRepoConfigCacheHandler: inRepoConfigCacheHandler,
 		inRepoConfigFailures:     map[string]bool{},
 		instancesWithWorker:      make(map[string]bool),
+		instancesWithEvents:      make(map[string]bool),
 		workerPoolSize:           workerPoolSize,
 	}
 
@@ -222,48 +226,50 @@ func (c *Controller) syncChange(latest client.LastSyncState, changeChan <-chan C
 	}
 }
 
-// Sync looks for newly made gerrit changes
-// and creates prowjobs according to specs
-func (c *Controller) Sync() {
-	processSingleInstance := func(instance string) {
-		// Assumes the passed in instance was already normalized with https:// prefix.
-		log := logrus.WithField("host", instance)
-		syncTime := c.tracker.Current()
-		latest := syncTime.DeepCopy()
+// syncInstance queries the instance for changes updated since the last sync
+// and processes them.
+func (c *Controller) syncInstance(instance string) {
+	// Assumes the passed in instance was already normalized with https:// prefix.
+	log := logrus.WithField("host", instance)
+	syncTime := c.tracker.Current()
+	latest := syncTime.DeepCopy()
 
-		now := time.Now()
-		defer func() {
-			gerritMetrics.changeProcessDuration.WithLabelValues(instance).Observe(float64(time.Since(now).Seconds()))
-		}()
+	now := time.Now()
+	defer func() {
+		gerritMetrics.changeProcessDuration.WithLabelValues(instance).Observe(float64(time.Since(now).Seconds()))
+	}()
 
-		changes := c.gc.QueryChangesForInstance(instance, syncTime, c.config().Gerrit.RateLimit)
-		log.WithFields(logrus.Fields{"instance": instance, "changes": len(changes), "duration(s)": time.Since(now).Seconds()}).Info("Time taken querying for gerrit changes")
+	changes := c.gc.QueryChangesForInstance(instance, syncTime, c.config().Gerrit.RateLimit)
+	log.WithFields(logrus.Fields{"instance": instance, "changes": len(changes), "duration(s)": time.Since(now).Seconds()}).Info("Time taken querying for gerrit changes")
 
-		if len(changes) == 0 {
-			return
-		}
+	if len(changes) == 0 {
+		return
+	}
 
-		var wg sync.WaitGroup
-		wg.Add(len(changes))
+	var wg sync.WaitGroup
+	wg.Add(len(changes))
 
-		changeChan := make(chan Change)
-		for i := 0; i < c.workerPoolSize; i++ {
-			go c.syncChange(latest, changeChan, log, &wg)
-		}
+	changeChan := make(chan Change)
+	for i := 0; i < c.workerPoolSize; i++ {
+		go c.syncChange(latest, changeChan, log, &wg)
+	}
 
-		// Trying to understand the performance bottleneck.
-		// Would like to understand how much time a change waits until being
-		// picked up by `c.syncChange`, this will probably be used as an
-		// indicator for deciding the most optimal number of `workerPoolSize`.
-		timeBeforeSent := time.Now()
-		for _, change := range changes {
-			changeChan <- Change{changeInfo: change, instance: instance, tracker: timeBeforeSent}
-		}
-		wg.Wait()
-		close(changeChan)
-		c.tracker.Update(latest)
+	// Trying to understand the performance bottleneck.
+	// Would like to understand how much time a change waits until being
+	// picked up by `c.syncChange`, this will probably be used as an
+	// indicator for deciding the most optimal number of `workerPoolSize`.
+	timeBeforeSent := time.Now()
+	for _, change := range changes {
+		changeChan <- Change{changeInfo: change, instance: instance, tracker: timeBeforeSent}
 	}
+	wg.Wait()
+	close(changeChan)
+	c.tracker.Update(latest)
+}
 
+// Sync looks for newly made gerrit changes
+// and creates prowjobs according to specs
+func (c *Controller) Sync() {
 	for instance := range c.config().Gerrit.OrgReposConfig.AllRepos() {
 		if _, ok := c.instancesWithWorker[instance]; ok {
 			// The work thread of already up for this instance, nothing needs
@@ -282,7 +288,7 @@ func (c *Controller) Sync() {
 					time.Sleep(timeDiff)
 				}
 				previousRun = time.Now()
-				processSingleInstance(instance)
+				c.syncInstance(instance)
 			}
 		}(instance)
 	}
@@ -92,6 +92,14 @@ func (f *fgc) QueryChangesForInstance(instance string, lastState client.LastSync
 	return nil
 }
 
+func (f *fgc) GetChangeIfNew(instance, id string, lastUpdate time.Time) (*client.ChangeInfo, error) {
+	return nil, nil
+}
+
+func (f *fgc) EventsSince(instance string, since time.Time) ([]client.Event, error) {
+	return nil, nil
+}
+
 func (f *fgc) SetReview(instance, id, revision, message string, labels map[string]string) error {
 	f.reviews++
 	return nil
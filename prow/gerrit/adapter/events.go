@@ -0,0 +1,366 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adapter
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"k8s.io/test-infra/prow/config"
+	"k8s.io/test-infra/prow/gerrit/client"
+)
+
+// triggeringEvents are the events that may make Prow trigger jobs.
+var triggeringEvents = sets.NewString(
+	client.EventPatchsetCreated,
+	client.EventCommentAdded,
+	client.EventChangeMerged,
+	client.EventWIPStateChanged,
+)
+
+// reconnectDelay is how long to wait before reconnecting a broken event stream.
+var reconnectDelay = 10 * time.Second
+
+// syncStateFlushInterval is how often the sync state of streamed events is
+// written to the tracker.
+var syncStateFlushInterval = 30 * time.Second
+
+// streamBufferSize is the number of events read from a stream that may wait
+// to be handled while the instance catches up on missed changes.
+const streamBufferSize = 10000
+
+// EventSource streams Gerrit events.
+type EventSource interface {
+	// Stream calls connected once it receives the events of the instance,
+	// then handle for each event, until the stream breaks or ctx is done.
+	// Events that happen while connected runs must not be lost, they are
+	// handled once it returns.
+	Stream(ctx context.Context, instance string, connected func(), handle func(client.Event)) error
+}
+
+// SSHEventSource streams events with `gerrit stream-events` over SSH.
+type SSHEventSource struct {
+	// User is the SSH user, defaults to the local user.
+	User string
+	// IdentityFile is the private key, defaults to the ones ssh picks.
+	IdentityFile string
+	Port         int
+	// Command is the ssh binary, defaults to ssh.
+	Command string
+}
+
+// args returns the arguments of the ssh command streaming the events.
+func (s *SSHEventSource) args(instance string) ([]string, error) {
+	u, err := url.Parse(instance)
+	if err != nil {
+		return nil, fmt.Errorf("invalid instance %q: %w", instance, err)
+	}
+	host := u.Hostname()
+	if host == "" {
+		return nil, fmt.Errorf("invalid instance %q: no host", instance)
+	}
+	if s.User != "" {
+		host = s.User + "@" + host
+	}
+	args := []string{"-p", strconv.Itoa(s.Port), "-o", "BatchMode=yes", "-o", "ServerAliveInterval=30"}
+	if s.IdentityFile != "" {
+		args = append(args, "-i", s.IdentityFile)
+	}
+	args = append(args, host, "gerrit", "stream-events")
+	for _, event := range triggeringEvents.List() {
+		args = append(args, "-s", event)
+	}
+	return args, nil
+}
+
+// Stream implements EventSource.
+func (s *SSHEventSource) Stream(ctx context.Context, instance string, connected func(), handle func(client.Event)) error {
+	args, err := s.args(instance)
+	if err != nil {
+		return err
+	}
+	command := s.Command
+	if command == "" {
+		command = "ssh"
+	}
+	cmd := exec.CommandContext(ctx, command, args...)
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("start %s: %w", command, err)
+	}
+	// Gerrit disconnects consumers that don't keep up, so the events are read
+	// while connected catches up and only handled once it is done.
+	events := make(chan client.Event, streamBufferSize)
+	readErrs := make(chan error, 1)
+	go func() {
+		readErrs <- client.ReadEvents(stdout, func(event client.Event) { events <- event })
+		close(events)
+	}()
+	connected()
+	for event := range events {
+		handle(event)
+	}
+	readErr := <-readErrs
+	if err := cmd.Wait(); err != nil {
+		return fmt.Errorf("stream-events: %w: %s", err, stderr.String())
+	}
+	if readErr != nil {
+		return readErr
+	}
+	return fmt.Errorf("stream-events ended: %s", stderr.String())
+}
+
+type eventsLogClient interface {
+	EventsSince(instance string, since time.Time) ([]client.Event, error)
+}
+
+// eventsLogSource streams events by repeatedly asking the events-log plugin
+// for the events since the last one.
+type eventsLogSource struct {
+	gc       eventsLogClient
+	interval time.Duration
+}
+
+// NewEventsLogSource returns an EventSource that reads the events-log plugin
+// of the instances every interval, with the client of the controller.
+func (c *Controller) NewEventsLogSource(interval time.Duration) EventSource {
+	return &eventsLogSource{gc: c.gc, interval: interval}
+}
+
+// Stream implements EventSource.
+func (s *eventsLogSource) Stream(ctx context.Context, instance string, connected func(), handle func(client.Event)) error {
+	// The events-log plugin has second precision, so the events of the last
+	// second are asked for again and skipped.
+	since := time.Now().Truncate(time.Second)
+	seen := sets.NewString()
+	connected()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(s.interval):
+		}
+		events, err := s.gc.EventsSince(instance, since)
+		if err != nil {
+			return err
+		}
+		for _, event := range events {
+			created := event.Created()
+			if created.Before(since) {
+				continue
+			}
+			key := eventKey(event)
+			if seen.Has(key) {
+				continue
+			}
+			if created.After(since) {
+				since = created
+				seen = sets.NewString()
+			}
+			seen.Insert(key)
+			handle(event)
+		}
+	}
+}
+
+func eventKey(event client.Event) string {
+	key := fmt.Sprintf("%s|%d|%s", event.Type, event.EventCreatedOn, event.ChangeID())
+	if event.PatchSet != nil {
+		key = fmt.Sprintf("%s|%d", key, event.PatchSet.Number)
+	}
+	return key
+}
+
+// SyncEvents processes the changes of instances as the source streams their
+// events, instead of polling them like Sync does. Whenever a stream
+// (re)connects, the instance is polled once to catch up on missed changes.
+// Like Sync, it is meant to be called periodically to pick up new instances.
+func (c *Controller) SyncEvents(ctx context.Context, source EventSource) {
+	for instance := range c.config().Gerrit.OrgReposConfig.AllRepos() {
+		if _, ok := c.instancesWithEvents[instance]; ok {
+			continue
+		}
+		c.instancesWithEvents[instance] = true
+
+		logrus.WithField("instance", instance).Info("Start streaming events for instance.")
+		go c.consumeEvents(ctx, source, instance)
+	}
+}
+
+func (c *Controller) consumeEvents(ctx context.Context, source EventSource, instance string) {
+	log := logrus.WithField("host", instance)
+	// processed records when changes were last processed, so that several
+	// events for the same update of a change trigger jobs only once.
+	processed := map[string]time.Time{}
+	synced := &pendingSyncState{instance: instance}
+	go func() {
+		ticker := time.NewTicker(syncStateFlushInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := synced.flush(c.tracker); err != nil {
+					log.WithError(err).Error("Failed to update last sync state")
+				}
+			}
+		}
+	}()
+	defer func() {
+		if err := synced.flush(c.tracker); err != nil {
+			log.WithError(err).Error("Failed to update last sync state")
+		}
+	}()
+	for {
+		err := source.Stream(ctx, instance, func() {
+			log.Info("Connected to event stream, catching up on missed changes.")
+			c.syncInstance(instance)
+		}, func(event client.Event) {
+			c.handleEvent(log, instance, event, processed, synced)
+		})
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+		log.WithError(err).Warnf("Event stream broke, reconnecting in %s.", reconnectDelay)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(reconnectDelay):
+		}
+	}
+}
+
+// pendingSyncState collects the sync state of the projects of an instance, so
+// that it is written to the tracker periodically instead of for every event.
+type pendingSyncState struct {
+	instance string
+
+	lock     sync.Mutex
+	projects map[string]time.Time
+}
+
+func (p *pendingSyncState) get(project string) (time.Time, bool) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	synced, ok := p.projects[project]
+	return synced, ok
+}
+
+func (p *pendingSyncState) set(project string, synced time.Time) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if p.projects == nil {
+		p.projects = map[string]time.Time{}
+	}
+	if synced.After(p.projects[project]) {
+		p.projects[project] = synced
+	}
+}
+
+// flush writes the pending sync state to the tracker. It is kept pending if
+// that fails.
+func (p *pendingSyncState) flush(tracker LastSyncTracker) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if len(p.projects) == 0 {
+		return nil
+	}
+	if err := tracker.Update(client.LastSyncState{p.instance: p.projects}); err != nil {
+		return err
+	}
+	p.projects = nil
+	return nil
+}
+
+// handleEvent processes the change of the event, if it may trigger jobs.
+func (c *Controller) handleEvent(log *logrus.Entry, instance string, event client.Event, processed map[string]time.Time, synced *pendingSyncState) {
+	if !triggeringEvents.Has(event.Type) || event.Change == nil {
+		return
+	}
+	project := event.Change.Project
+	filter, ok := c.config().Gerrit.OrgReposConfig.AllRepos()[instance][project]
+	if !ok || !branchMatches(filter, event.Change.Branch) {
+		return
+	}
+	log = log.WithFields(logrus.Fields{
+		"event":  event.Type,
+		"branch": event.Change.Branch,
+		"change": event.Change.Number,
+		"repo":   project,
+	})
+
+	lastUpdate, ok := c.tracker.Current()[instance][project]
+	if pending, isPending := synced.get(project); isPending && (!ok || pending.After(lastUpdate)) {
+		lastUpdate, ok = pending, true
+	}
+	if !ok {
+		lastUpdate = event.Created().Add(-time.Second)
+	}
+	change, err := c.gc.GetChangeIfNew(instance, event.ChangeID(), lastUpdate)
+	if err != nil {
+		log.WithError(err).Info("Failed to get change of event")
+		return
+	}
+	if change != nil && !processed[change.ID].Equal(change.Updated.Time) {
+		log = log.WithField("revision", change.CurrentRevision)
+		result := client.ResultSuccess
+		if err := c.processChange(log, instance, *change); err != nil {
+			result = client.ResultError
+			log.WithError(err).Info("Failed to process change")
+		}
+		gerritMetrics.processingResults.WithLabelValues(instance, project, result).Inc()
+		processed[change.ID] = change.Updated.Time
+	}
+
+	// Changes may have been updated after the event, so only the time of the
+	// event is known to be synced. Earlier updates need no deduplication.
+	created := event.Created()
+	for id, updated := range processed {
+		if !updated.After(created) {
+			delete(processed, id)
+		}
+	}
+	synced.set(project, created)
+}
+
+// branchMatches tells whether changes on the branch are queried with the filter.
+func branchMatches(filter *config.GerritQueryFilter, branch string) bool {
+	if filter == nil {
+		return true
+	}
+	if len(filter.Branches) > 0 && !sets.NewString(filter.Branches...).Has(branch) {
+		return false
+	}
+	return !sets.NewString(filter.ExcludedBranches...).Has(branch)
+}
@@ -0,0 +1,268 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adapter
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sirupsen/logrus"
+
+	prowfake "k8s.io/test-infra/prow/client/clientset/versioned/fake"
+	"k8s.io/test-infra/prow/config"
+	"k8s.io/test-infra/prow/gerrit/client"
+)
+
+func TestSSHEventSourceArgs(t *testing.T) {
+	s := &SSHEventSource{User: "prow", IdentityFile: "/etc/ssh/key", Port: 29418}
+	args, err := s.args("https://gerrit-review.example.com")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []string{
+		"-p", "29418", "-o", "BatchMode=yes", "-o", "ServerAliveInterval=30", "-i", "/etc/ssh/key",
+		"prow@gerrit-review.example.com", "gerrit", "stream-events",
+		"-s", "change-merged", "-s", "comment-added", "-s", "patchset-created", "-s", "wip-state-changed",
+	}
+	if diff := cmp.Diff(expected, args); diff != "" {
+		t.Errorf("unexpected args (-want +got):\n%s", diff)
+	}
+
+	if _, err := s.args("not a url"); err == nil {
+		t.Error("expected an error for an instance without host")
+	}
+}
+
+func TestSSHEventSourceStream(t *testing.T) {
+	dir := t.TempDir()
+	script := filepath.Join(dir, "ssh")
+	content := "#!/bin/sh\n" +
+		`echo '{"type":"patchset-created","change":{"project":"foo","branch":"main","id":"I1","number":1},"eventCreatedOn":100}'` + "\n" +
+		"echo 'not json'\n" +
+		`echo '{"type":"ref-updated","eventCreatedOn":101}'` + "\n"
+	if err := os.WriteFile(script, []byte(content), 0755); err != nil {
+		t.Fatalf("failed to write script: %v", err)
+	}
+
+	var connected bool
+	var events []string
+	s := &SSHEventSource{Port: 29418, Command: script}
+	err := s.Stream(context.Background(), "https://gerrit.example.com", func() { connected = true }, func(e client.Event) {
+		if !connected {
+			t.Errorf("event %s was handled before connected returned", e.Type)
+		}
+		events = append(events, e.Type)
+	})
+	if err == nil {
+		t.Error("expected an error when the stream ends")
+	}
+	if !connected {
+		t.Error("expected connected to be called")
+	}
+	if diff := cmp.Diff([]string{"patchset-created", "ref-updated"}, events); diff != "" {
+		t.Errorf("unexpected events (-want +got):\n%s", diff)
+	}
+}
+
+type fakeEventsLog struct {
+	calls  int
+	events [][]client.Event
+}
+
+func (f *fakeEventsLog) EventsSince(instance string, since time.Time) ([]client.Event, error) {
+	f.calls++
+	if f.calls > len(f.events) {
+		return nil, context.Canceled
+	}
+	var events []client.Event
+	for _, e := range f.events[f.calls-1] {
+		if !e.Created().Before(since) {
+			events = append(events, e)
+		}
+	}
+	return events, nil
+}
+
+func TestEventsLogSource(t *testing.T) {
+	now := time.Now().Unix() + 1
+	event := func(number int, created int64) client.Event {
+		return client.Event{
+			Type:           client.EventPatchsetCreated,
+			Change:         &client.EventChange{Project: "foo", Branch: "main", ID: fmt.Sprintf("I%d", number), Number: number},
+			EventCreatedOn: created,
+		}
+	}
+	gc := &fakeEventsLog{events: [][]client.Event{
+		{event(1, now), event(2, now)},
+		// Events of the last second are returned again.
+		{event(1, now), event(2, now), event(3, now+1)},
+		{event(3, now+1), event(4, now+2)},
+	}}
+	s := &eventsLogSource{gc: gc, interval: time.Millisecond}
+
+	var numbers []int
+	err := s.Stream(context.Background(), "https://gerrit", func() {}, func(e client.Event) {
+		numbers = append(numbers, e.Change.Number)
+	})
+	if err != context.Canceled {
+		t.Errorf("expected the error of the client, got %v", err)
+	}
+	if diff := cmp.Diff([]int{1, 2, 3, 4}, numbers); diff != "" {
+		t.Errorf("unexpected events (-want +got):\n%s", diff)
+	}
+}
+
+type eventsGerritClient struct {
+	fgc
+	changes map[string]*client.ChangeInfo
+	gets    []string
+}
+
+func (f *eventsGerritClient) GetChangeIfNew(instance, id string, lastUpdate time.Time) (*client.ChangeInfo, error) {
+	f.gets = append(f.gets, id)
+	change, ok := f.changes[id]
+	if !ok || !change.Updated.After(lastUpdate) {
+		return nil, nil
+	}
+	return change, nil
+}
+
+func TestHandleEvent(t *testing.T) {
+	instance := "https://gerrit"
+	created := timeNow.Unix()
+	merged := &client.ChangeInfo{
+		ID:              "postsubmits-project~main~I1",
+		CurrentRevision: "1",
+		Project:         "postsubmits-project",
+		Branch:          "main",
+		Status:          client.Merged,
+		Updated:         makeStamp(time.Unix(created+5, 0)),
+		Revisions: map[string]client.RevisionInfo{
+			"1": {Ref: "refs/changes/00/1/1", Created: makeStamp(time.Unix(created-60, 0))},
+		},
+	}
+	event := func(eventType, project, branch string) client.Event {
+		return client.Event{
+			Type:           eventType,
+			Change:         &client.EventChange{Project: project, Branch: branch, ID: "I1", Number: 1},
+			EventCreatedOn: created,
+		}
+	}
+
+	cfg := &config.Config{
+		JobConfig: config.JobConfig{
+			ProwYAMLGetterWithDefaults: fakeProwYAMLGetter,
+			ProwYAMLGetter:             fakeProwYAMLGetter,
+			PostsubmitsStatic: map[string][]config.Postsubmit{
+				"https://gerrit/postsubmits-project": {{
+					JobBase:  config.JobBase{Name: "test-bar"},
+					Reporter: config.Reporter{Context: "test-bar", SkipReport: true},
+				}},
+			},
+		},
+		ProwConfig: config.ProwConfig{
+			PodNamespace: namespace,
+			Gerrit: config.Gerrit{
+				OrgReposConfig: &config.GerritOrgRepoConfigs{
+					{Org: instance, Repos: []string{"postsubmits-project"}, Filters: &config.GerritQueryFilter{ExcludedBranches: []string{"excluded"}}},
+				},
+			},
+		},
+	}
+	fca := &fca{c: cfg}
+	cache, err := createTestRepoCache(t, fca)
+	if err != nil {
+		t.Fatalf("error making test repo cache %v", err)
+	}
+	gc := &eventsGerritClient{changes: map[string]*client.ChangeInfo{
+		"postsubmits-project~main~I1": merged,
+	}}
+	tracker := &fakeSync{val: client.LastSyncState{instance: {"postsubmits-project": time.Unix(created-10, 0)}}}
+	fakeProwJobClient := prowfake.NewSimpleClientset()
+	c := &Controller{
+		config:                   fca.Config,
+		prowJobClient:            fakeProwJobClient.ProwV1().ProwJobs("prowjobs"),
+		gc:                       gc,
+		tracker:                  tracker,
+		inRepoConfigCacheHandler: cache,
+		inRepoConfigFailures:     map[string]bool{},
+	}
+	log := logrus.WithField("test", t.Name())
+	processed := map[string]time.Time{}
+	synced := &pendingSyncState{instance: instance}
+
+	// Ignored: not triggering, unknown project, excluded branch.
+	c.handleEvent(log, instance, client.Event{Type: "ref-updated", EventCreatedOn: created}, processed, synced)
+	c.handleEvent(log, instance, event(client.EventChangeMerged, "other-project", "main"), processed, synced)
+	c.handleEvent(log, instance, event(client.EventChangeMerged, "postsubmits-project", "excluded"), processed, synced)
+	if len(gc.gets) != 0 {
+		t.Fatalf("expected ignored events not to get changes, got %v", gc.gets)
+	}
+
+	c.handleEvent(log, instance, event(client.EventChangeMerged, "postsubmits-project", "main"), processed, synced)
+	// The change was updated after the event, so a second event for the same
+	// update of the change must not trigger jobs again.
+	c.handleEvent(log, instance, event(client.EventCommentAdded, "postsubmits-project", "main"), processed, synced)
+
+	if diff := cmp.Diff([]string{"postsubmits-project~main~I1", "postsubmits-project~main~I1"}, gc.gets); diff != "" {
+		t.Errorf("unexpected changes gotten (-want +got):\n%s", diff)
+	}
+	var jobs int
+	for _, action := range fakeProwJobClient.Fake.Actions() {
+		if action.GetVerb() == "create" {
+			jobs++
+		}
+	}
+	if jobs != 1 {
+		t.Errorf("expected one ProwJob, got %d", jobs)
+	}
+	if updated := tracker.Current()[instance]["postsubmits-project"]; !updated.Equal(time.Unix(created-10, 0)) {
+		t.Errorf("expected the sync state to be written only when flushed, got %v", updated)
+	}
+	if err := synced.flush(tracker); err != nil {
+		t.Fatalf("failed to flush sync state: %v", err)
+	}
+	if updated := tracker.Current()[instance]["postsubmits-project"]; !updated.Equal(time.Unix(created, 0)) {
+		t.Errorf("expected the sync state to be the time of the event, got %v", updated)
+	}
+}
+
+func TestBranchMatches(t *testing.T) {
+	testcases := []struct {
+		name     string
+		filter   *config.GerritQueryFilter
+		branch   string
+		expected bool
+	}{
+		{name: "no filter", branch: "main", expected: true},
+		{name: "allowed", filter: &config.GerritQueryFilter{Branches: []string{"main"}}, branch: "main", expected: true},
+		{name: "not allowed", filter: &config.GerritQueryFilter{Branches: []string{"main"}}, branch: "dev", expected: false},
+		{name: "excluded", filter: &config.GerritQueryFilter{ExcludedBranches: []string{"dev"}}, branch: "dev", expected: false},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := branchMatches(tc.filter, tc.branch); actual != tc.expected {
+				t.Errorf("expected %t, got %t", tc.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,188 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package checks presents ProwJobs as the check runs of the Checks UI of
+// Gerrit, see https://gerrit-review.googlesource.com/Documentation/pg-plugin-checks-api.html
+package checks
+
+import (
+	"sort"
+	"strconv"
+	"time"
+
+	prowapi "k8s.io/test-infra/prow/apis/prowjobs/v1"
+	"k8s.io/test-infra/prow/kube"
+)
+
+// RunStatus is the status of a check run.
+type RunStatus string
+
+const (
+	RunStatusRunnable  RunStatus = "RUNNABLE"
+	RunStatusScheduled RunStatus = "SCHEDULED"
+	RunStatusRunning   RunStatus = "RUNNING"
+	RunStatusCompleted RunStatus = "COMPLETED"
+)
+
+// Category is the category of the result of a check run.
+type Category string
+
+const (
+	CategorySuccess Category = "SUCCESS"
+	CategoryInfo    Category = "INFO"
+	CategoryWarning Category = "WARNING"
+	CategoryError   Category = "ERROR"
+)
+
+// ResponseCodeOK is the response code of a successful fetch.
+const ResponseCodeOK = "OK"
+
+// Response is what the checks provider of the Prow plugin returns to Gerrit.
+type Response struct {
+	ResponseCode string   `json:"responseCode"`
+	ErrorMessage string   `json:"errorMessage,omitempty"`
+	Actions      []Action `json:"actions,omitempty"`
+	Runs         []Run    `json:"runs"`
+}
+
+// Run is a run of a ProwJob on a patchset.
+type Run struct {
+	Change            int        `json:"change,omitempty"`
+	Patchset          int        `json:"patchset,omitempty"`
+	Attempt           int        `json:"attempt,omitempty"`
+	ExternalID        string     `json:"externalId,omitempty"`
+	CheckName         string     `json:"checkName"`
+	CheckDescription  string     `json:"checkDescription,omitempty"`
+	Status            RunStatus  `json:"status"`
+	StatusDescription string     `json:"statusDescription,omitempty"`
+	StatusLink        string     `json:"statusLink,omitempty"`
+	LabelName         string     `json:"labelName,omitempty"`
+	Actions           []Action   `json:"actions,omitempty"`
+	ScheduledTime     *time.Time `json:"scheduledTimestamp,omitempty"`
+	StartedTime       *time.Time `json:"startedTimestamp,omitempty"`
+	FinishedTime      *time.Time `json:"finishedTimestamp,omitempty"`
+	Results           []Result   `json:"results,omitempty"`
+}
+
+// Result is the result of a completed run.
+type Result struct {
+	Category Category `json:"category"`
+	Summary  string   `json:"summary"`
+	Links    []Link   `json:"links,omitempty"`
+}
+
+// Link is a link shown with a result.
+type Link struct {
+	URL     string `json:"url"`
+	Tooltip string `json:"tooltip,omitempty"`
+	Primary bool   `json:"primary"`
+	// Icon is one of the icons of the Checks UI, like external or history.
+	Icon string `json:"icon"`
+}
+
+// Action is a button of the Checks UI. Gerrit actions are callbacks, the
+// plugin implements them by posting Comment on the patchset, like a user
+// would to trigger jobs.
+type Action struct {
+	Name    string `json:"name"`
+	Tooltip string `json:"tooltip,omitempty"`
+	Primary bool   `json:"primary,omitempty"`
+	Comment string `json:"comment"`
+}
+
+// RetestAction reruns the failed jobs of a patchset.
+var RetestAction = Action{Name: "Rerun failed", Tooltip: "Rerun the failed Prow jobs", Comment: "/retest"}
+
+// Runs returns the runs of the ProwJobs that are about the patchset of the
+// change, with one attempt for each time a job was triggered.
+func Runs(pjs []prowapi.ProwJob, instance string, change, patchset int) []Run {
+	var matching []prowapi.ProwJob
+	for _, pj := range pjs {
+		if pj.Annotations[kube.GerritInstance] != instance || pj.Spec.Refs == nil || len(pj.Spec.Refs.Pulls) != 1 {
+			continue
+		}
+		if pj.Spec.Refs.Pulls[0].Number != change || pj.Labels[kube.GerritPatchset] != strconv.Itoa(patchset) {
+			continue
+		}
+		matching = append(matching, pj)
+	}
+	sort.SliceStable(matching, func(i, j int) bool {
+		return matching[i].Status.StartTime.Before(&matching[j].Status.StartTime)
+	})
+
+	attempts := map[string]int{}
+	runs := make([]Run, 0, len(matching))
+	for _, pj := range matching {
+		attempts[pj.Spec.Job]++
+		run := runFromProwJob(pj)
+		run.Change = change
+		run.Patchset = patchset
+		run.Attempt = attempts[pj.Spec.Job]
+		runs = append(runs, run)
+	}
+	return runs
+}
+
+func runFromProwJob(pj prowapi.ProwJob) Run {
+	run := Run{
+		ExternalID:        pj.Name,
+		CheckName:         pj.Spec.Job,
+		CheckDescription:  pj.Spec.Context,
+		StatusDescription: pj.Status.Description,
+		StatusLink:        pj.Status.URL,
+		LabelName:         pj.Labels[kube.GerritReportLabel],
+	}
+	if pj.Spec.RerunCommand != "" {
+		run.Actions = []Action{{Name: "Rerun", Tooltip: "Trigger the job again", Primary: true, Comment: pj.Spec.RerunCommand}}
+	}
+	scheduled := pj.CreationTimestamp.Time
+	if scheduled.IsZero() {
+		scheduled = pj.Status.StartTime.Time
+	}
+	run.ScheduledTime = &scheduled
+	if pj.Status.PendingTime != nil {
+		run.StartedTime = &pj.Status.PendingTime.Time
+	}
+
+	var category Category
+	switch pj.Status.State {
+	case prowapi.TriggeredState, "":
+		run.Status = RunStatusScheduled
+		return run
+	case prowapi.PendingState:
+		run.Status = RunStatusRunning
+		return run
+	case prowapi.SuccessState:
+		category = CategorySuccess
+	case prowapi.AbortedState:
+		category = CategoryInfo
+	default:
+		category = CategoryError
+	}
+	run.Status = RunStatusCompleted
+	if pj.Status.CompletionTime != nil {
+		run.FinishedTime = &pj.Status.CompletionTime.Time
+	}
+	result := Result{Category: category, Summary: pj.Status.Description}
+	if result.Summary == "" {
+		result.Summary = string(pj.Status.State)
+	}
+	if pj.Status.URL != "" {
+		result.Links = []Link{{URL: pj.Status.URL, Tooltip: "Job results", Primary: true, Icon: "external"}}
+	}
+	run.Results = []Result{result}
+	return run
+}
@@ -0,0 +1,95 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package checks
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	prowapi "k8s.io/test-infra/prow/apis/prowjobs/v1"
+	"k8s.io/test-infra/prow/kube"
+)
+
+func TestRuns(t *testing.T) {
+	start := time.Date(2022, 4, 1, 10, 0, 0, 0, time.UTC)
+	pending := metav1.NewTime(start.Add(time.Minute))
+	completed := metav1.NewTime(start.Add(time.Hour))
+	pj := func(name, job string, change int, patchset string, started time.Duration, state prowapi.ProwJobState) prowapi.ProwJob {
+		return prowapi.ProwJob{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				Labels:      map[string]string{kube.GerritPatchset: patchset, kube.GerritReportLabel: "Verified"},
+				Annotations: map[string]string{kube.GerritInstance: "https://gerrit"},
+			},
+			Spec: prowapi.ProwJobSpec{
+				Job:          job,
+				Context:      job,
+				RerunCommand: "/test " + job,
+				Refs:         &prowapi.Refs{Org: "https://gerrit", Repo: "foo", Pulls: []prowapi.Pull{{Number: change}}},
+			},
+			Status: prowapi.ProwJobStatus{
+				State:          state,
+				StartTime:      metav1.NewTime(start.Add(started)),
+				PendingTime:    &pending,
+				CompletionTime: &completed,
+				Description:    "Job " + string(state),
+				URL:            "https://prow/view/" + name,
+			},
+		}
+	}
+	pjs := []prowapi.ProwJob{
+		pj("retry", "unit", 1, "2", time.Minute, prowapi.PendingState),
+		pj("first", "unit", 1, "2", 0, prowapi.FailureState),
+		pj("lint", "lint", 1, "2", 0, prowapi.SuccessState),
+		pj("old-patchset", "unit", 1, "1", 0, prowapi.SuccessState),
+		pj("other-change", "unit", 2, "2", 0, prowapi.SuccessState),
+	}
+	runs := Runs(pjs, "https://gerrit", 1, 2)
+
+	scheduled := func(d time.Duration) *time.Time {
+		t := start.Add(d)
+		return &t
+	}
+	expected := []Run{
+		{
+			Change: 1, Patchset: 2, Attempt: 1, ExternalID: "first", CheckName: "unit", CheckDescription: "unit",
+			Status: RunStatusCompleted, StatusDescription: "Job failure", StatusLink: "https://prow/view/first", LabelName: "Verified",
+			Actions:       []Action{{Name: "Rerun", Tooltip: "Trigger the job again", Primary: true, Comment: "/test unit"}},
+			ScheduledTime: scheduled(0), StartedTime: &pending.Time, FinishedTime: &completed.Time,
+			Results: []Result{{Category: CategoryError, Summary: "Job failure", Links: []Link{{URL: "https://prow/view/first", Tooltip: "Job results", Primary: true, Icon: "external"}}}},
+		},
+		{
+			Change: 1, Patchset: 2, Attempt: 1, ExternalID: "lint", CheckName: "lint", CheckDescription: "lint",
+			Status: RunStatusCompleted, StatusDescription: "Job success", StatusLink: "https://prow/view/lint", LabelName: "Verified",
+			Actions:       []Action{{Name: "Rerun", Tooltip: "Trigger the job again", Primary: true, Comment: "/test lint"}},
+			ScheduledTime: scheduled(0), StartedTime: &pending.Time, FinishedTime: &completed.Time,
+			Results: []Result{{Category: CategorySuccess, Summary: "Job success", Links: []Link{{URL: "https://prow/view/lint", Tooltip: "Job results", Primary: true, Icon: "external"}}}},
+		},
+		{
+			Change: 1, Patchset: 2, Attempt: 2, ExternalID: "retry", CheckName: "unit", CheckDescription: "unit",
+			Status: RunStatusRunning, StatusDescription: "Job pending", StatusLink: "https://prow/view/retry", LabelName: "Verified",
+			Actions:       []Action{{Name: "Rerun", Tooltip: "Trigger the job again", Primary: true, Comment: "/test unit"}},
+			ScheduledTime: scheduled(time.Minute), StartedTime: &pending.Time,
+		},
+	}
+	if diff := cmp.Diff(expected, runs); diff != "" {
+		t.Errorf("unexpected runs (-want +got):\n%s", diff)
+	}
+}
@@ -40,7 +40,12 @@ import (
 	"k8s.io/test-infra/prow/version"
 )
 
+// queryFields are the additional fields of changes needed to process them.
+var queryFields = []string{"CURRENT_REVISION", "CURRENT_COMMIT", "CURRENT_FILES", "MESSAGES"}
+
 const (
+	// AutogeneratedTag tags messages of Prow as autogenerated.
+	AutogeneratedTag = "autogenerated:prow"
 	// CodeReview is the default (soon to be removed) gerrit code review label
 	CodeReview = "Code-Review"
 
@@ -99,6 +104,11 @@ type gerritProjects interface {
 	GetBranch(projectName, branchID string) (*gerrit.BranchInfo, *gerrit.Response, error)
 }
 
+type gerritEvents interface {
+	NewRequest(method, urlStr string, body interface{}) (*http.Request, error)
+	Do(req *http.Request, v interface{}) (*gerrit.Response, error)
+}
+
 // gerritInstanceHandler holds all actual gerrit handlers
 type gerritInstanceHandler struct {
 	instance string
@@ -108,6 +118,7 @@ type gerritInstanceHandler struct {
 	accountService gerritAccount
 	changeService  gerritChange
 	projectService gerritProjects
+	eventsService  gerritEvents
 
 	log logrus.FieldLogger
 }
@@ -179,6 +190,7 @@ func NewClient(instances map[string]map[string]*config.GerritQueryFilter) (*Clie
 			accountService: gc.Accounts,
 			changeService:  gc.Changes,
 			projectService: gc.Projects,
+			eventsService:  gc,
 			log:            logrus.WithField("host", instance),
 		}
 	}
@@ -325,6 +337,7 @@ func (c *Client) UpdateClients(instances map[string]map[string]*config.GerritQue
 			accountService: gc.Accounts,
 			changeService:  gc.Changes,
 			projectService: gc.Projects,
+			eventsService:  gc,
 			log:            logrus.WithField("host", instance),
 		}
 	}
@@ -455,6 +468,12 @@ func responseBodyError(err error, resp *gerrit.Response) error {
 
 // SetReview writes a review comment base on the change id + revision
 func (c *Client) SetReview(instance, id, revision, message string, labels map[string]string) error {
+	return c.SetReviewWithTag(instance, id, revision, message, "", labels)
+}
+
+// SetReviewWithTag writes a review comment with the tag. Messages tagged with
+// an "autogenerated:" prefix are hidden from the change history by default.
+func (c *Client) SetReviewWithTag(instance, id, revision, message, tag string, labels map[string]string) error {
 	c.lock.RLock()
 	defer c.lock.RUnlock()
 	h, ok := c.handlers[instance]
@@ -464,6 +483,7 @@ func (c *Client) SetReview(instance, id, revision, message string, labels map[st
 
 	if _, resp, err := h.changeService.SetReview(id, revision, &gerrit.ReviewInput{
 		Message: message,
+		Tag:     tag,
 		Labels:  labels,
 	}); err != nil {
 		return fmt.Errorf("cannot comment to gerrit: %w", responseBodyError(err, resp))
@@ -602,7 +622,7 @@ func (h *gerritInstanceHandler) QueryChangesForProject(log logrus.FieldLogger, p
 
 	var opt gerrit.QueryChangeOptions
 	opt.Query = append(opt.Query, strings.Join(append(additionalFilters, "project:"+project), "+"))
-	opt.AdditionalFields = []string{"CURRENT_REVISION", "CURRENT_COMMIT", "CURRENT_FILES", "MESSAGES"}
+	opt.AdditionalFields = queryFields
 
 	log = log.WithFields(logrus.Fields{"query": opt.Query, "additional_fields": opt.AdditionalFields})
 	var start int
@@ -647,62 +667,71 @@ func (h *gerritInstanceHandler) QueryChangesForProject(log logrus.FieldLogger, p
 				return pending, nil
 			}
 
-			// process recently updated change
-			switch change.Status {
-			case Merged:
-				submitted := parseStamp(*change.Submitted)
-				log := log.WithField("submitted", submitted)
-				if !submitted.After(lastUpdate) {
-					log.Debug("Skipping previously merged change")
-					continue
-				}
-				log.Debug("Found merged change")
+			if h.isNew(log, &change, lastUpdate) {
 				pending = append(pending, change)
-			case New:
-				// we need to make sure the change update is from a fresh commit change
-				rev, ok := change.Revisions[change.CurrentRevision]
-				if !ok {
-					log.WithError(err).WithField("revision", change.CurrentRevision).Error("Revision not found")
-					continue
-				}
+			}
+		}
+	}
+}
 
-				created := parseStamp(rev.Created)
-				log := log.WithField("created", created)
-				if err := h.injectPatchsetMessages(&change); err != nil {
-					log.WithError(err).Error("Failed to inject patchset messages")
-				}
-				changeMessages := change.Messages
-				var newMessages bool
-
-				for _, message := range changeMessages {
-					if message.RevisionNumber == rev.Number {
-						messageTime := parseStamp(message.Date)
-						if messageTime.After(lastUpdate) {
-							log.WithFields(logrus.Fields{
-								"message":     message.Message,
-								"messageDate": messageTime,
-							}).Info("New messages")
-							newMessages = true
-							break
-						}
-					}
-				}
+// isNew tells whether the change has anything to process since lastUpdate:
+// a merge, a new revision or new messages on the current revision. Patchset
+// level comments are injected into the messages of new changes.
+func (h *gerritInstanceHandler) isNew(log logrus.FieldLogger, change *gerrit.ChangeInfo, lastUpdate time.Time) bool {
+	switch change.Status {
+	case Merged:
+		submitted := parseStamp(*change.Submitted)
+		log := log.WithField("submitted", submitted)
+		if !submitted.After(lastUpdate) {
+			log.Debug("Skipping previously merged change")
+			return false
+		}
+		log.Debug("Found merged change")
+		return true
+	case New:
+		// we need to make sure the change update is from a fresh commit change
+		rev, ok := change.Revisions[change.CurrentRevision]
+		if !ok {
+			log.WithField("revision", change.CurrentRevision).Error("Revision not found")
+			return false
+		}
 
-				if !newMessages && !created.After(lastUpdate) {
-					// stale commit
-					log.Debug("Skipping existing change")
-					continue
-				}
-				if !newMessages {
-					log.Debug("Found updated change")
+		created := parseStamp(rev.Created)
+		log := log.WithField("created", created)
+		if err := h.injectPatchsetMessages(change); err != nil {
+			log.WithError(err).Error("Failed to inject patchset messages")
+		}
+		changeMessages := change.Messages
+		var newMessages bool
+
+		for _, message := range changeMessages {
+			if message.RevisionNumber == rev.Number {
+				messageTime := parseStamp(message.Date)
+				if messageTime.After(lastUpdate) {
+					log.WithFields(logrus.Fields{
+						"message":     message.Message,
+						"messageDate": messageTime,
+					}).Info("New messages")
+					newMessages = true
+					break
 				}
-				pending = append(pending, change)
-			default:
-				// change has been abandoned, do nothing
-				log.Debug("Ignored change")
 			}
 		}
+
+		if !newMessages && !created.After(lastUpdate) {
+			// stale commit
+			log.Debug("Skipping existing change")
+			return false
+		}
+		if !newMessages {
+			log.Debug("Found updated change")
+		}
+		return true
+	default:
+		// change has been abandoned, do nothing
+		log.Debug("Ignored change")
 	}
+	return false
 }
 
 // ChangedFilesProvider lists (in lexicographic order) the files changed as part of a Gerrit patchset.
@@ -0,0 +1,157 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"time"
+
+	gerrit "github.com/andygrunwald/go-gerrit"
+	"github.com/sirupsen/logrus"
+)
+
+// Event types of Gerrit stream-events that can trigger Prow jobs.
+// See https://gerrit-review.googlesource.com/Documentation/cmd-stream-events.html
+const (
+	EventPatchsetCreated = "patchset-created"
+	EventCommentAdded    = "comment-added"
+	EventChangeMerged    = "change-merged"
+	EventWIPStateChanged = "wip-state-changed"
+)
+
+// eventsLogTimeFormat is the format of the time range parameters of the
+// events-log plugin, in UTC.
+const eventsLogTimeFormat = "2006-01-02 15:04:05"
+
+// Event is an event as emitted by Gerrit stream-events, and as returned by the
+// events-log plugin. Only the fields Prow needs are decoded.
+type Event struct {
+	Type           string         `json:"type"`
+	Change         *EventChange   `json:"change,omitempty"`
+	PatchSet       *EventPatchSet `json:"patchSet,omitempty"`
+	EventCreatedOn int64          `json:"eventCreatedOn"`
+}
+
+// EventChange is the change attribute of an event.
+type EventChange struct {
+	Project string `json:"project"`
+	Branch  string `json:"branch"`
+	// ID is the Change-Id.
+	ID     string `json:"id"`
+	Number int    `json:"number"`
+	Status string `json:"status,omitempty"`
+}
+
+// EventPatchSet is the patchSet attribute of an event.
+type EventPatchSet struct {
+	Number   int    `json:"number"`
+	Revision string `json:"revision"`
+}
+
+// Created returns the time the event was created at.
+func (e Event) Created() time.Time {
+	return time.Unix(e.EventCreatedOn, 0)
+}
+
+// ChangeID returns the ID identifying the change of the event in the REST API,
+// which is unique even when the Change-Id is used on several branches.
+func (e Event) ChangeID() string {
+	if e.Change == nil {
+		return ""
+	}
+	return fmt.Sprintf("%s~%s~%s", url.PathEscape(e.Change.Project), url.PathEscape(e.Change.Branch), e.Change.ID)
+}
+
+// ReadEvents calls handle for each event in r, which holds one JSON event per
+// line, until r is exhausted. Lines that are not valid events are skipped.
+func ReadEvents(r io.Reader, handle func(Event)) error {
+	scanner := bufio.NewScanner(r)
+	// Events include commit messages and comments, which can be long.
+	scanner.Buffer(make([]byte, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var event Event
+		if err := json.Unmarshal(line, &event); err != nil {
+			logrus.WithError(err).WithField("line", string(line)).Warn("Skipping malformed Gerrit event.")
+			continue
+		}
+		handle(event)
+	}
+	return scanner.Err()
+}
+
+// EventsSince returns the events created at or after since, as stored by the
+// events-log plugin of the instance.
+func (c *Client) EventsSince(instance string, since time.Time) ([]Event, error) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	h, ok := c.handlers[instance]
+	if !ok {
+		return nil, fmt.Errorf("not activated gerrit instance: %s", instance)
+	}
+
+	u := "plugins/events-log/events/?t1=" + url.QueryEscape(since.UTC().Format(eventsLogTimeFormat))
+	req, err := h.eventsService.NewRequest("GET", u, nil)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	resp, err := h.eventsService.Do(req, &buf)
+	if err != nil {
+		return nil, fmt.Errorf("error getting events: %w", responseBodyError(err, resp))
+	}
+	var events []Event
+	if err := ReadEvents(&buf, func(e Event) { events = append(events, e) }); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// GetChangeIfNew returns the change with the fields Prow needs to process it,
+// or nil if nothing happened to it since lastUpdate. This is how changes
+// found by QueryChangesForProject are filtered.
+func (c *Client) GetChangeIfNew(instance, id string, lastUpdate time.Time) (*ChangeInfo, error) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	h, ok := c.handlers[instance]
+	if !ok {
+		return nil, fmt.Errorf("not activated gerrit instance: %s", instance)
+	}
+
+	change, resp, err := h.changeService.GetChange(id, &gerrit.ChangeOptions{AdditionalFields: queryFields})
+	if err != nil {
+		return nil, fmt.Errorf("error getting change %s: %w", id, responseBodyError(err, resp))
+	}
+	log := h.log.WithFields(logrus.Fields{
+		"change":     change.Number,
+		"updated":    change.Updated,
+		"status":     change.Status,
+		"lastUpdate": lastUpdate,
+	})
+	if !h.isNew(log, change, lastUpdate) {
+		return nil, nil
+	}
+	return change, nil
+}
@@ -0,0 +1,99 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	gerrit "github.com/andygrunwald/go-gerrit"
+	"github.com/google/go-cmp/cmp"
+	"github.com/sirupsen/logrus"
+)
+
+const testEvents = `{"type":"patchset-created","change":{"project":"platform/build","branch":"main","id":"I1","number":1,"status":"NEW"},"patchSet":{"number":2,"revision":"abc"},"eventCreatedOn":1650000000}
+
+not an event
+{"type":"change-merged","change":{"project":"foo","branch":"main","id":"I2","number":2,"status":"MERGED"},"eventCreatedOn":1650000001}
+`
+
+func TestReadEvents(t *testing.T) {
+	var events []Event
+	if err := ReadEvents(strings.NewReader(testEvents), func(e Event) { events = append(events, e) }); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []Event{
+		{
+			Type:           EventPatchsetCreated,
+			Change:         &EventChange{Project: "platform/build", Branch: "main", ID: "I1", Number: 1, Status: New},
+			PatchSet:       &EventPatchSet{Number: 2, Revision: "abc"},
+			EventCreatedOn: 1650000000,
+		},
+		{
+			Type:           EventChangeMerged,
+			Change:         &EventChange{Project: "foo", Branch: "main", ID: "I2", Number: 2, Status: Merged},
+			EventCreatedOn: 1650000001,
+		},
+	}
+	if diff := cmp.Diff(expected, events); diff != "" {
+		t.Errorf("unexpected events (-want +got):\n%s", diff)
+	}
+	if id := events[0].ChangeID(); id != "platform%2Fbuild~main~I1" {
+		t.Errorf("unexpected change ID %q", id)
+	}
+	if created := events[1].Created(); !created.Equal(time.Unix(1650000001, 0)) {
+		t.Errorf("unexpected creation time %v", created)
+	}
+}
+
+type fakeEventsService struct {
+	url string
+}
+
+func (f *fakeEventsService) NewRequest(method, urlStr string, body interface{}) (*http.Request, error) {
+	return http.NewRequest(method, "https://gerrit/a/"+urlStr, nil)
+}
+
+func (f *fakeEventsService) Do(req *http.Request, v interface{}) (*gerrit.Response, error) {
+	f.url = req.URL.String()
+	_, err := io.Copy(v.(io.Writer), strings.NewReader(testEvents))
+	return nil, err
+}
+
+func TestEventsSince(t *testing.T) {
+	events := &fakeEventsService{}
+	c := &Client{handlers: map[string]*gerritInstanceHandler{
+		"https://gerrit": {instance: "https://gerrit", eventsService: events, log: logrus.WithField("host", "https://gerrit")},
+	}}
+	got, err := c.EventsSince("https://gerrit", time.Date(2022, 4, 15, 5, 20, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := "https://gerrit/a/plugins/events-log/events/?t1=2022-04-15+05%3A20%3A00"; events.url != expected {
+		t.Errorf("expected request to %s, got %s", expected, events.url)
+	}
+	if len(got) != 2 {
+		t.Errorf("expected 2 events, got %d", len(got))
+	}
+
+	if _, err := c.EventsSince("https://unknown", time.Now()); err == nil {
+		t.Error("expected an error for an unknown instance")
+	}
+}
@@ -18,9 +18,13 @@ package fakegerrit
 
 import (
 	"fmt"
+	"strings"
 	"sync"
+	"time"
 
 	gerrit "github.com/andygrunwald/go-gerrit"
+
+	"k8s.io/test-infra/prow/gerrit/client"
 )
 
 type Project struct {
@@ -37,6 +41,8 @@ type FakeGerrit struct {
 	Changes  map[string]Change
 	Accounts map[string]*gerrit.AccountInfo
 	Projects map[string]*Project
+	// Events are served like the events-log plugin does.
+	Events []client.Event
 	// lock to be thread safe
 	lock sync.Mutex
 }
@@ -48,6 +54,7 @@ func (fg *FakeGerrit) Reset() {
 	fg.Changes = make(map[string]Change)
 	fg.Accounts = make(map[string]*gerrit.AccountInfo)
 	fg.Projects = make(map[string]*Project)
+	fg.Events = nil
 }
 
 // Returns changes from project with name `projectName``. Skips the first `start` number of ChangeIDs. `desiredTotal` caps the total to a number smaller or equal to the actual total number of ChangeIDs.
@@ -93,6 +100,48 @@ func (fg *FakeGerrit) AddChange(projectName string, change *gerrit.ChangeInfo) {
 	}
 
 	fg.Changes[change.ChangeID] = Change{ChangeInfo: change, Comments: make(map[string][]*gerrit.CommentInfo)}
+
+	// Adding a change is like uploading or merging it.
+	event := client.Event{
+		Type: client.EventPatchsetCreated,
+		Change: &client.EventChange{
+			Project: projectName,
+			Branch:  change.Branch,
+			ID:      change.ChangeID,
+			Number:  change.Number,
+			Status:  change.Status,
+		},
+		EventCreatedOn: time.Now().Unix(),
+	}
+	if change.Status == client.Merged {
+		event.Type = client.EventChangeMerged
+	}
+	if rev, ok := change.Revisions[change.CurrentRevision]; ok {
+		event.PatchSet = &client.EventPatchSet{Number: rev.Number, Revision: change.CurrentRevision}
+	}
+	fg.Events = append(fg.Events, event)
+}
+
+// AddEvent adds an event, like one for a comment, to Fake gerrit.
+func (fg *FakeGerrit) AddEvent(event client.Event) {
+	fg.lock.Lock()
+	defer fg.lock.Unlock()
+
+	fg.Events = append(fg.Events, event)
+}
+
+// GetEvents returns the events created at or after since.
+func (fg *FakeGerrit) GetEvents(since time.Time) []client.Event {
+	fg.lock.Lock()
+	defer fg.lock.Unlock()
+
+	var res []client.Event
+	for _, event := range fg.Events {
+		if !event.Created().Before(since) {
+			res = append(res, event)
+		}
+	}
+	return res
 }
 
 func (fg *FakeGerrit) AddBranch(projectName, branchName string, branch *gerrit.BranchInfo) {
@@ -124,10 +173,15 @@ func (fg *FakeGerrit) GetBranch(projectName, branchID string) *gerrit.BranchInfo
 	return res
 }
 
+// GetChange returns the change with the Change-Id, which can also be given as
+// project~branch~Change-Id.
 func (fg *FakeGerrit) GetChange(id string) *gerrit.ChangeInfo {
 	fg.lock.Lock()
 	defer fg.lock.Unlock()
 
+	if i := strings.LastIndex(id, "~"); i >= 0 {
+		id = id[i+1:]
+	}
 	if res, ok := fg.Changes[id]; ok {
 		return res.ChangeInfo
 	}
@@ -18,8 +18,11 @@ package fakegerrit
 
 import (
 	"testing"
+	"time"
 
 	gerrit "github.com/andygrunwald/go-gerrit"
+
+	"k8s.io/test-infra/prow/gerrit/client"
 )
 
 func TestAddChange(t *testing.T) {
@@ -46,3 +49,22 @@ func TestAddChange(t *testing.T) {
 		})
 	}
 }
+
+func TestEvents(t *testing.T) {
+	fg := NewFakeGerritClient()
+	before := time.Now().Add(-time.Second)
+	fg.AddChange("testproject", &gerrit.ChangeInfo{ChangeID: "I1", Branch: "main", Number: 1})
+	fg.AddChange("testproject", &gerrit.ChangeInfo{ChangeID: "I2", Branch: "main", Number: 2, Status: client.Merged})
+	fg.AddEvent(client.Event{Type: client.EventCommentAdded, EventCreatedOn: before.Add(-time.Hour).Unix()})
+
+	events := fg.GetEvents(before)
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(events))
+	}
+	if events[0].Type != client.EventPatchsetCreated || events[1].Type != client.EventChangeMerged {
+		t.Errorf("unexpected event types %q and %q", events[0].Type, events[1].Type)
+	}
+	if change := fg.GetChange(events[1].ChangeID()); change == nil || change.Number != 2 {
+		t.Errorf("expected to get the change of the event, got %v", change)
+	}
+}
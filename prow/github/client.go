@@ -157,6 +157,7 @@ type CommitClient interface {
 	DeleteRef(org, repo, ref string) error
 	ListFileCommits(org, repo, path string) ([]RepositoryCommit, error)
 	CreateCheckRun(org, repo string, checkRun CheckRun) error
+	UpdateCheckRun(org, repo string, checkRunID int64, checkRun CheckRun) error
 }
 
 // RepositoryClient interface for repository related API actions
@@ -4983,6 +4984,22 @@ func (c *client) CreateCheckRun(org, repo string, checkRun CheckRun) error {
 	return nil
 }
 
+// UpdateCheckRun updates an existing check run for a specific commit in a repository.
+//
+// See https://docs.github.com/en/rest/checks/runs#update-a-check-run
+func (c *client) UpdateCheckRun(org, repo string, checkRunID int64, checkRun CheckRun) error {
+	durationLogger := c.log("UpdateCheckRun", org, repo, checkRunID, checkRun)
+	defer durationLogger()
+	_, err := c.request(&request{
+		method:      http.MethodPatch,
+		path:        fmt.Sprintf("/repos/%s/%s/check-runs/%d", org, repo, checkRunID),
+		org:         org,
+		requestBody: &checkRun,
+		exitCodes:   []int{200},
+	}, nil)
+	return err
+}
+
 // Simple function to check if GitHub App Authentication is being used
 func (c *client) UsesAppAuth() bool {
 	return c.delegate.usesAppsAuth
@@ -3608,6 +3608,38 @@ func TestCreateCheckRun(t *testing.T) {
 	}
 }
 
+func TestUpdateCheckRun(t *testing.T) {
+	checkRun := CheckRun{
+		Name:       "foo",
+		Status:     CheckRunStatusCompleted,
+		Conclusion: CheckRunConclusionSuccess,
+	}
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPatch {
+			t.Errorf("Bad method: %s", r.Method)
+		}
+		if r.URL.Path != "/repos/k8s/kuber/check-runs/42" {
+			t.Errorf("Bad request path: %s", r.URL.Path)
+		}
+		b, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Fatalf("Could not read request body: %v", err)
+		}
+		var cr CheckRun
+		if err := json.Unmarshal(b, &cr); err != nil {
+			t.Errorf("Could not unmarshal request: %v", err)
+		} else if !reflect.DeepEqual(checkRun, cr) {
+			t.Errorf("expected checkrun differs from actual: %s", cmp.Diff(checkRun, cr))
+		}
+		http.Error(w, "200 OK", http.StatusOK)
+	}))
+	defer ts.Close()
+	c := getClient(ts.URL)
+	if err := c.UpdateCheckRun("k8s", "kuber", 42, checkRun); err != nil {
+		t.Errorf("Didn't expect error: %v", err)
+	}
+}
+
 func TestIsAppInstalled(t *testing.T) {
 	testCases := []struct {
 		name     string
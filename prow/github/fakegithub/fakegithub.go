@@ -58,6 +58,7 @@ type FakeClient struct {
 	Reviews                    map[int][]github.Review
 	CombinedStatuses           map[string]*github.CombinedStatus
 	CreatedStatuses            map[string][]github.Status
+	CheckRuns                  map[string][]github.CheckRun
 	IssueEvents                map[int][]github.ListedIssueEvent
 	Commits                    map[string]github.RepositoryCommit
 
@@ -545,6 +546,56 @@ func (f *FakeClient) GetCombinedStatus(owner, repo, ref string) (*github.Combine
 	return f.CombinedStatuses[ref], nil
 }
 
+// ListCheckRuns lists the check runs created for a commit.
+func (f *FakeClient) ListCheckRuns(org, repo, ref string) (*github.CheckRunList, error) {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+	if f.Error != nil {
+		return nil, f.Error
+	}
+	checkRuns := append([]github.CheckRun(nil), f.CheckRuns[ref]...)
+	return &github.CheckRunList{Total: len(checkRuns), CheckRuns: checkRuns}, nil
+}
+
+// CreateCheckRun creates a check run for the commit in checkRun.HeadSHA.
+func (f *FakeClient) CreateCheckRun(org, repo string, checkRun github.CheckRun) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if f.Error != nil {
+		return f.Error
+	}
+	if f.CheckRuns == nil {
+		f.CheckRuns = make(map[string][]github.CheckRun)
+	}
+	var total int
+	for _, checkRuns := range f.CheckRuns {
+		total += len(checkRuns)
+	}
+	checkRun.ID = int64(total + 1)
+	f.CheckRuns[checkRun.HeadSHA] = append(f.CheckRuns[checkRun.HeadSHA], checkRun)
+	return nil
+}
+
+// UpdateCheckRun replaces the check run with the given ID.
+func (f *FakeClient) UpdateCheckRun(org, repo string, checkRunID int64, checkRun github.CheckRun) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if f.Error != nil {
+		return f.Error
+	}
+	for sha, checkRuns := range f.CheckRuns {
+		for i := range checkRuns {
+			if checkRuns[i].ID == checkRunID {
+				checkRun.ID = checkRunID
+				checkRun.HeadSHA = sha
+				checkRuns[i] = checkRun
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("check run %d not found", checkRunID)
+}
+
 // GetRepoLabels gets labels in a repo.
 func (f *FakeClient) GetRepoLabels(owner, repo string) ([]github.Label, error) {
 	f.lock.RLock()
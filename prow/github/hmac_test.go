@@ -101,3 +101,23 @@ func TestValidatePayload(t *testing.T) {
 		}
 	}
 }
+
+func TestSignPayload(t *testing.T) {
+	for _, payload := range []string{
+		"{}",
+		`{"organization": {"login": "org1"}}`,
+		`{"repository": {"full_name": "org2/repo"}}`,
+	} {
+		sig, err := SignPayload([]byte(payload), defaultTokenGenerator)
+		if err != nil {
+			t.Errorf("failed to sign %s: %v", payload, err)
+			continue
+		}
+		if !ValidatePayload([]byte(payload), sig, defaultTokenGenerator) {
+			t.Errorf("signature %s of %s doesn't validate", sig, payload)
+		}
+	}
+	if _, err := SignPayload([]byte("not json"), defaultTokenGenerator); err == nil {
+		t.Error("expected an error for a payload that isn't an event")
+	}
+}
@@ -0,0 +1,255 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package report
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/GoogleCloudPlatform/testgrid/metadata/junit"
+
+	prowapi "k8s.io/test-infra/prow/apis/prowjobs/v1"
+	"k8s.io/test-infra/prow/config"
+	"k8s.io/test-infra/prow/github"
+)
+
+const (
+	// maxCheckRunAnnotations is the number of annotations GitHub accepts in
+	// a single create or update request.
+	maxCheckRunAnnotations = 50
+	// maxCheckRunSummaryLength is the maximum length GitHub accepts for the
+	// summary of a check run output.
+	maxCheckRunSummaryLength = 65535
+	// maxFailuresInSummary is the number of failed tests listed in the summary.
+	maxFailuresInSummary = 20
+	// maxAnnotationMessageLength bounds the failure message of an annotation.
+	maxAnnotationMessageLength = 4096
+)
+
+// CheckRunClient provides a client interface to report job status updates
+// through GitHub check runs.
+type CheckRunClient interface {
+	ListCheckRuns(org, repo, ref string) (*github.CheckRunList, error)
+	CreateCheckRun(org, repo string, checkRun github.CheckRun) error
+	UpdateCheckRun(org, repo string, checkRunID int64, checkRun github.CheckRun) error
+}
+
+// prowjobStateToCheckRun maps prowjob states to check run statuses and
+// conclusions. The conclusion is only set once the check run is completed.
+func prowjobStateToCheckRun(pjState prowapi.ProwJobState) (status, conclusion string, err error) {
+	switch pjState {
+	case prowapi.TriggeredState:
+		return github.CheckRunStatusQueued, "", nil
+	case prowapi.PendingState:
+		return github.CheckRunStatusInProgress, "", nil
+	case prowapi.SuccessState:
+		return github.CheckRunStatusCompleted, github.CheckRunConclusionSuccess, nil
+	case prowapi.ErrorState, prowapi.FailureState:
+		return github.CheckRunStatusCompleted, github.CheckRunConclusionFailure, nil
+	case prowapi.AbortedState:
+		return github.CheckRunStatusCompleted, github.CheckRunConclusionCancelled, nil
+	}
+	return "", "", fmt.Errorf("Unknown prowjob state: %s", pjState)
+}
+
+// CheckRunForProwJob builds the check run reporting the given prowjob. The
+// junit suites, if any, are summarized in the check run output and each
+// failed test case is turned into an annotation.
+func CheckRunForProwJob(pj prowapi.ProwJob, suites []*junit.Suites) (github.CheckRun, error) {
+	status, conclusion, err := prowjobStateToCheckRun(pj.Status.State)
+	if err != nil {
+		return github.CheckRun{}, err
+	}
+	refs := pj.Spec.Refs
+	sha := refs.BaseSHA
+	if len(refs.Pulls) > 0 {
+		sha = refs.Pulls[0].SHA
+	}
+	checkRun := github.CheckRun{
+		Name:       pj.Spec.Context,
+		HeadSHA:    sha,
+		ExternalID: pj.Name,
+		DetailsURL: pj.Status.URL,
+		Status:     status,
+		Conclusion: conclusion,
+	}
+	if !pj.Status.StartTime.IsZero() {
+		checkRun.StartedAt = pj.Status.StartTime.UTC().Format(time.RFC3339)
+	}
+	if pj.Status.CompletionTime != nil && status == github.CheckRunStatusCompleted {
+		checkRun.CompletedAt = pj.Status.CompletionTime.UTC().Format(time.RFC3339)
+	}
+
+	title := config.ContextDescriptionWithBaseSha(pj.Status.Description, refs.BaseSHA)
+	if title == "" {
+		title = fmt.Sprintf("Job %s is %s", pj.Spec.Job, pj.Status.State)
+	}
+	checkRun.Output = checkRunOutput(title, pj.Status.URL, suites)
+	return checkRun, nil
+}
+
+// junitFailure is a failed test case together with the suite it belongs to.
+type junitFailure struct {
+	suite  string
+	result junit.Result
+}
+
+func checkRunOutput(title, url string, suites []*junit.Suites) github.CheckRunOutput {
+	var passed, failed, skipped int
+	var failures []junitFailure
+	var record func(suite junit.Suite)
+	record = func(suite junit.Suite) {
+		for _, subSuite := range suite.Suites {
+			record(subSuite)
+		}
+		for _, result := range suite.Results {
+			switch {
+			case result.Failure != nil || result.Errored != nil:
+				failed++
+				failures = append(failures, junitFailure{suite: suite.Name, result: result})
+			case result.Skipped != nil:
+				skipped++
+			default:
+				passed++
+			}
+		}
+	}
+	for _, s := range suites {
+		if s == nil {
+			continue
+		}
+		for _, suite := range s.Suites {
+			record(suite)
+		}
+	}
+
+	var summary strings.Builder
+	if passed+failed+skipped == 0 {
+		summary.WriteString("No junit results were found for this job.\n")
+	} else {
+		fmt.Fprintf(&summary, "%d tests: %d passed, %d failed, %d skipped.\n", passed+failed+skipped, passed, failed, skipped)
+	}
+	if len(failures) > 0 {
+		summary.WriteString("\n| Failed test | Suite |\n| --- | --- |\n")
+		for i, f := range failures {
+			if i == maxFailuresInSummary {
+				fmt.Fprintf(&summary, "| _and %d more_ | |\n", len(failures)-maxFailuresInSummary)
+				break
+			}
+			fmt.Fprintf(&summary, "| %s | %s |\n", escapeTableCell(f.result.Name), escapeTableCell(f.suite))
+		}
+	}
+	if url != "" {
+		fmt.Fprintf(&summary, "\n[Full job results](%s)\n", url)
+	}
+
+	output := github.CheckRunOutput{
+		Title:   title,
+		Summary: truncate(summary.String(), maxCheckRunSummaryLength),
+	}
+	for i, f := range failures {
+		if i == maxCheckRunAnnotations {
+			break
+		}
+		output.Annotations = append(output.Annotations, annotationForFailure(f))
+	}
+	return output
+}
+
+// annotationForFailure turns a failed test case into an annotation. Junit
+// results don't point at source lines, so the annotation is attached to the
+// first line of the test's class (or suite) name.
+func annotationForFailure(f junitFailure) github.CheckRunAnnotation {
+	path := f.result.ClassName
+	if path == "" {
+		path = f.suite
+	}
+	if path == "" {
+		path = f.result.Name
+	}
+	var message, details string
+	if f.result.Failure != nil {
+		message, details = f.result.Failure.Message, f.result.Failure.Value
+	} else if f.result.Errored != nil {
+		message, details = f.result.Errored.Message, f.result.Errored.Value
+	}
+	if message == "" {
+		message = "Test failed"
+	}
+	return github.CheckRunAnnotation{
+		Path:            path,
+		StartLine:       1,
+		EndLine:         1,
+		AnnotationLevel: github.CheckRunAnnotationLevelFailure,
+		Title:           f.result.Name,
+		Message:         truncate(message, maxAnnotationMessageLength),
+		RawDetails:      truncate(details, maxAnnotationMessageLength),
+	}
+}
+
+func escapeTableCell(s string) string {
+	return strings.NewReplacer("|", "\\|", "\n", " ").Replace(s)
+}
+
+func truncate(s string, max int) string {
+	const ellipsis = "..."
+	if len(s) <= max {
+		return s
+	}
+	return s[:max-len(ellipsis)] + ellipsis
+}
+
+// ReportCheckRun creates or updates the check run for the given prowjob. Each
+// prowjob gets its own check run, identified by the prowjob name in the
+// external ID, so a re-run shows up as a new check run on the commit.
+func ReportCheckRun(ghc CheckRunClient, pj prowapi.ProwJob, config config.GitHubReporter, suites []*junit.Suites) error {
+	if ghc == nil {
+		return fmt.Errorf("trying to report pj %s, but found empty github client", pj.ObjectMeta.Name)
+	}
+
+	if !ShouldReport(pj, config.JobTypesToReport) {
+		return nil
+	}
+
+	refs := pj.Spec.Refs
+	// we are not reporting for batch jobs, we can consider support that in the future
+	if refs == nil || len(refs.Pulls) > 1 {
+		return nil
+	}
+
+	checkRun, err := CheckRunForProwJob(pj, suites)
+	if err != nil {
+		return err
+	}
+	existing, err := ghc.ListCheckRuns(refs.Org, refs.Repo, checkRun.HeadSHA)
+	if err != nil {
+		return fmt.Errorf("error listing check runs: %w", err)
+	}
+	for _, cr := range existing.CheckRuns {
+		if cr.Name == checkRun.Name && cr.ExternalID == checkRun.ExternalID {
+			if err := ghc.UpdateCheckRun(refs.Org, refs.Repo, cr.ID, checkRun); err != nil {
+				return fmt.Errorf("error updating check run: %w", err)
+			}
+			return nil
+		}
+	}
+	if err := ghc.CreateCheckRun(refs.Org, refs.Repo, checkRun); err != nil {
+		return fmt.Errorf("error creating check run: %w", err)
+	}
+	return nil
+}
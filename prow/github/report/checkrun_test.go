@@ -0,0 +1,201 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package report
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/GoogleCloudPlatform/testgrid/metadata/junit"
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	prowapi "k8s.io/test-infra/prow/apis/prowjobs/v1"
+	"k8s.io/test-infra/prow/config"
+	"k8s.io/test-infra/prow/github"
+	"k8s.io/test-infra/prow/github/fakegithub"
+)
+
+func checkRunTestJob(state prowapi.ProwJobState) prowapi.ProwJob {
+	start := metav1.NewTime(time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC))
+	pj := prowapi.ProwJob{
+		ObjectMeta: metav1.ObjectMeta{Name: "some-job"},
+		Spec: prowapi.ProwJobSpec{
+			Type:    prowapi.PresubmitJob,
+			Job:     "pull-unit",
+			Context: "pull-unit",
+			Report:  true,
+			Refs: &prowapi.Refs{
+				Org:     "org",
+				Repo:    "repo",
+				BaseSHA: "base",
+				Pulls:   []prowapi.Pull{{Number: 1, SHA: "head"}},
+			},
+		},
+		Status: prowapi.ProwJobStatus{
+			State:       state,
+			StartTime:   start,
+			Description: "Job running.",
+			URL:         "https://prow.example.com/view/gs/bucket/some-job",
+		},
+	}
+	if state != prowapi.TriggeredState && state != prowapi.PendingState {
+		completion := metav1.NewTime(start.Add(time.Minute))
+		pj.Status.CompletionTime = &completion
+	}
+	return pj
+}
+
+func TestCheckRunForProwJob(t *testing.T) {
+	failure := "boom"
+	suites := []*junit.Suites{{
+		Suites: []junit.Suite{{
+			Name: "unit",
+			Results: []junit.Result{
+				{Name: "TestPass", ClassName: "pkg/a"},
+				{Name: "TestSkip", ClassName: "pkg/a", Skipped: &junit.Skipped{}},
+				{Name: "TestFail", ClassName: "pkg/b", Failure: &junit.Failure{Message: failure, Value: "stack"}},
+			},
+			Suites: []junit.Suite{{
+				Name:    "nested",
+				Results: []junit.Result{{Name: "TestError", Errored: &junit.Errored{Message: "oops"}}},
+			}},
+		}},
+	}}
+
+	testCases := []struct {
+		name               string
+		state              prowapi.ProwJobState
+		suites             []*junit.Suites
+		expectedStatus     string
+		expectedConclusion string
+		expectedSummary    string
+		expectedAnnotation []github.CheckRunAnnotation
+	}{
+		{
+			name:            "triggered job is queued",
+			state:           prowapi.TriggeredState,
+			expectedStatus:  github.CheckRunStatusQueued,
+			expectedSummary: "No junit results were found for this job.\n\n[Full job results](https://prow.example.com/view/gs/bucket/some-job)\n",
+		},
+		{
+			name:           "pending job is in progress",
+			state:          prowapi.PendingState,
+			expectedStatus: github.CheckRunStatusInProgress,
+		},
+		{
+			name:               "aborted job is cancelled",
+			state:              prowapi.AbortedState,
+			expectedStatus:     github.CheckRunStatusCompleted,
+			expectedConclusion: github.CheckRunConclusionCancelled,
+		},
+		{
+			name:               "failed job summarizes junit results",
+			state:              prowapi.FailureState,
+			suites:             suites,
+			expectedStatus:     github.CheckRunStatusCompleted,
+			expectedConclusion: github.CheckRunConclusionFailure,
+			expectedSummary: "4 tests: 1 passed, 2 failed, 1 skipped.\n\n" +
+				"| Failed test | Suite |\n| --- | --- |\n| TestError | nested |\n| TestFail | unit |\n\n" +
+				"[Full job results](https://prow.example.com/view/gs/bucket/some-job)\n",
+			expectedAnnotation: []github.CheckRunAnnotation{
+				{Path: "nested", StartLine: 1, EndLine: 1, AnnotationLevel: "failure", Title: "TestError", Message: "oops"},
+				{Path: "pkg/b", StartLine: 1, EndLine: 1, AnnotationLevel: "failure", Title: "TestFail", Message: failure, RawDetails: "stack"},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			checkRun, err := CheckRunForProwJob(checkRunTestJob(tc.state), tc.suites)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if checkRun.Name != "pull-unit" || checkRun.HeadSHA != "head" || checkRun.ExternalID != "some-job" {
+				t.Errorf("unexpected check run identity: %+v", checkRun)
+			}
+			if checkRun.DetailsURL != "https://prow.example.com/view/gs/bucket/some-job" {
+				t.Errorf("expected details URL to link to spyglass, got %q", checkRun.DetailsURL)
+			}
+			if checkRun.Status != tc.expectedStatus || checkRun.Conclusion != tc.expectedConclusion {
+				t.Errorf("expected status %q and conclusion %q, got %q and %q", tc.expectedStatus, tc.expectedConclusion, checkRun.Status, checkRun.Conclusion)
+			}
+			if (checkRun.CompletedAt != "") != (tc.expectedStatus == github.CheckRunStatusCompleted) {
+				t.Errorf("unexpected completion time %q for status %q", checkRun.CompletedAt, checkRun.Status)
+			}
+			if tc.expectedSummary != "" && checkRun.Output.Summary != tc.expectedSummary {
+				t.Errorf("unexpected summary: %s", cmp.Diff(tc.expectedSummary, checkRun.Output.Summary))
+			}
+			if diff := cmp.Diff(tc.expectedAnnotation, checkRun.Output.Annotations); diff != "" {
+				t.Errorf("unexpected annotations: %s", diff)
+			}
+		})
+	}
+}
+
+func TestCheckRunOutputLimits(t *testing.T) {
+	var results []junit.Result
+	for i := 0; i < maxCheckRunAnnotations+10; i++ {
+		results = append(results, junit.Result{Name: fmt.Sprintf("Test%d", i), Failure: &junit.Failure{Message: "failed"}})
+	}
+	output := checkRunOutput("title", "", []*junit.Suites{{Suites: []junit.Suite{{Name: "suite", Results: results}}}})
+	if len(output.Annotations) != maxCheckRunAnnotations {
+		t.Errorf("expected %d annotations, got %d", maxCheckRunAnnotations, len(output.Annotations))
+	}
+	if !strings.Contains(output.Summary, fmt.Sprintf("_and %d more_", len(results)-maxFailuresInSummary)) {
+		t.Errorf("expected summary to elide failures, got %s", output.Summary)
+	}
+}
+
+func TestReportCheckRun(t *testing.T) {
+	ghc := fakegithub.NewFakeClient()
+	cfg := config.GitHubReporter{JobTypesToReport: []prowapi.ProwJobType{prowapi.PresubmitJob}}
+
+	if err := ReportCheckRun(ghc, checkRunTestJob(prowapi.PendingState), cfg, nil); err != nil {
+		t.Fatalf("failed to report pending job: %v", err)
+	}
+	if err := ReportCheckRun(ghc, checkRunTestJob(prowapi.SuccessState), cfg, nil); err != nil {
+		t.Fatalf("failed to report successful job: %v", err)
+	}
+	rerun := checkRunTestJob(prowapi.TriggeredState)
+	rerun.Name = "rerun"
+	if err := ReportCheckRun(ghc, rerun, cfg, nil); err != nil {
+		t.Fatalf("failed to report re-run: %v", err)
+	}
+
+	checkRuns := ghc.CheckRuns["head"]
+	if len(checkRuns) != 2 {
+		t.Fatalf("expected the job and its re-run to have one check run each, got %d", len(checkRuns))
+	}
+	if checkRuns[0].ExternalID != "some-job" || checkRuns[0].Conclusion != github.CheckRunConclusionSuccess {
+		t.Errorf("expected the first check run to be updated to success, got %+v", checkRuns[0])
+	}
+	if checkRuns[1].ExternalID != "rerun" || checkRuns[1].Status != github.CheckRunStatusQueued {
+		t.Errorf("expected a queued check run for the re-run, got %+v", checkRuns[1])
+	}
+
+	postsubmit := checkRunTestJob(prowapi.SuccessState)
+	postsubmit.Spec.Type = prowapi.PostsubmitJob
+	if err := ReportCheckRun(ghc, postsubmit, cfg, nil); err != nil {
+		t.Fatalf("failed to skip postsubmit: %v", err)
+	}
+	if len(ghc.CheckRuns["base"]) != 0 {
+		t.Errorf("expected job types that are not reported to be skipped")
+	}
+}
@@ -95,12 +95,24 @@ func reportStatus(ctx context.Context, ghc GitHubClient, pj prowapi.ProwJob) err
 	return nil
 }
 
+// IsMergeGroupJob returns whether the prowjob was created for a GitHub merge
+// queue merge group. Those are batch jobs that report to the merge group's
+// head commit.
+func IsMergeGroupJob(pj prowapi.ProwJob) bool {
+	return pj.Spec.Type == prowapi.BatchJob && pj.Labels[kube.MergeGroupLabel] != ""
+}
+
 // TODO(krzyzacy):
 // Move this logic into github/reporter, once we unify all reporting logic to crier
 func ShouldReport(pj prowapi.ProwJob, validTypes []prowapi.ProwJobType) bool {
+	// Merge group jobs run presubmits, so they are reported like them.
+	jobType := pj.Spec.Type
+	if IsMergeGroupJob(pj) {
+		jobType = prowapi.PresubmitJob
+	}
 	valid := false
 	for _, t := range validTypes {
-		if pj.Spec.Type == t {
+		if jobType == t {
 			valid = true
 		}
 	}
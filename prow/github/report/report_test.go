@@ -29,6 +29,7 @@ import (
 	prowapi "k8s.io/test-infra/prow/apis/prowjobs/v1"
 	"k8s.io/test-infra/prow/config"
 	"k8s.io/test-infra/prow/github"
+	"k8s.io/test-infra/prow/github/fakegithub"
 	"k8s.io/test-infra/prow/kube"
 )
 
@@ -393,6 +394,35 @@ func TestReportStatus(t *testing.T) {
 	}
 }
 
+func TestReportStatusContextMergeGroup(t *testing.T) {
+	ghc := fakegithub.NewFakeClient()
+	pj := prowapi.ProwJob{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels: map[string]string{kube.MergeGroupLabel: "true"},
+		},
+		Spec: prowapi.ProwJobSpec{
+			Type:    prowapi.BatchJob,
+			Context: "pull-unit",
+			Report:  true,
+			Refs: &prowapi.Refs{
+				Org:     "org",
+				Repo:    "repo",
+				BaseRef: "gh-readonly-queue/main/pr-1-abc",
+				BaseSHA: "merge-group-head",
+			},
+		},
+		Status: prowapi.ProwJobStatus{State: prowapi.SuccessState},
+	}
+	cfg := config.GitHubReporter{JobTypesToReport: []prowapi.ProwJobType{prowapi.PresubmitJob}}
+	if err := ReportStatusContext(context.Background(), ghc, pj, cfg); err != nil {
+		t.Fatalf("failed to report status: %v", err)
+	}
+	statuses := ghc.CreatedStatuses["merge-group-head"]
+	if len(statuses) != 1 || statuses[0].Context != "pull-unit" || statuses[0].State != github.StatusSuccess {
+		t.Errorf("expected a success status for the merge group head, got %+v", statuses)
+	}
+}
+
 func TestShouldReport(t *testing.T) {
 	var testcases = []struct {
 		name       string
@@ -442,6 +472,30 @@ func TestShouldReport(t *testing.T) {
 			validTypes: []prowapi.ProwJobType{prowapi.PresubmitJob, prowapi.PostsubmitJob},
 			report:     true,
 		},
+		{
+			name: "should report merge group job like a presubmit",
+			pj: prowapi.ProwJob{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{kube.MergeGroupLabel: "true"},
+				},
+				Spec: prowapi.ProwJobSpec{
+					Type:   prowapi.BatchJob,
+					Report: true,
+				},
+			},
+			validTypes: []prowapi.ProwJobType{prowapi.PresubmitJob},
+			report:     true,
+		},
+		{
+			name: "should not report other batch jobs",
+			pj: prowapi.ProwJob{
+				Spec: prowapi.ProwJobSpec{
+					Type:   prowapi.BatchJob,
+					Report: true,
+				},
+			},
+			validTypes: []prowapi.ProwJobType{prowapi.PresubmitJob},
+		},
 	}
 
 	for _, tc := range testcases {
@@ -859,6 +859,60 @@ type StatusEvent struct {
 	GUID string
 }
 
+// MergeGroupEventAction enumerates the triggers for a MergeGroupEvent.
+type MergeGroupEventAction string
+
+const (
+	// MergeGroupActionChecksRequested means checks were requested for a
+	// merge group added to a merge queue.
+	MergeGroupActionChecksRequested MergeGroupEventAction = "checks_requested"
+	// MergeGroupActionDestroyed means the merge group was merged or removed
+	// from the merge queue.
+	MergeGroupActionDestroyed MergeGroupEventAction = "destroyed"
+)
+
+// MergeGroup is a group of pull requests GitHub's merge queue tests
+// together on a temporary branch.
+type MergeGroup struct {
+	// HeadSHA is the commit that has to pass the required checks.
+	HeadSHA string `json:"head_sha"`
+	// HeadRef is the full ref of the temporary branch, for example
+	// refs/heads/gh-readonly-queue/main/pr-123-<sha>.
+	HeadRef string `json:"head_ref"`
+	// BaseSHA is the commit of the target branch the group was built on.
+	BaseSHA string `json:"base_sha"`
+	// BaseRef is the full ref of the target branch.
+	BaseRef    string `json:"base_ref"`
+	HeadCommit Commit `json:"head_commit"`
+}
+
+// MergeGroupEvent is what GitHub sends us when a merge queue needs checks
+// for a merge group or when the merge group is destroyed.
+//
+// See https://docs.github.com/en/webhooks-and-events/webhooks/webhook-events-and-payloads#merge_group
+type MergeGroupEvent struct {
+	Action     MergeGroupEventAction `json:"action"`
+	MergeGroup MergeGroup            `json:"merge_group"`
+	// Reason is set for destroyed merge groups and is one of merged,
+	// invalidated or dequeued.
+	Reason string `json:"reason,omitempty"`
+	Repo   Repo   `json:"repository"`
+	Sender User   `json:"sender"`
+
+	// GUID is included in the header of the request received by GitHub.
+	GUID string
+}
+
+// BaseBranch returns the name of the branch the merge group targets.
+func (mg MergeGroup) BaseBranch() string {
+	return strings.TrimPrefix(mg.BaseRef, "refs/heads/")
+}
+
+// HeadBranch returns the name of the merge group's temporary branch.
+func (mg MergeGroup) HeadBranch() string {
+	return strings.TrimPrefix(mg.HeadRef, "refs/heads/")
+}
+
 // IssuesSearchResult represents the result of an issues search.
 type IssuesSearchResult struct {
 	Total  int     `json:"total_count,omitempty"`
@@ -1368,6 +1422,55 @@ type CheckRun struct {
 	PullRequests []PullRequest  `json:"pull_requests,omitempty"`
 }
 
+// Check run statuses and conclusions.
+//
+// See https://docs.github.com/en/rest/checks/runs#create-a-check-run
+const (
+	CheckRunStatusQueued     = "queued"
+	CheckRunStatusInProgress = "in_progress"
+	CheckRunStatusCompleted  = "completed"
+
+	CheckRunConclusionSuccess   = "success"
+	CheckRunConclusionFailure   = "failure"
+	CheckRunConclusionNeutral   = "neutral"
+	CheckRunConclusionCancelled = "cancelled"
+	CheckRunConclusionTimedOut  = "timed_out"
+
+	CheckRunAnnotationLevelNotice  = "notice"
+	CheckRunAnnotationLevelWarning = "warning"
+	CheckRunAnnotationLevelFailure = "failure"
+)
+
+// CheckRunEventAction enumerates the triggers for a CheckRunEvent.
+type CheckRunEventAction string
+
+const (
+	// CheckRunActionCreated means a new check run was created.
+	CheckRunActionCreated CheckRunEventAction = "created"
+	// CheckRunActionCompleted means the status of the check run is completed.
+	CheckRunActionCompleted CheckRunEventAction = "completed"
+	// CheckRunActionRerequested means someone asked to re-run the check run
+	// from the GitHub UI.
+	CheckRunActionRerequested CheckRunEventAction = "rerequested"
+	// CheckRunActionRequestedAction means someone requested an action the
+	// app provides for the check run.
+	CheckRunActionRequestedAction CheckRunEventAction = "requested_action"
+)
+
+// CheckRunEvent is what GitHub sends us when a check run is created,
+// completed or re-requested.
+//
+// See https://docs.github.com/en/developers/webhooks-and-events/webhooks/webhook-events-and-payloads#check_run
+type CheckRunEvent struct {
+	Action   CheckRunEventAction `json:"action"`
+	CheckRun CheckRun            `json:"check_run"`
+	Repo     Repo                `json:"repository"`
+	Sender   User                `json:"sender"`
+
+	// GUID is included in the header of the request received by GitHub.
+	GUID string
+}
+
 type CheckRunOutput struct {
 	Title            string               `json:"title,omitempty"`
 	Summary          string               `json:"summary,omitempty"`
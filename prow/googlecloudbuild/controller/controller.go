@@ -0,0 +1,371 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package controller runs the ProwJobs of the cloud-build agent as builds of
+// Google Cloud Build.
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"time"
+
+	"github.com/GoogleCloudPlatform/testgrid/metadata"
+	"github.com/sirupsen/logrus"
+	cloudbuildpb "google.golang.org/genproto/googleapis/devtools/cloudbuild/v1"
+	"google.golang.org/protobuf/types/known/durationpb"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/clock"
+	utilpointer "k8s.io/utils/pointer"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	prowv1 "k8s.io/test-infra/prow/apis/prowjobs/v1"
+	"k8s.io/test-infra/prow/config"
+	"k8s.io/test-infra/prow/crier/reporters/gcs/util"
+	cloudbuild "k8s.io/test-infra/prow/googlecloudbuild/client"
+	"k8s.io/test-infra/prow/io"
+	"k8s.io/test-infra/prow/io/providers"
+	"k8s.io/test-infra/prow/kube"
+	"k8s.io/test-infra/prow/pjutil"
+	"k8s.io/test-infra/prow/pod-utils/downwardapi"
+)
+
+const ControllerName = "cloud-build"
+
+// pollInterval is how often running builds are checked, Cloud Build has no
+// watches.
+const pollInterval = 30 * time.Second
+
+// Add adds the cloud-build controller to the manager.
+func Add(mgr controllerruntime.Manager, cfg config.Getter, cb cloudbuild.Operator, opener io.Opener, totURL string, numWorkers int) error {
+	r := newReconciler(mgr.GetClient(), cfg, cb, opener, totURL)
+	if err := controllerruntime.NewControllerManagedBy(mgr).
+		Named(ControllerName).
+		For(&prowv1.ProwJob{}).
+		WithEventFilter(predicate.NewPredicateFuncs(func(o ctrlruntimeclient.Object) bool {
+			pj, ok := o.(*prowv1.ProwJob)
+			return ok && !pj.Complete() && pj.Spec.Agent == prowv1.CloudBuildAgent
+		})).
+		WithOptions(controller.Options{MaxConcurrentReconciles: numWorkers}).
+		Complete(r); err != nil {
+		return fmt.Errorf("failed to build controller: %w", err)
+	}
+	return nil
+}
+
+func newReconciler(pjClient ctrlruntimeclient.Client, cfg config.Getter, cb cloudbuild.Operator, opener io.Opener, totURL string) *reconciler {
+	return &reconciler{
+		pjClient: pjClient,
+		cb:       cb,
+		log:      logrus.NewEntry(logrus.StandardLogger()).WithField("controller", ControllerName),
+		config:   cfg,
+		opener:   opener,
+		totURL:   totURL,
+		clock:    clock.RealClock{},
+	}
+}
+
+type reconciler struct {
+	pjClient ctrlruntimeclient.Client
+	cb       cloudbuild.Operator
+	log      *logrus.Entry
+	config   config.Getter
+	opener   io.Opener
+	totURL   string
+	clock    clock.Clock
+}
+
+func (r *reconciler) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
+	pj := &prowv1.ProwJob{}
+	if err := r.pjClient.Get(ctx, request.NamespacedName, pj); err != nil {
+		if !kerrors.IsNotFound(err) {
+			return reconcile.Result{}, fmt.Errorf("failed to get prowjob %s: %w", request.Name, err)
+		}
+		// Objects can be deleted from the API while being in our workqueue
+		return reconcile.Result{}, nil
+	}
+	if pj.Complete() || pj.Spec.Agent != prowv1.CloudBuildAgent {
+		return reconcile.Result{}, nil
+	}
+
+	var res reconcile.Result
+	var err error
+	switch pj.Status.State {
+	case prowv1.TriggeredState:
+		err = r.syncTriggeredJob(ctx, pj)
+	case prowv1.PendingState:
+		res, err = r.syncPendingJob(ctx, pj)
+	case prowv1.AbortedState:
+		err = r.syncAbortedJob(ctx, pj)
+	}
+	if err != nil {
+		r.log.WithError(err).WithFields(pjutil.ProwJobFields(pj)).Error("Reconciliation failed")
+	}
+	return res, err
+}
+
+// syncTriggeredJob creates the build of the job, unless a previous sync
+// already did and failed to update the job.
+func (r *reconciler) syncTriggeredJob(ctx context.Context, pj *prowv1.ProwJob) error {
+	prevPJ := pj.DeepCopy()
+	bld, err := r.build(ctx, pj)
+	if err != nil {
+		return err
+	}
+	if bld == nil {
+		buildID, err := pjutil.GetBuildID(pj.Spec.Job, r.totURL)
+		if err != nil {
+			return fmt.Errorf("error getting build ID: %w", err)
+		}
+		pj.Status.BuildID = buildID
+		if bld, err = buildForProwJob(pj); err != nil {
+			pj.Status.State = prowv1.ErrorState
+			pj.SetComplete()
+			pj.Status.Description = fmt.Sprintf("Build can not be created: %v", err)
+			return r.patch(ctx, prevPJ, pj)
+		}
+		if _, err := r.cb.CreateBuild(ctx, pj.Spec.BuildSpec.Project, bld); err != nil {
+			return fmt.Errorf("create build in project %s: %w", pj.Spec.BuildSpec.Project, err)
+		}
+		r.log.WithFields(pjutil.ProwJobFields(pj)).Debug("Created build.")
+	} else {
+		pj.Status.BuildID = cloudbuild.GetProwLabels(bld)[kube.ProwBuildIDLabel]
+	}
+
+	now := metav1.NewTime(r.clock.Now())
+	pj.Status.PendingTime = &now
+	pj.Status.State = prowv1.PendingState
+	pj.Status.Description = "Build queued."
+	if pj.Status.URL, err = pjutil.JobURL(r.config().Plank, *pj, r.log); err != nil {
+		r.log.WithFields(pjutil.ProwJobFields(pj)).WithError(err).Warn("failed to get jobURL")
+	}
+	if err := r.uploadStarted(ctx, pj, bld); err != nil {
+		r.log.WithFields(pjutil.ProwJobFields(pj)).WithError(err).Warn("Failed to upload started.json.")
+	}
+	return r.patch(ctx, prevPJ, pj)
+}
+
+// syncPendingJob copies the status of the build to the job.
+func (r *reconciler) syncPendingJob(ctx context.Context, pj *prowv1.ProwJob) (reconcile.Result, error) {
+	prevPJ := pj.DeepCopy()
+	bld, err := r.build(ctx, pj)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	if bld == nil {
+		pj.Status.State = prowv1.ErrorState
+		pj.Status.Description = "Build not found."
+	} else {
+		pj.Status.State, pj.Status.Description = stateForBuild(bld)
+	}
+	if pj.Status.State == prowv1.PendingState {
+		if err := r.patch(ctx, prevPJ, pj); err != nil {
+			return reconcile.Result{}, err
+		}
+		return reconcile.Result{RequeueAfter: pollInterval}, nil
+	}
+
+	pj.SetComplete()
+	if err := r.uploadFinished(ctx, pj); err != nil {
+		r.log.WithFields(pjutil.ProwJobFields(pj)).WithError(err).Warn("Failed to upload finished.json.")
+	}
+	return reconcile.Result{}, r.patch(ctx, prevPJ, pj)
+}
+
+// syncAbortedJob cancels the build of jobs that got aborted because their
+// result isn't needed anymore.
+func (r *reconciler) syncAbortedJob(ctx context.Context, pj *prowv1.ProwJob) error {
+	bld, err := r.build(ctx, pj)
+	if err != nil {
+		return err
+	}
+	if bld != nil && !isFinished(bld) {
+		if _, err := r.cb.CancelBuild(ctx, pj.Spec.BuildSpec.Project, bld.Id); err != nil {
+			return fmt.Errorf("cancel build %s: %w", bld.Id, err)
+		}
+	}
+	prevPJ := pj.DeepCopy()
+	pj.SetComplete()
+	if pj.Status.BuildID != "" {
+		if err := r.uploadFinished(ctx, pj); err != nil {
+			r.log.WithFields(pjutil.ProwJobFields(pj)).WithError(err).Warn("Failed to upload finished.json.")
+		}
+	}
+	return r.patch(ctx, prevPJ, pj)
+}
+
+func (r *reconciler) patch(ctx context.Context, prevPJ, pj *prowv1.ProwJob) error {
+	if prevPJ.Status.State != pj.Status.State {
+		r.log.WithFields(pjutil.ProwJobFields(pj)).
+			WithField("from", prevPJ.Status.State).
+			WithField("to", pj.Status.State).Info("Transitioning states.")
+	}
+	if err := r.pjClient.Patch(ctx, pj.DeepCopy(), ctrlruntimeclient.MergeFrom(prevPJ)); err != nil {
+		return fmt.Errorf("patch prowjob: %w", err)
+	}
+	return nil
+}
+
+// build returns the build of the job, or nil if there is none yet. Builds
+// are found by their tags, Cloud Build assigns their IDs.
+func (r *reconciler) build(ctx context.Context, pj *prowv1.ProwJob) (*cloudbuildpb.Build, error) {
+	if pj.Spec.BuildSpec == nil {
+		return nil, fmt.Errorf("nil BuildSpec in ProwJob/%s", pj.Name)
+	}
+	blds, err := r.cb.ListBuildsByTag(ctx, pj.Spec.BuildSpec.Project, []string{cloudbuild.ProwLabel(kube.ProwJobIDLabel, pj.Name)})
+	if err != nil {
+		return nil, fmt.Errorf("list builds in project %s: %w", pj.Spec.BuildSpec.Project, err)
+	}
+	if len(blds) == 0 {
+		return nil, nil
+	}
+	return blds[0], nil
+}
+
+// buildForProwJob returns the build that runs the job.
+func buildForProwJob(pj *prowv1.ProwJob) (*cloudbuildpb.Build, error) {
+	spec := pj.Spec.BuildSpec
+	jobEnv, err := downwardapi.EnvForSpec(downwardapi.NewJobSpec(pj.Spec, pj.Status.BuildID, pj.Name))
+	if err != nil {
+		return nil, err
+	}
+	var env []string
+	for k, v := range jobEnv {
+		env = append(env, k+"="+v)
+	}
+	sort.Strings(env)
+
+	bld := &cloudbuildpb.Build{
+		// Cloud Build assigns the ID of builds, this one only makes the
+		// build recognizable to fakes.
+		Id: pj.Name,
+		Tags: []string{
+			cloudbuild.ProwLabel(kube.CreatedByProw, "true"),
+			cloudbuild.ProwLabel(kube.ProwJobIDLabel, pj.Name),
+			cloudbuild.ProwLabel(kube.ProwBuildIDLabel, pj.Status.BuildID),
+		},
+		Substitutions:  spec.Substitutions,
+		ServiceAccount: spec.ServiceAccount,
+		LogsBucket:     spec.LogsBucket,
+	}
+	for _, step := range spec.Steps {
+		bld.Steps = append(bld.Steps, &cloudbuildpb.BuildStep{
+			Name:       step.Name,
+			Id:         step.ID,
+			Entrypoint: step.Entrypoint,
+			Args:       step.Args,
+			Env:        append(append([]string{}, env...), step.Env...),
+			Dir:        step.Dir,
+			WaitFor:    step.WaitFor,
+		})
+	}
+	if spec.Timeout != nil {
+		bld.Timeout = durationpb.New(spec.Timeout.Duration)
+	}
+	if spec.MachineType != "" {
+		machineType, ok := cloudbuildpb.BuildOptions_MachineType_value[spec.MachineType]
+		if !ok {
+			return nil, fmt.Errorf("unknown machine type %q", spec.MachineType)
+		}
+		bld.Options = &cloudbuildpb.BuildOptions{MachineType: cloudbuildpb.BuildOptions_MachineType(machineType)}
+	}
+	return bld, nil
+}
+
+// stateForBuild returns the state and the description of a job that runs
+// the build.
+func stateForBuild(bld *cloudbuildpb.Build) (prowv1.ProwJobState, string) {
+	switch bld.Status {
+	case cloudbuildpb.Build_SUCCESS:
+		return prowv1.SuccessState, "Build succeeded."
+	case cloudbuildpb.Build_FAILURE:
+		return prowv1.FailureState, descriptionOr(bld, "Build failed.")
+	case cloudbuildpb.Build_CANCELLED:
+		return prowv1.AbortedState, "Build cancelled."
+	case cloudbuildpb.Build_TIMEOUT:
+		return prowv1.ErrorState, "Build timed out."
+	case cloudbuildpb.Build_EXPIRED:
+		return prowv1.ErrorState, "Build expired in the queue."
+	case cloudbuildpb.Build_INTERNAL_ERROR:
+		return prowv1.ErrorState, descriptionOr(bld, "Build failed with an internal error.")
+	case cloudbuildpb.Build_WORKING:
+		return prowv1.PendingState, "Build running."
+	default:
+		return prowv1.PendingState, "Build queued."
+	}
+}
+
+func descriptionOr(bld *cloudbuildpb.Build, description string) string {
+	if bld.StatusDetail != "" {
+		return bld.StatusDetail
+	}
+	return description
+}
+
+func isFinished(bld *cloudbuildpb.Build) bool {
+	state, _ := stateForBuild(bld)
+	return state != prowv1.PendingState
+}
+
+// uploadStarted uploads the started.json of the job, builds don't run the
+// pod utilities that would upload it.
+func (r *reconciler) uploadStarted(ctx context.Context, pj *prowv1.ProwJob, bld *cloudbuildpb.Build) error {
+	started := downwardapi.PjToStarted(pj, nil)
+	started.Metadata = metadata.Metadata{"uploader": ControllerName}
+	if bld.LogUrl != "" {
+		started.Metadata["cloud-build-log-url"] = bld.LogUrl
+	}
+	return r.upload(ctx, pj, prowv1.StartedStatusFile, started)
+}
+
+// uploadFinished uploads the finished.json of a completed job.
+func (r *reconciler) uploadFinished(ctx context.Context, pj *prowv1.ProwJob) error {
+	completion := pj.Status.CompletionTime.Unix()
+	passed := pj.Status.State == prowv1.SuccessState
+	return r.upload(ctx, pj, prowv1.FinishedStatusFile, metadata.Finished{
+		Timestamp: &completion,
+		Passed:    &passed,
+		Metadata:  metadata.Metadata{"uploader": ControllerName},
+		Result:    string(pj.Status.State),
+	})
+}
+
+func (r *reconciler) upload(ctx context.Context, pj *prowv1.ProwJob, name string, content interface{}) error {
+	if r.opener == nil {
+		return nil
+	}
+	bucket, dir, err := util.GetJobDestination(r.config, pj)
+	if err != nil {
+		return fmt.Errorf("failed to get job destination: %w", err)
+	}
+	output, err := json.MarshalIndent(content, "", "\t")
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s: %w", name, err)
+	}
+	p, err := providers.StoragePath(bucket, path.Join(dir, name))
+	if err != nil {
+		return fmt.Errorf("failed to resolve %s path: %w", name, err)
+	}
+	return io.WriteContent(ctx, r.log, r.opener, p, output, io.WriterOptions{PreconditionDoesNotExist: utilpointer.BoolPtr(true)})
+}
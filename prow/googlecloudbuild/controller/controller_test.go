@@ -0,0 +1,271 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"text/template"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	cloudbuildpb "google.golang.org/genproto/googleapis/devtools/cloudbuild/v1"
+	"google.golang.org/protobuf/testing/protocmp"
+	"google.golang.org/protobuf/types/known/durationpb"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	clocktesting "k8s.io/utils/clock/testing"
+	fakectrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	prowv1 "k8s.io/test-infra/prow/apis/prowjobs/v1"
+	"k8s.io/test-infra/prow/config"
+	"k8s.io/test-infra/prow/googlecloudbuild/client/fake"
+	"k8s.io/test-infra/prow/io/fakeopener"
+)
+
+const project = "my-project"
+
+func prowJob(state prowv1.ProwJobState) *prowv1.ProwJob {
+	return &prowv1.ProwJob{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-pj", Namespace: "prowjobs"},
+		Spec: prowv1.ProwJobSpec{
+			Type:  prowv1.PeriodicJob,
+			Agent: prowv1.CloudBuildAgent,
+			Job:   "my-job",
+			BuildSpec: &prowv1.CloudBuildSpec{
+				Project: project,
+				Steps:   []prowv1.CloudBuildStep{{Name: "golang", Args: []string{"make", "test"}}},
+			},
+		},
+		Status: prowv1.ProwJobStatus{State: state},
+	}
+}
+
+func TestBuildForProwJob(t *testing.T) {
+	pj := prowJob(prowv1.TriggeredState)
+	pj.Status.BuildID = "42"
+	pj.Spec.BuildSpec.Timeout = &prowv1.Duration{Duration: time.Hour}
+	pj.Spec.BuildSpec.MachineType = "E2_HIGHCPU_8"
+	pj.Spec.BuildSpec.Steps[0].Env = []string{"FOO=bar"}
+
+	bld, err := buildForProwJob(pj)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := &cloudbuildpb.Build{
+		Id:   "my-pj",
+		Tags: []string{"created-by-prow ::: true", "prow.k8s.io/id ::: my-pj", "prow.k8s.io/build-id ::: 42"},
+		Steps: []*cloudbuildpb.BuildStep{{
+			Name: "golang",
+			Args: []string{"make", "test"},
+			Env: []string{
+				"BUILD_ID=42",
+				`CI=true`,
+				`JOB_NAME=my-job`,
+				`JOB_SPEC={"type":"periodic","job":"my-job","buildid":"42","prowjobid":"my-pj"}`,
+				"JOB_TYPE=periodic",
+				"PROW_JOB_ID=my-pj",
+				"FOO=bar",
+			},
+		}},
+		Timeout: durationpb.New(time.Hour),
+		Options: &cloudbuildpb.BuildOptions{MachineType: cloudbuildpb.BuildOptions_E2_HIGHCPU_8},
+	}
+	if diff := cmp.Diff(expected, bld, protocmp.Transform()); diff != "" {
+		t.Errorf("unexpected build (-want +got):\n%s", diff)
+	}
+
+	pj.Spec.BuildSpec.MachineType = "GIANT"
+	if _, err := buildForProwJob(pj); err == nil {
+		t.Error("expected an error for an unknown machine type")
+	}
+}
+
+func TestStateForBuild(t *testing.T) {
+	for status, expected := range map[cloudbuildpb.Build_Status]prowv1.ProwJobState{
+		cloudbuildpb.Build_STATUS_UNKNOWN: prowv1.PendingState,
+		cloudbuildpb.Build_QUEUED:         prowv1.PendingState,
+		cloudbuildpb.Build_WORKING:        prowv1.PendingState,
+		cloudbuildpb.Build_SUCCESS:        prowv1.SuccessState,
+		cloudbuildpb.Build_FAILURE:        prowv1.FailureState,
+		cloudbuildpb.Build_INTERNAL_ERROR: prowv1.ErrorState,
+		cloudbuildpb.Build_TIMEOUT:        prowv1.ErrorState,
+		cloudbuildpb.Build_CANCELLED:      prowv1.AbortedState,
+		cloudbuildpb.Build_EXPIRED:        prowv1.ErrorState,
+	} {
+		if state, _ := stateForBuild(&cloudbuildpb.Build{Status: status}); state != expected {
+			t.Errorf("%s: expected state %s, got %s", status, expected, state)
+		}
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	cfg := config.Config{ProwConfig: config.ProwConfig{
+		ProwJobNamespace: "prowjobs",
+		Plank: config.Plank{
+			Controller: config.Controller{
+				JobURLTemplate: template.Must(template.New("JobURL").Parse("https://prow.example.com/view/{{.Status.BuildID}}")),
+			},
+			DefaultDecorationConfigs: config.DefaultDecorationMapToSliceTesting(map[string]*prowv1.DecorationConfig{
+				"*": {GCSConfiguration: &prowv1.GCSConfiguration{Bucket: "gs://bucket", PathStrategy: prowv1.PathStrategyExplicit}},
+			}),
+		},
+	}}
+	cfgGetter := func() *config.Config { return &cfg }
+
+	testCases := []struct {
+		name string
+		pj   *prowv1.ProwJob
+		// build is the status of the existing build, if any.
+		build *cloudbuildpb.Build_Status
+
+		expectedState       prowv1.ProwJobState
+		expectedBuildStatus cloudbuildpb.Build_Status
+		expectedComplete    bool
+		expectedFiles       []string
+		expectedRequeue     bool
+	}{
+		{
+			name:                "triggered job creates a build",
+			pj:                  prowJob(prowv1.TriggeredState),
+			expectedState:       prowv1.PendingState,
+			expectedBuildStatus: cloudbuildpb.Build_QUEUED,
+			expectedFiles:       []string{"started.json"},
+		},
+		{
+			name:                "triggered job with a build doesn't create another",
+			pj:                  prowJob(prowv1.TriggeredState),
+			build:               statusPtr(cloudbuildpb.Build_WORKING),
+			expectedState:       prowv1.PendingState,
+			expectedBuildStatus: cloudbuildpb.Build_WORKING,
+			expectedFiles:       []string{"started.json"},
+		},
+		{
+			name:                "running build keeps the job pending",
+			pj:                  prowJob(prowv1.PendingState),
+			build:               statusPtr(cloudbuildpb.Build_WORKING),
+			expectedState:       prowv1.PendingState,
+			expectedBuildStatus: cloudbuildpb.Build_WORKING,
+			expectedRequeue:     true,
+		},
+		{
+			name:                "successful build completes the job",
+			pj:                  prowJob(prowv1.PendingState),
+			build:               statusPtr(cloudbuildpb.Build_SUCCESS),
+			expectedState:       prowv1.SuccessState,
+			expectedBuildStatus: cloudbuildpb.Build_SUCCESS,
+			expectedComplete:    true,
+			expectedFiles:       []string{"finished.json"},
+		},
+		{
+			name:                "failed build fails the job",
+			pj:                  prowJob(prowv1.PendingState),
+			build:               statusPtr(cloudbuildpb.Build_FAILURE),
+			expectedState:       prowv1.FailureState,
+			expectedBuildStatus: cloudbuildpb.Build_FAILURE,
+			expectedComplete:    true,
+			expectedFiles:       []string{"finished.json"},
+		},
+		{
+			name:             "missing build errors the job",
+			pj:               prowJob(prowv1.PendingState),
+			expectedState:    prowv1.ErrorState,
+			expectedComplete: true,
+			expectedFiles:    []string{"finished.json"},
+		},
+		{
+			name:                "aborted job cancels the build",
+			pj:                  prowJob(prowv1.AbortedState),
+			build:               statusPtr(cloudbuildpb.Build_WORKING),
+			expectedState:       prowv1.AbortedState,
+			expectedBuildStatus: cloudbuildpb.Build_CANCELLED,
+			expectedComplete:    true,
+			expectedFiles:       []string{"finished.json"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			cb := fake.NewFakeClient()
+			tc.pj.Status.BuildID = "42"
+			if tc.build != nil {
+				bld, err := buildForProwJob(tc.pj)
+				if err != nil {
+					t.Fatalf("failed to create build: %v", err)
+				}
+				if _, err := cb.CreateBuild(ctx, project, bld); err != nil {
+					t.Fatalf("failed to create build: %v", err)
+				}
+				bld.Status = *tc.build
+			}
+			if tc.pj.Status.State == prowv1.TriggeredState {
+				// The build ID of a triggered job is only known from its build.
+				tc.pj.Status.BuildID = ""
+			}
+			pjClient := fakectrlruntimeclient.NewFakeClient(tc.pj)
+			opener := &fakeopener.FakeOpener{}
+			r := newReconciler(pjClient, cfgGetter, cb, opener, "")
+			r.clock = clocktesting.NewFakeClock(time.Now())
+
+			res, err := r.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "prowjobs", Name: "my-pj"}})
+			if err != nil {
+				t.Fatalf("Reconcile failed: %v", err)
+			}
+			if requeue := res.RequeueAfter > 0; requeue != tc.expectedRequeue {
+				t.Errorf("expected requeue %t, got %+v", tc.expectedRequeue, res)
+			}
+
+			var pj prowv1.ProwJob
+			if err := pjClient.Get(ctx, types.NamespacedName{Namespace: "prowjobs", Name: "my-pj"}, &pj); err != nil {
+				t.Fatalf("failed to get prowjob: %v", err)
+			}
+			if pj.Status.State != tc.expectedState {
+				t.Errorf("expected state %s, got %s", tc.expectedState, pj.Status.State)
+			}
+			if pj.Complete() != tc.expectedComplete {
+				t.Errorf("expected complete %t, got %t", tc.expectedComplete, pj.Complete())
+			}
+			if pj.Status.BuildID == "" {
+				t.Error("expected a build ID")
+			}
+
+			builds := cb.Builds[project]
+			if tc.expectedBuildStatus == cloudbuildpb.Build_STATUS_UNKNOWN {
+				if len(builds) != 0 {
+					t.Errorf("expected no builds, got %v", builds)
+				}
+			} else if len(builds) != 1 || builds["my-pj"].Status != tc.expectedBuildStatus {
+				t.Errorf("expected one build with status %s, got %v", tc.expectedBuildStatus, builds)
+			}
+
+			var files []string
+			for p := range opener.Buffer {
+				files = append(files, p[strings.LastIndex(p, "/")+1:])
+			}
+			if diff := cmp.Diff(tc.expectedFiles, files); diff != "" {
+				t.Errorf("unexpected uploads (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func statusPtr(s cloudbuildpb.Build_Status) *cloudbuildpb.Build_Status {
+	return &s
+}
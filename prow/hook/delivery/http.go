@@ -0,0 +1,98 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package delivery
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// DeadLettersPath lists the dead letters. It takes an optional "plugin"
+	// query parameter.
+	DeadLettersPath = "/dead-letters"
+	// RedeliverPath redelivers the dead letters of the plugin passed as the
+	// "plugin" query parameter. Only the dead letters passed as "id" query
+	// parameters are redelivered, all of them if there are none.
+	RedeliverPath = "/redeliver"
+)
+
+// DeadLetter describes a dead letter without its payload.
+type DeadLetter struct {
+	ID         string    `json:"id"`
+	EventType  string    `json:"event_type"`
+	GUID       string    `json:"guid"`
+	EnqueuedAt time.Time `json:"enqueued_at"`
+	Attempts   int       `json:"attempts"`
+	LastError  string    `json:"last_error"`
+}
+
+// RedeliverResponse is returned by the RedeliverPath.
+type RedeliverResponse struct {
+	Redelivered int `json:"redelivered"`
+}
+
+// Handler serves the endpoints to inspect and redeliver dead letters. It must
+// not be exposed publicly.
+func (q *Queues) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(DeadLettersPath, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "only GET is supported", http.StatusMethodNotAllowed)
+			return
+		}
+		result := map[string][]DeadLetter{}
+		for plugin, deliveries := range q.DeadLetters(r.URL.Query().Get("plugin")) {
+			for _, d := range deliveries {
+				result[plugin] = append(result[plugin], DeadLetter{
+					ID:         d.ID,
+					EventType:  d.Header.Get("X-GitHub-Event"),
+					GUID:       d.Header.Get("X-GitHub-Delivery"),
+					EnqueuedAt: d.EnqueuedAt,
+					Attempts:   d.Attempts,
+					LastError:  d.LastError,
+				})
+			}
+		}
+		writeJSON(w, result)
+	})
+	mux.HandleFunc(RedeliverPath, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "only POST is supported", http.StatusMethodNotAllowed)
+			return
+		}
+		plugin := r.URL.Query().Get("plugin")
+		if plugin == "" {
+			http.Error(w, "the plugin parameter is required", http.StatusBadRequest)
+			return
+		}
+		moved := q.Redeliver(plugin, r.URL.Query()["id"]...)
+		logrus.WithField("external-plugin", plugin).Infof("Redelivering %d dead letters.", moved)
+		writeJSON(w, RedeliverResponse{Redelivered: moved})
+	})
+	return mux
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		logrus.WithError(err).Warn("Failed to write response.")
+	}
+}
@@ -0,0 +1,463 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package delivery retries the delivery of events to external plugins.
+//
+// Events hook fails to dispatch to an external plugin are put into a bounded
+// queue of that plugin and retried with exponential backoff. Once a delivery
+// ran out of attempts, it is moved to the dead letters of the plugin, from
+// where it can be redelivered manually. The queue and the dead letters of each
+// plugin are persisted to "<path>/<plugin>.json", so they survive restarts.
+// Payloads are censored and signatures dropped before events are queued, and
+// deliveries are signed again when they are retried.
+package delivery
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+
+	"k8s.io/test-infra/prow/github"
+	pio "k8s.io/test-infra/prow/io"
+)
+
+const (
+	initialBackoff = 10 * time.Second
+	maxBackoff     = time.Hour
+	// syncPeriod is how often due deliveries are retried and changes persisted.
+	syncPeriod = 5 * time.Second
+	// signatureHeader holds the signature of the payload.
+	signatureHeader = "X-Hub-Signature"
+)
+
+// signatureHeaders are dropped from queued deliveries, as they are only valid
+// for the uncensored payload.
+var signatureHeaders = []string{signatureHeader, "X-Hub-Signature-256"}
+
+var (
+	queueDepth = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "prow_external_plugin_queue_depth",
+		Help: "Number of events waiting to be redelivered to an external plugin.",
+	}, []string{"plugin"})
+	deadLetters = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "prow_external_plugin_dead_letters",
+		Help: "Number of events that could not be delivered to an external plugin.",
+	}, []string{"plugin"})
+	deliveryFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "prow_external_plugin_delivery_failures",
+		Help: "Number of failed attempts to redeliver an event to an external plugin.",
+	}, []string{"plugin"})
+)
+
+func init() {
+	prometheus.MustRegister(queueDepth)
+	prometheus.MustRegister(deadLetters)
+	prometheus.MustRegister(deliveryFailures)
+}
+
+// Delivery is an event that has to be delivered to an external plugin.
+type Delivery struct {
+	ID       string          `json:"id"`
+	Endpoint string          `json:"endpoint"`
+	Header   http.Header     `json:"header"`
+	Payload  json.RawMessage `json:"payload"`
+
+	EnqueuedAt  time.Time `json:"enqueued_at"`
+	Attempts    int       `json:"attempts"`
+	NextAttempt time.Time `json:"next_attempt"`
+	LastError   string    `json:"last_error,omitempty"`
+}
+
+// state is what is persisted for every plugin.
+type state struct {
+	Queue       []Delivery `json:"queue"`
+	DeadLetters []Delivery `json:"dead_letters"`
+}
+
+// Options bound the queues.
+type Options struct {
+	// MaxQueueSize is the number of deliveries queued per plugin. Deliveries
+	// that don't fit anymore are moved to the dead letters right away.
+	MaxQueueSize int
+	// MaxDeadLetters is the number of dead letters kept per plugin. The
+	// oldest ones are dropped first.
+	MaxDeadLetters int
+	// MaxAttempts is the number of times a delivery is retried.
+	MaxAttempts int
+}
+
+// Queues holds the retry queue of every external plugin.
+type Queues struct {
+	opener  pio.Opener
+	path    string
+	options Options
+	censor  func([]byte) []byte
+	// tokenGenerator returns the hmac tokens deliveries are signed with.
+	tokenGenerator func() []byte
+	post           func(endpoint string, payload []byte, h http.Header) error
+	now            func() time.Time
+
+	lock   sync.Mutex
+	states map[string]*state
+	// dirty holds the plugins whose state has to be persisted.
+	dirty map[string]bool
+	// inflight holds the plugins a redelivery is in progress for.
+	inflight map[string]bool
+}
+
+// NewQueues returns queues persisted to path, which may be a local directory
+// or a bucket. Payloads are passed through censor before they are queued, see
+// secretutil.AdaptCensorer, and signed with the hmac tokens of tokenGenerator
+// when they are retried. Load needs to be called before the queues are used.
+func NewQueues(opener pio.Opener, path string, options Options, censor func([]byte) []byte, tokenGenerator func() []byte) *Queues {
+	client := &http.Client{Timeout: time.Minute}
+	return &Queues{
+		opener:         opener,
+		path:           strings.TrimSuffix(path, "/"),
+		options:        options,
+		censor:         censor,
+		tokenGenerator: tokenGenerator,
+		post: func(endpoint string, payload []byte, h http.Header) error {
+			return post(client, endpoint, payload, h)
+		},
+		now:      time.Now,
+		states:   map[string]*state{},
+		dirty:    map[string]bool{},
+		inflight: map[string]bool{},
+	}
+}
+
+// Load reads the persisted state of the plugins. State of plugins that are no
+// longer configured is left alone.
+func (q *Queues) Load(ctx context.Context, plugins []string) error {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	for _, plugin := range plugins {
+		if _, loaded := q.states[plugin]; loaded {
+			continue
+		}
+		content, err := pio.ReadContent(ctx, logrus.WithField("external-plugin", plugin), q.opener, q.statePath(plugin))
+		if pio.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read state of %s: %w", plugin, err)
+		}
+		var s state
+		if err := json.Unmarshal(content, &s); err != nil {
+			return fmt.Errorf("failed to parse state of %s: %w", plugin, err)
+		}
+		q.states[plugin] = &s
+		q.updateMetrics(plugin)
+	}
+	return nil
+}
+
+func (q *Queues) statePath(plugin string) string {
+	return fmt.Sprintf("%s/%s.json", q.path, plugin)
+}
+
+// Enqueue schedules the redelivery of an event that could not be dispatched.
+func (q *Queues) Enqueue(plugin, endpoint string, payload []byte, h http.Header, dispatchErr error) {
+	censored := q.censor(payload)
+	if !json.Valid(censored) {
+		logrus.WithField("external-plugin", plugin).Error("Dropping event whose censored payload is not valid JSON.")
+		return
+	}
+	header := h.Clone()
+	for _, name := range signatureHeaders {
+		header.Del(name)
+	}
+	now := q.now()
+	d := Delivery{
+		ID:          uuid.New().String(),
+		Endpoint:    endpoint,
+		Header:      header,
+		Payload:     censored,
+		EnqueuedAt:  now,
+		Attempts:    1,
+		NextAttempt: now.Add(backoff(1)),
+		LastError:   dispatchErr.Error(),
+	}
+
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	s := q.state(plugin)
+	if len(s.Queue) >= q.options.MaxQueueSize {
+		d.LastError = fmt.Sprintf("queue is full, last error: %s", d.LastError)
+		q.deadLetter(plugin, d)
+	} else {
+		s.Queue = append(s.Queue, d)
+	}
+	q.dirty[plugin] = true
+	q.updateMetrics(plugin)
+}
+
+// state returns the state of the plugin. The lock must be held.
+func (q *Queues) state(plugin string) *state {
+	s, ok := q.states[plugin]
+	if !ok {
+		s = &state{}
+		q.states[plugin] = s
+	}
+	return s
+}
+
+// deadLetter adds the delivery to the dead letters. The lock must be held.
+func (q *Queues) deadLetter(plugin string, d Delivery) {
+	s := q.state(plugin)
+	s.DeadLetters = append(s.DeadLetters, d)
+	if overflow := len(s.DeadLetters) - q.options.MaxDeadLetters; overflow > 0 {
+		logrus.WithField("external-plugin", plugin).Warnf("Dropping %d dead letters.", overflow)
+		s.DeadLetters = s.DeadLetters[overflow:]
+	}
+}
+
+func (q *Queues) updateMetrics(plugin string) {
+	s := q.state(plugin)
+	queueDepth.WithLabelValues(plugin).Set(float64(len(s.Queue)))
+	deadLetters.WithLabelValues(plugin).Set(float64(len(s.DeadLetters)))
+}
+
+// Run retries due deliveries and persists changes until the context is
+// cancelled. Changes are persisted a last time before it returns.
+func (q *Queues) Run(ctx context.Context) {
+	ticker := time.NewTicker(syncPeriod)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			q.persist(context.Background())
+			return
+		case <-ticker.C:
+			q.retry()
+			q.persist(ctx)
+		}
+	}
+}
+
+// retry redelivers the due deliveries of all plugins. Plugins are handled in
+// parallel, but the deliveries of a plugin in order. Once a delivery fails,
+// the remaining deliveries of that plugin are left for the next round.
+func (q *Queues) retry() {
+	q.lock.Lock()
+	var plugins []string
+	for plugin, s := range q.states {
+		if len(s.Queue) > 0 && !q.inflight[plugin] {
+			q.inflight[plugin] = true
+			plugins = append(plugins, plugin)
+		}
+	}
+	q.lock.Unlock()
+
+	var wg sync.WaitGroup
+	for _, plugin := range plugins {
+		wg.Add(1)
+		go func(plugin string) {
+			defer wg.Done()
+			q.retryPlugin(plugin)
+			q.lock.Lock()
+			delete(q.inflight, plugin)
+			q.lock.Unlock()
+		}(plugin)
+	}
+	wg.Wait()
+}
+
+func (q *Queues) retryPlugin(plugin string) {
+	log := logrus.WithField("external-plugin", plugin)
+	for {
+		q.lock.Lock()
+		var due *Delivery
+		for i := range q.state(plugin).Queue {
+			if d := q.state(plugin).Queue[i]; !d.NextAttempt.After(q.now()) {
+				due = &d
+				break
+			}
+		}
+		q.lock.Unlock()
+		if due == nil {
+			return
+		}
+
+		err := q.deliver(due)
+
+		q.lock.Lock()
+		s := q.state(plugin)
+		for i := range s.Queue {
+			if s.Queue[i].ID != due.ID {
+				continue
+			}
+			if err == nil {
+				s.Queue = append(s.Queue[:i], s.Queue[i+1:]...)
+				break
+			}
+			deliveryFailures.WithLabelValues(plugin).Inc()
+			d := &s.Queue[i]
+			d.Attempts++
+			d.LastError = err.Error()
+			d.NextAttempt = q.now().Add(backoff(d.Attempts))
+			if d.Attempts >= q.options.MaxAttempts {
+				log.WithError(err).WithField("id", d.ID).Warn("Giving up on delivering event to external plugin.")
+				q.deadLetter(plugin, *d)
+				s.Queue = append(s.Queue[:i], s.Queue[i+1:]...)
+			}
+			break
+		}
+		q.dirty[plugin] = true
+		q.updateMetrics(plugin)
+		q.lock.Unlock()
+
+		if err != nil {
+			log.WithError(err).WithField("id", due.ID).Info("Failed to redeliver event to external plugin.")
+			return
+		}
+		log.WithField("id", due.ID).Info("Redelivered event to external plugin.")
+	}
+}
+
+// deliver signs the delivery and posts it to the plugin.
+func (q *Queues) deliver(d *Delivery) error {
+	sig, err := github.SignPayload(d.Payload, q.tokenGenerator)
+	if err != nil {
+		return fmt.Errorf("failed to sign payload: %w", err)
+	}
+	header := d.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	header.Set(signatureHeader, sig)
+	return q.post(d.Endpoint, d.Payload, header)
+}
+
+// persist writes the state of all plugins that changed.
+func (q *Queues) persist(ctx context.Context) {
+	q.lock.Lock()
+	contents := map[string][]byte{}
+	for plugin := range q.dirty {
+		content, err := json.Marshal(q.state(plugin))
+		if err != nil {
+			logrus.WithError(err).WithField("external-plugin", plugin).Error("Failed to marshal delivery state.")
+			continue
+		}
+		contents[plugin] = content
+	}
+	q.dirty = map[string]bool{}
+	q.lock.Unlock()
+
+	for plugin, content := range contents {
+		log := logrus.WithField("external-plugin", plugin)
+		if err := pio.WriteContent(ctx, log, q.opener, q.statePath(plugin), content); err != nil {
+			log.WithError(err).Error("Failed to persist delivery state.")
+			q.lock.Lock()
+			q.dirty[plugin] = true
+			q.lock.Unlock()
+		}
+	}
+}
+
+// DeadLetters returns the dead letters of the plugin, or of all plugins if
+// plugin is empty, keyed by plugin.
+func (q *Queues) DeadLetters(plugin string) map[string][]Delivery {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	result := map[string][]Delivery{}
+	for name, s := range q.states {
+		if (plugin == "" || plugin == name) && len(s.DeadLetters) > 0 {
+			result[name] = append([]Delivery(nil), s.DeadLetters...)
+		}
+	}
+	return result
+}
+
+// Redeliver moves dead letters of the plugin back into its queue, so that
+// they are retried right away with a fresh set of attempts. If no ids are
+// passed, all dead letters of the plugin are redelivered. Dead letters that
+// don't fit into the queue anymore are left alone. It returns the number of
+// dead letters that were moved.
+func (q *Queues) Redeliver(plugin string, ids ...string) int {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	s, ok := q.states[plugin]
+	if !ok {
+		return 0
+	}
+	wanted := map[string]bool{}
+	for _, id := range ids {
+		wanted[id] = true
+	}
+	var remaining []Delivery
+	var moved int
+	for _, d := range s.DeadLetters {
+		if (len(ids) > 0 && !wanted[d.ID]) || len(s.Queue) >= q.options.MaxQueueSize {
+			remaining = append(remaining, d)
+			continue
+		}
+		d.Attempts = 0
+		d.NextAttempt = q.now()
+		s.Queue = append(s.Queue, d)
+		moved++
+	}
+	s.DeadLetters = remaining
+	if moved > 0 {
+		q.dirty[plugin] = true
+		q.updateMetrics(plugin)
+	}
+	return moved
+}
+
+// backoff returns how long to wait after the given number of attempts.
+func backoff(attempts int) time.Duration {
+	wait := initialBackoff
+	for i := 1; i < attempts && wait < maxBackoff; i++ {
+		wait *= 2
+	}
+	if wait > maxBackoff {
+		return maxBackoff
+	}
+	return wait
+}
+
+func post(client *http.Client, endpoint string, payload []byte, h http.Header) error {
+	req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewBuffer(payload))
+	if err != nil {
+		return err
+	}
+	req.Header = h.Clone()
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	rb, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("response has status %q and body %q", resp.Status, string(rb))
+	}
+	return nil
+}
@@ -0,0 +1,297 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package delivery
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sirupsen/logrus"
+
+	"k8s.io/test-infra/prow/github"
+	pio "k8s.io/test-infra/prow/io"
+)
+
+type fakeEndpoint struct {
+	lock      sync.Mutex
+	down      bool
+	delivered []string
+	payloads  []string
+}
+
+func (f *fakeEndpoint) post(endpoint string, payload []byte, h http.Header) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if f.down {
+		return errors.New("connection refused")
+	}
+	if !github.ValidatePayload(payload, h.Get("X-Hub-Signature"), tokenGenerator) {
+		return errors.New("invalid signature")
+	}
+	f.delivered = append(f.delivered, h.Get("X-GitHub-Delivery"))
+	f.payloads = append(f.payloads, string(payload))
+	return nil
+}
+
+func tokenGenerator() []byte {
+	return []byte("hmac")
+}
+
+func censor(content []byte) []byte {
+	return bytes.ReplaceAll(content, []byte("hunter2"), []byte("XXXXXXX"))
+}
+
+func newTestQueues(t *testing.T, options Options) (*Queues, *fakeEndpoint, *time.Time) {
+	opener, err := pio.NewOpener(context.Background(), "", "")
+	if err != nil {
+		t.Fatalf("failed to create opener: %v", err)
+	}
+	q := NewQueues(opener, t.TempDir(), options, censor, tokenGenerator)
+	endpoint := &fakeEndpoint{}
+	q.post = endpoint.post
+	now := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	q.now = func() time.Time { return now }
+	return q, endpoint, &now
+}
+
+func enqueue(q *Queues, plugin, guid string) {
+	q.Enqueue(plugin, "http://"+plugin, []byte(`{}`), http.Header{"X-Github-Delivery": []string{guid}}, errors.New("connection refused"))
+}
+
+func guids(deliveries []Delivery) []string {
+	var result []string
+	for _, d := range deliveries {
+		result = append(result, d.Header.Get("X-GitHub-Delivery"))
+	}
+	return result
+}
+
+func TestRetry(t *testing.T) {
+	q, endpoint, now := newTestQueues(t, Options{MaxQueueSize: 10, MaxDeadLetters: 10, MaxAttempts: 3})
+	endpoint.down = true
+	enqueue(q, "cherrypicker", "first")
+	enqueue(q, "cherrypicker", "second")
+
+	// Nothing is due yet.
+	q.retry()
+	if diff := cmp.Diff([]string{"first", "second"}, guids(q.states["cherrypicker"].Queue)); diff != "" {
+		t.Fatalf("unexpected queue (-want +got):\n%s", diff)
+	}
+	if q.states["cherrypicker"].Queue[0].Attempts != 1 {
+		t.Errorf("expected one attempt, got %d", q.states["cherrypicker"].Queue[0].Attempts)
+	}
+
+	// Only the first delivery is attempted while the plugin is down.
+	*now = now.Add(initialBackoff)
+	q.retry()
+	first := q.states["cherrypicker"].Queue[0]
+	if first.Attempts != 2 || !first.NextAttempt.Equal(now.Add(2*initialBackoff)) {
+		t.Errorf("expected second attempt in %s, got attempt %d at %s", 2*initialBackoff, first.Attempts, first.NextAttempt)
+	}
+	if second := q.states["cherrypicker"].Queue[1]; second.Attempts != 1 {
+		t.Errorf("expected the second delivery not to be attempted, got %d attempts", second.Attempts)
+	}
+
+	// Once the plugin is back, everything that is due is delivered in order.
+	endpoint.down = false
+	*now = now.Add(2 * initialBackoff)
+	q.retry()
+	if diff := cmp.Diff([]string{"first", "second"}, endpoint.delivered); diff != "" {
+		t.Errorf("unexpected deliveries (-want +got):\n%s", diff)
+	}
+	if len(q.states["cherrypicker"].Queue) != 0 {
+		t.Errorf("expected the queue to be empty, got %v", guids(q.states["cherrypicker"].Queue))
+	}
+}
+
+func TestDeadLetters(t *testing.T) {
+	q, endpoint, now := newTestQueues(t, Options{MaxQueueSize: 2, MaxDeadLetters: 2, MaxAttempts: 2})
+	endpoint.down = true
+	enqueue(q, "needs-rebase", "first")
+	enqueue(q, "needs-rebase", "second")
+	// The queue is full, so these go to the dead letters right away.
+	enqueue(q, "needs-rebase", "third")
+	enqueue(q, "needs-rebase", "fourth")
+	if diff := cmp.Diff([]string{"third", "fourth"}, guids(q.DeadLetters("needs-rebase")["needs-rebase"])); diff != "" {
+		t.Errorf("unexpected dead letters (-want +got):\n%s", diff)
+	}
+
+	*now = now.Add(maxBackoff)
+	q.retry()
+	// first ran out of attempts and pushed out the oldest dead letter.
+	if diff := cmp.Diff([]string{"fourth", "first"}, guids(q.DeadLetters("")["needs-rebase"])); diff != "" {
+		t.Errorf("unexpected dead letters (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"second"}, guids(q.states["needs-rebase"].Queue)); diff != "" {
+		t.Errorf("unexpected queue (-want +got):\n%s", diff)
+	}
+
+	id := q.DeadLetters("")["needs-rebase"][1].ID
+	if moved := q.Redeliver("needs-rebase", id); moved != 1 {
+		t.Errorf("expected one dead letter to be redelivered, got %d", moved)
+	}
+	endpoint.down = false
+	q.retry()
+	// The redelivered dead letter is queued after the pending delivery.
+	if diff := cmp.Diff([]string{"second", "first"}, endpoint.delivered); diff != "" {
+		t.Errorf("unexpected deliveries (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"fourth"}, guids(q.DeadLetters("")["needs-rebase"])); diff != "" {
+		t.Errorf("unexpected dead letters (-want +got):\n%s", diff)
+	}
+}
+
+func TestRedeliverRespectsMaxQueueSize(t *testing.T) {
+	q, endpoint, _ := newTestQueues(t, Options{MaxQueueSize: 2, MaxDeadLetters: 10, MaxAttempts: 2})
+	endpoint.down = true
+	for _, guid := range []string{"first", "second", "third", "fourth"} {
+		enqueue(q, "needs-rebase", guid)
+	}
+	// Only one more delivery fits into the queue once one was delivered.
+	endpoint.down = false
+	q.states["needs-rebase"].Queue = q.states["needs-rebase"].Queue[1:]
+	if moved := q.Redeliver("needs-rebase"); moved != 1 {
+		t.Errorf("expected one dead letter to be redelivered, got %d", moved)
+	}
+	if diff := cmp.Diff([]string{"second", "third"}, guids(q.states["needs-rebase"].Queue)); diff != "" {
+		t.Errorf("unexpected queue (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"fourth"}, guids(q.DeadLetters("needs-rebase")["needs-rebase"])); diff != "" {
+		t.Errorf("unexpected dead letters (-want +got):\n%s", diff)
+	}
+}
+
+func TestEnqueueCensorsAndDropsSignatures(t *testing.T) {
+	q, endpoint, now := newTestQueues(t, Options{MaxQueueSize: 10, MaxDeadLetters: 10, MaxAttempts: 2})
+	endpoint.down = true
+	payload := []byte(`{"comment":{"body":"my password is hunter2"}}`)
+	h := http.Header{}
+	h.Set("X-GitHub-Delivery", "guid")
+	h.Set("X-Hub-Signature", github.PayloadSignature(payload, tokenGenerator()))
+	h.Set("X-Hub-Signature-256", "sha256=signature")
+	q.Enqueue("cherrypicker", "http://cherrypicker", payload, h, errors.New("connection refused"))
+	q.persist(context.Background())
+
+	content, err := pio.ReadContent(context.Background(), logrus.WithField("test", t.Name()), q.opener, q.statePath("cherrypicker"))
+	if err != nil {
+		t.Fatalf("failed to read persisted state: %v", err)
+	}
+	for _, leaked := range []string{"hunter2", "X-Hub-Signature", "sha256=signature"} {
+		if strings.Contains(string(content), leaked) {
+			t.Errorf("persisted state contains %q: %s", leaked, content)
+		}
+	}
+
+	// The censored payload is signed again when it is retried.
+	endpoint.down = false
+	*now = now.Add(initialBackoff)
+	q.retry()
+	if diff := cmp.Diff([]string{`{"comment":{"body":"my password is XXXXXXX"}}`}, endpoint.payloads); diff != "" {
+		t.Errorf("unexpected payloads (-want +got):\n%s", diff)
+	}
+}
+
+func TestPersistAndLoad(t *testing.T) {
+	q, endpoint, _ := newTestQueues(t, Options{MaxQueueSize: 1, MaxDeadLetters: 10, MaxAttempts: 2})
+	endpoint.down = true
+	enqueue(q, "cherrypicker", "queued")
+	enqueue(q, "cherrypicker", "dead")
+	q.persist(context.Background())
+	if len(q.dirty) != 0 {
+		t.Errorf("expected nothing to be left to persist, got %v", q.dirty)
+	}
+
+	loaded := NewQueues(q.opener, q.path, q.options, censor, tokenGenerator)
+	if err := loaded.Load(context.Background(), []string{"cherrypicker", "unknown"}); err != nil {
+		t.Fatalf("failed to load: %v", err)
+	}
+	if diff := cmp.Diff(q.states, loaded.states); diff != "" {
+		t.Errorf("loaded state differs (-want +got):\n%s", diff)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	q, endpoint, _ := newTestQueues(t, Options{MaxQueueSize: 0, MaxDeadLetters: 10, MaxAttempts: 2})
+	endpoint.down = true
+	enqueue(q, "cherrypicker", "first")
+	enqueue(q, "needs-rebase", "second")
+	// Make room for the dead letters to be redelivered.
+	q.options.MaxQueueSize = 1
+	server := httptest.NewServer(q.Handler())
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + DeadLettersPath + "?plugin=cherrypicker")
+	if err != nil {
+		t.Fatalf("failed to list dead letters: %v", err)
+	}
+	var listed map[string][]DeadLetter
+	if err := json.NewDecoder(resp.Body).Decode(&listed); err != nil {
+		t.Fatalf("failed to decode dead letters: %v", err)
+	}
+	resp.Body.Close()
+	if len(listed) != 1 || len(listed["cherrypicker"]) != 1 || listed["cherrypicker"][0].GUID != "first" {
+		t.Errorf("expected the dead letter of cherrypicker, got %+v", listed)
+	}
+
+	resp, err = http.Get(server.URL + RedeliverPath + "?plugin=cherrypicker")
+	if err != nil {
+		t.Fatalf("failed to redeliver: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("expected redelivering with GET to be rejected, got %s", resp.Status)
+	}
+	resp, err = http.Post(server.URL+RedeliverPath+"?plugin=cherrypicker", "", nil)
+	if err != nil {
+		t.Fatalf("failed to redeliver: %v", err)
+	}
+	var redelivered RedeliverResponse
+	if err := json.NewDecoder(resp.Body).Decode(&redelivered); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	resp.Body.Close()
+	if redelivered.Redelivered != 1 {
+		t.Errorf("expected one dead letter to be redelivered, got %d", redelivered.Redelivered)
+	}
+	if diff := cmp.Diff([]string{"first"}, guids(q.states["cherrypicker"].Queue)); diff != "" {
+		t.Errorf("unexpected queue (-want +got):\n%s", diff)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	for attempts, expected := range map[int]time.Duration{
+		1:  10 * time.Second,
+		2:  20 * time.Second,
+		5:  160 * time.Second,
+		9:  2560 * time.Second,
+		10: time.Hour,
+		50: time.Hour,
+	} {
+		if got := backoff(attempts); got != expected {
+			t.Errorf("backoff(%d) = %s, expected %s", attempts, got, expected)
+		}
+	}
+}
@@ -419,6 +419,63 @@ func (s *Server) handleStatusEvent(l *logrus.Entry, se github.StatusEvent) {
 	}
 }
 
+func (s *Server) handleCheckRunEvent(l *logrus.Entry, cre github.CheckRunEvent) {
+	defer s.wg.Done()
+	l = l.WithFields(logrus.Fields{
+		github.OrgLogField:  cre.Repo.Owner.Login,
+		github.RepoLogField: cre.Repo.Name,
+		"check_run":         cre.CheckRun.Name,
+		"sha":               cre.CheckRun.HeadSHA,
+		"id":                cre.CheckRun.ID,
+		"action":            cre.Action,
+		"sender":            cre.Sender.Login,
+	})
+	l.Infof("Check run %s %s.", cre.CheckRun.Name, cre.Action)
+	for p, h := range s.Plugins.CheckRunEventHandlers(cre.Repo.Owner.Login, cre.Repo.Name) {
+		s.wg.Add(1)
+		go func(p string, h plugins.CheckRunEventHandler) {
+			defer s.wg.Done()
+			agent := plugins.NewAgent(s.ConfigAgent, s.Plugins, s.ClientAgent, cre.Repo.Owner.Login, s.Metrics.Metrics, l, p)
+			start := time.Now()
+			err := errorOnPanic(func() error { return h(agent, cre) })
+			labels := prometheus.Labels{"event_type": l.Data[eventTypeField].(string), "action": string(cre.Action), "plugin": p, "took_action": strconv.FormatBool(agent.TookAction())}
+			if err != nil {
+				agent.Logger.WithError(err).Error("Error handling CheckRunEvent.")
+				s.Metrics.PluginHandleErrors.With(labels).Inc()
+			}
+			s.Metrics.PluginHandleDuration.With(labels).Observe(time.Since(start).Seconds())
+		}(p, h)
+	}
+}
+
+func (s *Server) handleMergeGroupEvent(l *logrus.Entry, mge github.MergeGroupEvent) {
+	defer s.wg.Done()
+	l = l.WithFields(logrus.Fields{
+		github.OrgLogField:  mge.Repo.Owner.Login,
+		github.RepoLogField: mge.Repo.Name,
+		"head_ref":          mge.MergeGroup.HeadRef,
+		"head_sha":          mge.MergeGroup.HeadSHA,
+		"base_ref":          mge.MergeGroup.BaseRef,
+		"action":            mge.Action,
+	})
+	l.Infof("Merge group %s %s.", mge.MergeGroup.HeadRef, mge.Action)
+	for p, h := range s.Plugins.MergeGroupEventHandlers(mge.Repo.Owner.Login, mge.Repo.Name) {
+		s.wg.Add(1)
+		go func(p string, h plugins.MergeGroupEventHandler) {
+			defer s.wg.Done()
+			agent := plugins.NewAgent(s.ConfigAgent, s.Plugins, s.ClientAgent, mge.Repo.Owner.Login, s.Metrics.Metrics, l, p)
+			start := time.Now()
+			err := errorOnPanic(func() error { return h(agent, mge) })
+			labels := prometheus.Labels{"event_type": l.Data[eventTypeField].(string), "action": string(mge.Action), "plugin": p, "took_action": strconv.FormatBool(agent.TookAction())}
+			if err != nil {
+				agent.Logger.WithError(err).Error("Error handling MergeGroupEvent.")
+				s.Metrics.PluginHandleErrors.With(labels).Inc()
+			}
+			s.Metrics.PluginHandleDuration.With(labels).Observe(time.Since(start).Seconds())
+		}(p, h)
+	}
+}
+
 // genericCommentAction normalizes the action string to a GenericCommentEventAction or returns ""
 // if the action is unrelated to the comment text. (For example a PR 'label' action.)
 func genericCommentAction(action string) github.GenericCommentEventAction {
@@ -0,0 +1,81 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hook
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"k8s.io/test-infra/prow/io"
+)
+
+// RecordedEvent is a webhook as stored by the Recorder.
+type RecordedEvent struct {
+	// Type is the GitHub event type, eg "issue_comment".
+	Type string `json:"event_type"`
+	// GUID is the GitHub delivery ID of the event.
+	GUID       string          `json:"guid"`
+	ReceivedAt time.Time       `json:"received_at"`
+	Payload    json.RawMessage `json:"payload"`
+}
+
+// Recorder stores the webhooks hook receives so that they can be replayed
+// later on.
+type Recorder struct {
+	opener io.Opener
+	path   string
+	censor func([]byte) []byte
+	now    func() time.Time
+}
+
+// NewRecorder returns a Recorder that writes every event to its own file in
+// path, which may be a local directory or a bucket. Payloads are passed
+// through censor before they are written, see secretutil.AdaptCensorer.
+func NewRecorder(opener io.Opener, path string, censor func([]byte) []byte) *Recorder {
+	return &Recorder{
+		opener: opener,
+		path:   strings.TrimSuffix(path, "/"),
+		censor: censor,
+		now:    time.Now,
+	}
+}
+
+// Record stores the event. Files are named after the time the event was
+// received so that listing them yields the order they arrived in.
+func (r *Recorder) Record(ctx context.Context, eventType, eventGUID string, payload []byte) error {
+	censored := r.censor(payload)
+	if !json.Valid(censored) {
+		return fmt.Errorf("censored payload of event %s is not valid JSON", eventGUID)
+	}
+	event := RecordedEvent{
+		Type:       eventType,
+		GUID:       eventGUID,
+		ReceivedAt: r.now().UTC(),
+		Payload:    censored,
+	}
+	content, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+	path := fmt.Sprintf("%s/%s-%s.json", r.path, event.ReceivedAt.Format("20060102T150405.000000000Z"), eventGUID)
+	return io.WriteContent(ctx, logrus.WithField("guid", eventGUID), r.opener, path, content)
+}
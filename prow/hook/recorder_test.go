@@ -0,0 +1,69 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hook
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"k8s.io/test-infra/prow/io"
+	"k8s.io/test-infra/prow/secretutil"
+)
+
+func TestRecorder(t *testing.T) {
+	dir := t.TempDir()
+	opener, err := io.NewOpener(context.Background(), "", "")
+	if err != nil {
+		t.Fatalf("failed to create opener: %v", err)
+	}
+	censorer := secretutil.NewCensorer()
+	censorer.Refresh("hunter2")
+	recorder := NewRecorder(opener, dir+"/", secretutil.AdaptCensorer(censorer))
+	recorder.now = func() time.Time { return time.Date(2022, 3, 4, 5, 6, 7, 0, time.UTC) }
+
+	payload := []byte(`{"comment":{"body":"my password is hunter2"}}`)
+	if err := recorder.Record(context.Background(), "issue_comment", "guid", payload); err != nil {
+		t.Fatalf("failed to record: %v", err)
+	}
+	if string(payload) != `{"comment":{"body":"my password is hunter2"}}` {
+		t.Errorf("payload was modified: %s", payload)
+	}
+
+	content, err := os.ReadFile(filepath.Join(dir, "20220304T050607.000000000Z-guid.json"))
+	if err != nil {
+		t.Fatalf("failed to read recorded event: %v", err)
+	}
+	var got RecordedEvent
+	if err := json.Unmarshal(content, &got); err != nil {
+		t.Fatalf("failed to parse recorded event: %v", err)
+	}
+	expected := RecordedEvent{
+		Type:       "issue_comment",
+		GUID:       "guid",
+		ReceivedAt: time.Date(2022, 3, 4, 5, 6, 7, 0, time.UTC),
+		Payload:    json.RawMessage(`{"comment":{"body":"my password is XXXXXXX"}}`),
+	}
+	if diff := cmp.Diff(expected, got); diff != "" {
+		t.Errorf("unexpected recorded event (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,385 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package replay runs webhooks recorded by hook through the configured
+// plugins against fake clients.
+package replay
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/util/sets"
+	clienttesting "k8s.io/client-go/testing"
+
+	prowapi "k8s.io/test-infra/prow/apis/prowjobs/v1"
+	"k8s.io/test-infra/prow/bugzilla"
+	prowfake "k8s.io/test-infra/prow/client/clientset/versioned/fake"
+	"k8s.io/test-infra/prow/config"
+	"k8s.io/test-infra/prow/github"
+	"k8s.io/test-infra/prow/github/fakegithub"
+	"k8s.io/test-infra/prow/githubeventserver"
+	"k8s.io/test-infra/prow/hook"
+	"k8s.io/test-infra/prow/plugins"
+	"k8s.io/test-infra/prow/plugins/ownersconfig"
+	"k8s.io/test-infra/prow/repoowners"
+	"k8s.io/test-infra/prow/slack"
+)
+
+// Result holds what the plugins did with a recorded event.
+type Result struct {
+	Event hook.RecordedEvent
+	// Mutations are the changes each plugin would have made on GitHub,
+	// keyed by plugin name. Plugins that made no changes are omitted.
+	Mutations map[string][]string
+	// ProwJobs are the ProwJobs the plugins would have created.
+	ProwJobs []string
+	// Err is set if the event could not be dispatched to the plugins.
+	// Errors of individual plugins are only logged, just like in hook.
+	Err error
+}
+
+// Run runs recorded events through the configured plugins in the same way
+// hook does. The plugins talk to a fake GitHub client seeded with the issue or
+// pull request of the event, so nothing is changed on GitHub. External and
+// WebAssembly plugins are not run.
+func Run(configAgent *config.Agent, pluginAgent *plugins.ConfigAgent, events []hook.RecordedEvent) []Result {
+	pluginConfig := *pluginAgent.Config()
+	pluginConfig.ExternalPlugins = nil
+	replayPlugins := &plugins.ConfigAgent{}
+	replayPlugins.Set(&pluginConfig)
+
+	var results []Result
+	for _, event := range events {
+		clients := &replayClients{payload: event.Payload, byPlugin: map[string]*replayGitHubClient{}}
+		prowJobs := prowfake.NewSimpleClientset()
+		s := &hook.Server{
+			ClientAgent: replayClientAgent(configAgent, replayPlugins, clients.forPlugin(""), prowJobs),
+			ConfigAgent: configAgent,
+			Plugins:     replayPlugins,
+			Metrics:     githubeventserver.NewMetrics(),
+			RepoEnabled: func(_, _ string) bool { return true },
+		}
+		err := s.HandleEvent(event.Type, event.GUID, event.Payload)
+		s.GracefulShutdown()
+		results = append(results, Result{Event: event, Mutations: clients.mutations(), ProwJobs: createdProwJobs(prowJobs), Err: err})
+	}
+	return results
+}
+
+func replayClientAgent(configAgent *config.Agent, pluginAgent *plugins.ConfigAgent, ghc *replayGitHubClient, prowJobs *prowfake.Clientset) *plugins.ClientAgent {
+	ownersDirDenylist := func() *config.OwnersDirDenylist {
+		if l := configAgent.Config().OwnersDirDenylist; l != nil {
+			return l
+		}
+		return &config.OwnersDirDenylist{}
+	}
+	resolver := func(org, repo string) ownersconfig.Filenames {
+		return pluginAgent.Config().OwnersFilenames(org, repo)
+	}
+	return &plugins.ClientAgent{
+		GitHubClient:  ghc,
+		ProwJobClient: prowJobs.ProwV1().ProwJobs(configAgent.Config().ProwJobNamespace),
+		SlackClient:   slack.NewFakeClient(),
+		OwnersClient: repoowners.NewClient(nil, ghc,
+			pluginAgent.Config().MDYAMLEnabled, pluginAgent.Config().SkipCollaborators, ownersDirDenylist, resolver),
+		BugzillaClient: &bugzilla.Fake{},
+	}
+}
+
+// unimplementedGitHubClient provides the methods of github.Client that the
+// fake lacks. Calling them panics, which hook reports as an error of the plugin.
+type unimplementedGitHubClient struct {
+	github.Client
+}
+
+// replayGitHubClient is a fake github.Client that hands every plugin a fake of
+// its own, so that mutations can be attributed to the plugin that made them.
+type replayGitHubClient struct {
+	*fakegithub.FakeClient
+	unimplementedGitHubClient
+
+	clients *replayClients
+
+	lock sync.Mutex
+	// recorded are the changes that the fake doesn't keep track of itself.
+	recorded []string
+}
+
+func (c *replayGitHubClient) record(format string, args ...interface{}) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.recorded = append(c.recorded, fmt.Sprintf(format, args...))
+}
+
+func (c *replayGitHubClient) WithFields(logrus.Fields) github.Client {
+	return c
+}
+
+func (c *replayGitHubClient) ForPlugin(plugin string) github.Client {
+	return c.clients.forPlugin(plugin)
+}
+
+func (c *replayGitHubClient) ForSubcomponent(string) github.Client {
+	return c
+}
+
+func (c *replayGitHubClient) Used() bool {
+	return len(c.mutations()) > 0
+}
+
+func (c *replayGitHubClient) CreateIssue(org, repo, title, body string, milestone int, labels, assignees []string) (int, error) {
+	c.record("create issue %s/%s:%s", org, repo, title)
+	return c.FakeClient.CreateIssue(org, repo, title, body, milestone, labels, assignees)
+}
+
+func (c *replayGitHubClient) EditIssue(org, repo string, number int, issue *github.Issue) (*github.Issue, error) {
+	c.record("edit issue %s/%s#%d", org, repo, number)
+	return c.FakeClient.EditIssue(org, repo, number, issue)
+}
+
+func (c *replayGitHubClient) CloseIssue(org, repo string, number int) error {
+	c.record("close issue %s/%s#%d", org, repo, number)
+	return c.FakeClient.CloseIssue(org, repo, number)
+}
+
+func (c *replayGitHubClient) CloseIssueAsNotPlanned(org, repo string, number int) error {
+	c.record("close issue as not planned %s/%s#%d", org, repo, number)
+	return c.FakeClient.CloseIssueAsNotPlanned(org, repo, number)
+}
+
+func (c *replayGitHubClient) ReopenIssue(org, repo string, number int) error {
+	c.record("reopen issue %s/%s#%d", org, repo, number)
+	return nil
+}
+
+func (c *replayGitHubClient) UnassignIssue(org, repo string, number int, logins []string) error {
+	for _, login := range logins {
+		c.record("remove assignee %s/%s#%d:%s", org, repo, number, login)
+	}
+	return nil
+}
+
+func (c *replayGitHubClient) SetMilestone(org, repo string, number, milestone int) error {
+	c.record("set milestone %s/%s#%d:%d", org, repo, number, milestone)
+	return c.FakeClient.SetMilestone(org, repo, number, milestone)
+}
+
+func (c *replayGitHubClient) ClearMilestone(org, repo string, number int) error {
+	c.record("clear milestone %s/%s#%d", org, repo, number)
+	return c.FakeClient.ClearMilestone(org, repo, number)
+}
+
+func (c *replayGitHubClient) CreatePullRequest(org, repo, title, body, head, base string, canModify bool) (int, error) {
+	c.record("create pull request %s/%s:%s->%s", org, repo, head, base)
+	return c.FakeClient.CreatePullRequest(org, repo, title, body, head, base, canModify)
+}
+
+func (c *replayGitHubClient) EditPullRequest(org, repo string, number int, pr *github.PullRequest) (*github.PullRequest, error) {
+	c.record("edit pull request %s/%s#%d", org, repo, number)
+	return c.FakeClient.EditPullRequest(org, repo, number, pr)
+}
+
+func (c *replayGitHubClient) UpdatePullRequest(org, repo string, number int, title, body *string, open *bool, branch *string, canModify *bool) error {
+	c.record("edit pull request %s/%s#%d", org, repo, number)
+	return c.FakeClient.UpdatePullRequest(org, repo, number, title, body, open, branch, canModify)
+}
+
+func (c *replayGitHubClient) ClosePR(org, repo string, number int) error {
+	c.record("close pull request %s/%s#%d", org, repo, number)
+	return nil
+}
+
+func (c *replayGitHubClient) ReopenPR(org, repo string, number int) error {
+	c.record("reopen pull request %s/%s#%d", org, repo, number)
+	return nil
+}
+
+func (c *replayGitHubClient) Merge(org, repo string, number int, details github.MergeDetails) error {
+	c.record("merge pull request %s/%s#%d:%s", org, repo, number, details.MergeMethod)
+	return nil
+}
+
+func (c *replayGitHubClient) CreateReview(org, repo string, number int, r github.DraftReview) error {
+	c.record("create review %s/%s#%d:%s", org, repo, number, r.Action)
+	return c.FakeClient.CreateReview(org, repo, number, r)
+}
+
+func (c *replayGitHubClient) UnrequestReview(org, repo string, number int, logins []string) error {
+	for _, login := range logins {
+		c.record("unrequest review %s/%s#%d:%s", org, repo, number, login)
+	}
+	return nil
+}
+
+func (c *replayGitHubClient) CreateCheckRun(org, repo string, checkRun github.CheckRun) error {
+	c.record("create check run %s/%s@%s:%s", org, repo, checkRun.HeadSHA, checkRun.Name)
+	return c.FakeClient.CreateCheckRun(org, repo, checkRun)
+}
+
+func (c *replayGitHubClient) UpdateCheckRun(org, repo string, checkRunID int64, checkRun github.CheckRun) error {
+	c.record("update check run %s/%s#%d:%s", org, repo, checkRunID, checkRun.Name)
+	return c.FakeClient.UpdateCheckRun(org, repo, checkRunID, checkRun)
+}
+
+func (c *replayGitHubClient) AddRepoLabel(org, repo, label, description, color string) error {
+	c.record("create repo label %s/%s:%s", org, repo, label)
+	return c.FakeClient.AddRepoLabel(org, repo, label, description, color)
+}
+
+func (c *replayGitHubClient) UpdateRepoLabel(org, repo, label, newName, description, color string) error {
+	c.record("update repo label %s/%s:%s", org, repo, label)
+	return nil
+}
+
+func (c *replayGitHubClient) DeleteRepoLabel(org, repo, label string) error {
+	c.record("delete repo label %s/%s:%s", org, repo, label)
+	return nil
+}
+
+func (c *replayGitHubClient) CreateProjectCard(org string, columnID int, projectCard github.ProjectCard) (*github.ProjectCard, error) {
+	c.record("create project card %s:%d", org, columnID)
+	return c.FakeClient.CreateProjectCard(org, columnID, projectCard)
+}
+
+func (c *replayGitHubClient) MoveProjectCard(org string, projectCardID int, newColumnID int) error {
+	c.record("move project card %s#%d:%d", org, projectCardID, newColumnID)
+	return c.FakeClient.MoveProjectCard(org, projectCardID, newColumnID)
+}
+
+func (c *replayGitHubClient) DeleteProjectCard(org string, projectCardID int) error {
+	c.record("delete project card %s#%d", org, projectCardID)
+	return c.FakeClient.DeleteProjectCard(org, projectCardID)
+}
+
+type replayClients struct {
+	payload []byte
+
+	lock     sync.Mutex
+	byPlugin map[string]*replayGitHubClient
+}
+
+func (r *replayClients) forPlugin(plugin string) *replayGitHubClient {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if c, ok := r.byPlugin[plugin]; ok {
+		return c
+	}
+	fake := fakegithub.NewFakeClient()
+	seed(fake, r.payload)
+	c := &replayGitHubClient{FakeClient: fake, clients: r}
+	r.byPlugin[plugin] = c
+	return c
+}
+
+func (r *replayClients) mutations() map[string][]string {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	result := map[string][]string{}
+	for plugin, c := range r.byPlugin {
+		if m := c.mutations(); len(m) > 0 && plugin != "" {
+			result[plugin] = m
+		}
+	}
+	return result
+}
+
+// seed makes the issue or pull request the event is about known to the fake.
+func seed(fake *fakegithub.FakeClient, payload []byte) {
+	var event struct {
+		Repo        github.Repo         `json:"repository"`
+		Issue       *github.Issue       `json:"issue"`
+		PullRequest *github.PullRequest `json:"pull_request"`
+	}
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return
+	}
+	org, repo := event.Repo.Owner.Login, event.Repo.Name
+	labels := sets.NewString()
+	if pr := event.PullRequest; pr != nil {
+		fake.PullRequests[pr.Number] = pr
+		for _, label := range pr.Labels {
+			labels.Insert(fmt.Sprintf("%s/%s#%d:%s", org, repo, pr.Number, label.Name))
+		}
+	}
+	if issue := event.Issue; issue != nil {
+		fake.Issues[issue.Number] = issue
+		for _, label := range issue.Labels {
+			labels.Insert(fmt.Sprintf("%s/%s#%d:%s", org, repo, issue.Number, label.Name))
+		}
+	}
+	fake.IssueLabelsExisting = labels.List()
+}
+
+// mutations lists the changes recorded by the fake in a stable order, followed
+// by the ones recorded by the client in the order they were made.
+func (c *replayGitHubClient) mutations() []string {
+	fake := c.FakeClient
+	var result []string
+	for _, m := range []struct {
+		kind    string
+		entries []string
+	}{
+		{kind: "add label", entries: fake.IssueLabelsAdded},
+		{kind: "remove label", entries: fake.IssueLabelsRemoved},
+		{kind: "create comment", entries: fake.IssueCommentsAdded},
+		{kind: "edit comment", entries: fake.IssueCommentsEdited},
+		{kind: "delete comment", entries: fake.IssueCommentsDeleted},
+		{kind: "create review comment", entries: fake.PullRequestReviewCommentsAdded},
+		{kind: "add issue reaction", entries: fake.IssueReactionsAdded},
+		{kind: "add comment reaction", entries: fake.CommentReactionsAdded},
+		{kind: "add assignee", entries: fake.AssigneesAdded},
+		{kind: "request review", entries: fake.ReviewersRequested},
+	} {
+		for _, entry := range m.entries {
+			result = append(result, fmt.Sprintf("%s %s", m.kind, entry))
+		}
+	}
+	for _, ref := range fake.RefsDeleted {
+		result = append(result, fmt.Sprintf("delete ref %s/%s:%s", ref.Org, ref.Repo, ref.Ref))
+	}
+	var shas []string
+	for sha := range fake.CreatedStatuses {
+		shas = append(shas, sha)
+	}
+	sort.Strings(shas)
+	for _, sha := range shas {
+		for _, status := range fake.CreatedStatuses[sha] {
+			result = append(result, fmt.Sprintf("create status %s@%s:%s %s", status.Context, sha, status.State, status.Description))
+		}
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return append(result, c.recorded...)
+}
+
+// createdProwJobs lists the ProwJobs created through the fake client.
+func createdProwJobs(client *prowfake.Clientset) []string {
+	var result []string
+	for _, action := range client.Actions() {
+		create, ok := action.(clienttesting.CreateAction)
+		if !ok {
+			continue
+		}
+		if pj, ok := create.GetObject().(*prowapi.ProwJob); ok {
+			result = append(result, fmt.Sprintf("create %s prowjob %s", pj.Spec.Type, pj.Spec.Job))
+		}
+	}
+	return result
+}
@@ -0,0 +1,151 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package replay
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	prowapi "k8s.io/test-infra/prow/apis/prowjobs/v1"
+	"k8s.io/test-infra/prow/config"
+	"k8s.io/test-infra/prow/github"
+	"k8s.io/test-infra/prow/hook"
+	"k8s.io/test-infra/prow/plugins"
+)
+
+func TestRun(t *testing.T) {
+	plugins.RegisterGenericCommentHandler("replay-labeler", func(pc plugins.Agent, e github.GenericCommentEvent) error {
+		if e.Body != "/label ok" {
+			return nil
+		}
+		if err := pc.GitHubClient.AddLabel(e.Repo.Owner.Login, e.Repo.Name, e.Number, "ok"); err != nil {
+			return err
+		}
+		return pc.GitHubClient.CreateComment(e.Repo.Owner.Login, e.Repo.Name, e.Number, "labeled")
+	}, nil)
+	plugins.RegisterGenericCommentHandler("replay-reader", func(pc plugins.Agent, e github.GenericCommentEvent) error {
+		labels, err := pc.GitHubClient.GetIssueLabels(e.Repo.Owner.Login, e.Repo.Name, e.Number)
+		if err != nil {
+			return err
+		}
+		if !github.HasLabel("existing", labels) {
+			return errors.New("issue was not seeded")
+		}
+		return pc.GitHubClient.RemoveLabel(e.Repo.Owner.Login, e.Repo.Name, e.Number, "existing")
+	}, nil)
+	plugins.RegisterGenericCommentHandler("replay-closer", func(pc plugins.Agent, e github.GenericCommentEvent) error {
+		if e.Body != "/label ok" {
+			return nil
+		}
+		if err := pc.GitHubClient.CloseIssue(e.Repo.Owner.Login, e.Repo.Name, e.Number); err != nil {
+			return err
+		}
+		if err := pc.GitHubClient.SetMilestone(e.Repo.Owner.Login, e.Repo.Name, e.Number, 2); err != nil {
+			return err
+		}
+		if err := pc.GitHubClient.CreateCheckRun(e.Repo.Owner.Login, e.Repo.Name, github.CheckRun{Name: "check", HeadSHA: "abc"}); err != nil {
+			return err
+		}
+		return pc.GitHubClient.Merge(e.Repo.Owner.Login, e.Repo.Name, e.Number, github.MergeDetails{MergeMethod: "squash"})
+	}, nil)
+	plugins.RegisterGenericCommentHandler("replay-trigger", func(pc plugins.Agent, e github.GenericCommentEvent) error {
+		if e.Body != "/label ok" {
+			return nil
+		}
+		pj := prowapi.ProwJob{
+			ObjectMeta: metav1.ObjectMeta{Name: "pj"},
+			Spec:       prowapi.ProwJobSpec{Type: prowapi.PresubmitJob, Job: "unit"},
+		}
+		_, err := pc.ProwJobClient.Create(context.Background(), &pj, metav1.CreateOptions{})
+		return err
+	}, nil)
+	plugins.RegisterGenericCommentHandler("replay-panicking", func(pc plugins.Agent, e github.GenericCommentEvent) error {
+		// The fake doesn't implement this.
+		_, err := pc.GitHubClient.GetRepos("org", false)
+		return err
+	}, nil)
+
+	pa := &plugins.ConfigAgent{}
+	pa.Set(&plugins.Configuration{
+		Plugins: plugins.Plugins{"org/repo": {Plugins: []string{"replay-labeler", "replay-reader", "replay-closer", "replay-trigger", "replay-panicking"}}},
+		ExternalPlugins: map[string][]plugins.ExternalPlugin{
+			"org": {{Name: "external", Endpoint: "http://unreachable.invalid"}},
+		},
+	})
+	ca := &config.Agent{}
+	ca.Set(&config.Config{})
+
+	comment := func(body string) json.RawMessage {
+		payload, err := json.Marshal(github.IssueCommentEvent{
+			Action:  github.IssueCommentActionCreated,
+			Repo:    github.Repo{Owner: github.User{Login: "org"}, Name: "repo", FullName: "org/repo"},
+			Issue:   github.Issue{Number: 5, State: "open", Labels: []github.Label{{Name: "existing"}}},
+			Comment: github.IssueComment{Body: body},
+		})
+		if err != nil {
+			t.Fatalf("failed to marshal event: %v", err)
+		}
+		return payload
+	}
+	events := []hook.RecordedEvent{
+		{Type: "issue_comment", GUID: "first", Payload: comment("/label ok")},
+		{Type: "issue_comment", GUID: "second", Payload: comment("hello")},
+		{Type: "issue_comment", GUID: "broken", Payload: json.RawMessage(`{"issue": 5}`)},
+	}
+
+	results := Run(ca, pa, events)
+	if len(results) != len(events) {
+		t.Fatalf("expected %d results, got %d", len(events), len(results))
+	}
+	expected := []map[string][]string{
+		{
+			"replay-labeler": {"add label org/repo#5:ok", "create comment org/repo#5:labeled"},
+			"replay-reader":  {"remove label org/repo#5:existing"},
+			"replay-closer": {
+				"close issue org/repo#5",
+				"set milestone org/repo#5:2",
+				"create check run org/repo@abc:check",
+				"merge pull request org/repo#5:squash",
+			},
+		},
+		{
+			"replay-reader": {"remove label org/repo#5:existing"},
+		},
+		nil,
+	}
+	for i, result := range results {
+		if diff := cmp.Diff(expected[i], result.Mutations, cmpopts.EquateEmpty()); diff != "" {
+			t.Errorf("unexpected mutations for event %s (-want +got):\n%s", result.Event.GUID, diff)
+		}
+		var expectedProwJobs []string
+		if i == 0 {
+			expectedProwJobs = []string{"create presubmit prowjob unit"}
+		}
+		if diff := cmp.Diff(expectedProwJobs, result.ProwJobs); diff != "" {
+			t.Errorf("unexpected prowjobs for event %s (-want +got):\n%s", result.Event.GUID, diff)
+		}
+		if hasErr := result.Err != nil; hasErr != (i == 2) {
+			t.Errorf("unexpected error for event %s: %v", result.Event.GUID, result.Err)
+		}
+	}
+}
@@ -18,6 +18,7 @@ package hook
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -27,13 +28,17 @@ import (
 	"sync"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/util/sets"
 
 	"k8s.io/test-infra/prow/config"
 	"k8s.io/test-infra/prow/github"
 	"k8s.io/test-infra/prow/githubeventserver"
+	"k8s.io/test-infra/prow/hook/delivery"
 	_ "k8s.io/test-infra/prow/hook/plugin-imports"
 	"k8s.io/test-infra/prow/plugins"
+	"k8s.io/test-infra/prow/plugins/wasm"
 )
 
 // Server implements http.Handler. It validates incoming GitHub webhooks and
@@ -45,6 +50,14 @@ type Server struct {
 	TokenGenerator func() []byte
 	Metrics        *githubeventserver.Metrics
 	RepoEnabled    func(org, repo string) bool
+	// WasmPlugins runs the plugins compiled to WebAssembly. May be nil
+	// when hook isn't configured to load them.
+	WasmPlugins *wasm.Runtime
+	// Recorder stores the received webhooks for replaying them. May be nil.
+	Recorder *Recorder
+	// ExternalQueues retries failed deliveries to external plugins. May be
+	// nil, in which case such events are dropped.
+	ExternalQueues *delivery.Queues
 
 	// c is an http client used for dispatching events
 	// to external plugin services.
@@ -69,11 +82,26 @@ func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 	fmt.Fprint(w, "Event received. Have a nice day.")
 
+	if s.Recorder != nil {
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			if err := s.Recorder.Record(context.Background(), eventType, eventGUID, payload); err != nil {
+				logrus.WithError(err).WithField(github.EventGUID, eventGUID).Warn("Failed to record event.")
+			}
+		}()
+	}
 	if err := s.demuxEvent(eventType, eventGUID, payload, r.Header); err != nil {
 		logrus.WithError(err).Error("Error parsing event.")
 	}
 }
 
+// HandleEvent dispatches an event that was already validated to the plugins.
+// Call GracefulShutdown to wait for the plugins to finish handling it.
+func (s *Server) HandleEvent(eventType, eventGUID string, payload []byte) error {
+	return s.demuxEvent(eventType, eventGUID, payload, http.Header{})
+}
+
 func (s *Server) demuxEvent(eventType, eventGUID string, payload []byte, h http.Header) error {
 	l := logrus.WithFields(
 		logrus.Fields{
@@ -166,6 +194,28 @@ func (s *Server) demuxEvent(eventType, eventGUID string, payload []byte, h http.
 			s.wg.Add(1)
 			go s.handleStatusEvent(l, se)
 		}
+	case "check_run":
+		var cre github.CheckRunEvent
+		if err := json.Unmarshal(payload, &cre); err != nil {
+			return err
+		}
+		cre.GUID = eventGUID
+		srcRepo = cre.Repo.FullName
+		if s.RepoEnabled(cre.Repo.Owner.Login, cre.Repo.Name) {
+			s.wg.Add(1)
+			go s.handleCheckRunEvent(l, cre)
+		}
+	case "merge_group":
+		var mge github.MergeGroupEvent
+		if err := json.Unmarshal(payload, &mge); err != nil {
+			return err
+		}
+		mge.GUID = eventGUID
+		srcRepo = mge.Repo.FullName
+		if s.RepoEnabled(mge.Repo.Owner.Login, mge.Repo.Name) {
+			s.wg.Add(1)
+			go s.handleMergeGroupEvent(l, mge)
+		}
 	default:
 		var ge github.GenericEvent
 		if err := json.Unmarshal(payload, &ge); err != nil {
@@ -179,6 +229,10 @@ func (s *Server) demuxEvent(eventType, eventGUID string, payload []byte, h http.
 		s.wg.Add(1)
 		go s.demuxExternal(l, external, payload, h)
 	}
+	if wasmPlugins := s.needWasm(eventType, srcRepo); len(wasmPlugins) > 0 {
+		s.wg.Add(1)
+		go s.demuxWasm(l, wasmPlugins, wasm.Event{Type: eventType, GUID: eventGUID, Payload: payload}, srcRepo)
+	}
 	return nil
 }
 
@@ -230,6 +284,9 @@ func (s *Server) demuxExternal(l *logrus.Entry, externalPlugins []plugins.Extern
 			defer s.wg.Done()
 			if err := s.dispatch(p.Endpoint, payload, h); err != nil {
 				l.WithError(err).WithField("external-plugin", p.Name).Error("Error dispatching event to external plugin.")
+				if s.ExternalQueues != nil {
+					s.ExternalQueues.Enqueue(p.Name, p.Endpoint, payload, h, err)
+				}
 			} else {
 				l.WithField("external-plugin", p.Name).Info("Dispatched event to external plugin")
 			}
@@ -237,6 +294,61 @@ func (s *Server) demuxExternal(l *logrus.Entry, externalPlugins []plugins.Extern
 	}
 }
 
+// needWasm returns the WebAssembly plugins that need to handle the present event.
+func (s *Server) needWasm(eventType, orgRepo string) []plugins.WasmPlugin {
+	if s.WasmPlugins == nil {
+		return nil
+	}
+	org, repo, _ := strings.Cut(orgRepo, "/")
+	if !s.RepoEnabled(org, repo) {
+		return nil
+	}
+
+	// A plugin configured for both the org and the repo runs once, with the
+	// repo's configuration.
+	config := s.Plugins.Config().WasmPlugins
+	var names []string
+	byName := map[string]plugins.WasmPlugin{}
+	for _, key := range []string{org, orgRepo} {
+		for _, p := range config[key] {
+			if _, ok := byName[p.Name]; !ok {
+				names = append(names, p.Name)
+			}
+			byName[p.Name] = p
+		}
+	}
+
+	var matching []plugins.WasmPlugin
+	for _, name := range names {
+		p := byName[name]
+		if len(p.Events) == 0 || sets.NewString(p.Events...).Has(eventType) {
+			matching = append(matching, p)
+		}
+	}
+	return matching
+}
+
+// demuxWasm runs the WebAssembly plugins for the provided event.
+func (s *Server) demuxWasm(l *logrus.Entry, wasmPlugins []plugins.WasmPlugin, event wasm.Event, orgRepo string) {
+	defer s.wg.Done()
+	event.Org, event.Repo, _ = strings.Cut(orgRepo, "/")
+	for _, p := range wasmPlugins {
+		s.wg.Add(1)
+		go func(p plugins.WasmPlugin) {
+			defer s.wg.Done()
+			agent := plugins.NewAgent(s.ConfigAgent, s.Plugins, s.ClientAgent, event.Org, s.Metrics.Metrics, l, p.Name)
+			start := time.Now()
+			err := errorOnPanic(func() error { return s.WasmPlugins.Handle(agent, p, event) })
+			labels := prometheus.Labels{"event_type": event.Type, "action": "none", "plugin": p.Name, "took_action": strconv.FormatBool(agent.TookAction())}
+			if err != nil {
+				agent.Logger.WithError(err).Error("Error running WebAssembly plugin.")
+				s.Metrics.PluginHandleErrors.With(labels).Inc()
+			}
+			s.Metrics.PluginHandleDuration.With(labels).Observe(time.Since(start).Seconds())
+		}(p)
+	}
+}
+
 // dispatch creates a new request using the provided payload and headers
 // and dispatches the request to the provided endpoint.
 func (s *Server) dispatch(endpoint string, payload []byte, h http.Header) error {
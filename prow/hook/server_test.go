@@ -22,6 +22,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"reflect"
+	"sort"
 	"strings"
 	"sync"
 	"testing"
@@ -31,6 +32,7 @@ import (
 
 	"k8s.io/test-infra/prow/githubeventserver"
 	"k8s.io/test-infra/prow/plugins"
+	"k8s.io/test-infra/prow/plugins/wasm"
 )
 
 func TestServeHTTPErrors(t *testing.T) {
@@ -390,6 +392,95 @@ func TestNeedDemux(t *testing.T) {
 	}
 }
 
+func TestNeedWasm(t *testing.T) {
+	wasmPlugins := map[string][]plugins.WasmPlugin{
+		"kubernetes": {
+			{Name: "greeter", Events: []string{"pull_request"}},
+			{Name: "everything"},
+		},
+		"kubernetes/test-infra": {
+			{Name: "labeler", Events: []string{"issue_comment", "pull_request"}},
+		},
+		"kubernetes/website": {
+			{Name: "greeter", Events: []string{"issue_comment"}},
+			{Name: "everything"},
+		},
+		"other/repo": {
+			{Name: "other"},
+		},
+	}
+	tests := []struct {
+		name string
+
+		disabled    bool
+		eventType   string
+		srcRepo     string
+		repoEnabled func(org, repo string) bool
+
+		expected []string
+	}{
+		{
+			name:      "runtime not configured",
+			disabled:  true,
+			eventType: "pull_request",
+			srcRepo:   "kubernetes/test-infra",
+		},
+		{
+			name:      "org and repo plugins match",
+			eventType: "pull_request",
+			srcRepo:   "kubernetes/test-infra",
+			expected:  []string{"everything", "greeter", "labeler"},
+		},
+		{
+			name:      "plugins are filtered by event",
+			eventType: "issue_comment",
+			srcRepo:   "kubernetes/kubernetes",
+			expected:  []string{"everything"},
+		},
+		{
+			name:      "plugins configured for the org and the repo run once",
+			eventType: "issue_comment",
+			srcRepo:   "kubernetes/website",
+			expected:  []string{"everything", "greeter"},
+		},
+		{
+			name:      "repo configuration of a plugin overrides the org's",
+			eventType: "pull_request",
+			srcRepo:   "kubernetes/website",
+			expected:  []string{"everything"},
+		},
+		{
+			name:        "repo not enabled",
+			eventType:   "pull_request",
+			srcRepo:     "kubernetes/test-infra",
+			repoEnabled: func(_, _ string) bool { return false },
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pa := &plugins.ConfigAgent{}
+			pa.Set(&plugins.Configuration{WasmPlugins: wasmPlugins})
+			if test.repoEnabled == nil {
+				test.repoEnabled = func(_, _ string) bool { return true }
+			}
+			s := &Server{Plugins: pa, RepoEnabled: test.repoEnabled}
+			if !test.disabled {
+				s.WasmPlugins = wasm.NewRuntime(nil, "/plugins")
+			}
+
+			var got []string
+			for _, p := range s.needWasm(test.eventType, test.srcRepo) {
+				got = append(got, p.Name)
+			}
+			sort.Strings(got)
+			if diff := cmp.Diff(test.expected, got); diff != "" {
+				t.Errorf("unexpected plugins (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
 type roundTripFunc func(req *http.Request) *http.Response
 
 // RoundTrip .
@@ -19,9 +19,16 @@ package fakeopener
 import (
 	"bytes"
 	"context"
+	"io"
+	"net/http"
 	"os"
+	"sort"
+	"strings"
+
+	"google.golang.org/api/googleapi"
 
 	pkgio "k8s.io/test-infra/prow/io"
+	"k8s.io/test-infra/prow/io/providers"
 )
 
 type FakeOpener struct {
@@ -29,6 +36,9 @@ type FakeOpener struct {
 	Buffer     map[string]*bytes.Buffer
 	ReadError  error
 	WriteError error
+	// Generations counts the writes of each path, like the generations of
+	// GCS objects.
+	Generations map[string]int64
 }
 
 type nopReadWriteCloser struct {
@@ -65,16 +75,22 @@ func (fo *FakeOpener) Writer(ctx context.Context, path string, opts ...pkgio.Wri
 		fo.Buffer = make(map[string]*bytes.Buffer)
 	}
 
+	if fo.Generations == nil {
+		fo.Generations = make(map[string]int64)
+	}
+
 	overWrite := true
 	for _, o := range opts {
 		if o.PreconditionDoesNotExist != nil && *o.PreconditionDoesNotExist {
 			overWrite = false
-			break
+		}
+		if o.PreconditionGenerationMatch != nil && *o.PreconditionGenerationMatch != fo.Generations[path] {
+			return nil, &googleapi.Error{Code: http.StatusPreconditionFailed}
 		}
 	}
 	if fo.Buffer[path] != nil {
 		if !overWrite {
-			return nil, os.ErrExist
+			return nil, pkgio.PreconditionFailedObjectAlreadyExists
 		}
 		fo.Buffer[path] = &bytes.Buffer{}
 	}
@@ -82,6 +98,63 @@ func (fo *FakeOpener) Writer(ctx context.Context, path string, opts ...pkgio.Wri
 	if _, ok := fo.Buffer[path]; !ok {
 		fo.Buffer[path] = &bytes.Buffer{}
 	}
+	fo.Generations[path]++
 
 	return &nopReadWriteCloser{Buffer: fo.Buffer[path]}, nil
 }
+
+// Attributes returns the size and the generation of path.
+func (fo *FakeOpener) Attributes(ctx context.Context, path string) (pkgio.Attributes, error) {
+	if fo.ReadError != nil {
+		return pkgio.Attributes{}, fo.ReadError
+	}
+	buf, ok := fo.Buffer[path]
+	if !ok {
+		return pkgio.Attributes{}, os.ErrNotExist
+	}
+	return pkgio.Attributes{Size: int64(buf.Len()), Generation: fo.Generations[path]}, nil
+}
+
+// Iterator lists the objects in Buffer below prefix. Like the real opener it
+// returns object names relative to their bucket.
+func (fo *FakeOpener) Iterator(ctx context.Context, prefix, delimiter string) (pkgio.ObjectIterator, error) {
+	_, bucket, relativePrefix, err := providers.ParseStoragePath(prefix)
+	if err != nil {
+		return nil, err
+	}
+	seen := map[string]bool{}
+	var attrs []pkgio.ObjectAttributes
+	for path := range fo.Buffer {
+		_, b, name, err := providers.ParseStoragePath(path)
+		if err != nil || b != bucket || !strings.HasPrefix(name, relativePrefix) {
+			continue
+		}
+		if delimiter != "" {
+			if i := strings.Index(name[len(relativePrefix):], delimiter); i >= 0 {
+				dir := name[:len(relativePrefix)+i+len(delimiter)]
+				if !seen[dir] {
+					seen[dir] = true
+					attrs = append(attrs, pkgio.ObjectAttributes{Name: dir, IsDir: true})
+				}
+				continue
+			}
+		}
+		nameSplit := strings.Split(name, "/")
+		attrs = append(attrs, pkgio.ObjectAttributes{Name: name, ObjName: nameSplit[len(nameSplit)-1]})
+	}
+	sort.Slice(attrs, func(i, j int) bool { return attrs[i].Name < attrs[j].Name })
+	return &fakeIterator{attrs: attrs}, nil
+}
+
+type fakeIterator struct {
+	attrs []pkgio.ObjectAttributes
+}
+
+func (fi *fakeIterator) Next(_ context.Context) (pkgio.ObjectAttributes, error) {
+	if len(fi.attrs) == 0 {
+		return pkgio.ObjectAttributes{}, io.EOF
+	}
+	attr := fi.attrs[0]
+	fi.attrs = fi.attrs[1:]
+	return attr, nil
+}
@@ -66,6 +66,9 @@ type Attributes struct {
 	Size int64
 	// Metadata includes user-metadata associated with the file
 	Metadata map[string]string
+	// Generation is the generation of the object, it changes whenever the
+	// content of the object is replaced. Only GCS sets it.
+	Generation int64
 }
 
 type ObjectAttrsToUpdate struct {
@@ -269,12 +272,17 @@ func (o *opener) Writer(ctx context.Context, p string, opts ...WriterOptions) (i
 		}
 		if options.PreconditionDoesNotExist != nil && *options.PreconditionDoesNotExist {
 			g = g.If(storage.Conditions{DoesNotExist: true})
+		} else if options.PreconditionGenerationMatch != nil {
+			g = g.If(storage.Conditions{GenerationMatch: *options.PreconditionGenerationMatch})
 		}
 
 		writer := g.NewWriter(ctx)
 		options.apply(writer, nil)
 		return writer, nil
 	}
+	if options.PreconditionGenerationMatch != nil {
+		return nil, fmt.Errorf("generation preconditions are only supported by GCS: %q", p)
+	}
 	if strings.HasPrefix(p, "/") || strings.HasPrefix(p, providers.File+"://") {
 		p := strings.TrimPrefix(p, providers.File+"://")
 		// create parent dir if doesn't exist
@@ -329,6 +337,7 @@ func (o *opener) Attributes(ctx context.Context, path string) (Attributes, error
 			ContentEncoding: attr.ContentEncoding,
 			Size:            attr.Size,
 			Metadata:        attr.Metadata,
+			Generation:      attr.Generation,
 		}, nil
 	}
 
@@ -523,15 +532,20 @@ func isErrUnexpected(err error) bool {
 		return false
 	}
 	// Precondition Failed is expected and we can silently ignore it.
-	if e, ok := err.(*googleapi.Error); ok {
-		if e.Code == http.StatusPreconditionFailed {
-			return false
-		}
+	return !IsPreconditionFailed(err)
+}
+
+// IsPreconditionFailed will return true if the error shows that a write was
+// rejected because of its preconditions, e.g. the object already exists or
+// its generation changed in the meantime.
+func IsPreconditionFailed(err error) bool {
+	var e *googleapi.Error
+	if errors.As(err, &e) && e.Code == http.StatusPreconditionFailed {
+		return true
 	}
-	// Precondition file already exists is expected
+	// Precondition file already exists
 	if errors.Is(err, PreconditionFailedObjectAlreadyExists) {
-		return false
+		return true
 	}
-
-	return true
+	return false
 }
@@ -232,6 +232,16 @@ func TestIsErrUnexpected(t *testing.T) {
 			err:        &googleapi.Error{Code: http.StatusPreconditionFailed},
 			unexpected: false,
 		},
+		{
+			name:       "wrapped Precondition Failed googleapi errors are expected",
+			err:        fmt.Errorf("close: %w", &googleapi.Error{Code: http.StatusPreconditionFailed}),
+			unexpected: false,
+		},
+		{
+			name:       "existing objects are expected",
+			err:        PreconditionFailedObjectAlreadyExists,
+			unexpected: false,
+		},
 	}
 
 	for _, tc := range tests {
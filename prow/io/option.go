@@ -30,7 +30,10 @@ type WriterOptions struct {
 	ContentType              *string
 	Metadata                 map[string]string
 	PreconditionDoesNotExist *bool
-	CacheControl             *string
+	// PreconditionGenerationMatch only writes the object if its generation
+	// still matches, see Attributes.Generation. Only GCS supports it.
+	PreconditionGenerationMatch *int64
+	CacheControl                *string
 }
 
 func (wo WriterOptions) Apply(opts *WriterOptions) {
@@ -49,6 +52,9 @@ func (wo WriterOptions) Apply(opts *WriterOptions) {
 	if wo.PreconditionDoesNotExist != nil {
 		opts.PreconditionDoesNotExist = wo.PreconditionDoesNotExist
 	}
+	if wo.PreconditionGenerationMatch != nil {
+		opts.PreconditionGenerationMatch = wo.PreconditionGenerationMatch
+	}
 	if wo.CacheControl != nil {
 		opts.CacheControl = wo.CacheControl
 	}
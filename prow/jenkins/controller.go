@@ -22,9 +22,11 @@ import (
 	"fmt"
 	"strconv"
 	"sync"
+	"time"
 
 	"github.com/bwmarrin/snowflake"
 	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/api/equality"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	ktypes "k8s.io/apimachinery/pkg/types"
 	prowv1 "k8s.io/test-infra/prow/client/clientset/versioned/typed/prowjobs/v1"
@@ -48,6 +50,7 @@ type jenkinsClient interface {
 	Build(*prowapi.ProwJob, string) error
 	ListBuilds(jobs []BuildQueryParams) (map[string]Build, error)
 	Abort(job string, build *Build) error
+	GetStages(spec *prowapi.ProwJobSpec, number int) ([]Stage, error)
 }
 
 type githubClient interface {
@@ -364,23 +367,27 @@ func (c *Controller) syncPendingJob(pj prowapi.ProwJob, reports chan<- prowapi.P
 		case jb.IsRunning():
 			// Build still going.
 			c.incrementNumPendingJobs(pj.Spec.Job)
-			if pj.Status.Description == "Jenkins job running." {
+			stagesChanged := c.syncStages(&pj, &jb)
+			if pj.Status.Description == "Jenkins job running." && !stagesChanged {
 				return nil
 			}
 			pj.Status.Description = "Jenkins job running."
 
 		case jb.IsSuccess():
 			// Build is complete.
+			c.syncStages(&pj, &jb)
 			pj.SetComplete()
 			pj.Status.State = prowapi.SuccessState
 			pj.Status.Description = "Jenkins job succeeded."
 
 		case jb.IsFailure():
+			c.syncStages(&pj, &jb)
 			pj.SetComplete()
 			pj.Status.State = prowapi.FailureState
 			pj.Status.Description = "Jenkins job failed."
 
 		case jb.IsAborted():
+			c.syncStages(&pj, &jb)
 			pj.SetComplete()
 			pj.Status.State = prowapi.AbortedState
 			pj.Status.Description = "Jenkins job aborted."
@@ -407,6 +414,39 @@ func (c *Controller) syncPendingJob(pj prowapi.ProwJob, reports chan<- prowapi.P
 	return err
 }
 
+// syncStages updates the stages of the pending job from its Jenkins build and
+// returns whether they changed. Failing to get the stages only loses detail,
+// so the stages are kept as they are in that case.
+func (c *Controller) syncStages(pj *prowapi.ProwJob, jb *Build) bool {
+	stages, err := c.jc.GetStages(&pj.Spec, jb.Number)
+	if err != nil {
+		c.log.WithError(err).WithFields(pjutil.ProwJobFields(pj)).Warn("Cannot get the stages of the Jenkins build")
+		return false
+	}
+	jenkinsStages := make([]prowapi.JenkinsStage, 0, len(stages))
+	for _, stage := range stages {
+		js := prowapi.JenkinsStage{
+			Name:     stage.Name,
+			Status:   stage.Status,
+			Duration: &prowapi.Duration{Duration: time.Duration(stage.DurationMillis) * time.Millisecond},
+		}
+		if stage.StartTimeMillis > 0 {
+			// Status times are stored with a precision of seconds.
+			startTime := metav1.NewTime(time.Unix(stage.StartTimeMillis/1000, 0))
+			js.StartTime = &startTime
+		}
+		jenkinsStages = append(jenkinsStages, js)
+	}
+	if len(jenkinsStages) == 0 {
+		jenkinsStages = nil
+	}
+	if equality.Semantic.DeepEqual(pj.Status.JenkinsStages, jenkinsStages) {
+		return false
+	}
+	pj.Status.JenkinsStages = jenkinsStages
+	return true
+}
+
 func (c *Controller) syncAbortedJob(pj prowapi.ProwJob, _ chan<- prowapi.ProwJob, jbs map[string]Build) error {
 	if pj.Status.State != prowapi.AbortedState || pj.Complete() {
 		return nil
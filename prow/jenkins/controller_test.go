@@ -29,8 +29,10 @@ import (
 	"time"
 
 	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/api/equality"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/diff"
 	"k8s.io/test-infra/prow/client/clientset/versioned/fake"
 	"k8s.io/utils/clock"
 	clocktesting "k8s.io/utils/clock/testing"
@@ -112,6 +114,7 @@ type fjc struct {
 	builds      map[string]Build
 	didAbort    bool
 	abortErrors bool
+	stages      map[int][]Stage
 }
 
 func (f *fjc) Build(pj *prowapi.ProwJob, buildID string) error {
@@ -144,6 +147,12 @@ func (f *fjc) Abort(job string, build *Build) error {
 	return nil
 }
 
+func (f *fjc) GetStages(spec *prowapi.ProwJobSpec, number int) ([]Stage, error) {
+	f.Lock()
+	defer f.Unlock()
+	return f.stages[number], nil
+}
+
 type fghc struct {
 	sync.Mutex
 	changes []github.PullRequestChange
@@ -371,15 +380,19 @@ func TestSyncTriggeredJobs(t *testing.T) {
 }
 
 func TestSyncPendingJobs(t *testing.T) {
+	stageStart := metav1.NewTime(time.Date(2022, 6, 1, 12, 0, 0, 0, time.UTC))
+	stageTestStart := metav1.NewTime(stageStart.Add(time.Minute))
 	var testcases = []struct {
 		name        string
 		pj          prowapi.ProwJob
 		pendingJobs map[string]int
 		builds      map[string]Build
+		stages      map[int][]Stage
 		err         error
 
 		// TODO: Change to pass a ProwJobStatus
 		expectedState    prowapi.ProwJobState
+		expectedStages   []prowapi.JenkinsStage
 		expectedBuild    bool
 		expectedURL      string
 		expectedComplete bool
@@ -452,6 +465,72 @@ func TestSyncPendingJobs(t *testing.T) {
 			expectedState:  prowapi.PendingState,
 			expectedReport: true,
 		},
+		{
+			name: "building, stages changed",
+			pj: prowapi.ProwJob{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "stagey",
+					Namespace: "prowjobs",
+				},
+				Spec: prowapi.ProwJobSpec{
+					Job: "folder/test-job",
+				},
+				Status: prowapi.ProwJobStatus{
+					State:       prowapi.PendingState,
+					Description: "Jenkins job running.",
+					JenkinsStages: []prowapi.JenkinsStage{
+						{Name: "build", Status: "IN_PROGRESS", StartTime: &stageStart, Duration: &prowapi.Duration{Duration: time.Second}},
+					},
+				},
+			},
+			builds: map[string]Build{
+				"stagey": {enqueued: false, Number: 10},
+			},
+			stages: map[int][]Stage{
+				10: {
+					{Name: "build", Status: "SUCCESS", StartTimeMillis: stageStart.UnixMilli(), DurationMillis: 60000},
+					{Name: "test", Status: "IN_PROGRESS", StartTimeMillis: stageStart.UnixMilli() + 60000, DurationMillis: 1500},
+					{Name: "deploy", Status: "NOT_EXECUTED"},
+				},
+			},
+			expectedURL:   "stagey/pending",
+			expectedState: prowapi.PendingState,
+			expectedStages: []prowapi.JenkinsStage{
+				{Name: "build", Status: "SUCCESS", StartTime: &stageStart, Duration: &prowapi.Duration{Duration: time.Minute}},
+				{Name: "test", Status: "IN_PROGRESS", StartTime: &stageTestStart, Duration: &prowapi.Duration{Duration: 1500 * time.Millisecond}},
+				{Name: "deploy", Status: "NOT_EXECUTED", Duration: &prowapi.Duration{}},
+			},
+			expectedReport: true,
+		},
+		{
+			name: "building, stages unchanged",
+			pj: prowapi.ProwJob{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "samesame",
+					Namespace: "prowjobs",
+				},
+				Spec: prowapi.ProwJobSpec{
+					Job: "test-job",
+				},
+				Status: prowapi.ProwJobStatus{
+					State:       prowapi.PendingState,
+					Description: "Jenkins job running.",
+					JenkinsStages: []prowapi.JenkinsStage{
+						{Name: "build", Status: "IN_PROGRESS", StartTime: &stageStart, Duration: &prowapi.Duration{Duration: time.Second}},
+					},
+				},
+			},
+			builds: map[string]Build{
+				"samesame": {enqueued: false, Number: 10},
+			},
+			stages: map[int][]Stage{
+				10: {{Name: "build", Status: "IN_PROGRESS", StartTimeMillis: stageStart.UnixMilli(), DurationMillis: 1000}},
+			},
+			expectedState: prowapi.PendingState,
+			expectedStages: []prowapi.JenkinsStage{
+				{Name: "build", Status: "IN_PROGRESS", StartTime: &stageStart, Duration: &prowapi.Duration{Duration: time.Second}},
+			},
+		},
 		{
 			name: "missing build",
 			pj: prowapi.ProwJob{
@@ -522,8 +601,14 @@ func TestSyncPendingJobs(t *testing.T) {
 			builds: map[string]Build{
 				"whatapity": {Result: pState(failure), Number: 12},
 			},
-			expectedURL:      "whatapity/failure",
-			expectedState:    prowapi.FailureState,
+			stages: map[int][]Stage{
+				12: {{Name: "build", Status: "FAILED", StartTimeMillis: stageStart.UnixMilli(), DurationMillis: 60000}},
+			},
+			expectedURL:   "whatapity/failure",
+			expectedState: prowapi.FailureState,
+			expectedStages: []prowapi.JenkinsStage{
+				{Name: "build", Status: "FAILED", StartTime: &stageStart, Duration: &prowapi.Duration{Duration: time.Minute}},
+			},
 			expectedComplete: true,
 			expectedReport:   true,
 		},
@@ -535,7 +620,8 @@ func TestSyncPendingJobs(t *testing.T) {
 		}))
 		defer totServ.Close()
 		fjc := &fjc{
-			err: tc.err,
+			err:    tc.err,
+			stages: tc.stages,
 		}
 		fakeProwJobClient := fake.NewSimpleClientset(&tc.pj)
 
@@ -595,6 +681,9 @@ func TestSyncPendingJobs(t *testing.T) {
 		if tc.expectedURL != actual.Status.URL {
 			t.Errorf("expected status URL: %s, got: %s", tc.expectedURL, actual.Status.URL)
 		}
+		if !equality.Semantic.DeepEqual(tc.expectedStages, actual.Status.JenkinsStages) {
+			t.Errorf("unexpected stages: %s", diff.ObjectReflectDiff(tc.expectedStages, actual.Status.JenkinsStages))
+		}
 	}
 }
 
@@ -121,6 +121,17 @@ type JobInfo struct {
 	Property  []JobProperty `json:"property"`
 }
 
+// Stage is a stage of a build of a pipeline job, as reported by the
+// wfapi/describe endpoint of the Pipeline Stage View plugin.
+type Stage struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	// StartTimeMillis is the start of the stage in milliseconds since
+	// the epoch. It is zero for stages that did not start.
+	StartTimeMillis int64 `json:"startTimeMillis"`
+	DurationMillis  int64 `json:"durationMillis"`
+}
+
 // IsRunning means the job started but has not finished.
 func (jb *Build) IsRunning() bool {
 	return jb.Result == nil
@@ -419,20 +430,24 @@ func (c *Client) doRequest(method, path string) (*http.Response, error) {
 	return c.client.Do(req)
 }
 
-// getJobName generates the correct job name for this job type
+// getJobName generates the correct job name for this job type.
+// Jobs in folders are named by their path, e.g. folder/job.
 func getJobName(spec *prowapi.ProwJobSpec) string {
-	jobName := spec.Job
-	if strings.Contains(jobName, "/") {
-		jobParts := strings.Split(strings.Trim(jobName, "/"), "/")
-		jobName = strings.Join(jobParts, "/job/")
+	jobParts := strings.Split(strings.Trim(spec.Job, "/"), "/")
+	for i := range jobParts {
+		jobParts[i] = url.PathEscape(jobParts[i])
 	}
+	jobName := strings.Join(jobParts, "/job/")
 
 	if spec.JenkinsSpec != nil && spec.JenkinsSpec.GitHubBranchSourceJob && spec.Refs != nil {
 		if len(spec.Refs.Pulls) > 0 {
 			return fmt.Sprintf("%s/view/change-requests/job/PR-%d", jobName, spec.Refs.Pulls[0].Number)
 		}
 
-		return fmt.Sprintf("%s/job/%s", jobName, spec.Refs.BaseRef)
+		// Multibranch pipelines name the job of a branch by the escaped
+		// name of the branch, e.g. release%2F1.0, which has to be escaped
+		// once more in the path.
+		return fmt.Sprintf("%s/job/%s", jobName, url.PathEscape(url.PathEscape(spec.Refs.BaseRef)))
 	}
 
 	return jobName
@@ -747,6 +762,29 @@ func (c *Client) GetBuilds(job string) (map[string]Build, error) {
 	return jenkinsBuilds, nil
 }
 
+// GetStages returns the stages of a build of the job of the provided spec.
+// Jobs that are not pipelines have no stages.
+func (c *Client) GetStages(spec *prowapi.ProwJobSpec, number int) ([]Stage, error) {
+	path := fmt.Sprintf("/job/%s/%d/wfapi/describe", getJobName(spec), number)
+	c.logger.Debugf("GetStages(%v)", path)
+
+	// The path is unique per build, don't gather metrics for it.
+	data, err := c.GetSkipMetrics(path)
+	if err != nil {
+		if _, isNotFound := err.(NotFoundError); isNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("cannot get stages of build %d of job %q: %w", number, spec.Job, err)
+	}
+	run := struct {
+		Stages []Stage `json:"stages"`
+	}{}
+	if err := json.Unmarshal(data, &run); err != nil {
+		return nil, fmt.Errorf("cannot unmarshal stages of build %d of job %q: %w", number, spec.Job, err)
+	}
+	return run.Stages, nil
+}
+
 // Abort aborts the provided Jenkins build for job.
 func (c *Client) Abort(job string, build *Build) error {
 	c.logger.Debugf("Abort(%v %v)", job, build.Number)
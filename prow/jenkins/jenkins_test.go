@@ -439,6 +439,22 @@ func TestGetJobName(t *testing.T) {
 			},
 			output: "folder1/job/folder2/job/my-jenkins-job-name/job/master",
 		},
+		{
+			name: "GitHub Branch Source based job of a branch with a slash",
+			input: &prowapi.ProwJobSpec{
+				Agent: "jenkins",
+				Type:  prowapi.PostsubmitJob,
+				Job:   "folder1/my-jenkins-job-name",
+				JenkinsSpec: &prowapi.JenkinsSpec{
+					GitHubBranchSourceJob: true,
+				},
+				Refs: &prowapi.Refs{
+					BaseRef: "release/1.0",
+					BaseSHA: "deadbeef",
+				},
+			},
+			output: "folder1/job/my-jenkins-job-name/job/release%252F1.0",
+		},
 		{
 			name: "Static Jenkins job",
 			input: &prowapi.ProwJobSpec{
@@ -489,6 +505,73 @@ func TestGetJobName(t *testing.T) {
 	}
 }
 
+func TestGetStages(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.EscapedPath() {
+		case "/job/folder/job/pipeline/job/release%252F1.0/3/wfapi/describe":
+			fmt.Fprint(w, `{"id": "3", "status": "IN_PROGRESS", "stages": [
+				{"id": "6", "name": "build", "status": "SUCCESS", "startTimeMillis": 1654084800000, "durationMillis": 60000},
+				{"id": "13", "name": "test", "status": "IN_PROGRESS", "startTimeMillis": 1654084860000, "durationMillis": 1500}
+			]}`)
+		case "/job/freestyle/1/wfapi/describe":
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer ts.Close()
+	jc := Client{
+		logger:  logrus.WithField("client", "jenkins"),
+		client:  ts.Client(),
+		baseURL: ts.URL,
+	}
+
+	testCases := []struct {
+		name           string
+		spec           *prowapi.ProwJobSpec
+		number         int
+		expectedStages []Stage
+		expectedErr    bool
+	}{
+		{
+			name: "pipeline in a folder",
+			spec: &prowapi.ProwJobSpec{
+				Type:        prowapi.PostsubmitJob,
+				Job:         "folder/pipeline",
+				JenkinsSpec: &prowapi.JenkinsSpec{GitHubBranchSourceJob: true},
+				Refs:        &prowapi.Refs{BaseRef: "release/1.0"},
+			},
+			number: 3,
+			expectedStages: []Stage{
+				{Name: "build", Status: "SUCCESS", StartTimeMillis: 1654084800000, DurationMillis: 60000},
+				{Name: "test", Status: "IN_PROGRESS", StartTimeMillis: 1654084860000, DurationMillis: 1500},
+			},
+		},
+		{
+			name:   "job that is not a pipeline has no stages",
+			spec:   &prowapi.ProwJobSpec{Job: "freestyle"},
+			number: 1,
+		},
+		{
+			name:        "server error",
+			spec:        &prowapi.ProwJobSpec{Job: "broken"},
+			number:      1,
+			expectedErr: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			stages, err := jc.GetStages(tc.spec, tc.number)
+			if (err != nil) != tc.expectedErr {
+				t.Fatalf("expected error %t, got %v", tc.expectedErr, err)
+			}
+			if !reflect.DeepEqual(tc.expectedStages, stages) {
+				t.Errorf("expected stages %+v, got %+v", tc.expectedStages, stages)
+			}
+		})
+	}
+}
+
 func TestGetJobInfoPath(t *testing.T) {
 	testCases := []struct {
 		name   string
@@ -318,7 +318,7 @@ func (f *FakeClient) UpdateIssue(issue *jira.Issue) (*jira.Issue, error) {
 	}
 	// convert `fields` field of both retrieved and provided issue to interfaces and update the non-nil
 	// fields from the provided issue to the retrieved one
-	var issueFields, retrievedFields map[string]interface{}
+	var issueFields map[string]interface{}
 	issueBytes, err := json.Marshal(issue.Fields)
 	if err != nil {
 		return nil, fmt.Errorf("error converting provided issue to json: %v", err)
@@ -326,26 +326,51 @@ func (f *FakeClient) UpdateIssue(issue *jira.Issue) (*jira.Issue, error) {
 	if err := json.Unmarshal(issueBytes, &issueFields); err != nil {
 		return nil, fmt.Errorf("failed converting provided issue to map: %v", err)
 	}
+	if err := updateFields(retrievedIssue, issueFields); err != nil {
+		return nil, err
+	}
+	return retrievedIssue, nil
+}
+
+func (f *FakeClient) UpdateIssueFields(issueID string, fields map[string]interface{}) error {
+	if f.UpdateIssueError != nil {
+		if err, ok := f.UpdateIssueError[issueID]; ok {
+			return err
+		}
+	}
+	retrievedIssue, err := f.GetIssue(issueID)
+	if err != nil {
+		return fmt.Errorf("unable to find issue to update: %v", err)
+	}
+	return updateFields(retrievedIssue, fields)
+}
+
+// updateFields overwrites the fields of an issue with the given ones.
+func updateFields(retrievedIssue *jira.Issue, issueFields map[string]interface{}) error {
+	var retrievedFields map[string]interface{}
 	retrievedIssueBytes, err := json.Marshal(retrievedIssue.Fields)
 	if err != nil {
-		return nil, fmt.Errorf("error converting original issue to json: %v", err)
+		return fmt.Errorf("error converting original issue to json: %v", err)
 	}
 	if err := json.Unmarshal(retrievedIssueBytes, &retrievedFields); err != nil {
-		return nil, fmt.Errorf("failed converting original issue to map: %v", err)
+		return fmt.Errorf("failed converting original issue to map: %v", err)
+	}
+	if retrievedFields == nil {
+		retrievedFields = map[string]interface{}{}
 	}
 	for key, value := range issueFields {
 		retrievedFields[key] = value
 	}
 	updatedIssueBytes, err := json.Marshal(retrievedFields)
 	if err != nil {
-		return nil, fmt.Errorf("error converting updated issue to json: %v", err)
+		return fmt.Errorf("error converting updated issue to json: %v", err)
 	}
 	var newFields jira.IssueFields
 	if err := json.Unmarshal(updatedIssueBytes, &newFields); err != nil {
-		return nil, fmt.Errorf("failed converting updated issue to struct: %v", err)
+		return fmt.Errorf("failed converting updated issue to struct: %v", err)
 	}
 	retrievedIssue.Fields = &newFields
-	return retrievedIssue, nil
+	return nil
 }
 
 func (f *FakeClient) UpdateStatus(issueID, statusName string) error {
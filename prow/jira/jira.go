@@ -56,6 +56,9 @@ type Client interface {
 	// Jira API docs: https://developer.atlassian.com/jiradev/jira-apis/jira-rest-apis/jira-rest-api-tutorials/jira-rest-api-example-query-issues
 	SearchWithContext(ctx context.Context, jql string, options *jira.SearchOptions) ([]jira.Issue, *jira.Response, error)
 	UpdateIssue(*jira.Issue) (*jira.Issue, error)
+	// UpdateIssueFields sets the given fields of an issue, keyed by field ID. Unlike
+	// UpdateIssue, fields set to empty values are cleared instead of being ignored.
+	UpdateIssueFields(issueID string, fields map[string]interface{}) error
 	CreateIssue(*jira.Issue) (*jira.Issue, error)
 	CreateIssueLink(*jira.IssueLink) error
 	// CloneIssue copies an issue struct, clears unsettable fields, creates a new
@@ -425,6 +428,14 @@ func (jc *client) UpdateIssue(issue *jira.Issue) (*jira.Issue, error) {
 	return result, nil
 }
 
+func (jc *client) UpdateIssueFields(issueID string, fields map[string]interface{}) error {
+	resp, err := jc.upstream.Issue.UpdateIssue(issueID, map[string]interface{}{"fields": fields})
+	if err != nil {
+		return HandleJiraError(resp, err)
+	}
+	return nil
+}
+
 func (jc *client) AddComment(issueID string, comment *jira.Comment) (*jira.Comment, error) {
 	result, resp, err := jc.upstream.Issue.AddComment(issueID, comment)
 	if err != nil {
@@ -69,6 +69,10 @@ const (
 	// IsOptionalLabel is added in resources created by prow and
 	// carries the Optional from a Presubmit job.
 	IsOptionalLabel = "prow.k8s.io/is-optional"
+	// MergeGroupLabel is added to batch jobs created for a GitHub merge
+	// queue merge group. Those jobs test the merge group's head commit and
+	// report their status to it.
+	MergeGroupLabel = "prow.k8s.io/merge-group"
 
 	// Gerrit related labels that are used by Prow
 
@@ -0,0 +1,113 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pjutil
+
+import (
+	"fmt"
+	"sort"
+
+	"k8s.io/test-infra/prow/config"
+)
+
+// PresubmitImpact tells whether a presubmit is triggered automatically for
+// a pull request and why.
+type PresubmitImpact struct {
+	Name     string `json:"name"`
+	Context  string `json:"context"`
+	Runs     bool   `json:"runs"`
+	Optional bool   `json:"optional,omitempty"`
+	// InRepo is set for presubmits defined in the repository itself.
+	InRepo bool   `json:"in_repo,omitempty"`
+	Reason string `json:"reason"`
+	// MatchingFiles are the changed files that make a job with
+	// run_if_changed or skip_if_only_changed run.
+	MatchingFiles []string `json:"matching_files,omitempty"`
+	// RerunCommand triggers the job manually.
+	RerunCommand string `json:"rerun_command,omitempty"`
+}
+
+// SimulatePresubmits determines which presubmits trigger would start when a
+// pull request against the branch changing the given files is opened or
+// updated. The presubmits must have been defaulted, as they are by config.Load.
+// The result is sorted by name.
+func SimulatePresubmits(branch string, changes []string, static, inRepo []config.Presubmit) ([]PresubmitImpact, error) {
+	var impacts []PresubmitImpact
+	for i, presubmits := range [][]config.Presubmit{static, inRepo} {
+		for _, ps := range presubmits {
+			impact, err := simulatePresubmit(branch, changes, ps)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", ps.Name, err)
+			}
+			impact.InRepo = i == 1
+			impacts = append(impacts, impact)
+		}
+	}
+	sort.SliceStable(impacts, func(i, j int) bool {
+		return impacts[i].Name < impacts[j].Name
+	})
+	return impacts, nil
+}
+
+func simulatePresubmit(branch string, changes []string, ps config.Presubmit) (PresubmitImpact, error) {
+	impact := PresubmitImpact{
+		Name:         ps.Name,
+		Context:      ps.Context,
+		Optional:     ps.Optional,
+		RerunCommand: ps.RerunCommand,
+	}
+	// This is how trigger decides when a pull request is opened or updated.
+	runs, err := ps.ShouldRun(branch, func() ([]string, error) { return changes, nil }, false, false)
+	if err != nil {
+		return impact, err
+	}
+	impact.Runs = runs
+
+	switch {
+	case !ps.CouldRun(branch):
+		impact.Reason = fmt.Sprintf("does not run against branch %q", branch)
+	case ps.AlwaysRun:
+		impact.Reason = "always_run is set"
+	case ps.RunIfChanged != "":
+		impact.MatchingFiles = matchingFiles(ps, changes)
+		if runs {
+			impact.Reason = fmt.Sprintf("run_if_changed %q matches %d changed files", ps.RunIfChanged, len(impact.MatchingFiles))
+		} else {
+			impact.Reason = fmt.Sprintf("run_if_changed %q matches none of the changed files", ps.RunIfChanged)
+		}
+	case ps.SkipIfOnlyChanged != "":
+		impact.MatchingFiles = matchingFiles(ps, changes)
+		if runs {
+			impact.Reason = fmt.Sprintf("skip_if_only_changed %q does not match %d changed files", ps.SkipIfOnlyChanged, len(impact.MatchingFiles))
+		} else {
+			impact.Reason = fmt.Sprintf("skip_if_only_changed %q matches all changed files", ps.SkipIfOnlyChanged)
+		}
+	default:
+		impact.Reason = "only runs when triggered explicitly"
+	}
+	return impact, nil
+}
+
+// matchingFiles returns the changes that each would make the job run.
+func matchingFiles(ps config.Presubmit, changes []string) []string {
+	var matching []string
+	for _, change := range changes {
+		if ps.RunsAgainstChanges([]string{change}) {
+			matching = append(matching, change)
+		}
+	}
+	return matching
+}
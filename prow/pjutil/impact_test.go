@@ -0,0 +1,85 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pjutil
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"k8s.io/test-infra/prow/config"
+)
+
+func TestSimulatePresubmits(t *testing.T) {
+	presubmit := func(name string, modify func(*config.Presubmit)) config.Presubmit {
+		ps := config.Presubmit{
+			JobBase:      config.JobBase{Name: name},
+			Reporter:     config.Reporter{Context: name},
+			RerunCommand: "/test " + name,
+		}
+		modify(&ps)
+		return ps
+	}
+	static := []config.Presubmit{
+		presubmit("always", func(ps *config.Presubmit) { ps.AlwaysRun = true }),
+		presubmit("release-only", func(ps *config.Presubmit) {
+			ps.AlwaysRun = true
+			ps.Branches = []string{"release-1.0"}
+		}),
+		presubmit("foo", func(ps *config.Presubmit) { ps.RunIfChanged = "^pkg/foo/" }),
+		presubmit("bar", func(ps *config.Presubmit) { ps.RunIfChanged = "^pkg/bar/" }),
+		presubmit("not-docs", func(ps *config.Presubmit) {
+			ps.SkipIfOnlyChanged = `\.md$`
+			ps.Optional = true
+		}),
+		presubmit("manual", func(ps *config.Presubmit) {}),
+	}
+	inRepo := []config.Presubmit{
+		presubmit("in-repo", func(ps *config.Presubmit) { ps.SkipIfOnlyChanged = `^docs/` }),
+	}
+	if err := config.SetPresubmitRegexes(static); err != nil {
+		t.Fatalf("failed to set regexes: %v", err)
+	}
+	if err := config.SetPresubmitRegexes(inRepo); err != nil {
+		t.Fatalf("failed to set regexes: %v", err)
+	}
+
+	impacts, err := SimulatePresubmits("main", []string{"pkg/foo/a.go", "pkg/foo/b.go", "README.md", "docs/x.md"}, static, inRepo)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []PresubmitImpact{
+		{Name: "always", Context: "always", Runs: true, Reason: "always_run is set", RerunCommand: "/test always"},
+		{Name: "bar", Context: "bar", Reason: `run_if_changed "^pkg/bar/" matches none of the changed files`, RerunCommand: "/test bar"},
+		{Name: "foo", Context: "foo", Runs: true, Reason: `run_if_changed "^pkg/foo/" matches 2 changed files`, MatchingFiles: []string{"pkg/foo/a.go", "pkg/foo/b.go"}, RerunCommand: "/test foo"},
+		{Name: "in-repo", Context: "in-repo", Runs: true, InRepo: true, Reason: `skip_if_only_changed "^docs/" does not match 3 changed files`, MatchingFiles: []string{"pkg/foo/a.go", "pkg/foo/b.go", "README.md"}, RerunCommand: "/test in-repo"},
+		{Name: "manual", Context: "manual", Reason: "only runs when triggered explicitly", RerunCommand: "/test manual"},
+		{Name: "not-docs", Context: "not-docs", Runs: true, Optional: true, Reason: `skip_if_only_changed "\\.md$" does not match 2 changed files`, MatchingFiles: []string{"pkg/foo/a.go", "pkg/foo/b.go"}, RerunCommand: "/test not-docs"},
+		{Name: "release-only", Context: "release-only", Reason: `does not run against branch "main"`, RerunCommand: "/test release-only"},
+	}
+	if diff := cmp.Diff(expected, impacts); diff != "" {
+		t.Errorf("unexpected impacts (-want +got):\n%s", diff)
+	}
+
+	impacts, err = SimulatePresubmits("main", []string{"README.md"}, static[4:5], nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if impacts[0].Runs || impacts[0].Reason != `skip_if_only_changed "\\.md$" matches all changed files` {
+		t.Errorf("expected docs-only change to skip the job, got %+v", impacts[0])
+	}
+}
@@ -207,6 +207,7 @@ func specFromJobBase(jb config.JobBase) prowapi.ProwJobSpec {
 
 		PodSpec:         jb.Spec,
 		PipelineRunSpec: jb.PipelineRunSpec,
+		BuildSpec:       jb.BuildSpec,
 
 		ReporterConfig:  jb.ReporterConfig,
 		RerunAuthConfig: jb.RerunAuthConfig,
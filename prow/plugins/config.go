@@ -44,6 +44,11 @@ import (
 
 const (
 	defaultBlunderbussReviewerCount = 2
+
+	defaultWasmPluginTimeout     = "30s"
+	defaultWasmPluginCPULimit    = "1s"
+	defaultWasmPluginMaxMemoryMB = 16
+	maxWasmPluginMemoryMB        = 1024
 )
 
 // Configuration is the top-level serialization target for plugin Configuration.
@@ -59,6 +64,12 @@ type Configuration struct {
 	// external plugins.
 	ExternalPlugins map[string][]ExternalPlugin `json:"external_plugins,omitempty"`
 
+	// WasmPlugins is a map of repositories (eg "k/k") to lists of
+	// plugins compiled to WebAssembly that hook runs in a sandbox. A plugin
+	// configured for both an org and one of its repos runs once, with the
+	// repo's configuration.
+	WasmPlugins map[string][]WasmPlugin `json:"wasm_plugins,omitempty"`
+
 	// Owners contains configuration related to handling OWNERS files.
 	Owners Owners `json:"owners,omitempty"`
 
@@ -142,6 +153,31 @@ type ExternalPlugin struct {
 	Events []string `json:"events,omitempty"`
 }
 
+// WasmPlugin holds configuration for a plugin compiled to WebAssembly
+// that is run by hook itself.
+type WasmPlugin struct {
+	// Name of the plugin. The module is loaded from "<name>.wasm" in the
+	// directory or bucket hook loads WebAssembly plugins from.
+	Name string `json:"name"`
+	// Events are the events that are passed to the plugin. If no events
+	// are specified, everything is sent.
+	Events []string `json:"events,omitempty"`
+	// Settings are handed to the plugin when it looks up its config.
+	Settings map[string]string `json:"settings,omitempty"`
+	// Timeout bounds the time the plugin may take to handle a single event,
+	// including the time spent waiting for GitHub. Defaults to 30s.
+	Timeout string `json:"timeout,omitempty"`
+	// CPULimit bounds the wall-clock time the plugin may spend running its own
+	// code while handling a single event, excluding the time spent in calls to
+	// prow. It is not a measure of CPU time. Defaults to 1s.
+	CPULimit string `json:"cpu_limit,omitempty"`
+	// MaxMemoryMB bounds the memory available to the plugin. Defaults to 16.
+	MaxMemoryMB int `json:"max_memory_mb,omitempty"`
+
+	TimeoutDuration  time.Duration `json:"-"`
+	CPULimitDuration time.Duration `json:"-"`
+}
+
 // Blunderbuss defines configuration for the blunderbuss plugin.
 type Blunderbuss struct {
 	// ReviewerCount is the minimum number of reviewers to request
@@ -366,6 +402,47 @@ type Jira struct {
 	// for example including `enterprise` here would disable linking for all issues
 	// that start with `enterprise-` like `enterprise-4.` Matching is case-insenitive.
 	DisabledJiraProjects []string `json:"disabled_jira_projects,omitempty"`
+	// Sync configures the two-way sync between pull requests and the Jira issues
+	// they reference, keyed by "org" or "org/repo". Repo entries take precedence
+	// over org entries.
+	Sync map[string]JiraSync `json:"sync,omitempty"`
+}
+
+// JiraSync holds the two-way sync config of the jira plugin for an org or repo.
+type JiraSync struct {
+	// MergeTransition is the name of the workflow transition the referenced Jira
+	// issues are moved through when a pull request merges. Issues for which the
+	// transition is not available, e.g. because they were already moved, are skipped.
+	MergeTransition string `json:"merge_transition,omitempty"`
+	// LabelComponents maps GitHub labels to Jira components. The component is
+	// added to the referenced issues when the label is added to a pull request
+	// and removed from them when the label is removed.
+	LabelComponents map[string]string `json:"label_components,omitempty"`
+	// LabelFields maps GitHub labels to Jira fields and the values they are set to
+	// on the referenced issues when the label is added to a pull request. Fields are
+	// identified by their ID, e.g. `customfield_12345`, and are left untouched when
+	// the label is removed.
+	LabelFields map[string]map[string]string `json:"label_fields,omitempty"`
+	// StatusLabels maps Jira statuses to GitHub labels. When an issue changes status,
+	// the pull requests linked to it get the label of its new status and lose the
+	// labels of all other statuses. This requires a Jira webhook that sends
+	// `jira:issue_updated` events to the `/jira` endpoint of hook, which is served
+	// when hook is started with `--jira-webhook-secret-file`.
+	StatusLabels map[string]string `json:"status_labels,omitempty"`
+}
+
+// SyncFor finds the JiraSync for a repo, if one exists.
+func (j *Jira) SyncFor(org, repo string) *JiraSync {
+	if j == nil {
+		return nil
+	}
+	if sync, ok := j.Sync[fmt.Sprintf("%s/%s", org, repo)]; ok {
+		return &sync
+	}
+	if sync, ok := j.Sync[org]; ok {
+		return &sync
+	}
+	return nil
 }
 
 // Cat contains the configuration for the cat plugin.
@@ -1015,6 +1092,20 @@ func (c *Configuration) setDefaults() {
 			c.ExternalPlugins[repo][i].Endpoint = fmt.Sprintf("http://%s", p.Name)
 		}
 	}
+	for repo, plugins := range c.WasmPlugins {
+		for i := range plugins {
+			p := &c.WasmPlugins[repo][i]
+			if p.Timeout == "" {
+				p.Timeout = defaultWasmPluginTimeout
+			}
+			if p.CPULimit == "" {
+				p.CPULimit = defaultWasmPluginCPULimit
+			}
+			if p.MaxMemoryMB == 0 {
+				p.MaxMemoryMB = defaultWasmPluginMaxMemoryMB
+			}
+		}
+	}
 	if c.Blunderbuss.ReviewerCount == nil {
 		c.Blunderbuss.ReviewerCount = new(int)
 		*c.Blunderbuss.ReviewerCount = defaultBlunderbussReviewerCount
@@ -1128,6 +1219,47 @@ func validateExternalPlugins(pluginMap map[string][]ExternalPlugin) error {
 	return nil
 }
 
+func validateWasmPlugins(pluginMap map[string][]WasmPlugin) error {
+	var errors []string
+
+	for repo, plugins := range pluginMap {
+		for _, p := range plugins {
+			if p.Name == "" || strings.ContainsAny(p.Name, "/\\") || strings.Contains(p.Name, "..") {
+				errors = append(errors, fmt.Sprintf("wasm plugin %q for %s must have a name that is a plain file name", p.Name, repo))
+			}
+			if p.TimeoutDuration <= 0 || p.CPULimitDuration <= 0 {
+				errors = append(errors, fmt.Sprintf("wasm plugin %s for %s must have a positive timeout and cpu_limit", p.Name, repo))
+			}
+			if p.MaxMemoryMB < 0 || p.MaxMemoryMB > maxWasmPluginMemoryMB {
+				errors = append(errors, fmt.Sprintf("max_memory_mb of wasm plugin %s for %s must be between 1 and %d", p.Name, repo, maxWasmPluginMemoryMB))
+			}
+		}
+		if !strings.Contains(repo, "/") {
+			continue
+		}
+		org := strings.Split(repo, "/")[0]
+
+		var orgConfig []string
+		for _, p := range pluginMap[org] {
+			orgConfig = append(orgConfig, p.Name)
+		}
+
+		var repoConfig []string
+		for _, p := range plugins {
+			repoConfig = append(repoConfig, p.Name)
+		}
+
+		if dupes := findDuplicatedPluginConfig(repoConfig, orgConfig); len(dupes) > 0 {
+			errors = append(errors, fmt.Sprintf("wasm plugins %v are duplicated for %s and %s", dupes, repo, org))
+		}
+	}
+
+	if len(errors) > 0 {
+		return fmt.Errorf("invalid plugin configuration:\n\t%v", strings.Join(errors, "\n\t"))
+	}
+	return nil
+}
+
 func validateBlunderbuss(b *Blunderbuss) error {
 	if b.ReviewerCount != nil && *b.ReviewerCount < 1 {
 		return fmt.Errorf("invalid request_count: %v (needs to be positive)", *b.ReviewerCount)
@@ -1305,6 +1437,21 @@ func compileRegexpsAndDurations(pc *Configuration) error {
 		}
 		rs[i].GracePeriodDuration = dur
 	}
+
+	for repo, plugins := range pc.WasmPlugins {
+		for i, p := range plugins {
+			timeout, err := time.ParseDuration(p.Timeout)
+			if err != nil {
+				return fmt.Errorf("failed to parse timeout of wasm plugin %s for %s: %w", p.Name, repo, err)
+			}
+			cpuLimit, err := time.ParseDuration(p.CPULimit)
+			if err != nil {
+				return fmt.Errorf("failed to parse cpu_limit of wasm plugin %s for %s: %w", p.Name, repo, err)
+			}
+			pc.WasmPlugins[repo][i].TimeoutDuration = timeout
+			pc.WasmPlugins[repo][i].CPULimitDuration = cpuLimit
+		}
+	}
 	return nil
 }
 
@@ -1326,6 +1473,9 @@ func (c *Configuration) Validate() error {
 	if err := validateExternalPlugins(c.ExternalPlugins); err != nil {
 		return err
 	}
+	if err := validateWasmPlugins(c.WasmPlugins); err != nil {
+		return err
+	}
 	if err := validateBlunderbuss(&c.Blunderbuss); err != nil {
 		return err
 	}
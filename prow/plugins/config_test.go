@@ -104,6 +104,67 @@ func TestValidateExternalPlugins(t *testing.T) {
 	}
 }
 
+func TestValidateWasmPlugins(t *testing.T) {
+	valid := func(name string) WasmPlugin {
+		return WasmPlugin{Name: name, MaxMemoryMB: 16, TimeoutDuration: 30 * time.Second, CPULimitDuration: time.Second}
+	}
+	tests := []struct {
+		name        string
+		plugins     map[string][]WasmPlugin
+		expectedErr string
+	}{
+		{
+			name: "valid config",
+			plugins: map[string][]WasmPlugin{
+				"kubernetes/test-infra": {valid("labeler")},
+				"kubernetes":            {valid("greeter")},
+			},
+		},
+		{
+			name: "duplicated for org and repo",
+			plugins: map[string][]WasmPlugin{
+				"kubernetes/test-infra": {valid("labeler")},
+				"kubernetes":            {valid("labeler")},
+			},
+			expectedErr: "invalid plugin configuration:\n\twasm plugins [labeler] are duplicated for kubernetes/test-infra and kubernetes",
+		},
+		{
+			name: "name escapes the plugin directory",
+			plugins: map[string][]WasmPlugin{
+				"kubernetes": {valid("../labeler")},
+			},
+			expectedErr: "invalid plugin configuration:\n\twasm plugin \"../labeler\" for kubernetes must have a name that is a plain file name",
+		},
+		{
+			name: "too much memory",
+			plugins: map[string][]WasmPlugin{
+				"kubernetes": {{Name: "labeler", MaxMemoryMB: 2048, TimeoutDuration: time.Second, CPULimitDuration: time.Second}},
+			},
+			expectedErr: "invalid plugin configuration:\n\tmax_memory_mb of wasm plugin labeler for kubernetes must be between 1 and 1024",
+		},
+		{
+			name: "no timeout",
+			plugins: map[string][]WasmPlugin{
+				"kubernetes": {{Name: "labeler", MaxMemoryMB: 16, CPULimitDuration: time.Second}},
+			},
+			expectedErr: "invalid plugin configuration:\n\twasm plugin labeler for kubernetes must have a positive timeout and cpu_limit",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := validateWasmPlugins(test.plugins)
+			var got string
+			if err != nil {
+				got = err.Error()
+			}
+			if got != test.expectedErr {
+				t.Errorf("unexpected error: %q, expected: %q", got, test.expectedErr)
+			}
+		})
+	}
+}
+
 func TestSetDefault_Maps(t *testing.T) {
 	cases := []struct {
 		name     string
@@ -218,6 +279,62 @@ func TestTriggerFor(t *testing.T) {
 	}
 }
 
+func TestJiraSyncFor(t *testing.T) {
+	jira := &Jira{
+		Sync: map[string]JiraSync{
+			"org":      {MergeTransition: "Org"},
+			"org/repo": {MergeTransition: "Repo"},
+		},
+	}
+
+	testCases := []struct {
+		name      string
+		jira      *Jira
+		org, repo string
+		expected  string
+	}{
+		{
+			name:     "repo sync takes precedence",
+			jira:     jira,
+			org:      "org",
+			repo:     "repo",
+			expected: "Repo",
+		},
+		{
+			name:     "org sync",
+			jira:     jira,
+			org:      "org",
+			repo:     "other",
+			expected: "Org",
+		},
+		{
+			name: "no sync",
+			jira: jira,
+			org:  "other",
+			repo: "repo",
+		},
+		{
+			name: "no jira config",
+			org:  "org",
+			repo: "repo",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := tc.jira.SyncFor(tc.org, tc.repo)
+			if tc.expected == "" {
+				if actual != nil {
+					t.Errorf("expected no sync config, got %+v", actual)
+				}
+				return
+			}
+			if actual == nil || actual.MergeTransition != tc.expected {
+				t.Errorf("expected merge transition %q, got %+v", tc.expected, actual)
+			}
+		})
+	}
+}
+
 func TestSetApproveDefaults(t *testing.T) {
 	c := &Configuration{
 		Approve: []Approve{
@@ -61,12 +61,48 @@ func extractCandidatesFromText(t string) []string {
 
 func init() {
 	plugins.RegisterGenericCommentHandler(PluginName, handleGenericComment, helpProvider)
+	plugins.RegisterPullRequestHandler(PluginName, handlePullRequest, helpProvider)
 }
 
-func helpProvider(config *plugins.Configuration, _ []config.OrgRepo) (*pluginhelp.PluginHelp, error) {
-	// The Config field is omitted because this plugin is not configurable.
+func helpProvider(config *plugins.Configuration, enabledRepos []config.OrgRepo) (*pluginhelp.PluginHelp, error) {
+	configInfo := map[string]string{}
+	for _, repo := range enabledRepos {
+		sync := config.Jira.SyncFor(repo.Org, repo.Repo)
+		if sync == nil {
+			continue
+		}
+		var info []string
+		if sync.MergeTransition != "" {
+			info = append(info, fmt.Sprintf("Referenced Jira issues are moved through the %q transition when a pull request merges.", sync.MergeTransition))
+		}
+		if len(sync.LabelComponents) > 0 || len(sync.LabelFields) > 0 {
+			info = append(info, "Pull request labels are synced to the components and fields of referenced Jira issues.")
+		}
+		if len(sync.StatusLabels) > 0 {
+			info = append(info, "The statuses of linked Jira issues are synced to pull request labels.")
+		}
+		configInfo[repo.String()] = strings.Join(info, " ")
+	}
+	yamlSnippet, err := plugins.CommentMap.GenYaml(&plugins.Configuration{
+		Jira: &plugins.Jira{
+			DisabledJiraProjects: []string{"enterprise"},
+			Sync: map[string]plugins.JiraSync{
+				"org/repo": {
+					MergeTransition: "Close",
+					LabelComponents: map[string]string{"area/prow": "Prow"},
+					LabelFields:     map[string]map[string]string{"priority/critical-urgent": {"customfield_12345": "Critical"}},
+					StatusLabels:    map[string]string{"In Review": "jira/in-review", "Done": "jira/done"},
+				},
+			},
+		},
+	})
+	if err != nil {
+		logrus.WithError(err).Warnf("cannot generate comments for %s plugin", PluginName)
+	}
 	pluginHelp := &pluginhelp.PluginHelp{
-		Description: "The Jira plugin links Pull Requests and Issues to Jira issues",
+		Description: "The Jira plugin links Pull Requests and Issues to Jira issues. It can also transition the referenced Jira issues when a pull request merges, sync pull request labels to their components and fields and sync their statuses back to pull request labels.",
+		Config:      configInfo,
+		Snippet:     yamlSnippet,
 	}
 	return pluginHelp, nil
 }
@@ -75,6 +111,9 @@ type githubClient interface {
 	EditComment(org, repo string, id int, comment string) error
 	GetIssue(org, repo string, number int) (*github.Issue, error)
 	EditIssue(org, repo string, number int, issue *github.Issue) (*github.Issue, error)
+	GetIssueLabels(org, repo string, number int) ([]github.Label, error)
+	AddLabel(org, repo string, number int, label string) error
+	RemoveLabel(org, repo string, number int, label string) error
 }
 
 func handleGenericComment(pc plugins.Agent, e github.GenericCommentEvent) error {
@@ -82,21 +121,30 @@ func handleGenericComment(pc plugins.Agent, e github.GenericCommentEvent) error
 }
 
 func handle(jc jiraclient.Client, ghc githubClient, cfg *plugins.Jira, log *logrus.Entry, e *github.GenericCommentEvent) error {
-	if projectCache.entryCount() == 0 {
-		projects, err := jc.ListProjects()
-		if err != nil {
-			return fmt.Errorf("failed to list jira projects: %w", err)
-		}
-		var projectNames []string
-		for _, project := range *projects {
-			projectNames = append(projectNames, strings.ToLower(project.Key))
-		}
-		projectCache.insert(projectNames...)
+	if err := ensureProjectCache(jc); err != nil {
+		return err
 	}
 
 	return handleWithProjectCache(jc, ghc, cfg, log, e, projectCache)
 }
 
+// ensureProjectCache fills the project cache if it is empty.
+func ensureProjectCache(jc jiraclient.Client) error {
+	if projectCache.entryCount() != 0 {
+		return nil
+	}
+	projects, err := jc.ListProjects()
+	if err != nil {
+		return fmt.Errorf("failed to list jira projects: %w", err)
+	}
+	var projectNames []string
+	for _, project := range *projects {
+		projectNames = append(projectNames, strings.ToLower(project.Key))
+	}
+	projectCache.insert(projectNames...)
+	return nil
+}
+
 func handleWithProjectCache(jc jiraclient.Client, ghc githubClient, cfg *plugins.Jira, log *logrus.Entry, e *github.GenericCommentEvent, projectCache *threadsafeSet) error {
 	// Nothing to do on deletion
 	if e.Action == github.GenericCommentActionDeleted {
@@ -0,0 +1,379 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jira
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/andygrunwald/go-jira"
+	"github.com/sirupsen/logrus"
+
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/test-infra/prow/github"
+	jiraclient "k8s.io/test-infra/prow/jira"
+	"k8s.io/test-infra/prow/plugins"
+)
+
+func handlePullRequest(pc plugins.Agent, e github.PullRequestEvent) error {
+	if pc.PluginConfig.Jira.SyncFor(e.Repo.Owner.Login, e.Repo.Name) == nil {
+		return nil
+	}
+	if err := ensureProjectCache(pc.JiraClient); err != nil {
+		return err
+	}
+	return handlePR(&projectCachingJiraClient{pc.JiraClient, projectCache}, pc.PluginConfig.Jira, pc.Logger, &e)
+}
+
+// handlePR syncs the state of a pull request to the Jira issues it references:
+// merging it transitions them and labeling it updates their components and fields.
+func handlePR(jc jiraclient.Client, cfg *plugins.Jira, log *logrus.Entry, e *github.PullRequestEvent) error {
+	sync := cfg.SyncFor(e.Repo.Owner.Login, e.Repo.Name)
+	if sync == nil {
+		return nil
+	}
+
+	var update func(*jira.Issue) error
+	switch e.Action {
+	case github.PullRequestActionClosed:
+		if !e.PullRequest.Merged || sync.MergeTransition == "" {
+			return nil
+		}
+		update = func(issue *jira.Issue) error {
+			return transitionIssue(jc, log, issue, sync.MergeTransition)
+		}
+	case github.PullRequestActionLabeled, github.PullRequestActionUnlabeled:
+		added := e.Action == github.PullRequestActionLabeled
+		_, hasComponent := sync.LabelComponents[e.Label.Name]
+		if !hasComponent && (!added || len(sync.LabelFields[e.Label.Name]) == 0) {
+			return nil
+		}
+		update = func(issue *jira.Issue) error {
+			return updateIssueForLabel(jc, log, issue, sync, e.Label.Name, added)
+		}
+	default:
+		return nil
+	}
+
+	issues, err := referencedIssues(jc, cfg, e.PullRequest.Title, e.PullRequest.Body)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, issue := range issues {
+		if err := update(issue); err != nil {
+			errs = append(errs, fmt.Errorf("failed to update issue %s: %w", issue.Key, err))
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// referencedIssues returns the existing Jira issues referenced in the given texts.
+func referencedIssues(jc jiraclient.Client, cfg *plugins.Jira, texts ...string) ([]*jira.Issue, error) {
+	var candidates []string
+	for _, text := range texts {
+		candidates = append(candidates, extractCandidatesFromText(text)...)
+	}
+	candidates = filterOutDisabledJiraProjects(candidates, cfg)
+
+	var issues []*jira.Issue
+	var errs []error
+	for _, candidate := range sets.NewString(candidates...).List() {
+		issue, err := jc.GetIssue(candidate)
+		if err != nil {
+			if !jiraclient.IsNotFound(err) {
+				errs = append(errs, fmt.Errorf("failed to get issue %s: %w", candidate, err))
+			}
+			continue
+		}
+		issues = append(issues, issue)
+	}
+	return issues, utilerrors.NewAggregate(errs)
+}
+
+// transitionIssue moves an issue through the named workflow transition. Jira only
+// offers the transitions that are possible from the current status of the issue,
+// so the issue is left alone if the transition is not available.
+func transitionIssue(jc jiraclient.Client, log *logrus.Entry, issue *jira.Issue, name string) error {
+	transitions, err := jc.GetTransitions(issue.Key)
+	if err != nil {
+		return fmt.Errorf("failed to get transitions: %w", err)
+	}
+	for _, transition := range transitions {
+		if strings.EqualFold(transition.Name, name) {
+			if err := jc.DoTransition(issue.Key, transition.ID); err != nil {
+				return fmt.Errorf("failed to transition issue: %w", err)
+			}
+			log.WithField("issue", issue.Key).Infof("Transitioned issue through %q.", name)
+			return nil
+		}
+	}
+	log.WithField("issue", issue.Key).Infof("Transition %q is not available, not transitioning issue.", name)
+	return nil
+}
+
+// updateIssueForLabel updates the components and fields of an issue that a
+// label being added or removed maps to. Fields are only set when it is added.
+func updateIssueForLabel(jc jiraclient.Client, log *logrus.Entry, issue *jira.Issue, sync *plugins.JiraSync, label string, added bool) error {
+	fields := map[string]interface{}{}
+	if component, ok := sync.LabelComponents[label]; ok {
+		if components, changed := updatedComponents(issue, component, added); changed {
+			fields["components"] = components
+		}
+	}
+	if added {
+		for field, value := range sync.LabelFields[label] {
+			fields[field] = value
+		}
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+	if err := jc.UpdateIssueFields(issue.Key, fields); err != nil {
+		return err
+	}
+	log.WithField("issue", issue.Key).Infof("Updated issue for label %q.", label)
+	return nil
+}
+
+// updatedComponents returns the components of the issue with the given one added
+// or removed, and whether that is a change.
+func updatedComponents(issue *jira.Issue, component string, added bool) ([]map[string]string, bool) {
+	components := []map[string]string{}
+	var found bool
+	if issue.Fields != nil {
+		for _, existing := range issue.Fields.Components {
+			if existing.Name == component {
+				found = true
+				if !added {
+					continue
+				}
+			}
+			components = append(components, map[string]string{"name": existing.Name})
+		}
+	}
+	if added && !found {
+		components = append(components, map[string]string{"name": component})
+	}
+	return components, found != added
+}
+
+// webhookEvent is the payload Jira sends to webhooks.
+type webhookEvent struct {
+	WebhookEvent string                 `json:"webhookEvent"`
+	Issue        *jira.Issue            `json:"issue"`
+	Changelog    *jira.ChangelogHistory `json:"changelog"`
+}
+
+type webhookHandler struct {
+	config         func() *plugins.Configuration
+	jc             jiraclient.Client
+	ghc            githubClient
+	githubHost     string
+	tokenGenerator func() []byte
+	log            *logrus.Entry
+}
+
+// NewWebhookHandler returns a handler for Jira webhooks that labels the pull
+// requests linked to an issue according to its status when it changes. Requests
+// must carry a `X-Hub-Signature` header with the HMAC-SHA256 of their payload.
+// Only links to pull requests on githubHost are considered.
+func NewWebhookHandler(config func() *plugins.Configuration, jc jiraclient.Client, ghc githubClient, githubHost string, tokenGenerator func() []byte) http.Handler {
+	return &webhookHandler{
+		config:         config,
+		githubHost:     githubHost,
+		jc:             jc,
+		ghc:            ghc,
+		tokenGenerator: tokenGenerator,
+		log:            logrus.WithField("plugin", PluginName),
+	}
+}
+
+func (h *webhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "405 Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	payload, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "500 Internal server error: failed to read request body", http.StatusInternalServerError)
+		return
+	}
+	if !validSignature(payload, r.Header.Get("X-Hub-Signature"), h.tokenGenerator()) {
+		http.Error(w, "403 Forbidden: invalid X-Hub-Signature", http.StatusForbidden)
+		return
+	}
+	var event webhookEvent
+	if err := json.Unmarshal(payload, &event); err != nil {
+		http.Error(w, fmt.Sprintf("400 Bad Request: invalid payload: %v", err), http.StatusBadRequest)
+		return
+	}
+	if err := h.handleEvent(&event); err != nil {
+		h.log.WithError(err).Error("Failed to handle Jira webhook.")
+		http.Error(w, "500 Internal server error: failed to handle event", http.StatusInternalServerError)
+		return
+	}
+	fmt.Fprint(w, "Event received. Have a nice day.")
+}
+
+func validSignature(payload []byte, sig string, key []byte) bool {
+	if !strings.HasPrefix(sig, "sha256=") {
+		return false
+	}
+	sb, err := hex.DecodeString(strings.TrimPrefix(sig, "sha256="))
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write(payload)
+	return hmac.Equal(sb, mac.Sum(nil))
+}
+
+// newStatus returns the status an issue changed to, if it did.
+func (e *webhookEvent) newStatus() (string, bool) {
+	if e.WebhookEvent != "jira:issue_updated" || e.Issue == nil || e.Changelog == nil {
+		return "", false
+	}
+	for _, item := range e.Changelog.Items {
+		if item.Field == "status" {
+			return item.ToString, true
+		}
+	}
+	return "", false
+}
+
+func (h *webhookHandler) handleEvent(e *webhookEvent) error {
+	status, changed := e.newStatus()
+	if !changed {
+		return nil
+	}
+	log := h.log.WithFields(logrus.Fields{"issue": e.Issue.Key, "status": status})
+	links, err := h.jc.GetRemoteLinks(e.Issue.Key)
+	if err != nil {
+		return fmt.Errorf("failed to get remote links of %s: %w", e.Issue.Key, err)
+	}
+
+	pluginConfig := h.config()
+	cfg := pluginConfig.Jira
+	var errs []error
+	for _, link := range links {
+		if link.Object == nil {
+			continue
+		}
+		org, repo, number, ok := parsePullRequestURL(link.Object.URL, h.githubHost)
+		if !ok {
+			continue
+		}
+		sync := cfg.SyncFor(org, repo)
+		if sync == nil || len(sync.StatusLabels) == 0 || !pluginEnabled(pluginConfig, org, repo) {
+			continue
+		}
+		if err := syncStatusLabels(h.ghc, log, org, repo, number, sync.StatusLabels, status); err != nil {
+			errs = append(errs, fmt.Errorf("failed to sync labels of %s/%s#%d: %w", org, repo, number, err))
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// pluginEnabled tells whether the jira plugin is enabled for the repo.
+func pluginEnabled(cfg *plugins.Configuration, org, repo string) bool {
+	orgs, repos, orgExceptions := cfg.EnabledReposForPlugin(PluginName)
+	fullName := org + "/" + repo
+	for _, r := range repos {
+		if r == fullName {
+			return true
+		}
+	}
+	for _, o := range orgs {
+		if o == org && !orgExceptions[org].Has(fullName) {
+			return true
+		}
+	}
+	return false
+}
+
+// parsePullRequestURL parses the links to pull requests and issues that the plugin
+// adds to Jira issues, e.g. https://github.com/org/repo/pull/1. Links to other
+// hosts than the given GitHub host are ignored.
+func parsePullRequestURL(rawURL, githubHost string) (string, string, int, bool) {
+	u, err := url.Parse(rawURL)
+	if err != nil || !strings.EqualFold(u.Host, githubHost) {
+		return "", "", 0, false
+	}
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(parts) != 4 || (parts[2] != "pull" && parts[2] != "issues") {
+		return "", "", 0, false
+	}
+	number, err := strconv.Atoi(parts[3])
+	if err != nil {
+		return "", "", 0, false
+	}
+	return parts[0], parts[1], number, true
+}
+
+// syncStatusLabels adds the label of the given status to a pull request and
+// removes the labels of all other statuses.
+func syncStatusLabels(ghc githubClient, log *logrus.Entry, org, repo string, number int, statusLabels map[string]string, status string) error {
+	var wanted string
+	for s, label := range statusLabels {
+		if strings.EqualFold(s, status) {
+			wanted = label
+		}
+	}
+
+	labels, err := ghc.GetIssueLabels(org, repo, number)
+	if err != nil {
+		return fmt.Errorf("failed to get labels: %w", err)
+	}
+	existing := sets.NewString()
+	for _, label := range labels {
+		existing.Insert(label.Name)
+	}
+
+	var errs []error
+	if wanted != "" && !existing.Has(wanted) {
+		if err := ghc.AddLabel(org, repo, number, wanted); err != nil {
+			errs = append(errs, err)
+		} else {
+			log.Infof("Added label %q to %s/%s#%d.", wanted, org, repo, number)
+		}
+	}
+	statusLabelSet := sets.NewString()
+	for _, label := range statusLabels {
+		statusLabelSet.Insert(label)
+	}
+	for _, label := range statusLabelSet.List() {
+		if label == wanted || !existing.Has(label) {
+			continue
+		}
+		if err := ghc.RemoveLabel(org, repo, number, label); err != nil {
+			errs = append(errs, err)
+		} else {
+			log.Infof("Removed label %q from %s/%s#%d.", label, org, repo, number)
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
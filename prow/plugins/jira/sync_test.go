@@ -0,0 +1,291 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jira
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/andygrunwald/go-jira"
+	"github.com/google/go-cmp/cmp"
+	"github.com/sirupsen/logrus"
+
+	"k8s.io/test-infra/prow/github"
+	"k8s.io/test-infra/prow/github/fakegithub"
+	"k8s.io/test-infra/prow/jira/fakejira"
+	"k8s.io/test-infra/prow/plugins"
+)
+
+var syncConfig = &plugins.Jira{
+	DisabledJiraProjects: []string{"disabled"},
+	Sync: map[string]plugins.JiraSync{
+		"org/repo": {
+			MergeTransition: "Close",
+			LabelComponents: map[string]string{"area/prow": "Prow"},
+			LabelFields:     map[string]map[string]string{"priority/critical": {"customfield_1": "Critical"}},
+			StatusLabels:    map[string]string{"In Review": "jira/in-review", "Done": "jira/done"},
+		},
+	},
+}
+
+func TestHandlePR(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name       string
+		repo       string
+		action     github.PullRequestEventAction
+		merged     bool
+		label      string
+		components []*jira.Component
+
+		expectedStatus     string
+		expectedComponents []string
+		expectedUnknowns   map[string]interface{}
+	}{
+		{
+			name:           "merge transitions referenced issues",
+			repo:           "repo",
+			action:         github.PullRequestActionClosed,
+			merged:         true,
+			expectedStatus: "Closed",
+		},
+		{
+			name:           "closing without merging does nothing",
+			repo:           "repo",
+			action:         github.PullRequestActionClosed,
+			expectedStatus: "Open",
+		},
+		{
+			name:           "merge in repo without sync does nothing",
+			repo:           "other",
+			action:         github.PullRequestActionClosed,
+			merged:         true,
+			expectedStatus: "Open",
+		},
+		{
+			name:               "label adds component",
+			repo:               "repo",
+			action:             github.PullRequestActionLabeled,
+			label:              "area/prow",
+			components:         []*jira.Component{{Name: "Deck"}},
+			expectedStatus:     "Open",
+			expectedComponents: []string{"Deck", "Prow"},
+		},
+		{
+			name:               "removing label removes component",
+			repo:               "repo",
+			action:             github.PullRequestActionUnlabeled,
+			label:              "area/prow",
+			components:         []*jira.Component{{Name: "Prow"}},
+			expectedStatus:     "Open",
+			expectedComponents: []string{},
+		},
+		{
+			name:             "label sets fields",
+			repo:             "repo",
+			action:           github.PullRequestActionLabeled,
+			label:            "priority/critical",
+			expectedStatus:   "Open",
+			expectedUnknowns: map[string]interface{}{"customfield_1": "Critical"},
+		},
+		{
+			name:           "unknown label does nothing",
+			repo:           "repo",
+			action:         github.PullRequestActionLabeled,
+			label:          "lgtm",
+			expectedStatus: "Open",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			jc := &fakejira.FakeClient{
+				Issues: []*jira.Issue{
+					{ID: "1", Key: "ABC-1", Fields: &jira.IssueFields{Status: &jira.Status{Name: "Open"}, Components: tc.components}},
+					{ID: "2", Key: "DISABLED-1", Fields: &jira.IssueFields{Status: &jira.Status{Name: "Open"}}},
+				},
+				Transitions: []jira.Transition{{ID: "3", Name: "Close", To: jira.Status{Name: "Closed"}}},
+			}
+			e := &github.PullRequestEvent{
+				Action: tc.action,
+				Repo:   github.Repo{Owner: github.User{Login: "org"}, Name: tc.repo},
+				PullRequest: github.PullRequest{
+					Title:  "ABC-1: fix the thing",
+					Body:   "Also fixes DISABLED-1 and ABC-404.",
+					Merged: tc.merged,
+				},
+				Label: github.Label{Name: tc.label},
+			}
+			if err := handlePR(jc, syncConfig, logrus.WithField("test", tc.name), e); err != nil {
+				t.Fatalf("handlePR failed: %v", err)
+			}
+
+			issue, _ := jc.GetIssue("ABC-1")
+			if issue.Fields.Status.Name != tc.expectedStatus {
+				t.Errorf("expected status %q, got %q", tc.expectedStatus, issue.Fields.Status.Name)
+			}
+			if disabled, _ := jc.GetIssue("DISABLED-1"); disabled.Fields.Status.Name != "Open" {
+				t.Errorf("expected issue of disabled project to be left alone, got status %q", disabled.Fields.Status.Name)
+			}
+			if tc.expectedComponents != nil {
+				components := []string{}
+				for _, c := range issue.Fields.Components {
+					components = append(components, c.Name)
+				}
+				if diff := cmp.Diff(tc.expectedComponents, components); diff != "" {
+					t.Errorf("unexpected components (-want +got):\n%s", diff)
+				}
+			}
+			if tc.expectedUnknowns != nil {
+				for field, value := range tc.expectedUnknowns {
+					if actual := issue.Fields.Unknowns[field]; actual != value {
+						t.Errorf("expected field %s to be %v, got %v", field, value, actual)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestWebhookHandler(t *testing.T) {
+	t.Parallel()
+	const payload = `{"webhookEvent": "jira:issue_updated", "issue": {"key": "ABC-1"}, "changelog": {"items": [{"field": "status", "fromString": "In Review", "toString": "Done"}]}}`
+	key := []byte("secret")
+	sign := func(payload string) string {
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(payload))
+		return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	}
+
+	testCases := []struct {
+		name      string
+		method    string
+		payload   string
+		signature string
+
+		expectedCode    int
+		expectedAdded   []string
+		expectedRemoved []string
+	}{
+		{
+			name:            "status change syncs labels",
+			method:          http.MethodPost,
+			payload:         payload,
+			signature:       sign(payload),
+			expectedCode:    http.StatusOK,
+			expectedAdded:   []string{"org/repo#1:jira/done"},
+			expectedRemoved: []string{"org/repo#1:jira/in-review"},
+		},
+		{
+			name:         "other changes are ignored",
+			method:       http.MethodPost,
+			payload:      `{"webhookEvent": "jira:issue_updated", "issue": {"key": "ABC-1"}, "changelog": {"items": [{"field": "summary"}]}}`,
+			signature:    sign(`{"webhookEvent": "jira:issue_updated", "issue": {"key": "ABC-1"}, "changelog": {"items": [{"field": "summary"}]}}`),
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "invalid signature is rejected",
+			method:       http.MethodPost,
+			payload:      payload,
+			signature:    "sha256=abcd",
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			name:         "GET is rejected",
+			method:       http.MethodGet,
+			expectedCode: http.StatusMethodNotAllowed,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			jc := &fakejira.FakeClient{
+				Issues: []*jira.Issue{{ID: "1", Key: "ABC-1"}},
+				ExistingLinks: map[string][]jira.RemoteLink{"ABC-1": {
+					{Object: &jira.RemoteLinkObject{URL: "https://github.com/org/repo/pull/1"}},
+					{Object: &jira.RemoteLinkObject{URL: "https://github.com/org/other/pull/2"}},
+					{Object: &jira.RemoteLinkObject{URL: "https://example.com/some/page"}},
+					{Object: &jira.RemoteLinkObject{URL: "https://example.com/org/repo/pull/3"}},
+					{Object: &jira.RemoteLinkObject{URL: "https://github.com/org/excluded/pull/4"}},
+					{Object: &jira.RemoteLinkObject{URL: "https://github.com/disabled/repo/pull/5"}},
+				}},
+			}
+			ghc := fakegithub.NewFakeClient()
+			ghc.IssueLabelsExisting = []string{"org/repo#1:jira/in-review", "org/other#2:jira/in-review", "org/excluded#4:jira/in-review", "disabled/repo#5:jira/in-review"}
+			// Status labels are only synced for repos the plugin is enabled for.
+			jiraConfig := *syncConfig
+			jiraConfig.Sync = map[string]plugins.JiraSync{
+				"org/repo":      syncConfig.Sync["org/repo"],
+				"org/excluded":  syncConfig.Sync["org/repo"],
+				"disabled/repo": syncConfig.Sync["org/repo"],
+			}
+			cfg := &plugins.Configuration{
+				Plugins: plugins.Plugins{"org": {Plugins: []string{PluginName}, ExcludedRepos: []string{"excluded"}}},
+				Jira:    &jiraConfig,
+			}
+			handler := NewWebhookHandler(func() *plugins.Configuration { return cfg }, jc, ghc, "github.com", func() []byte { return key })
+
+			req := httptest.NewRequest(tc.method, "/jira", bytes.NewBufferString(tc.payload))
+			req.Header.Set("X-Hub-Signature", tc.signature)
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != tc.expectedCode {
+				t.Errorf("expected code %d, got %d: %s", tc.expectedCode, rr.Code, rr.Body.String())
+			}
+			if diff := cmp.Diff(tc.expectedAdded, ghc.IssueLabelsAdded); diff != "" {
+				t.Errorf("unexpected added labels (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.expectedRemoved, ghc.IssueLabelsRemoved); diff != "" {
+				t.Errorf("unexpected removed labels (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestParsePullRequestURL(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		url            string
+		expectedOrg    string
+		expectedRepo   string
+		expectedNumber int
+		expectedOK     bool
+	}{
+		{url: "https://github.com/org/repo/pull/1", expectedOrg: "org", expectedRepo: "repo", expectedNumber: 1, expectedOK: true},
+		{url: "https://github.com/org/repo/issues/12", expectedOrg: "org", expectedRepo: "repo", expectedNumber: 12, expectedOK: true},
+		{url: "https://github.com/org/repo/pull/1/files"},
+		{url: "https://github.com/org/repo/tree/main"},
+		{url: "https://github.com/org/repo/pull/abc"},
+		{url: "https://example.com/org/repo/pull/1"},
+	}
+	for _, tc := range testCases {
+		org, repo, number, ok := parsePullRequestURL(tc.url, "github.com")
+		if org != tc.expectedOrg || repo != tc.expectedRepo || number != tc.expectedNumber || ok != tc.expectedOK {
+			t.Errorf("%s: expected %s/%s#%d (%t), got %s/%s#%d (%t)", tc.url, tc.expectedOrg, tc.expectedRepo, tc.expectedNumber, tc.expectedOK, org, repo, number, ok)
+		}
+	}
+}
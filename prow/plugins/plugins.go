@@ -62,6 +62,8 @@ var (
 	reviewEventHandlers        = map[string]ReviewEventHandler{}
 	reviewCommentEventHandlers = map[string]ReviewCommentEventHandler{}
 	statusEventHandlers        = map[string]StatusEventHandler{}
+	checkRunEventHandlers      = map[string]CheckRunEventHandler{}
+	mergeGroupEventHandlers    = map[string]MergeGroupEventHandler{}
 	// CommentMap is used by many plugins for printing help messages defined in
 	// config.go.
 	CommentMap, _ = genyaml.NewCommentMap(nil)
@@ -131,6 +133,24 @@ func RegisterStatusEventHandler(name string, fn StatusEventHandler, help HelpPro
 	statusEventHandlers[name] = fn
 }
 
+// CheckRunEventHandler defines the function contract for a github.CheckRunEvent handler.
+type CheckRunEventHandler func(Agent, github.CheckRunEvent) error
+
+// RegisterCheckRunEventHandler registers a plugin's github.CheckRunEvent handler.
+func RegisterCheckRunEventHandler(name string, fn CheckRunEventHandler, help HelpProvider) {
+	pluginHelp[name] = help
+	checkRunEventHandlers[name] = fn
+}
+
+// MergeGroupEventHandler defines the function contract for a github.MergeGroupEvent handler.
+type MergeGroupEventHandler func(Agent, github.MergeGroupEvent) error
+
+// RegisterMergeGroupEventHandler registers a plugin's github.MergeGroupEvent handler.
+func RegisterMergeGroupEventHandler(name string, fn MergeGroupEventHandler, help HelpProvider) {
+	pluginHelp[name] = help
+	mergeGroupEventHandlers[name] = fn
+}
+
 // PushEventHandler defines the function contract for a github.PushEvent handler.
 type PushEventHandler func(Agent, github.PushEvent) error
 
@@ -500,6 +520,36 @@ func (pa *ConfigAgent) StatusEventHandlers(owner, repo string) map[string]Status
 	return hs
 }
 
+// CheckRunEventHandlers returns a map of plugin names to handlers for the repo.
+func (pa *ConfigAgent) CheckRunEventHandlers(owner, repo string) map[string]CheckRunEventHandler {
+	pa.mut.Lock()
+	defer pa.mut.Unlock()
+
+	hs := map[string]CheckRunEventHandler{}
+	for _, p := range pa.getPlugins(owner, repo) {
+		if h, ok := checkRunEventHandlers[p]; ok {
+			hs[p] = h
+		}
+	}
+
+	return hs
+}
+
+// MergeGroupEventHandlers returns a map of plugin names to handlers for the repo.
+func (pa *ConfigAgent) MergeGroupEventHandlers(owner, repo string) map[string]MergeGroupEventHandler {
+	pa.mut.Lock()
+	defer pa.mut.Unlock()
+
+	hs := map[string]MergeGroupEventHandler{}
+	for _, p := range pa.getPlugins(owner, repo) {
+		if h, ok := mergeGroupEventHandlers[p]; ok {
+			hs[p] = h
+		}
+	}
+
+	return hs
+}
+
 // PushEventHandlers returns a map of plugin names to handlers for the repo.
 func (pa *ConfigAgent) PushEventHandlers(owner, repo string) map[string]PushEventHandler {
 	pa.mut.Lock()
@@ -552,6 +602,12 @@ func EventsForPlugin(name string) []string {
 	if _, ok := statusEventHandlers[name]; ok {
 		events = append(events, "status")
 	}
+	if _, ok := checkRunEventHandlers[name]; ok {
+		events = append(events, "check_run")
+	}
+	if _, ok := mergeGroupEventHandlers[name]; ok {
+		events = append(events, "merge_group")
+	}
 	if _, ok := genericCommentHandlers[name]; ok {
 		events = append(events, "GenericCommentEvent (any event for user text)")
 	}
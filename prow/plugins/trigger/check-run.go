@@ -0,0 +1,72 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package trigger
+
+import (
+	"context"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"k8s.io/test-infra/prow/github"
+	"k8s.io/test-infra/prow/pjutil"
+	"k8s.io/test-infra/prow/plugins"
+)
+
+// handleCheckRun re-runs the job behind a check run when a trusted user
+// re-requests it. Crier's GitHub reporter sets the check run's external ID
+// to the name of the prowjob it reports, so that prowjob is copied.
+func handleCheckRun(c Client, trigger plugins.Trigger, cre github.CheckRunEvent) error {
+	if cre.Action != github.CheckRunActionRerequested || cre.CheckRun.ExternalID == "" {
+		return nil
+	}
+	org, repo := cre.Repo.Owner.Login, cre.Repo.Name
+	log := c.Logger.WithField("prowjob", cre.CheckRun.ExternalID)
+
+	pj, err := c.ProwJobClient.Get(context.TODO(), cre.CheckRun.ExternalID, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			log.Info("Prowjob of re-requested check run not found, it may have been garbage collected.")
+			return nil
+		}
+		return fmt.Errorf("failed to get prowjob %s: %w", cre.CheckRun.ExternalID, err)
+	}
+	// Only re-run the job the check run was reported for.
+	if refs := pj.Spec.Refs; refs == nil || refs.Org != org || refs.Repo != repo || pj.Spec.Context != cre.CheckRun.Name {
+		log.Infof("Prowjob does not match re-requested check run %q.", cre.CheckRun.Name)
+		return nil
+	}
+
+	trustedResponse, err := TrustedUser(c.GitHubClient, trigger.OnlyOrgMembers, trigger.TrustedApps, trigger.TrustedOrg, cre.Sender.Login, org, repo)
+	if err != nil {
+		return fmt.Errorf("error checking trust of %s: %w", cre.Sender.Login, err)
+	}
+	if !trustedResponse.IsTrusted {
+		log.Infof("Not re-running check run %q requested by untrusted user %s: %s", cre.CheckRun.Name, cre.Sender.Login, trustedResponse.Reason)
+		return nil
+	}
+
+	labels := make(map[string]string)
+	for k, v := range pj.Labels {
+		labels[k] = v
+	}
+	labels[github.EventGUID] = cre.GUID
+	newPJ := pjutil.NewProwJob(pj.Spec, labels, pj.Annotations)
+	c.Logger.WithFields(pjutil.ProwJobFields(&newPJ)).Info("Creating a new prowjob.")
+	return createWithRetry(context.TODO(), c.ProwJobClient, &newPJ)
+}
@@ -0,0 +1,151 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package trigger
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	prowapi "k8s.io/test-infra/prow/apis/prowjobs/v1"
+	"k8s.io/test-infra/prow/client/clientset/versioned/fake"
+	"k8s.io/test-infra/prow/config"
+	"k8s.io/test-infra/prow/github"
+	"k8s.io/test-infra/prow/github/fakegithub"
+	"k8s.io/test-infra/prow/plugins"
+)
+
+func TestHandleCheckRun(t *testing.T) {
+	existing := &prowapi.ProwJob{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "old-job",
+			Namespace: "prowjobs",
+			Labels:    map[string]string{"foo": "bar"},
+		},
+		Spec: prowapi.ProwJobSpec{
+			Type:    prowapi.PresubmitJob,
+			Job:     "pull-test",
+			Context: "pull-test",
+			Report:  true,
+			Refs: &prowapi.Refs{
+				Org:   "org",
+				Repo:  "repo",
+				Pulls: []prowapi.Pull{{Number: 1, SHA: "abc"}},
+			},
+		},
+	}
+	event := func(mutate func(*github.CheckRunEvent)) github.CheckRunEvent {
+		cre := github.CheckRunEvent{
+			Action: github.CheckRunActionRerequested,
+			CheckRun: github.CheckRun{
+				Name:       "pull-test",
+				ExternalID: "old-job",
+				HeadSHA:    "abc",
+			},
+			Repo:   github.Repo{Owner: github.User{Login: "org"}, Name: "repo"},
+			Sender: github.User{Login: "trusted"},
+			GUID:   "guid",
+		}
+		if mutate != nil {
+			mutate(&cre)
+		}
+		return cre
+	}
+
+	testCases := []struct {
+		name        string
+		event       github.CheckRunEvent
+		expectRerun bool
+	}{
+		{
+			name:        "trusted user re-requests check run",
+			event:       event(nil),
+			expectRerun: true,
+		},
+		{
+			name:  "other actions are ignored",
+			event: event(func(cre *github.CheckRunEvent) { cre.Action = github.CheckRunActionCompleted }),
+		},
+		{
+			name:  "check runs without external ID are ignored",
+			event: event(func(cre *github.CheckRunEvent) { cre.CheckRun.ExternalID = "" }),
+		},
+		{
+			name:  "missing prowjob is ignored",
+			event: event(func(cre *github.CheckRunEvent) { cre.CheckRun.ExternalID = "garbage-collected" }),
+		},
+		{
+			name:  "prowjob of another repo is not re-run",
+			event: event(func(cre *github.CheckRunEvent) { cre.Repo.Name = "other" }),
+		},
+		{
+			name:  "prowjob with another context is not re-run",
+			event: event(func(cre *github.CheckRunEvent) { cre.CheckRun.Name = "pull-other" }),
+		},
+		{
+			name:  "untrusted user cannot re-run",
+			event: event(func(cre *github.CheckRunEvent) { cre.Sender.Login = "stranger" }),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := fakegithub.NewFakeClient()
+			g.OrgMembers = map[string][]string{"org": {"trusted"}}
+			fakeProwJobClient := fake.NewSimpleClientset(existing.DeepCopy())
+			c := Client{
+				GitHubClient:  g,
+				ProwJobClient: fakeProwJobClient.ProwV1().ProwJobs("prowjobs"),
+				Config:        &config.Config{ProwConfig: config.ProwConfig{ProwJobNamespace: "prowjobs"}},
+				Logger:        logrus.WithField("plugin", PluginName),
+			}
+
+			if err := handleCheckRun(c, plugins.Trigger{}, tc.event); err != nil {
+				t.Fatalf("handleCheckRun returned unexpected error: %v", err)
+			}
+
+			pjs, err := fakeProwJobClient.ProwV1().ProwJobs("prowjobs").List(context.Background(), metav1.ListOptions{})
+			if err != nil {
+				t.Fatalf("failed to list prowjobs: %v", err)
+			}
+			var reruns []prowapi.ProwJob
+			for _, pj := range pjs.Items {
+				if pj.Name != existing.Name {
+					reruns = append(reruns, pj)
+				}
+			}
+			if !tc.expectRerun {
+				if len(reruns) != 0 {
+					t.Errorf("expected no re-run, got %d prowjobs", len(reruns))
+				}
+				return
+			}
+			if len(reruns) != 1 {
+				t.Fatalf("expected exactly one re-run, got %d", len(reruns))
+			}
+			rerun := reruns[0]
+			if rerun.Spec.Job != existing.Spec.Job || rerun.Spec.Refs.Pulls[0].SHA != "abc" {
+				t.Errorf("re-run does not match the original job: %+v", rerun.Spec)
+			}
+			if rerun.Labels["foo"] != "bar" || rerun.Labels[github.EventGUID] != "guid" {
+				t.Errorf("unexpected labels on re-run: %v", rerun.Labels)
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package trigger
+
+import (
+	"context"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	klabels "k8s.io/apimachinery/pkg/labels"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+
+	prowapi "k8s.io/test-infra/prow/apis/prowjobs/v1"
+	"k8s.io/test-infra/prow/config"
+	"k8s.io/test-infra/prow/github"
+	"k8s.io/test-infra/prow/kube"
+	"k8s.io/test-infra/prow/pjutil"
+)
+
+// handleMergeGroup starts the required presubmits of the target branch for
+// a merge group GitHub's merge queue wants to test, and aborts them once the
+// merge group is destroyed. The jobs are batch jobs that check out the merge
+// group's temporary branch, so they test exactly the commit GitHub merges
+// and report their status contexts to it.
+func handleMergeGroup(c Client, mge github.MergeGroupEvent) error {
+	switch mge.Action {
+	case github.MergeGroupActionChecksRequested:
+		return runMergeGroup(c, mge)
+	case github.MergeGroupActionDestroyed:
+		if err := abortMergeGroupJobs(c, mge); err != nil {
+			c.Logger.WithError(err).Error("Failed to abort jobs for destroyed merge group")
+			return err
+		}
+	}
+	return nil
+}
+
+func runMergeGroup(c Client, mge github.MergeGroupEvent) error {
+	org, repo := mge.Repo.Owner.Login, mge.Repo.Name
+	mg := mge.MergeGroup
+	baseSHAGetter := func() (string, error) {
+		return mg.BaseSHA, nil
+	}
+	headSHAGetter := func() (string, error) {
+		return mg.HeadSHA, nil
+	}
+	presubmits := getPresubmits(c.Logger, c.GitClient, c.Config, org+"/"+repo, baseSHAGetter, headSHAGetter)
+
+	refs := prowapi.Refs{
+		Org:      org,
+		Repo:     repo,
+		RepoLink: mge.Repo.HTMLURL,
+		BaseRef:  mg.HeadBranch(),
+		BaseSHA:  mg.HeadSHA,
+		BaseLink: fmt.Sprintf("%s/commit/%s", mge.Repo.HTMLURL, mg.HeadSHA),
+	}
+	var errs []error
+	for _, job := range mergeGroupJobs(mg.BaseBranch(), presubmits) {
+		labels := make(map[string]string)
+		for k, v := range job.Labels {
+			labels[k] = v
+		}
+		labels[github.EventGUID] = mge.GUID
+		labels[kube.MergeGroupLabel] = "true"
+		pj := pjutil.NewProwJob(pjutil.BatchSpec(job, refs), labels, job.Annotations)
+		c.Logger.WithFields(pjutil.ProwJobFields(&pj)).Info("Creating a new prowjob for merge group.")
+		if err := createWithRetry(context.TODO(), c.ProwJobClient, &pj); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// mergeGroupJobs returns the presubmits whose contexts branch protection
+// requires on the given branch. Jobs that only run if certain files changed
+// are not required contexts, so they are left out.
+func mergeGroupJobs(branch string, presubmits []config.Presubmit) []config.Presubmit {
+	var jobs []config.Presubmit
+	for _, job := range presubmits {
+		if !job.CouldRun(branch) || !job.ContextRequired() || job.TriggersConditionally() {
+			continue
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs
+}
+
+func abortMergeGroupJobs(c Client, mge github.MergeGroupEvent) error {
+	selector := klabels.SelectorFromSet(klabels.Set{
+		kube.OrgLabel:         mge.Repo.Owner.Login,
+		kube.RepoLabel:        mge.Repo.Name,
+		kube.ProwJobTypeLabel: string(prowapi.BatchJob),
+		kube.MergeGroupLabel:  "true",
+	})
+	jobs, err := c.ProwJobClient.List(context.TODO(), metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return fmt.Errorf("failed to list prowjobs for merge group: %w", err)
+	}
+
+	var errs []error
+	for _, job := range jobs.Items {
+		if job.Complete() || job.Spec.Refs == nil || job.Spec.Refs.BaseSHA != mge.MergeGroup.HeadSHA {
+			continue
+		}
+		job.Status.State = prowapi.AbortedState
+		// See abortAllJobs for why this is an Update and not a Patch.
+		if _, err := c.ProwJobClient.Update(context.TODO(), &job, metav1.UpdateOptions{}); err != nil && !apierrors.IsConflict(err) {
+			errs = append(errs, fmt.Errorf("failed to abort job %s: %w", job.Name, err))
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
@@ -0,0 +1,133 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package trigger
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	prowapi "k8s.io/test-infra/prow/apis/prowjobs/v1"
+	"k8s.io/test-infra/prow/client/clientset/versioned/fake"
+	"k8s.io/test-infra/prow/config"
+	"k8s.io/test-infra/prow/github"
+	"k8s.io/test-infra/prow/github/fakegithub"
+	"k8s.io/test-infra/prow/kube"
+)
+
+func TestHandleMergeGroup(t *testing.T) {
+	presubmits := map[string][]config.Presubmit{
+		"org/repo": {
+			{
+				JobBase:   config.JobBase{Name: "required"},
+				AlwaysRun: true,
+				Reporter:  config.Reporter{Context: "required"},
+			},
+			{
+				JobBase:   config.JobBase{Name: "optional"},
+				AlwaysRun: true,
+				Optional:  true,
+				Reporter:  config.Reporter{Context: "optional"},
+			},
+			{
+				JobBase:  config.JobBase{Name: "manual"},
+				Reporter: config.Reporter{Context: "manual"},
+			},
+			{
+				JobBase:             config.JobBase{Name: "conditional"},
+				RegexpChangeMatcher: config.RegexpChangeMatcher{RunIfChanged: `\.go$`},
+				Reporter:            config.Reporter{Context: "conditional"},
+			},
+			{
+				JobBase:   config.JobBase{Name: "other-branch"},
+				AlwaysRun: true,
+				Brancher:  config.Brancher{Branches: []string{"release"}},
+				Reporter:  config.Reporter{Context: "other-branch"},
+			},
+		},
+	}
+	event := func(action github.MergeGroupEventAction, headSHA string) github.MergeGroupEvent {
+		return github.MergeGroupEvent{
+			Action: action,
+			MergeGroup: github.MergeGroup{
+				HeadSHA: headSHA,
+				HeadRef: "refs/heads/gh-readonly-queue/main/pr-1-abc",
+				BaseSHA: "base",
+				BaseRef: "refs/heads/main",
+			},
+			Repo: github.Repo{Owner: github.User{Login: "org"}, Name: "repo", HTMLURL: "https://github.com/org/repo"},
+			GUID: "guid",
+		}
+	}
+
+	fakeProwJobClient := fake.NewSimpleClientset()
+	pjClient := fakeProwJobClient.ProwV1().ProwJobs("prowjobs")
+	c := Client{
+		GitHubClient:  fakegithub.NewFakeClient(),
+		ProwJobClient: pjClient,
+		Config:        &config.Config{ProwConfig: config.ProwConfig{ProwJobNamespace: "prowjobs"}},
+		Logger:        logrus.WithField("plugin", PluginName),
+	}
+	if err := c.Config.SetPresubmits(presubmits); err != nil {
+		t.Fatalf("failed to set presubmits: %v", err)
+	}
+
+	for _, sha := range []string{"head", "other-head"} {
+		if err := handleMergeGroup(c, event(github.MergeGroupActionChecksRequested, sha)); err != nil {
+			t.Fatalf("failed to handle checks_requested: %v", err)
+		}
+	}
+	pjs, err := pjClient.List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		t.Fatalf("failed to list prowjobs: %v", err)
+	}
+	var jobs []string
+	for _, pj := range pjs.Items {
+		if pj.Spec.Refs.BaseSHA != "head" {
+			continue
+		}
+		jobs = append(jobs, pj.Spec.Job)
+		if pj.Spec.Type != prowapi.BatchJob || pj.Labels[kube.MergeGroupLabel] != "true" {
+			t.Errorf("expected a merge group batch job, got type %s with labels %v", pj.Spec.Type, pj.Labels)
+		}
+		if pj.Spec.Refs.BaseRef != "gh-readonly-queue/main/pr-1-abc" || len(pj.Spec.Refs.Pulls) != 0 {
+			t.Errorf("expected job to test the merge group branch, got refs %+v", pj.Spec.Refs)
+		}
+	}
+	sort.Strings(jobs)
+	if diff := cmp.Diff([]string{"required"}, jobs); diff != "" {
+		t.Errorf("unexpected jobs for merge group: %s", diff)
+	}
+
+	if err := handleMergeGroup(c, event(github.MergeGroupActionDestroyed, "head")); err != nil {
+		t.Fatalf("failed to handle destroyed: %v", err)
+	}
+	pjs, err = pjClient.List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		t.Fatalf("failed to list prowjobs: %v", err)
+	}
+	for _, pj := range pjs.Items {
+		aborted := pj.Status.State == prowapi.AbortedState
+		if expected := pj.Spec.Refs.BaseSHA == "head"; aborted != expected {
+			t.Errorf("job for %s: expected aborted=%t, got state %q", pj.Spec.Refs.BaseSHA, expected, pj.Status.State)
+		}
+	}
+}
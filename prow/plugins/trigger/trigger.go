@@ -75,6 +75,8 @@ func init() {
 	plugins.RegisterGenericCommentHandler(PluginName, handleGenericCommentEvent, helpProvider)
 	plugins.RegisterPullRequestHandler(PluginName, handlePullRequest, helpProvider)
 	plugins.RegisterPushEventHandler(PluginName, handlePush, helpProvider)
+	plugins.RegisterCheckRunEventHandler(PluginName, handleCheckRunEvent, helpProvider)
+	plugins.RegisterMergeGroupEventHandler(PluginName, handleMergeGroupEvent, helpProvider)
 }
 
 func helpProvider(config *plugins.Configuration, enabledRepos []config.OrgRepo) (*pluginhelp.PluginHelp, error) {
@@ -106,7 +108,9 @@ func helpProvider(config *plugins.Configuration, enabledRepos []config.OrgRepo)
 	pluginHelp := &pluginhelp.PluginHelp{
 		Description: `The trigger plugin starts tests in reaction to commands and pull request events. It is responsible for ensuring that test jobs are only run on trusted PRs. A PR is considered trusted if the author is a member of the 'trusted organization' for the repository or if such a member has left an '/ok-to-test' command on the PR.
 <br>Trigger starts jobs automatically when a new trusted PR is created or when an untrusted PR becomes trusted, but it can also be used to start jobs manually via the '/test' command.
-<br>The '/retest' command can be used to rerun jobs that have reported failure.`,
+<br>The '/retest' command can be used to rerun jobs that have reported failure.
+<br>Jobs reported as GitHub check runs can also be re-run by trusted users with the 'Re-run' button of the check run.
+<br>When GitHub's merge queue requests checks for a merge group, trigger runs the presubmits that are required on the target branch against the merge group's commit.`,
 		Config:  configInfo,
 		Snippet: yamlSnippet,
 	}
@@ -167,6 +171,7 @@ type trustedPullRequestClient interface {
 
 type prowJobClient interface {
 	Create(context.Context, *prowapi.ProwJob, metav1.CreateOptions) (*prowapi.ProwJob, error)
+	Get(ctx context.Context, name string, opts metav1.GetOptions) (*prowapi.ProwJob, error)
 	List(ctx context.Context, opts metav1.ListOptions) (*prowapi.ProwJobList, error)
 	Update(context.Context, *prowapi.ProwJob, metav1.UpdateOptions) (*prowapi.ProwJob, error)
 }
@@ -212,6 +217,14 @@ func handlePush(pc plugins.Agent, pe github.PushEvent) error {
 	return handlePE(getClient(pc), pe)
 }
 
+func handleMergeGroupEvent(pc plugins.Agent, mge github.MergeGroupEvent) error {
+	return handleMergeGroup(getClient(pc), mge)
+}
+
+func handleCheckRunEvent(pc plugins.Agent, cre github.CheckRunEvent) error {
+	return handleCheckRun(getClient(pc), pc.PluginConfig.TriggerFor(cre.Repo.Owner.Login, cre.Repo.Name), cre)
+}
+
 // TrustedUserResponse is a response from TrustedUser. It contains the boolean response for trust as well
 // a reason for denial if the user is not trusted.
 type TrustedUserResponse struct {
@@ -0,0 +1,200 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wasm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+
+	"k8s.io/test-infra/prow/github"
+	"k8s.io/test-infra/prow/plugins"
+)
+
+// The methods a plugin may call through the host API.
+const (
+	methodCreateComment = "create_comment"
+	methodAddLabel      = "add_label"
+	methodRemoveLabel   = "remove_label"
+	methodCreateStatus  = "create_status"
+	methodGetConfig     = "get_config"
+)
+
+// hostRequest is a call from the plugin to the host. The params depend on
+// the method:
+//
+//	create_comment: {"org", "repo", "number", "body"}
+//	add_label:      {"org", "repo", "number", "label"}
+//	remove_label:   {"org", "repo", "number", "label"}
+//	create_status:  {"org", "repo", "sha", "status": {"state", "target_url", "description", "context"}}
+//	get_config:     {}, returns the settings of the plugin for the repo
+type hostRequest struct {
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params,omitempty"`
+}
+
+type hostResponse struct {
+	Result interface{} `json:"result,omitempty"`
+	Error  string      `json:"error,omitempty"`
+}
+
+type issueParams struct {
+	Org    string `json:"org"`
+	Repo   string `json:"repo"`
+	Number int    `json:"number"`
+	Body   string `json:"body,omitempty"`
+	Label  string `json:"label,omitempty"`
+}
+
+type statusParams struct {
+	Org    string        `json:"org"`
+	Repo   string        `json:"repo"`
+	SHA    string        `json:"sha"`
+	Status github.Status `json:"status"`
+}
+
+type githubClient interface {
+	CreateComment(org, repo string, number int, comment string) error
+	AddLabel(org, repo string, number int, label string) error
+	RemoveLabel(org, repo string, number int, label string) error
+	CreateStatus(org, repo, SHA string, s github.Status) error
+}
+
+type invocationKey struct{}
+
+// invocation holds everything the host API needs to serve a single call
+// of a plugin's handle_event.
+type invocation struct {
+	ghc          githubClient
+	pluginConfig *plugins.Configuration
+	log          *logrus.Entry
+	plugin       string
+	event        Event
+	budget       *cpuBudget
+}
+
+func instantiateHostModule(ctx context.Context, runtime wazero.Runtime) error {
+	_, err := runtime.NewHostModuleBuilder(hostModuleName).
+		NewFunctionBuilder().WithFunc(hostCall).Export("call").
+		NewFunctionBuilder().WithFunc(hostLog).Export("log").
+		Instantiate(ctx)
+	return err
+}
+
+func hostCall(ctx context.Context, m api.Module, ptr, length uint32) uint64 {
+	inv := ctx.Value(invocationKey{}).(*invocation)
+	inv.budget.pause()
+	defer inv.budget.resume()
+
+	var resp hostResponse
+	if raw, ok := m.Memory().Read(ptr, length); !ok {
+		resp.Error = fmt.Sprintf("request at %d with length %d is out of range", ptr, length)
+	} else if result, err := inv.call(raw); err != nil {
+		resp.Error = err.Error()
+	} else {
+		resp.Result = result
+	}
+
+	out, err := json.Marshal(resp)
+	if err != nil {
+		inv.log.WithError(err).Error("Failed to marshal host call response.")
+		return 0
+	}
+	respPtr, err := writeToGuest(ctx, m, out)
+	if err != nil {
+		inv.log.WithError(err).Warn("Failed to write host call response.")
+		return 0
+	}
+	return uint64(respPtr)<<32 | uint64(len(out))
+}
+
+func hostLog(ctx context.Context, m api.Module, level, ptr, length uint32) {
+	inv := ctx.Value(invocationKey{}).(*invocation)
+	msg, ok := m.Memory().Read(ptr, length)
+	if !ok {
+		return
+	}
+	levels := []logrus.Level{logrus.DebugLevel, logrus.InfoLevel, logrus.WarnLevel, logrus.ErrorLevel}
+	if int(level) >= len(levels) {
+		level = uint32(len(levels) - 1)
+	}
+	inv.log.Log(levels[level], string(msg))
+}
+
+// call serves a request of the plugin.
+func (inv *invocation) call(raw []byte) (interface{}, error) {
+	var req hostRequest
+	if err := json.Unmarshal(raw, &req); err != nil {
+		return nil, fmt.Errorf("invalid request: %w", err)
+	}
+	gc := inv.ghc
+	switch req.Method {
+	case methodCreateComment, methodAddLabel, methodRemoveLabel:
+		var params issueParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, fmt.Errorf("invalid params for %s: %w", req.Method, err)
+		}
+		if err := inv.checkRepo(params.Org, params.Repo); err != nil {
+			return nil, err
+		}
+		switch req.Method {
+		case methodCreateComment:
+			return nil, gc.CreateComment(params.Org, params.Repo, params.Number, params.Body)
+		case methodAddLabel:
+			return nil, gc.AddLabel(params.Org, params.Repo, params.Number, params.Label)
+		default:
+			return nil, gc.RemoveLabel(params.Org, params.Repo, params.Number, params.Label)
+		}
+	case methodCreateStatus:
+		var params statusParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, fmt.Errorf("invalid params for %s: %w", req.Method, err)
+		}
+		if err := inv.checkRepo(params.Org, params.Repo); err != nil {
+			return nil, err
+		}
+		return nil, gc.CreateStatus(params.Org, params.Repo, params.SHA, params.Status)
+	case methodGetConfig:
+		return inv.settings(), nil
+	}
+	return nil, fmt.Errorf("unknown method %q", req.Method)
+}
+
+// checkRepo makes sure plugins only act on the repo the event belongs to.
+func (inv *invocation) checkRepo(org, repo string) error {
+	if repo == "" || org != inv.event.Org || repo != inv.event.Repo {
+		return fmt.Errorf("plugins may only act on the repository of the event, not %s/%s", org, repo)
+	}
+	return nil
+}
+
+// settings returns the settings of the plugin for the repo of the event.
+// Settings configured for the repo take precedence over the ones of its org.
+func (inv *invocation) settings() map[string]string {
+	for _, key := range []string{fmt.Sprintf("%s/%s", inv.event.Org, inv.event.Repo), inv.event.Org} {
+		for _, p := range inv.pluginConfig.WasmPlugins[key] {
+			if p.Name == inv.plugin {
+				return p.Settings
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,380 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package wasm runs hook plugins that are compiled to WebAssembly.
+//
+// A plugin is a WebAssembly module that exports its linear memory as
+// "memory" as well as two functions:
+//
+//	allocate(size i32) i32
+//	handle_event(ptr i32, len i32) i32
+//
+// For every event the plugin is configured for, hook instantiates the module,
+// writes the JSON encoded Event into memory returned by allocate and calls
+// handle_event, which returns zero on success. Modules may additionally export
+// "_initialize", which is called after instantiation.
+//
+// Plugins talk to the outside world through the functions the host module
+// "prow" provides:
+//
+//	call(ptr i32, len i32) i64
+//	log(level i32, ptr i32, len i32)
+//
+// call takes a JSON encoded request like {"method": "add_label", "params": {...}}
+// and returns the location of the JSON encoded response, with the pointer in
+// the upper and the length in the lower 32 bits. The response is written to
+// memory obtained from allocate and has the form {"result": ..., "error": "..."}.
+// See hostRequest for the available methods. WASI is provided without access
+// to the file system, the network or the environment.
+package wasm
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+	"golang.org/x/sync/singleflight"
+
+	"k8s.io/test-infra/prow/io"
+	"k8s.io/test-infra/prow/plugins"
+)
+
+const (
+	hostModuleName    = "prow"
+	allocateExport    = "allocate"
+	handleEventExport = "handle_event"
+	initializeExport  = "_initialize"
+
+	// pagesPerMB is the number of 64KiB WebAssembly pages in a MiB.
+	pagesPerMB = 16
+	// defaultRefreshInterval is how often modules are checked for updates.
+	defaultRefreshInterval = time.Minute
+)
+
+// Event is handed to the plugin for every event it is configured for.
+type Event struct {
+	// Type is the GitHub event type, eg "issue_comment".
+	Type string `json:"event_type"`
+	// GUID is the GitHub delivery ID of the event.
+	GUID string `json:"guid"`
+	// Org and Repo the event belongs to. The plugin may only act on this repo.
+	Org  string `json:"org"`
+	Repo string `json:"repo"`
+	// Payload is the unmodified webhook payload.
+	Payload json.RawMessage `json:"payload"`
+}
+
+// Runtime loads WebAssembly plugins and runs them.
+type Runtime struct {
+	opener  io.Opener
+	path    string
+	refresh time.Duration
+
+	lock    sync.Mutex
+	modules map[string]*module
+	// loads makes sure every module is only loaded once at a time. Loading
+	// happens without holding the lock so other plugins are not blocked.
+	loads singleflight.Group
+}
+
+// module is a compiled plugin together with the wazero runtime it was
+// compiled for, as each plugin gets its own memory limit.
+type module struct {
+	runtime  wazero.Runtime
+	compiled wazero.CompiledModule
+	sha      [sha256.Size]byte
+	memoryMB int
+	checked  time.Time
+
+	// inflight tracks the invocations using the module so that it is only
+	// closed once they are done.
+	inflight sync.WaitGroup
+}
+
+// NewRuntime returns a Runtime that loads the module of a plugin from
+// "<path>/<name>.wasm". The path may be a local directory or a bucket.
+func NewRuntime(opener io.Opener, path string) *Runtime {
+	return &Runtime{
+		opener:  opener,
+		path:    strings.TrimSuffix(path, "/"),
+		refresh: defaultRefreshInterval,
+		modules: map[string]*module{},
+	}
+}
+
+// Close releases all modules once they are no longer in use.
+func (r *Runtime) Close() {
+	r.lock.Lock()
+	modules := r.modules
+	r.modules = map[string]*module{}
+	r.lock.Unlock()
+	for _, m := range modules {
+		m.close()
+	}
+}
+
+func (m *module) close() {
+	m.inflight.Wait()
+	if err := m.runtime.Close(context.Background()); err != nil {
+		logrus.WithError(err).Warn("Failed to close WebAssembly runtime.")
+	}
+}
+
+// module returns the compiled module for the plugin, (re)loading it if needed.
+// Callers must call inflight.Done() on the result once they are done with it.
+func (r *Runtime) module(ctx context.Context, p plugins.WasmPlugin) (*module, error) {
+	if m := r.acquire(p, true); m != nil {
+		return m, nil
+	}
+	if _, err, _ := r.loads.Do(p.Name, func() (interface{}, error) {
+		return nil, r.load(ctx, p)
+	}); err != nil {
+		return nil, err
+	}
+	if m := r.acquire(p, false); m != nil {
+		return m, nil
+	}
+	return nil, fmt.Errorf("plugin %s was unloaded while loading it", p.Name)
+}
+
+// acquire marks the cached module of the plugin as in use and returns it. If
+// fresh is set, it only does so if the module does not need to be reloaded.
+func (r *Runtime) acquire(p plugins.WasmPlugin, fresh bool) *module {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	cached, ok := r.modules[p.Name]
+	if !ok || (fresh && (cached.memoryMB != p.MaxMemoryMB || time.Since(cached.checked) >= r.refresh)) {
+		return nil
+	}
+	cached.inflight.Add(1)
+	return cached
+}
+
+// load reads the module of the plugin and compiles it if it changed.
+func (r *Runtime) load(ctx context.Context, p plugins.WasmPlugin) error {
+	r.lock.Lock()
+	cached, ok := r.modules[p.Name]
+	r.lock.Unlock()
+	markChecked := func() {
+		r.lock.Lock()
+		cached.checked = time.Now()
+		r.lock.Unlock()
+	}
+
+	path := fmt.Sprintf("%s/%s.wasm", r.path, p.Name)
+	content, err := io.ReadContent(ctx, logrus.WithField("plugin", p.Name), r.opener, path)
+	if err != nil {
+		if ok {
+			// Keep serving the last version we know of.
+			logrus.WithError(err).WithField("plugin", p.Name).Warn("Failed to refresh WebAssembly plugin.")
+			markChecked()
+			return nil
+		}
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	sha := sha256.Sum256(content)
+	if ok && cached.memoryMB == p.MaxMemoryMB && cached.sha == sha {
+		markChecked()
+		return nil
+	}
+
+	m, err := compile(ctx, content, p.MaxMemoryMB)
+	if err != nil {
+		return fmt.Errorf("failed to compile %s: %w", path, err)
+	}
+	m.sha = sha
+	r.lock.Lock()
+	previous, replaced := r.modules[p.Name]
+	r.modules[p.Name] = m
+	r.lock.Unlock()
+	if replaced {
+		go previous.close()
+	}
+	return nil
+}
+
+func compile(ctx context.Context, content []byte, memoryMB int) (*module, error) {
+	config := wazero.NewRuntimeConfig().
+		WithMemoryLimitPages(uint32(memoryMB * pagesPerMB)).
+		WithCloseOnContextDone(true)
+	runtime := wazero.NewRuntimeWithConfig(ctx, config)
+	if _, err := wasi_snapshot_preview1.Instantiate(ctx, runtime); err != nil {
+		runtime.Close(ctx)
+		return nil, fmt.Errorf("failed to instantiate WASI: %w", err)
+	}
+	if err := instantiateHostModule(ctx, runtime); err != nil {
+		runtime.Close(ctx)
+		return nil, fmt.Errorf("failed to instantiate host module: %w", err)
+	}
+	compiled, err := runtime.CompileModule(ctx, content)
+	if err != nil {
+		runtime.Close(ctx)
+		return nil, err
+	}
+	return &module{
+		runtime:  runtime,
+		compiled: compiled,
+		memoryMB: memoryMB,
+		checked:  time.Now(),
+	}, nil
+}
+
+// Handle runs the plugin for the event. The plugin is stopped once it exceeds
+// its timeout or CPU limit. The CPU limit is measured as the wall-clock time
+// the plugin spends running its own code, not as CPU time or instructions, so
+// it also counts time the host is descheduled.
+func (r *Runtime) Handle(agent plugins.Agent, p plugins.WasmPlugin, event Event) error {
+	return r.handle(agent.GitHubClient, agent.PluginConfig, agent.Logger, p, event)
+}
+
+func (r *Runtime) handle(ghc githubClient, pluginConfig *plugins.Configuration, log *logrus.Entry, p plugins.WasmPlugin, event Event) error {
+	ctx, cancel := context.WithTimeout(context.Background(), p.TimeoutDuration)
+	defer cancel()
+
+	m, err := r.module(ctx, p)
+	if err != nil {
+		return err
+	}
+	defer m.inflight.Done()
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	budget := newCPUBudget(p.CPULimitDuration, cancel)
+	defer budget.pause()
+	inv := &invocation{
+		ghc:          ghc,
+		pluginConfig: pluginConfig,
+		log:          log,
+		plugin:       p.Name,
+		event:        event,
+		budget:       budget,
+	}
+	ctx = context.WithValue(ctx, invocationKey{}, inv)
+
+	instance, err := m.runtime.InstantiateModule(ctx, m.compiled, wazero.NewModuleConfig().WithName("").WithStartFunctions(initializeExport))
+	if err != nil {
+		return budget.explain(ctx, p, fmt.Errorf("failed to instantiate plugin: %w", err))
+	}
+	defer instance.Close(context.Background())
+
+	handle := instance.ExportedFunction(handleEventExport)
+	if handle == nil {
+		return fmt.Errorf("plugin does not export %q", handleEventExport)
+	}
+	ptr, err := writeToGuest(ctx, instance, payload)
+	if err != nil {
+		return budget.explain(ctx, p, err)
+	}
+	results, err := handle.Call(ctx, uint64(ptr), uint64(len(payload)))
+	if err != nil {
+		return budget.explain(ctx, p, fmt.Errorf("failed to handle event: %w", err))
+	}
+	if code := int32(results[0]); code != 0 {
+		return fmt.Errorf("plugin returned %d", code)
+	}
+	return nil
+}
+
+// writeToGuest copies data into memory obtained from the guest's allocate export.
+func writeToGuest(ctx context.Context, m api.Module, data []byte) (uint32, error) {
+	allocate := m.ExportedFunction(allocateExport)
+	if allocate == nil {
+		return 0, fmt.Errorf("plugin does not export %q", allocateExport)
+	}
+	results, err := allocate.Call(ctx, uint64(len(data)))
+	if err != nil {
+		return 0, fmt.Errorf("failed to allocate %d bytes: %w", len(data), err)
+	}
+	ptr := uint32(results[0])
+	if !m.Memory().Write(ptr, data) {
+		return 0, fmt.Errorf("allocated memory at %d with length %d is out of range", ptr, len(data))
+	}
+	return ptr, nil
+}
+
+// cpuBudget cancels an invocation once the plugin spent more than its CPU
+// limit running its own code. The limit is wall-clock time, time spent in host
+// calls is not counted.
+type cpuBudget struct {
+	lock      sync.Mutex
+	limit     time.Duration
+	remaining time.Duration
+	resumed   time.Time
+	timer     *time.Timer
+	exceeded  bool
+	cancel    context.CancelFunc
+}
+
+func newCPUBudget(limit time.Duration, cancel context.CancelFunc) *cpuBudget {
+	b := &cpuBudget{limit: limit, remaining: limit, cancel: cancel}
+	b.resume()
+	return b
+}
+
+// resume starts counting the time against the budget.
+func (b *cpuBudget) resume() {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if b.timer != nil {
+		return
+	}
+	b.resumed = time.Now()
+	b.timer = time.AfterFunc(b.remaining, func() {
+		b.lock.Lock()
+		b.exceeded = true
+		b.lock.Unlock()
+		b.cancel()
+	})
+}
+
+// pause stops counting the time against the budget.
+func (b *cpuBudget) pause() {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if b.timer == nil {
+		return
+	}
+	b.timer.Stop()
+	b.timer = nil
+	b.remaining -= time.Since(b.resumed)
+}
+
+// explain replaces errors caused by the invocation being stopped with one
+// that names the exceeded limit.
+func (b *cpuBudget) explain(ctx context.Context, p plugins.WasmPlugin, err error) error {
+	b.lock.Lock()
+	exceeded := b.exceeded
+	b.lock.Unlock()
+	if exceeded {
+		return fmt.Errorf("plugin exceeded its CPU limit of %s", b.limit)
+	}
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("plugin exceeded its timeout of %s", p.TimeoutDuration)
+	}
+	return err
+}
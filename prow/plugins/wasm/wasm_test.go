@@ -0,0 +1,338 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wasm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sirupsen/logrus"
+
+	"k8s.io/test-infra/prow/github/fakegithub"
+	"k8s.io/test-infra/prow/io"
+	"k8s.io/test-infra/prow/plugins"
+)
+
+// The tests use modules assembled by hand, so that no WebAssembly toolchain is
+// needed. All of them import prow.call, export their memory, a bump allocator
+// and handle_event, and carry their request to the host in a data segment at
+// offset zero.
+
+func uleb(v uint32) []byte {
+	var out []byte
+	for {
+		b := byte(v & 0x7f)
+		v >>= 7
+		if v != 0 {
+			out = append(out, b|0x80)
+			continue
+		}
+		return append(out, b)
+	}
+}
+
+func sleb(v int32) []byte {
+	var out []byte
+	for {
+		b := byte(v & 0x7f)
+		v >>= 7
+		if (v == 0 && b&0x40 == 0) || (v == -1 && b&0x40 != 0) {
+			return append(out, b)
+		}
+		out = append(out, b|0x80)
+	}
+}
+
+func vec(items ...[]byte) []byte {
+	out := uleb(uint32(len(items)))
+	for _, item := range items {
+		out = append(out, item...)
+	}
+	return out
+}
+
+func name(s string) []byte {
+	return append(uleb(uint32(len(s))), s...)
+}
+
+func section(id byte, content []byte) []byte {
+	return append(append([]byte{id}, uleb(uint32(len(content)))...), content...)
+}
+
+func code(body []byte) []byte {
+	fn := append([]byte{0x00}, body...) // no locals
+	return append(uleb(uint32(len(fn))), fn...)
+}
+
+const (
+	i32 = 0x7f
+	i64 = 0x7e
+)
+
+// buildModule returns a module with the given body for handle_event and the
+// data placed at offset zero of its memory.
+func buildModule(handleEvent []byte, data []byte) []byte {
+	module := []byte{0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00}
+	module = append(module, section(1, vec(
+		[]byte{0x60, 0x02, i32, i32, 0x01, i64}, // call
+		[]byte{0x60, 0x01, i32, 0x01, i32},      // allocate
+		[]byte{0x60, 0x02, i32, i32, 0x01, i32}, // handle_event
+	))...)
+	module = append(module, section(2, vec(
+		append(append(name("prow"), name("call")...), 0x00, 0x00),
+	))...)
+	module = append(module, section(3, vec([]byte{0x01}, []byte{0x02}))...)
+	module = append(module, section(5, vec([]byte{0x00, 0x01}))...)
+	heap := append(append([]byte{i32, 0x01, 0x41}, sleb(4096)...), 0x0b)
+	module = append(module, section(6, vec(heap))...)
+	module = append(module, section(7, vec(
+		append(name("memory"), 0x02, 0x00),
+		append(name(allocateExport), 0x00, 0x01),
+		append(name(handleEventExport), 0x00, 0x02),
+	))...)
+	// allocate returns the current heap pointer and bumps it by the size.
+	allocate := []byte{0x23, 0x00, 0x23, 0x00, 0x20, 0x00, 0x6a, 0x24, 0x00, 0x0b}
+	module = append(module, section(10, vec(code(allocate), code(handleEvent)))...)
+	segment := append([]byte{0x00, 0x41, 0x00, 0x0b}, uleb(uint32(len(data)))...)
+	segment = append(segment, data...)
+	return append(module, section(11, vec(segment))...)
+}
+
+// callingModule returns a module that passes the data to prow.call and
+// returns zero.
+func callingModule(data string) []byte {
+	body := append([]byte{0x41, 0x00, 0x41}, sleb(int32(len(data)))...)
+	body = append(body, 0x10, 0x00, 0x1a, 0x41, 0x00, 0x0b) // call 0, drop, i32.const 0, end
+	return buildModule(body, []byte(data))
+}
+
+// spinningModule returns a module that never returns from handle_event.
+func spinningModule() []byte {
+	return buildModule([]byte{0x03, 0x40, 0x0c, 0x00, 0x0b, 0x41, 0x00, 0x0b}, nil)
+}
+
+// failingModule returns a module that returns the code from handle_event.
+func failingModule(code int32) []byte {
+	return buildModule(append(append([]byte{0x41}, sleb(code)...), 0x0b), nil)
+}
+
+func TestHandle(t *testing.T) {
+	addLabel := `{"method": "add_label", "params": {"org": "org", "repo": "repo", "number": 1, "label": "wasm"}}`
+	otherRepo := `{"method": "add_label", "params": {"org": "org", "repo": "other", "number": 1, "label": "wasm"}}`
+	testCases := []struct {
+		name           string
+		module         []byte
+		missing        bool
+		timeout        time.Duration
+		cpuLimit       time.Duration
+		expectedErr    string
+		expectedLabels []string
+	}{
+		{
+			name:           "plugin acts through the host API",
+			module:         callingModule(addLabel),
+			expectedLabels: []string{"org/repo#1:wasm"},
+		},
+		{
+			name:   "plugin may not act on other repos",
+			module: callingModule(otherRepo),
+		},
+		{
+			name:        "non-zero result is an error",
+			module:      failingModule(3),
+			expectedErr: "plugin returned 3",
+		},
+		{
+			name:        "cpu limit is enforced",
+			module:      spinningModule(),
+			cpuLimit:    50 * time.Millisecond,
+			expectedErr: "plugin exceeded its CPU limit of 50ms",
+		},
+		{
+			name:        "timeout is enforced",
+			module:      spinningModule(),
+			timeout:     50 * time.Millisecond,
+			expectedErr: "plugin exceeded its timeout of 50ms",
+		},
+		{
+			name:        "missing module",
+			missing:     true,
+			expectedErr: "failed to read",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if !tc.missing {
+				if err := os.WriteFile(filepath.Join(dir, "plugin.wasm"), tc.module, 0644); err != nil {
+					t.Fatalf("failed to write module: %v", err)
+				}
+			}
+			opener, err := io.NewOpener(context.Background(), "", "")
+			if err != nil {
+				t.Fatalf("failed to create opener: %v", err)
+			}
+			runtime := NewRuntime(opener, dir)
+			defer runtime.Close()
+
+			p := plugins.WasmPlugin{
+				Name:             "plugin",
+				MaxMemoryMB:      1,
+				TimeoutDuration:  10 * time.Second,
+				CPULimitDuration: 10 * time.Second,
+			}
+			if tc.timeout != 0 {
+				p.TimeoutDuration = tc.timeout
+			}
+			if tc.cpuLimit != 0 {
+				p.CPULimitDuration = tc.cpuLimit
+			}
+			ghc := fakegithub.NewFakeClient()
+			ghc.RepoLabelsExisting = []string{"wasm"}
+			event := Event{Type: "issues", GUID: "guid", Org: "org", Repo: "repo", Payload: json.RawMessage(`{}`)}
+
+			err = runtime.handle(ghc, &plugins.Configuration{}, logrus.WithField("plugin", p.Name), p, event)
+			if tc.expectedErr == "" && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tc.expectedErr != "" && (err == nil || !strings.Contains(err.Error(), tc.expectedErr)) {
+				t.Fatalf("expected error containing %q, got %v", tc.expectedErr, err)
+			}
+			if diff := cmp.Diff(tc.expectedLabels, ghc.IssueLabelsAdded); diff != "" {
+				t.Errorf("unexpected labels added (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestModuleIsReloaded(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "plugin.wasm")
+	if err := os.WriteFile(path, failingModule(1), 0644); err != nil {
+		t.Fatalf("failed to write module: %v", err)
+	}
+	opener, err := io.NewOpener(context.Background(), "", "")
+	if err != nil {
+		t.Fatalf("failed to create opener: %v", err)
+	}
+	runtime := NewRuntime(opener, dir)
+	defer runtime.Close()
+	p := plugins.WasmPlugin{Name: "plugin", MaxMemoryMB: 1, TimeoutDuration: 10 * time.Second, CPULimitDuration: 10 * time.Second}
+	handle := func() error {
+		return runtime.handle(fakegithub.NewFakeClient(), &plugins.Configuration{}, logrus.WithField("plugin", p.Name), p, Event{})
+	}
+
+	if err := handle(); err == nil || err.Error() != "plugin returned 1" {
+		t.Fatalf("expected the first version to run, got %v", err)
+	}
+	if err := os.WriteFile(path, failingModule(2), 0644); err != nil {
+		t.Fatalf("failed to write module: %v", err)
+	}
+	if err := handle(); err == nil || err.Error() != "plugin returned 1" {
+		t.Fatalf("expected the cached version to run, got %v", err)
+	}
+	runtime.refresh = 0
+	if err := handle(); err == nil || err.Error() != "plugin returned 2" {
+		t.Fatalf("expected the new version to run, got %v", err)
+	}
+}
+
+func TestHostCall(t *testing.T) {
+	event := Event{Org: "org", Repo: "repo"}
+	pluginConfig := &plugins.Configuration{
+		WasmPlugins: map[string][]plugins.WasmPlugin{
+			"org":      {{Name: "plugin", Settings: map[string]string{"from": "org"}}},
+			"org/repo": {{Name: "plugin", Settings: map[string]string{"from": "repo"}}, {Name: "other"}},
+		},
+	}
+	testCases := []struct {
+		name        string
+		request     string
+		expected    interface{}
+		expectedErr string
+		verify      func(*fakegithub.FakeClient) error
+	}{
+		{
+			name:     "settings of the repo take precedence",
+			request:  `{"method": "get_config"}`,
+			expected: map[string]string{"from": "repo"},
+		},
+		{
+			name:    "comment",
+			request: `{"method": "create_comment", "params": {"org": "org", "repo": "repo", "number": 2, "body": "hi"}}`,
+			verify: func(ghc *fakegithub.FakeClient) error {
+				if len(ghc.IssueComments[2]) != 1 || ghc.IssueComments[2][0].Body != "hi" {
+					return fmt.Errorf("expected a comment, got %v", ghc.IssueComments)
+				}
+				return nil
+			},
+		},
+		{
+			name:    "status",
+			request: `{"method": "create_status", "params": {"org": "org", "repo": "repo", "sha": "abc", "status": {"state": "success", "context": "wasm"}}}`,
+			verify: func(ghc *fakegithub.FakeClient) error {
+				if statuses := ghc.CreatedStatuses["abc"]; len(statuses) != 1 || statuses[0].Context != "wasm" {
+					return fmt.Errorf("expected a status, got %v", ghc.CreatedStatuses)
+				}
+				return nil
+			},
+		},
+		{
+			name:        "other repos are off limits",
+			request:     `{"method": "create_comment", "params": {"org": "other", "repo": "repo", "number": 2, "body": "hi"}}`,
+			expectedErr: "plugins may only act on the repository of the event, not other/repo",
+		},
+		{
+			name:        "unknown method",
+			request:     `{"method": "merge"}`,
+			expectedErr: `unknown method "merge"`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ghc := fakegithub.NewFakeClient()
+			inv := &invocation{ghc: ghc, pluginConfig: pluginConfig, log: logrus.WithField("plugin", "plugin"), plugin: "plugin", event: event}
+			result, err := inv.call([]byte(tc.request))
+			if tc.expectedErr != "" {
+				if err == nil || err.Error() != tc.expectedErr {
+					t.Fatalf("expected error %q, got %v", tc.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tc.expected != nil {
+				if diff := cmp.Diff(tc.expected, result); diff != "" {
+					t.Errorf("unexpected result (-want +got):\n%s", diff)
+				}
+			}
+			if tc.verify != nil {
+				if err := tc.verify(ghc); err != nil {
+					t.Error(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,285 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package subscriber
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+
+	prowapi "k8s.io/test-infra/prow/apis/prowjobs/v1"
+	"k8s.io/test-infra/prow/config"
+)
+
+const (
+	// cloudEventsContentType is the content type of CloudEvents in structured mode.
+	cloudEventsContentType = "application/cloudevents+json"
+	// cloudEventsAttributePrefix prefixes the attributes of CloudEvents in binary mode.
+	cloudEventsAttributePrefix = "ce-"
+	// contentTypeAttribute holds the content type of the message data.
+	contentTypeAttribute = "content-type"
+	// pubSubMessageEventType is the type of the CloudEvents that other
+	// messages are mapped to, like Eventarc does.
+	pubSubMessageEventType = "google.cloud.pubsub.topic.v1.messagePublished"
+)
+
+// CloudEvent is a CloudEvent received via Pub/Sub, see https://github.com/cloudevents/spec.
+// It is what the env templates of config.CloudEventMapping are executed on.
+type CloudEvent struct {
+	SpecVersion     string `json:"specversion"`
+	ID              string `json:"id"`
+	Type            string `json:"type"`
+	Source          string `json:"source"`
+	Subject         string `json:"subject,omitempty"`
+	Time            string `json:"time,omitempty"`
+	DataContentType string `json:"datacontenttype,omitempty"`
+	// Data is the payload of the event. Payloads with a JSON content type are
+	// decoded, others are kept as a string.
+	Data interface{} `json:"data,omitempty"`
+	// DataBase64 is the binary payload of structured events, if any.
+	DataBase64 []byte `json:"data_base64,omitempty"`
+	// Attributes are the attributes of the Pub/Sub message.
+	Attributes map[string]string `json:"-"`
+}
+
+func isCloudEvent(attrs map[string]string) bool {
+	return strings.HasPrefix(attrs[contentTypeAttribute], cloudEventsContentType) || attrs[cloudEventsAttributePrefix+"specversion"] != ""
+}
+
+// cloudEventFromMessage reads a CloudEvent in structured or binary mode.
+func cloudEventFromMessage(msg messageInterface) (*CloudEvent, error) {
+	attrs := msg.getAttributes()
+	var e CloudEvent
+	if strings.HasPrefix(attrs[contentTypeAttribute], cloudEventsContentType) {
+		if err := json.Unmarshal(msg.getPayload(), &e); err != nil {
+			return nil, fmt.Errorf("invalid structured CloudEvent: %w", err)
+		}
+		if e.Data == nil && len(e.DataBase64) > 0 {
+			e.Data = decodeData(e.DataBase64, e.DataContentType)
+		}
+	} else {
+		attr := func(name string) string { return attrs[cloudEventsAttributePrefix+name] }
+		e = CloudEvent{
+			SpecVersion:     attr("specversion"),
+			ID:              attr("id"),
+			Type:            attr("type"),
+			Source:          attr("source"),
+			Subject:         attr("subject"),
+			Time:            attr("time"),
+			DataContentType: attrs[contentTypeAttribute],
+			Data:            decodeData(msg.getPayload(), attrs[contentTypeAttribute]),
+		}
+	}
+	e.Attributes = attrs
+	if e.SpecVersion == "" || e.ID == "" || e.Type == "" || e.Source == "" {
+		return nil, errors.New("CloudEvent must have specversion, id, type and source")
+	}
+	return &e, nil
+}
+
+// pubSubMessageFromMessage maps a message that is neither a CloudEvent nor a
+// ProwJobEvent to a CloudEvent. Pub/Sub messages often don't declare a content
+// type, so their data is also decoded if it has none but is valid JSON.
+func pubSubMessageFromMessage(msg messageInterface, subscription string) *CloudEvent {
+	attrs := msg.getAttributes()
+	contentType := attrs[contentTypeAttribute]
+	data := decodeData(msg.getPayload(), contentType)
+	if contentType == "" {
+		data = decodeData(msg.getPayload(), "application/json")
+	}
+	return &CloudEvent{
+		SpecVersion:     "1.0",
+		ID:              msg.getID(),
+		Type:            pubSubMessageEventType,
+		Source:          "//pubsub.googleapis.com/" + subscription,
+		DataContentType: contentType,
+		Data:            data,
+		Attributes:      attrs,
+	}
+}
+
+// isJSONContentType tells whether data of the content type is JSON, e.g.
+// application/json or application/vnd.api+json; charset=utf-8.
+func isJSONContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/json" || mediaType == "text/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// decodeData decodes data with a JSON content type and keeps other data, or
+// data that fails to decode, as a string.
+func decodeData(data []byte, contentType string) interface{} {
+	if len(data) == 0 {
+		return nil
+	}
+	if !isJSONContentType(contentType) {
+		return string(data)
+	}
+	var decoded interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return string(data)
+	}
+	return decoded
+}
+
+// executeTemplates executes templates of a mapping on the event. The kind of
+// the templates, e.g. "env " or "refs.", prefixes their names in errors.
+func executeTemplates(templates map[string]string, kind string, e *CloudEvent) (map[string]string, error) {
+	if len(templates) == 0 {
+		return nil, nil
+	}
+	values := make(map[string]string, len(templates))
+	for name, value := range templates {
+		tmpl, err := template.New(name).Option("missingkey=error").Parse(value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse template for %s%s: %w", kind, name, err)
+		}
+		b := &bytes.Buffer{}
+		if err := tmpl.Execute(b, e); err != nil {
+			return nil, fmt.Errorf("failed to execute template for %s%s: %w", kind, name, err)
+		}
+		values[name] = b.String()
+	}
+	return values, nil
+}
+
+// cloudEventRefs executes the refs templates of a mapping on the event.
+func cloudEventRefs(refs *config.CloudEventRefs, e *CloudEvent) (*prowapi.Refs, error) {
+	if refs == nil {
+		return nil, nil
+	}
+	values, err := executeTemplates(refs.Templates(), "refs.", e)
+	if err != nil {
+		return nil, err
+	}
+	result := &prowapi.Refs{
+		Org:     values["org"],
+		Repo:    values["repo"],
+		BaseRef: values["base_ref"],
+		BaseSHA: values["base_sha"],
+	}
+	if values["pull_number"] != "" {
+		number, err := strconv.Atoi(values["pull_number"])
+		if err != nil {
+			return nil, fmt.Errorf("invalid refs.pull_number %q: %w", values["pull_number"], err)
+		}
+		result.Pulls = []prowapi.Pull{{Number: number, SHA: values["pull_sha"]}}
+	}
+	return result, nil
+}
+
+// cloudEventJobHandler returns the handler for the job type of a mapping.
+func cloudEventJobHandler(jobType prowapi.ProwJobType) (jobHandler, error) {
+	switch jobType {
+	case "", prowapi.PeriodicJob:
+		return &periodicJobHandler{}, nil
+	case prowapi.PresubmitJob:
+		return &presubmitJobHandler{}, nil
+	case prowapi.PostsubmitJob:
+		return &postsubmitJobHandler{}, nil
+	}
+	return nil, fmt.Errorf("unsupported job type %q", jobType)
+}
+
+// handleCloudEvent triggers the jobs that the CloudEvent maps to. Messages that
+// aren't CloudEvents are mapped as Pub/Sub message events.
+func (s *Subscriber) handleCloudEvent(l *logrus.Entry, msg messageInterface, subscription string, trigger config.PubSubTrigger) error {
+	countError := func(errorType string) {
+		s.Metrics.ErrorCounter.With(prometheus.Labels{
+			subscriptionLabel: subscription,
+			errorTypeLabel:    errorType,
+		}).Inc()
+	}
+
+	var e *CloudEvent
+	if isCloudEvent(msg.getAttributes()) {
+		var err error
+		if e, err = cloudEventFromMessage(msg); err != nil {
+			l.WithError(err).Error("failed to read CloudEvent")
+			countError("malformed-message")
+			return err
+		}
+	} else {
+		e = pubSubMessageFromMessage(msg, subscription)
+	}
+	l = l.WithFields(logrus.Fields{
+		"cloudevent-id":     e.ID,
+		"cloudevent-type":   e.Type,
+		"cloudevent-source": e.Source,
+	})
+
+	var matched bool
+	var errs []error
+	for _, mapping := range trigger.CloudEvents {
+		if !mapping.Matches(e.Type, e.Source) {
+			continue
+		}
+		matched = true
+		if !mapping.AuthConfig.IsAuthorized(e.Source, e.Subject) {
+			l.WithField("jobs", mapping.Jobs).Info("CloudEvent is not allowed to trigger jobs")
+			countError("unauthorized-cloudevent")
+			errs = append(errs, fmt.Errorf("event from %s is not allowed to trigger %s", e.Source, strings.Join(mapping.Jobs, ", ")))
+			continue
+		}
+		jh, err := cloudEventJobHandler(mapping.JobType)
+		if err != nil {
+			l.WithError(err).Info("failed to trigger jobs")
+			countError("unsupported-event-type")
+			errs = append(errs, err)
+			continue
+		}
+		envs, err := executeTemplates(mapping.Envs, "env ", e)
+		if err != nil {
+			l.WithError(err).Info("failed to render envs")
+			countError("malformed-message")
+			errs = append(errs, err)
+			continue
+		}
+		refs, err := cloudEventRefs(mapping.Refs, e)
+		if err != nil {
+			l.WithError(err).Info("failed to render refs")
+			countError("malformed-message")
+			errs = append(errs, err)
+			continue
+		}
+		for _, job := range mapping.Jobs {
+			pe := ProwJobEvent{Name: job, Refs: refs, Envs: envs}
+			if err := s.createProwJob(l, jh, pe, trigger.AllowedClusters); err != nil {
+				l.WithError(err).Info("failed to create Prow Job")
+				countError("failed-handle-prowjob")
+				errs = append(errs, err)
+			}
+		}
+	}
+	if !matched {
+		l.Info("Unsupported CloudEvent")
+		countError("unsupported-event-type")
+		return fmt.Errorf("no cloud_events mapping for type %s from %s", e.Type, e.Source)
+	}
+	return utilerrors.NewAggregate(errs)
+}
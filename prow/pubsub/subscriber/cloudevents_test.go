@@ -0,0 +1,273 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package subscriber
+
+import (
+	"testing"
+
+	"cloud.google.com/go/pubsub"
+	"github.com/google/go-cmp/cmp"
+	v1 "k8s.io/api/core/v1"
+	clienttesting "k8s.io/client-go/testing"
+
+	prowapi "k8s.io/test-infra/prow/apis/prowjobs/v1"
+	"k8s.io/test-infra/prow/client/clientset/versioned/fake"
+	"k8s.io/test-infra/prow/config"
+)
+
+func TestHandleCloudEvent(t *testing.T) {
+	structured := func(event string) *pubSubMessage {
+		return &pubSubMessage{pubsub.Message{
+			ID:         "id",
+			Data:       []byte(event),
+			Attributes: map[string]string{"content-type": "application/cloudevents+json; charset=utf-8"},
+		}}
+	}
+	binaryWithContentType := func(eventType, source, subject, contentType, data string) *pubSubMessage {
+		return &pubSubMessage{pubsub.Message{
+			ID:   "id",
+			Data: []byte(data),
+			Attributes: map[string]string{
+				"ce-specversion": "1.0",
+				"ce-id":          "1",
+				"ce-type":        eventType,
+				"ce-source":      source,
+				"ce-subject":     subject,
+				"content-type":   contentType,
+			},
+		}}
+	}
+	binary := func(eventType, source, subject, data string) *pubSubMessage {
+		return binaryWithContentType(eventType, source, subject, "application/json", data)
+	}
+	message := func(attrs map[string]string, data string) *pubSubMessage {
+		return &pubSubMessage{pubsub.Message{ID: "id", Data: []byte(data), Attributes: attrs}}
+	}
+	mappings := []config.CloudEventMapping{
+		{
+			Type:       "com.example.image.pushed",
+			Source:     "//artifactregistry.googleapis.com/projects/*",
+			Jobs:       []string{"test-image", "scan-image"},
+			Envs:       map[string]string{"IMAGE": "{{.Data.tag}}", "SUBJECT": "{{.Subject}}"},
+			AuthConfig: &config.CloudEventAuthConfig{Subjects: []string{"images/allowed*"}},
+		},
+		{
+			Type:       "com.example.release",
+			Jobs:       []string{"release"},
+			AuthConfig: &config.CloudEventAuthConfig{AllowAnyone: true},
+		},
+		{
+			Type:       "com.example.note",
+			Jobs:       []string{"release"},
+			Envs:       map[string]string{"NOTE": "{{.Data}}"},
+			AuthConfig: &config.CloudEventAuthConfig{AllowAnyone: true},
+		},
+		{
+			Type:    "com.example.change.proposed",
+			JobType: prowapi.PresubmitJob,
+			Jobs:    []string{"pull-test"},
+			Refs: &config.CloudEventRefs{
+				Org:        "{{.Data.org}}",
+				Repo:       "{{.Data.repo}}",
+				BaseRef:    "main",
+				BaseSHA:    "{{.Data.base}}",
+				PullNumber: "{{.Data.number}}",
+				PullSHA:    "{{.Data.head}}",
+			},
+			AuthConfig: &config.CloudEventAuthConfig{AllowAnyone: true},
+		},
+		{
+			Type:    "com.example.change.merged",
+			JobType: prowapi.PostsubmitJob,
+			Jobs:    []string{"post-test"},
+			Refs: &config.CloudEventRefs{
+				Org:     "{{.Data.org}}",
+				Repo:    "{{.Data.repo}}",
+				BaseRef: "main",
+				BaseSHA: "{{.Data.base}}",
+			},
+			AuthConfig: &config.CloudEventAuthConfig{AllowAnyone: true},
+		},
+		{
+			Type:       "google.cloud.pubsub.topic.v1.messagePublished",
+			Source:     "//pubsub.googleapis.com/sub",
+			Jobs:       []string{"release"},
+			Envs:       map[string]string{"VERSION": "{{.Data.version}}", "KIND": `{{index .Attributes "kind"}}`},
+			AuthConfig: &config.CloudEventAuthConfig{AllowAnyone: true},
+		},
+	}
+
+	testCases := []struct {
+		name string
+		msg  *pubSubMessage
+
+		expectedErr  string
+		expectedJobs map[string]map[string]string
+		expectedRefs map[string]*prowapi.Refs
+	}{
+		{
+			name: "structured event triggers jobs",
+			msg:  structured(`{"specversion": "1.0", "id": "1", "type": "com.example.image.pushed", "source": "//artifactregistry.googleapis.com/projects/p", "subject": "images/allowed", "data": {"tag": "v1"}}`),
+			expectedJobs: map[string]map[string]string{
+				"test-image": {"IMAGE": "v1", "SUBJECT": "images/allowed"},
+				"scan-image": {"IMAGE": "v1", "SUBJECT": "images/allowed"},
+			},
+		},
+		{
+			name: "structured event with base64 data triggers jobs",
+			msg:  structured(`{"specversion": "1.0", "id": "1", "type": "com.example.image.pushed", "source": "//artifactregistry.googleapis.com/projects/p", "subject": "images/allowed-too", "datacontenttype": "application/json", "data_base64": "eyJ0YWciOiAidjIifQ=="}`),
+			expectedJobs: map[string]map[string]string{
+				"test-image": {"IMAGE": "v2", "SUBJECT": "images/allowed-too"},
+				"scan-image": {"IMAGE": "v2", "SUBJECT": "images/allowed-too"},
+			},
+		},
+		{
+			name:         "binary event triggers jobs",
+			msg:          binary("com.example.release", "//example.com", "", `{"version": "v1.2.3"}`),
+			expectedJobs: map[string]map[string]string{"release": {}},
+		},
+		{
+			name:        "unauthorized event is rejected",
+			msg:         binary("com.example.image.pushed", "//artifactregistry.googleapis.com/projects/p", "images/other", `{"tag": "v1"}`),
+			expectedErr: "event from //artifactregistry.googleapis.com/projects/p is not allowed to trigger test-image, scan-image",
+		},
+		{
+			name:        "source must match",
+			msg:         binary("com.example.image.pushed", "//example.com", "images/allowed", `{"tag": "v1"}`),
+			expectedErr: "no cloud_events mapping for type com.example.image.pushed from //example.com",
+		},
+		{
+			name:        "missing data for env is an error",
+			msg:         binary("com.example.image.pushed", "//artifactregistry.googleapis.com/projects/p", "images/allowed", `{}`),
+			expectedErr: `failed to execute template for env IMAGE: template: IMAGE:1:7: executing "IMAGE" at <.Data.tag>: map has no entry for key "tag"`,
+		},
+		{
+			name:         "binary event without JSON content type keeps data as a string",
+			msg:          binaryWithContentType("com.example.note", "//example.com", "", "text/plain", `{"not": "decoded"}`),
+			expectedJobs: map[string]map[string]string{"release": {"NOTE": `{"not": "decoded"}`}},
+		},
+		{
+			name:         "event triggers presubmits with refs",
+			msg:          binary("com.example.change.proposed", "//example.com", "", `{"org": "org", "repo": "repo", "base": "abc", "number": "42", "head": "def"}`),
+			expectedJobs: map[string]map[string]string{"pull-test": {}},
+			expectedRefs: map[string]*prowapi.Refs{
+				"pull-test": {Org: "org", Repo: "repo", BaseRef: "main", BaseSHA: "abc", Pulls: []prowapi.Pull{{Number: 42, SHA: "def"}}},
+			},
+		},
+		{
+			name:        "invalid pull number is an error",
+			msg:         binary("com.example.change.proposed", "//example.com", "", `{"org": "org", "repo": "repo", "base": "abc", "number": "x", "head": "def"}`),
+			expectedErr: `invalid refs.pull_number "x": strconv.Atoi: parsing "x": invalid syntax`,
+		},
+		{
+			name:         "event triggers postsubmits with refs",
+			msg:          binary("com.example.change.merged", "//example.com", "", `{"org": "org", "repo": "repo", "base": "abc"}`),
+			expectedJobs: map[string]map[string]string{"post-test": {}},
+			expectedRefs: map[string]*prowapi.Refs{
+				"post-test": {Org: "org", Repo: "repo", BaseRef: "main", BaseSHA: "abc"},
+			},
+		},
+		{
+			name:         "generic message triggers jobs",
+			msg:          message(map[string]string{"kind": "release"}, `{"version": "v1.2.3"}`),
+			expectedJobs: map[string]map[string]string{"release": {"VERSION": "v1.2.3", "KIND": "release"}},
+		},
+		{
+			name:        "event without type is malformed",
+			msg:         structured(`{"specversion": "1.0", "id": "1", "source": "//example.com"}`),
+			expectedErr: "CloudEvent must have specversion, id, type and source",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			periodic := func(name string) config.Periodic {
+				return config.Periodic{JobBase: config.JobBase{
+					Name: name,
+					Spec: &v1.PodSpec{Containers: []v1.Container{{Image: "image"}}},
+				}}
+			}
+			presubmits := []config.Presubmit{{JobBase: config.JobBase{
+				Name: "pull-test",
+				Spec: &v1.PodSpec{Containers: []v1.Container{{Image: "image"}}},
+			}}}
+			if err := config.SetPresubmitRegexes(presubmits); err != nil {
+				t.Fatalf("failed to set presubmit regexes: %v", err)
+			}
+			postsubmits := []config.Postsubmit{{JobBase: config.JobBase{
+				Name: "post-test",
+				Spec: &v1.PodSpec{Containers: []v1.Container{{Image: "image"}}},
+			}}}
+			if err := config.SetPostsubmitRegexes(postsubmits); err != nil {
+				t.Fatalf("failed to set postsubmit regexes: %v", err)
+			}
+			ca := &config.Agent{}
+			ca.Set(&config.Config{
+				JobConfig: config.JobConfig{
+					Periodics:         []config.Periodic{periodic("test-image"), periodic("scan-image"), periodic("release")},
+					PresubmitsStatic:  map[string][]config.Presubmit{"org/repo": presubmits},
+					PostsubmitsStatic: map[string][]config.Postsubmit{"org/repo": postsubmits},
+				},
+				ProwConfig: config.ProwConfig{ProwJobNamespace: "prowjobs"},
+			})
+			fakeProwJobClient := fake.NewSimpleClientset()
+			s := Subscriber{
+				Metrics:       NewMetrics(),
+				ProwJobClient: fakeProwJobClient.ProwV1().ProwJobs("prowjobs"),
+				ConfigAgent:   ca,
+				Reporter:      &fakeReporter{},
+			}
+
+			err := s.handleMessage(tc.msg, "sub", config.PubSubTrigger{AllowedClusters: []string{"*"}, CloudEvents: mappings})
+			var errMsg string
+			if err != nil {
+				errMsg = err.Error()
+			}
+			if errMsg != tc.expectedErr {
+				t.Errorf("expected error %q, got %q", tc.expectedErr, errMsg)
+			}
+
+			jobs := map[string]map[string]string{}
+			refs := map[string]*prowapi.Refs{}
+			for _, action := range fakeProwJobClient.Fake.Actions() {
+				if action, ok := action.(clienttesting.CreateActionImpl); ok {
+					pj := action.Object.(*prowapi.ProwJob)
+					envs := map[string]string{}
+					for _, env := range pj.Spec.PodSpec.Containers[0].Env {
+						envs[env.Name] = env.Value
+					}
+					jobs[pj.Spec.Job] = envs
+					if pj.Spec.Refs != nil {
+						refs[pj.Spec.Job] = pj.Spec.Refs
+					}
+				}
+			}
+			if tc.expectedJobs == nil {
+				tc.expectedJobs = map[string]map[string]string{}
+			}
+			if diff := cmp.Diff(tc.expectedJobs, jobs); diff != "" {
+				t.Errorf("unexpected jobs (-want +got):\n%s", diff)
+			}
+			if tc.expectedRefs == nil {
+				tc.expectedRefs = map[string]*prowapi.Refs{}
+			}
+			if diff := cmp.Diff(tc.expectedRefs, refs); diff != "" {
+				t.Errorf("unexpected refs (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
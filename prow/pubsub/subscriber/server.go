@@ -111,7 +111,8 @@ func (s *PullServer) handlePulls(ctx context.Context, projectSubscriptions confi
 	// Since config might change we need be able to cancel the current run
 	errGroup, derivedCtx := errgroup.WithContext(ctx)
 	for _, topics := range projectSubscriptions {
-		project, subscriptions, allowedClusters := topics.Project, topics.Topics, topics.AllowedClusters
+		trigger := topics
+		project, subscriptions := topics.Project, topics.Topics
 		client, err := s.Client.new(ctx, project)
 		if err != nil {
 			return errGroup, derivedCtx, err
@@ -126,7 +127,7 @@ func (s *PullServer) handlePulls(ctx context.Context, projectSubscriptions confi
 				logger.Info("Listening for subscription")
 				defer logger.Warn("Stopped Listening for subscription")
 				err := sub.receive(derivedCtx, func(ctx context.Context, msg messageInterface) {
-					if err = s.Subscriber.handleMessage(msg, sub.string(), allowedClusters); err != nil {
+					if err = s.Subscriber.handleMessage(msg, sub.string(), trigger); err != nil {
 						s.Subscriber.Metrics.ACKMessageCounter.With(prometheus.Labels{subscriptionLabel: sub.string()}).Inc()
 					} else {
 						s.Subscriber.Metrics.NACKMessageCounter.With(prometheus.Labels{subscriptionLabel: sub.string()}).Inc()
@@ -365,12 +365,16 @@ func extractFromAttribute(attrs map[string]string, key string) (string, error) {
 	return value, nil
 }
 
-func (s *Subscriber) handleMessage(msg messageInterface, subscription string, allowedClusters []string) error {
+func (s *Subscriber) handleMessage(msg messageInterface, subscription string, trigger config.PubSubTrigger) error {
 	l := logrus.WithFields(logrus.Fields{
 		"pubsub-subscription": subscription,
 		"pubsub-id":           msg.getID()})
 	s.Metrics.MessageCounter.With(prometheus.Labels{subscriptionLabel: subscription}).Inc()
 	l.Info("Received message")
+	// Messages other than ProwJobEvents are mapped to jobs if configured.
+	if attrs := msg.getAttributes(); isCloudEvent(attrs) || (attrs[ProwEventType] == "" && len(trigger.CloudEvents) > 0) {
+		return s.handleCloudEvent(l, msg, subscription, trigger)
+	}
 	eType, err := extractFromAttribute(msg.getAttributes(), ProwEventType)
 	if err != nil {
 		l.WithError(err).Error("failed to read message")
@@ -397,7 +401,7 @@ func (s *Subscriber) handleMessage(msg messageInterface, subscription string, al
 		}).Inc()
 		return fmt.Errorf("unsupported event type: %s", eType)
 	}
-	if err = s.handleProwJob(l, jh, msg, subscription, eType, allowedClusters); err != nil {
+	if err = s.handleProwJob(l, jh, msg, subscription, eType, trigger.AllowedClusters); err != nil {
 		l.WithError(err).Info("failed to create Prow Job")
 		s.Metrics.ErrorCounter.With(prometheus.Labels{
 			subscriptionLabel: subscription,
@@ -445,11 +449,16 @@ func tryGetCloneURIAndHost(pe ProwJobEvent) (cloneURI, host string) {
 func (s *Subscriber) handleProwJob(l *logrus.Entry, jh jobHandler, msg messageInterface, subscription, eType string, allowedClusters []string) error {
 
 	var pe ProwJobEvent
-	var prowJob prowapi.ProwJob
 
 	if err := pe.FromPayload(msg.getPayload()); err != nil {
 		return err
 	}
+	return s.createProwJob(l, jh, pe, allowedClusters)
+}
+
+// createProwJob creates the ProwJob requested by the event and reports it.
+func (s *Subscriber) createProwJob(l *logrus.Entry, jh jobHandler, pe ProwJobEvent, allowedClusters []string) error {
+	var prowJob prowapi.ProwJob
 
 	reportProwJob := func(pj *prowapi.ProwJob, state v1.ProwJobState, err error) {
 		pj.Status.State = state
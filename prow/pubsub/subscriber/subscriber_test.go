@@ -318,7 +318,7 @@ func TestHandleMessage(t *testing.T) {
 				m.ID = "id"
 				tc.msg = &pubSubMessage{*m}
 			}
-			if err := s.handleMessage(tc.msg, "", []string{"*"}); err != nil {
+			if err := s.handleMessage(tc.msg, "", config.PubSubTrigger{AllowedClusters: []string{"*"}}); err != nil {
 				if err.Error() != tc.err {
 					t1.Errorf("Expected error '%v' got '%v'", tc.err, err.Error())
 				} else if tc.err == "" {
@@ -72,6 +72,7 @@ type Client struct {
 
 const (
 	chatPostMessage = "https://slack.com/api/chat.postMessage"
+	chatUpdate      = "https://slack.com/api/chat.update"
 
 	botName      = "prow"
 	botIconEmoji = ":prow:"
@@ -111,10 +112,19 @@ func (sl *Client) urlValues() *url.Values {
 	return &uv
 }
 
-func (sl *Client) postMessage(url string, uv *url.Values) error {
+// MessageRef identifies a message that was posted to a channel.
+type MessageRef struct {
+	// Channel is the ID of the channel, which is not necessarily the name the
+	// message was posted to.
+	Channel string `json:"channel"`
+	// Timestamp is the ts of the message, which Slack uses as its ID.
+	Timestamp string `json:"ts"`
+}
+
+func (sl *Client) postMessage(url string, uv *url.Values) (*MessageRef, error) {
 	resp, err := http.PostForm(url, *uv)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer resp.Body.Close()
 
@@ -122,17 +132,18 @@ func (sl *Client) postMessage(url string, uv *url.Values) error {
 	apiResponse := struct {
 		Ok    bool   `json:"ok"`
 		Error string `json:"error"`
+		MessageRef
 	}{}
 
 	if err := json.Unmarshal(body, &apiResponse); err != nil {
-		return fmt.Errorf("API returned invalid JSON (%q): %w", string(body), err)
+		return nil, fmt.Errorf("API returned invalid JSON (%q): %w", string(body), err)
 	}
 
 	if resp.StatusCode != 200 || !apiResponse.Ok {
-		return fmt.Errorf("request failed: %s", apiResponse.Error)
+		return nil, fmt.Errorf("request failed: %s", apiResponse.Error)
 	}
 
-	return nil
+	return &apiResponse.MessageRef, nil
 }
 
 // WriteMessage adds text to channel
@@ -146,8 +157,78 @@ func (sl *Client) WriteMessage(text, channel string) error {
 	uv.Add("channel", channel)
 	uv.Add("text", text)
 
-	if err := sl.postMessage(chatPostMessage, uv); err != nil {
+	if _, err := sl.postMessage(chatPostMessage, uv); err != nil {
 		return fmt.Errorf("failed to post message to %s: %w", channel, err)
 	}
 	return nil
 }
+
+// WriteMessageWithBlocks adds a message made of Block Kit blocks to channel. The
+// text is shown in notifications and by clients that cannot display blocks.
+func (sl *Client) WriteMessageWithBlocks(text string, blocks []Block, channel string) error {
+	_, err := sl.PostMessage(text, blocks, channel, "")
+	return err
+}
+
+// PostMessage adds a message to channel and returns a reference to it. If threadTS
+// is set, the message is a reply in the thread of the message with that timestamp.
+// Blocks are optional.
+func (sl *Client) PostMessage(text string, blocks []Block, channel, threadTS string) (*MessageRef, error) {
+	sl.log("PostMessage", text, channel, threadTS)
+	if sl.fake {
+		return &MessageRef{Channel: channel}, nil
+	}
+
+	var uv = sl.urlValues()
+	uv.Add("channel", channel)
+	uv.Add("text", text)
+	if err := addBlocks(uv, blocks); err != nil {
+		return nil, err
+	}
+	if threadTS != "" {
+		uv.Add("thread_ts", threadTS)
+	}
+
+	ref, err := sl.postMessage(chatPostMessage, uv)
+	if err != nil {
+		return nil, fmt.Errorf("failed to post message to %s: %w", channel, err)
+	}
+	return ref, nil
+}
+
+// UpdateMessage replaces the text and blocks of a message. Passing no blocks
+// removes those the message had.
+func (sl *Client) UpdateMessage(ref MessageRef, text string, blocks []Block) error {
+	sl.log("UpdateMessage", ref.Channel, ref.Timestamp, text)
+	if sl.fake {
+		return nil
+	}
+
+	var uv = sl.urlValues()
+	uv.Add("channel", ref.Channel)
+	uv.Add("ts", ref.Timestamp)
+	uv.Add("text", text)
+	if len(blocks) == 0 {
+		// chat.update keeps the blocks of a message unless they are overwritten.
+		uv.Add("blocks", "[]")
+	} else if err := addBlocks(uv, blocks); err != nil {
+		return err
+	}
+
+	if _, err := sl.postMessage(chatUpdate, uv); err != nil {
+		return fmt.Errorf("failed to update message %s in %s: %w", ref.Timestamp, ref.Channel, err)
+	}
+	return nil
+}
+
+func addBlocks(uv *url.Values, blocks []Block) error {
+	if len(blocks) == 0 {
+		return nil
+	}
+	rawBlocks, err := json.Marshal(blocks)
+	if err != nil {
+		return fmt.Errorf("failed to marshal blocks: %w", err)
+	}
+	uv.Add("blocks", string(rawBlocks))
+	return nil
+}
@@ -0,0 +1,181 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package slack
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Block is a Block Kit layout block, see https://api.slack.com/reference/block-kit/blocks.
+// Only the section and actions blocks are supported.
+type Block struct {
+	Type     string    `json:"type"`
+	Text     *Text     `json:"text,omitempty"`
+	Elements []Element `json:"elements,omitempty"`
+}
+
+// Text is a Block Kit text object.
+type Text struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// Element is an interactive Block Kit element. Only buttons are supported.
+type Element struct {
+	Type     string `json:"type"`
+	Text     *Text  `json:"text,omitempty"`
+	ActionID string `json:"action_id,omitempty"`
+	Value    string `json:"value,omitempty"`
+	URL      string `json:"url,omitempty"`
+	Style    string `json:"style,omitempty"`
+}
+
+// MaxSectionTextLength is the maximum number of characters Slack accepts in the
+// text of a section block.
+const MaxSectionTextLength = 3000
+
+// NewSectionBlock returns a section block showing the given mrkdwn text. Text
+// longer than MaxSectionTextLength, e.g. because of long job names or
+// descriptions, is truncated.
+func NewSectionBlock(text string) Block {
+	return Block{Type: "section", Text: &Text{Type: "mrkdwn", Text: truncate(text, MaxSectionTextLength)}}
+}
+
+// truncate shortens text to at most max characters, ending it with an
+// ellipsis if it was cut.
+func truncate(text string, max int) string {
+	runes := []rune(text)
+	if len(runes) <= max {
+		return text
+	}
+	return string(runes[:max-1]) + "…"
+}
+
+// NewActionsBlock returns an actions block holding the given elements.
+func NewActionsBlock(elements ...Element) Block {
+	return Block{Type: "actions", Elements: elements}
+}
+
+// NewButton returns a button that sends the action ID and value to the interactivity
+// endpoint of the Slack app when clicked. If url is set, the button also opens it.
+func NewButton(text, actionID, value, url string) Element {
+	return Element{
+		Type:     "button",
+		Text:     &Text{Type: "plain_text", Text: text},
+		ActionID: actionID,
+		Value:    value,
+		URL:      url,
+	}
+}
+
+// InteractionPayload is the payload Slack sends to the interactivity endpoint of
+// an app when a user clicks a button, see https://api.slack.com/reference/interaction-payloads/block-actions.
+type InteractionPayload struct {
+	Type        string   `json:"type"`
+	User        User     `json:"user"`
+	Channel     Channel  `json:"channel"`
+	Actions     []Action `json:"actions"`
+	ResponseURL string   `json:"response_url"`
+}
+
+// User is the Slack user who took an action.
+type User struct {
+	ID       string `json:"id"`
+	Username string `json:"username"`
+}
+
+// Channel is the Slack channel an action was taken in.
+type Channel struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// Action is an action taken on an interactive element.
+type Action struct {
+	ActionID string `json:"action_id"`
+	Value    string `json:"value"`
+}
+
+// InteractionResponse is a message sent to the response URL of an interaction.
+type InteractionResponse struct {
+	// ResponseType is either "ephemeral", to only show the message to the user who
+	// took the action, or "in_channel".
+	ResponseType    string `json:"response_type"`
+	ReplaceOriginal bool   `json:"replace_original"`
+	Text            string `json:"text"`
+}
+
+// maxRequestAge is how old the timestamp of a signed request may be before the
+// request is rejected as a possible replay.
+const maxRequestAge = 5 * time.Minute
+
+// VerifySignature verifies that a request was sent by Slack, see
+// https://api.slack.com/authentication/verifying-requests-from-slack.
+func VerifySignature(header http.Header, body []byte, signingSecret []byte, now time.Time) error {
+	timestamp := header.Get("X-Slack-Request-Timestamp")
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid X-Slack-Request-Timestamp %q: %w", timestamp, err)
+	}
+	if age := now.Sub(time.Unix(seconds, 0)); age > maxRequestAge || age < -maxRequestAge {
+		return fmt.Errorf("request timestamp is %s off", age)
+	}
+
+	signature := header.Get("X-Slack-Signature")
+	if len(signature) < 3 || signature[:3] != "v0=" {
+		return errors.New("missing or invalid X-Slack-Signature")
+	}
+	sig, err := hex.DecodeString(signature[3:])
+	if err != nil {
+		return fmt.Errorf("invalid X-Slack-Signature: %w", err)
+	}
+	mac := hmac.New(sha256.New, signingSecret)
+	fmt.Fprintf(mac, "v0:%s:", timestamp)
+	mac.Write(body)
+	if !hmac.Equal(sig, mac.Sum(nil)) {
+		return errors.New("signature mismatch")
+	}
+	return nil
+}
+
+// Respond sends a message to the response URL of an interaction.
+func Respond(responseURL string, response InteractionResponse) error {
+	body, err := json.Marshal(response)
+	if err != nil {
+		return fmt.Errorf("failed to marshal response: %w", err)
+	}
+	resp, err := http.Post(responseURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		b, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("response URL returned %d: %s", resp.StatusCode, string(b))
+	}
+	return nil
+}
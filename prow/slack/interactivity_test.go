@@ -0,0 +1,98 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package slack
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestVerifySignature(t *testing.T) {
+	// The example from https://api.slack.com/authentication/verifying-requests-from-slack.
+	secret := []byte("8f742231b10e8888abcd99yyyzzz85a5")
+	body := []byte("token=xyzz0WbapA4vBCDEFasx0q6G&team_id=T1DC2JH3J&team_domain=testteamnow&channel_id=G8PSS9T3V&channel_name=foobar&user_id=U2CERLKJA&user_name=roadrunner&command=%2Fwebhook-collect&text=&response_url=https%3A%2F%2Fhooks.slack.com%2Fcommands%2FT1DC2JH3J%2F397700885554%2F96rGlfmibIGlgcZRskXaIFfN&trigger_id=398738663015.47445629121.803a0bc887a14d10d2c447fce8b6703c")
+	const timestamp = 1531420618
+	signed := time.Unix(timestamp, 0)
+
+	testCases := []struct {
+		name        string
+		timestamp   string
+		signature   string
+		now         time.Time
+		expectError bool
+	}{
+		{
+			name:      "valid signature",
+			timestamp: strconv.Itoa(timestamp),
+			signature: "v0=a2114d57b48eac39b9ad189dd8316235a7b4a8d21a10bd27519666489c69b503",
+			now:       signed.Add(time.Minute),
+		},
+		{
+			name:        "invalid signature",
+			timestamp:   strconv.Itoa(timestamp),
+			signature:   "v0=a2114d57b48eac39b9ad189dd8316235a7b4a8d21a10bd27519666489c69b504",
+			now:         signed,
+			expectError: true,
+		},
+		{
+			name:        "old request",
+			timestamp:   strconv.Itoa(timestamp),
+			signature:   "v0=a2114d57b48eac39b9ad189dd8316235a7b4a8d21a10bd27519666489c69b503",
+			now:         signed.Add(time.Hour),
+			expectError: true,
+		},
+		{
+			name:        "missing signature",
+			timestamp:   strconv.Itoa(timestamp),
+			now:         signed,
+			expectError: true,
+		},
+		{
+			name:        "missing timestamp",
+			signature:   "v0=a2114d57b48eac39b9ad189dd8316235a7b4a8d21a10bd27519666489c69b503",
+			now:         signed,
+			expectError: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			header := http.Header{}
+			header.Set("X-Slack-Request-Timestamp", tc.timestamp)
+			header.Set("X-Slack-Signature", tc.signature)
+			err := VerifySignature(header, body, secret, tc.now)
+			if tc.expectError != (err != nil) {
+				t.Errorf("expected error %t, got %v", tc.expectError, err)
+			}
+		})
+	}
+}
+
+func TestNewSectionBlock(t *testing.T) {
+	if text := NewSectionBlock("short").Text.Text; text != "short" {
+		t.Errorf("expected short text to be kept, got %q", text)
+	}
+	text := NewSectionBlock(strings.Repeat("ü", MaxSectionTextLength+1)).Text.Text
+	if length := len([]rune(text)); length != MaxSectionTextLength {
+		t.Errorf("expected text to be truncated to %d characters, got %d", MaxSectionTextLength, length)
+	}
+	if !strings.HasSuffix(text, "…") {
+		t.Errorf("expected truncated text to end with an ellipsis, got %q", text[len(text)-10:])
+	}
+}
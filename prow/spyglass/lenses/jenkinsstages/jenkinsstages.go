@@ -0,0 +1,135 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package jenkinsstages provides a viewer of the stages of Jenkins pipeline
+// builds for Spyglass.
+package jenkinsstages
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"path/filepath"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	prowapi "k8s.io/test-infra/prow/apis/prowjobs/v1"
+	"k8s.io/test-infra/prow/config"
+	"k8s.io/test-infra/prow/spyglass/api"
+	"k8s.io/test-infra/prow/spyglass/lenses"
+)
+
+const (
+	name     = "jenkinsstages"
+	title    = "Jenkins Stages"
+	priority = 15
+)
+
+func init() {
+	lenses.RegisterLens(Lens{})
+}
+
+// Lens shows the stages of a Jenkins pipeline build from prowjob.json.
+type Lens struct{}
+
+// Config returns the lens's configuration.
+func (lens Lens) Config() lenses.LensConfig {
+	return lenses.LensConfig{
+		Name:     name,
+		Title:    title,
+		Priority: priority,
+	}
+}
+
+// Header renders the content of <head> from template.html.
+func (lens Lens) Header(artifacts []api.Artifact, resourceDir string, config json.RawMessage, spyglassConfig config.Spyglass) string {
+	output, err := renderTemplate(resourceDir, "header", nil)
+	if err != nil {
+		logrus.Warnf("Failed to render header: %v", err)
+		return "Error: " + err.Error()
+	}
+	return output
+}
+
+// Callback does nothing.
+func (lens Lens) Callback(artifacts []api.Artifact, resourceDir string, data string, config json.RawMessage, spyglassConfig config.Spyglass) string {
+	return ""
+}
+
+// stage is a row of the table of stages.
+type stage struct {
+	prowapi.JenkinsStage
+	// Class is the CSS class of the status of the stage.
+	Class string
+}
+
+// Body renders the stages of the build.
+func (lens Lens) Body(artifacts []api.Artifact, resourceDir string, data string, config json.RawMessage, spyglassConfig config.Spyglass) string {
+	var pj prowapi.ProwJob
+	for _, artifact := range artifacts {
+		if artifact.JobPath() != "prowjob.json" {
+			continue
+		}
+		content, err := artifact.ReadAll()
+		if err != nil {
+			logrus.WithError(err).Warn("Couldn't read a prowjob file that should exist.")
+			return fmt.Sprintf("Failed to read the prowjob file: %v", err)
+		}
+		if err := json.Unmarshal(content, &pj); err != nil {
+			logrus.WithError(err).Info("Error unmarshalling prowjob")
+			return fmt.Sprintf("Couldn't unmarshal prowjob.json: %v", err)
+		}
+	}
+
+	var stages []stage
+	for _, js := range pj.Status.JenkinsStages {
+		stages = append(stages, stage{JenkinsStage: js, Class: statusClass(js.Status)})
+	}
+	output, err := renderTemplate(resourceDir, "body", struct{ Stages []stage }{Stages: stages})
+	if err != nil {
+		logrus.Warnf("Failed to render body: %v", err)
+		return "Error: " + err.Error()
+	}
+	return output
+}
+
+// statusClass maps the status of a stage to a CSS class.
+func statusClass(status string) string {
+	switch status {
+	case "SUCCESS":
+		return "success"
+	case "FAILED", "UNSTABLE":
+		return "failure"
+	case "IN_PROGRESS", "PAUSED_PENDING_INPUT":
+		return "pending"
+	}
+	return strings.ToLower(status)
+}
+
+func renderTemplate(resourceDir, block string, params interface{}) (string, error) {
+	t, err := template.ParseFiles(filepath.Join(resourceDir, "template.html"))
+	if err != nil {
+		return "", fmt.Errorf("Failed to parse template: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := t.ExecuteTemplate(&buf, block, params); err != nil {
+		return "", fmt.Errorf("Failed to execute template: %w", err)
+	}
+	return buf.String(), nil
+}
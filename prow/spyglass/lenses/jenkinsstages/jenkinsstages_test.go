@@ -0,0 +1,81 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jenkinsstages
+
+import (
+	"strings"
+	"testing"
+
+	"k8s.io/test-infra/prow/config"
+	"k8s.io/test-infra/prow/spyglass/api"
+	"k8s.io/test-infra/prow/spyglass/lenses/fake"
+)
+
+func TestBody(t *testing.T) {
+	testCases := []struct {
+		name      string
+		artifacts []api.Artifact
+		expected  []string
+	}{
+		{
+			name: "stages",
+			artifacts: []api.Artifact{&fake.Artifact{
+				Path: "prowjob.json",
+				Content: []byte(`{"status": {"jenkins_stages": [
+					{"name": "build", "status": "SUCCESS", "startTime": "2022-06-01T12:00:00Z", "duration": "1m0s"},
+					{"name": "test", "status": "FAILED", "startTime": "2022-06-01T12:01:00Z", "duration": "1.5s"},
+					{"name": "deploy", "status": "NOT_EXECUTED", "duration": "0s"}
+				]}}`),
+			}},
+			expected: []string{
+				`<tr class="success">`,
+				`<td class="mdl-data-table__cell--non-numeric">build</td>`,
+				`2022-06-01 12:00:00 UTC`,
+				`<td>1m0s</td>`,
+				`<tr class="failure">`,
+				`<td>1.5s</td>`,
+				`<tr class="not_executed">`,
+			},
+		},
+		{
+			name: "no stages",
+			artifacts: []api.Artifact{&fake.Artifact{
+				Path:    "prowjob.json",
+				Content: []byte(`{"status": {"state": "success"}}`),
+			}},
+			expected: []string{"The build has no stages."},
+		},
+		{
+			name: "invalid prowjob.json",
+			artifacts: []api.Artifact{&fake.Artifact{
+				Path:    "prowjob.json",
+				Content: []byte(`{`),
+			}},
+			expected: []string{"Couldn't unmarshal prowjob.json"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			body := Lens{}.Body(tc.artifacts, ".", "", nil, config.Spyglass{})
+			for _, expected := range tc.expected {
+				if !strings.Contains(body, expected) {
+					t.Errorf("expected the body to contain %q, got:\n%s", expected, body)
+				}
+			}
+		})
+	}
+}
@@ -30,6 +30,7 @@ import (
 	"github.com/sirupsen/logrus"
 
 	gerrit "github.com/andygrunwald/go-gerrit"
+	"k8s.io/test-infra/prow/gerrit/client"
 	"k8s.io/test-infra/prow/gerrit/fakegerrit"
 	"k8s.io/test-infra/prow/interrupts"
 	"k8s.io/test-infra/prow/logrusutil"
@@ -83,12 +84,16 @@ func main() {
 		// SetUsername PUT
 		r.Path("/accounts/{account-id}/username").Handler(response(accountHandler(fakeClient)))
 
+		// events-log plugin GET
+		r.Path("/plugins/events-log/events/").Handler(response(eventsHandler(fakeClient)))
+
 		// GetBranch GET
 		r.Path("/projects/{project-name}/branches/{branch-id}").Handler(response(projectHandler(fakeClient)))
 
 		// Use to populate the server for testing
 		r.Path("/admin/add/change/{project}").Handler(response(addChangeHandler(fakeClient)))
 		r.Path("/admin/add/branch/{project}/{branch-name}").Handler(response(addBranchHandler(fakeClient)))
+		r.Path("/admin/add/event").Handler(response(addEventHandler(fakeClient)))
 		r.Path("/admin/add/account").Handler(response(addAccountHandler(fakeClient)))
 		r.Path("/admin/login/{id}").Handler(response(loginHandler(fakeClient)))
 		r.Path("/admin/reset").Handler(response(resetHandler(fakeClient)))
@@ -204,6 +209,45 @@ func addBranchHandler(fgc *fakegerrit.FakeGerrit) func(*http.Request) (interface
 	}
 }
 
+// Admin endpoint to add an event to the Fake Gerrit Server
+func addEventHandler(fgc *fakegerrit.FakeGerrit) func(*http.Request) (interface{}, int, error) {
+	return func(r *http.Request) (interface{}, int, error) {
+		event := client.Event{}
+		if err := unmarshal(r, &event); err != nil {
+			logrus.Infof("Error unmarshaling: %v", err)
+			return "", http.StatusInternalServerError, err
+		}
+		if event.EventCreatedOn == 0 {
+			event.EventCreatedOn = time.Now().Unix()
+		}
+		fgc.AddEvent(event)
+		return "", http.StatusOK, nil
+	}
+}
+
+// Handles the events of the events-log plugin, one JSON event per line
+func eventsHandler(fgc *fakegerrit.FakeGerrit) func(*http.Request) (interface{}, int, error) {
+	return func(r *http.Request) (interface{}, int, error) {
+		logrus.Infof("Serving: %s, %s", r.URL.Path, r.Method)
+		var since time.Time
+		if t1 := r.URL.Query().Get("t1"); t1 != "" {
+			var err error
+			if since, err = time.Parse("2006-01-02 15:04:05", t1); err != nil {
+				return "", http.StatusBadRequest, nil
+			}
+		}
+		var lines []string
+		for _, event := range fgc.GetEvents(since) {
+			content, err := json.Marshal(event)
+			if err != nil {
+				return "", http.StatusInternalServerError, err
+			}
+			lines = append(lines, string(content)+"\n")
+		}
+		return strings.Join(lines, ""), http.StatusOK, nil
+	}
+}
+
 // Admin endpoint to reset the Fake Gerrit Server
 func resetHandler(fgc *fakegerrit.FakeGerrit) func(*http.Request) (interface{}, int, error) {
 	return func(r *http.Request) (interface{}, int, error) {
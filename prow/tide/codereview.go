@@ -127,6 +127,21 @@ func (crc *CodeReviewCommon) GitHubLabels() *Labels {
 	return &crc.GitHub.Labels
 }
 
+// labelNames returns the labels of a GitHub PR, or the hashtags of a Gerrit
+// change.
+func (crc *CodeReviewCommon) labelNames() []string {
+	var names []string
+	if crc.GitHub != nil {
+		for _, label := range crc.GitHub.Labels.Nodes {
+			names = append(names, string(label.Name))
+		}
+	}
+	if crc.Gerrit != nil {
+		names = append(names, crc.Gerrit.Hashtags...)
+	}
+	return names
+}
+
 // GitHubCommits returns Commits struct from GitHub.
 //
 // This is used by checking status context to determine whether the PR is ready
@@ -306,6 +306,13 @@ func (sc *statusController) expectedStatus(log *logrus.Entry, queryMap *config.Q
 			return github.StatusError, fmt.Sprintf(statusNotInPool, fmt.Sprintf(" Merging is blocked by issue%s %s.", s, strings.Join(numbers, ", "))), nil
 		}
 
+		now := time.Now()
+		for _, f := range sc.config().Tide.ActiveMergeFreezes(crc.Org, crc.Repo, crc.BaseRefName, now) {
+			if !f.Exempts(crc.labelNames()) {
+				return github.StatusError, fmt.Sprintf(statusNotInPool, fmt.Sprintf(" Merging is frozen by %q until %s.", f.Name, f.ActiveUntil(now).UTC().Format("Jan 2 15:04 MST"))), nil
+			}
+		}
+
 		// hasFullfilledQuery is a weird state, it means that the PR is not in the pool but should be. It happens when all requirements were fulfilled
 		// at the time the status controller queried GitHub but not at the time the sync controller queried GitHub.
 		// We just fall through to check if there are missing jobs to avoid wasting api tokens by sending it to pending and then to success in the next
@@ -23,6 +23,7 @@ import (
 	"sort"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/go-test/deep"
 	"github.com/google/go-cmp/cmp"
@@ -67,6 +68,7 @@ func TestExpectedStatus(t *testing.T) {
 		additionalTideQueries []config.TideQuery
 		hasApprovingReview    bool
 		singleQuery           bool
+		mergeFreezes          []config.TideMergeFreeze
 
 		state string
 		desc  string
@@ -513,6 +515,39 @@ func TestExpectedStatus(t *testing.T) {
 			state: github.StatusError,
 			desc:  fmt.Sprintf(statusNotInPool, " Merging is blocked by issues 1, 2."),
 		},
+		{
+			name:              "merge freezes take precedence over other queries",
+			labels:            []string{"3", "4", "5", "6", "7"},
+			author:            "batman",
+			firstQueryAuthor:  "batman",
+			secondQueryAuthor: "batman",
+			milestone:         "v1.0",
+			mergeFreezes: []config.TideMergeFreeze{{
+				Name:  "code freeze",
+				Start: &metav1.Time{Time: time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)},
+				End:   &metav1.Time{Time: time.Date(2200, 1, 1, 0, 0, 0, 0, time.UTC)},
+			}},
+
+			state: github.StatusError,
+			desc:  fmt.Sprintf(statusNotInPool, ` Merging is frozen by "code freeze" until Jan 1 00:00 UTC.`),
+		},
+		{
+			name:              "exempt from merge freeze",
+			labels:            []string{"1", "2", "3", "4", "5", "6", "7", "critical"},
+			author:            "batman",
+			firstQueryAuthor:  "batman",
+			secondQueryAuthor: "batman",
+			milestone:         "v1.0",
+			mergeFreezes: []config.TideMergeFreeze{{
+				Name:         "code freeze",
+				Start:        &metav1.Time{Time: time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)},
+				End:          &metav1.Time{Time: time.Date(2200, 1, 1, 0, 0, 0, 0, time.UTC)},
+				ExemptLabels: []string{"critical"},
+			}},
+
+			state: github.StatusSuccess,
+			desc:  statusInPool,
+		},
 		{
 			name:             "missing passing up-to-date context",
 			inPool:           true,
@@ -816,6 +851,7 @@ func TestExpectedStatus(t *testing.T) {
 
 			ca := &config.Agent{}
 			ca.Set(&config.Config{ProwConfig: config.ProwConfig{Tide: config.Tide{
+				MergeFreezes:     tc.mergeFreezes,
 				TideGitHubConfig: config.TideGitHubConfig{DisplayAllQueriesInStatus: tc.displayAllTideQueries}}}})
 			mmc := newMergeChecker(ca.Config, &fgc{})
 
@@ -103,6 +103,7 @@ const (
 	Merge        Action = "MERGE"
 	MergeBatch   Action = "MERGE_BATCH"
 	PoolBlocked  Action = "BLOCKED"
+	PoolFrozen   Action = "FROZEN"
 )
 
 // recordableActions is the subset of actions that we keep historical record of.
@@ -137,10 +138,19 @@ type Pool struct {
 	Blockers []blockers.Blocker
 	Error    string
 
+	// Active merge freezes. Only PRs exempt from all of them are in the pool.
+	MergeFreezes []MergeFreeze
+
 	// All of the TenantIDs associated with PRs in the pool.
 	TenantIDs []string
 }
 
+// MergeFreeze is an active merge freeze of a pool.
+type MergeFreeze struct {
+	Name  string
+	Until time.Time
+}
+
 // PoolForDeck contains the same data as Pool, the only exception is that it has
 // a minified version of CodeReviewCommon which is good for deck, as
 // MinCodeReview is a very small superset of CodeReviewCommon.
@@ -165,6 +175,8 @@ type PoolForDeck struct {
 	Blockers []blockers.Blocker
 	Error    string
 
+	MergeFreezes []MergeFreeze
+
 	// All of the TenantIDs associated with PRs in the pool.
 	TenantIDs []string
 }
@@ -189,6 +201,7 @@ func PoolToPoolForDeck(p *Pool) *PoolForDeck {
 		Target:       crcToMin(p.Target),
 		Blockers:     p.Blockers,
 		Error:        p.Error,
+		MergeFreezes: p.MergeFreezes,
 		TenantIDs:    p.TenantIDs,
 	}
 	return pfd
@@ -587,6 +600,7 @@ func subpoolsInParallel(goroutines int, sps map[string]*subpool, process func(*s
 func (c *syncController) filterSubpools(mergeAllowed func(*CodeReviewCommon) (string, error), raw map[string]*subpool) map[string]*subpool {
 	filtered := make(map[string]*subpool)
 	var lock sync.Mutex
+	now := time.Now()
 
 	subpoolsInParallel(
 		c.config().Tide.MaxGoroutines,
@@ -597,12 +611,22 @@ func (c *syncController) filterSubpools(mergeAllowed func(*CodeReviewCommon) (st
 				return
 			}
 			key := poolKey(sp.org, sp.repo, sp.branch)
+			filterFrozen(sp, c.config().Tide.ActiveMergeFreezes(sp.org, sp.repo, sp.branch, now), now)
 			if spFiltered := filterSubpool(c.provider, mergeAllowed, sp); spFiltered != nil {
 				sp.log.WithField("key", key).WithField("pool", spFiltered).Debug("filtered sub-pool")
 
 				lock.Lock()
 				filtered[key] = spFiltered
 				lock.Unlock()
+			} else if len(sp.freezes) > 0 {
+				// Keep frozen sub-pools so that the freeze shows up on the
+				// Tide dashboard.
+				sp.log.WithField("key", key).Debug("sub-pool is frozen")
+				sp.prs = nil
+
+				lock.Lock()
+				filtered[key] = sp
+				lock.Unlock()
 			} else {
 				sp.log.WithField("key", key).WithField("pool", spFiltered).Debug("filtering sub-pool removed all PRs")
 			}
@@ -643,6 +667,37 @@ func (c *syncController) initSubpoolData(sp *subpool) error {
 	return nil
 }
 
+// filterFrozen records the active merge freezes of the subpool and removes the
+// PRs that are not exempt from all of them.
+func filterFrozen(sp *subpool, freezes []config.TideMergeFreeze, now time.Time) {
+	sp.freezes = nil
+	if len(freezes) == 0 {
+		return
+	}
+	for _, f := range freezes {
+		sp.freezes = append(sp.freezes, MergeFreeze{Name: f.Name, Until: f.ActiveUntil(now)})
+	}
+	var toKeep []CodeReviewCommon
+	for _, pr := range sp.prs {
+		if exemptFromMergeFreezes(freezes, &pr) {
+			toKeep = append(toKeep, pr)
+		} else {
+			sp.log.WithFields(pr.logFields()).Debug("filtering out PR as merging is frozen")
+		}
+	}
+	sp.prs = toKeep
+}
+
+func exemptFromMergeFreezes(freezes []config.TideMergeFreeze, pr *CodeReviewCommon) bool {
+	labels := pr.labelNames()
+	for _, f := range freezes {
+		if !f.Exempts(labels) {
+			return false
+		}
+	}
+	return true
+}
+
 // filterSubpool filters PRs from an initially identified subpool, returning the
 // filtered subpool.
 // If the subpool becomes empty 'nil' is returned to indicate that the subpool
@@ -1643,6 +1698,8 @@ func (c *syncController) syncSubpool(sp subpool, blocks []blockers.Blocker) (Poo
 	var errorString string
 	if len(blocks) > 0 {
 		act = PoolBlocked
+	} else if len(sp.freezes) > 0 && len(sp.prs) == 0 {
+		act = PoolFrozen
 	} else {
 		act, targets, err = c.takeAction(sp, batchPending, successes, pendings, missings, batchMerge, missingSerialTests)
 		if err != nil {
@@ -1682,6 +1739,8 @@ func (c *syncController) syncSubpool(sp subpool, blocks []blockers.Blocker) (Poo
 			Blockers: blocks,
 			Error:    errorString,
 
+			MergeFreezes: sp.freezes,
+
 			TenantIDs: tenantIDs,
 		},
 		err
@@ -1740,6 +1799,8 @@ type subpool struct {
 	// presubmit contains all required presubmits for each PR
 	// in this subpool
 	presubmits map[int][]config.Presubmit
+	// freezes are the active merge freezes of the subpool
+	freezes []MergeFreeze
 }
 
 func (sp subpool) TenantIDs() []string {
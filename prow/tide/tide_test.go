@@ -5240,3 +5240,71 @@ func TestSerialRetestingConsidersPRThatIsCurrentlyBeingSRetested(t *testing.T) {
 	}
 
 }
+
+func TestFilterFrozen(t *testing.T) {
+	now := time.Date(2022, 12, 28, 12, 0, 0, 0, time.UTC)
+	end := time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC)
+	freeze := func(name string, exempt ...string) config.TideMergeFreeze {
+		return config.TideMergeFreeze{
+			Name:         name,
+			Start:        &metav1.Time{Time: time.Date(2022, 12, 23, 0, 0, 0, 0, time.UTC)},
+			End:          &metav1.Time{Time: end},
+			ExemptLabels: exempt,
+		}
+	}
+	pr := func(number int, labels ...string) CodeReviewCommon {
+		var pr PullRequest
+		pr.Number = githubql.Int(number)
+		for _, label := range labels {
+			pr.Labels.Nodes = append(pr.Labels.Nodes, struct{ Name githubql.String }{Name: githubql.String(label)})
+		}
+		return *CodeReviewCommonFromPullRequest(&pr)
+	}
+	testCases := []struct {
+		name            string
+		freezes         []config.TideMergeFreeze
+		expectedPRs     []int
+		expectedFreezes []MergeFreeze
+	}{
+		{
+			name:        "not frozen",
+			expectedPRs: []int{1, 2, 3},
+		},
+		{
+			name:            "frozen",
+			freezes:         []config.TideMergeFreeze{freeze("holidays")},
+			expectedFreezes: []MergeFreeze{{Name: "holidays", Until: end}},
+		},
+		{
+			name:            "exempt labels",
+			freezes:         []config.TideMergeFreeze{freeze("holidays", "critical", "security")},
+			expectedPRs:     []int{2, 3},
+			expectedFreezes: []MergeFreeze{{Name: "holidays", Until: end}},
+		},
+		{
+			name:            "exempt from all freezes",
+			freezes:         []config.TideMergeFreeze{freeze("holidays", "critical", "security"), freeze("code freeze", "critical")},
+			expectedPRs:     []int{2},
+			expectedFreezes: []MergeFreeze{{Name: "holidays", Until: end}, {Name: "code freeze", Until: end}},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			sp := &subpool{
+				log: logrus.WithField("test", tc.name),
+				prs: []CodeReviewCommon{pr(1, "lgtm"), pr(2, "critical"), pr(3, "security")},
+			}
+			filterFrozen(sp, tc.freezes, now)
+			var numbers []int
+			for _, pr := range sp.prs {
+				numbers = append(numbers, pr.Number)
+			}
+			if diff := cmp.Diff(tc.expectedPRs, numbers); diff != "" {
+				t.Errorf("unexpected PRs (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.expectedFreezes, sp.freezes); diff != "" {
+				t.Errorf("unexpected freezes (-want +got):\n%s", diff)
+			}
+		})
+	}
+}